@@ -19,6 +19,9 @@ limitations under the License.
 // and only defines the needed objects for the client to function.
 // See https://github.com/Nike-Inc/cerberus-management-service/blob/master/API.md
 // for full documentation
+//
+// Deprecated: this is the legacy, unmaintained version of the client and receives no
+// fixes. Use github.com/Nike-Inc/cerberus-go-client/v3/api instead.
 package api
 
 import (
@@ -17,6 +17,9 @@ limitations under the License.
 // Package auth contains various implementations for authenticating with Cerberus.
 // These implementations can be used standalone from the main Cerberus client
 // to get a login token or manage authentication without having to set up a full client
+//
+// Deprecated: this is the legacy, unmaintained version of the client and receives no
+// fixes. Use github.com/Nike-Inc/cerberus-go-client/v3/auth instead.
 package auth
 
 import (
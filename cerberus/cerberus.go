@@ -14,6 +14,10 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+// Package cerberus contains the legacy Cerberus client.
+//
+// Deprecated: this is the legacy, unmaintained version of the client and receives no
+// fixes. Use github.com/Nike-Inc/cerberus-go-client/v3/cerberus instead.
 package cerberus
 
 import (
@@ -15,6 +15,9 @@ limitations under the License.
 */
 
 // Package utils contains common functionality needed across the Cerberus Go client
+//
+// Deprecated: this is the legacy, unmaintained version of the client and receives no
+// fixes. Use github.com/Nike-Inc/cerberus-go-client/v3/utils instead.
 package utils
 
 import (
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorServiceUnavailable is returned when Cerberus responds with a 503, which usually
+// means it is in maintenance mode. Callers can use errors.As to detect it and degrade gracefully
+type ErrorServiceUnavailable struct {
+	// RetryAfter is how long the server asked to wait before trying again. It is zero
+	// if the server didn't send a Retry-After header
+	RetryAfter time.Duration
+}
+
+func (e ErrorServiceUnavailable) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("Cerberus is unavailable (possibly in maintenance mode). Retry after %v", e.RetryAfter)
+	}
+	return "Cerberus is unavailable (possibly in maintenance mode)"
+}
+
+// ErrorValidation is returned when a request fails client side validation, before it is
+// sent to Cerberus. Problems has one entry per invalid value
+type ErrorValidation struct {
+	Problems []string
+}
+
+func (e ErrorValidation) Error() string {
+	return "Validation failed: " + strings.Join(e.Problems, "; ")
+}
+
+// ErrorResponseTooLarge is returned when a response body is larger than the limit for bodies
+// read into memory, which protects memory-constrained services from unexpected or malicious responses
+type ErrorResponseTooLarge struct {
+	// Limit is the largest body, in bytes, that was allowed
+	Limit int64
+}
+
+func (e ErrorResponseTooLarge) Error() string {
+	return fmt.Sprintf("Response body is larger than %d bytes", e.Limit)
+}
+
+// ErrorUnexpectedResponse is returned when Cerberus, or something in front of it such as a load
+// balancer or WAF, returns an error response that isn't JSON, like an HTML 502 or 403 page
+type ErrorUnexpectedResponse struct {
+	StatusCode  int
+	ContentType string
+	// Snippet is the start of the response body, truncated and with whitespace collapsed
+	Snippet string
+}
+
+func (e ErrorUnexpectedResponse) Error() string {
+	msg := fmt.Sprintf("Unexpected non-JSON response with status code %d and content type %q, possibly from a proxy or load balancer in front of Cerberus", e.StatusCode, e.ContentType)
+	if e.Snippet != "" {
+		msg += ": " + e.Snippet
+	}
+	return msg
+}
+
+// ErrorConnection is returned when a request never got a response from Cerberus because of a
+// network-level failure, such as a DNS lookup, dial or TLS handshake error. It usually means
+// the Cerberus URL is wrong or unreachable, as opposed to Cerberus rejecting the request
+type ErrorConnection struct {
+	// Host is the host the client tried to reach
+	Host string
+	// Err is the underlying network error
+	Err error
+}
+
+func (e ErrorConnection) Error() string {
+	return fmt.Sprintf("Unable to connect to Cerberus at %s: %v", e.Host, e.Err)
+}
+
+// Unwrap returns the underlying network error
+func (e ErrorConnection) Unwrap() error {
+	return e.Err
+}
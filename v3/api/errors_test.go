@@ -0,0 +1,60 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestErrorServiceUnavailable(t *testing.T) {
+	Convey("An ErrorServiceUnavailable with a retry hint", t, func() {
+		err := ErrorServiceUnavailable{RetryAfter: 30 * time.Second}
+		Convey("Should mention when to retry", func() {
+			So(err.Error(), ShouldContainSubstring, "Retry after 30s")
+		})
+	})
+	Convey("An ErrorServiceUnavailable without a retry hint", t, func() {
+		err := ErrorServiceUnavailable{}
+		Convey("Should give a valid string", func() {
+			So(err.Error(), ShouldStartWith, "Cerberus is unavailable")
+			So(err.Error(), ShouldNotContainSubstring, "Retry after")
+		})
+	})
+}
+
+func TestErrorValidation(t *testing.T) {
+	Convey("An ErrorValidation", t, func() {
+		err := ErrorValidation{Problems: []string{"first problem", "second problem"}}
+		Convey("Should list every problem", func() {
+			So(err.Error(), ShouldEqual, "Validation failed: first problem; second problem")
+		})
+	})
+}
+
+func TestErrorUnexpectedResponse(t *testing.T) {
+	Convey("An ErrorUnexpectedResponse", t, func() {
+		err := ErrorUnexpectedResponse{StatusCode: 502, ContentType: "text/html", Snippet: "<html>Bad Gateway</html>"}
+		Convey("Should include the status, content type and snippet", func() {
+			So(err.Error(), ShouldContainSubstring, "status code 502")
+			So(err.Error(), ShouldContainSubstring, `"text/html"`)
+			So(err.Error(), ShouldEndWith, ": <html>Bad Gateway</html>")
+		})
+	})
+}
@@ -23,11 +23,19 @@ package api
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// ClientHeader is the header version for all requests. It should be updated on version bumps
-const ClientHeader = "CerberusGoClient/3.0.11"
+// ClientHeader is the static client version header.
+//
+// Deprecated: requests now send ClientInfo().Header(""), which also has the Go version and
+// features, and is built from the version in the build information when available
+const ClientHeader = "CerberusGoClient/" + ClientVersion
+
+// NamespaceHeader is the header used by multi-tenant Cerberus gateways to select a namespace
+const NamespaceHeader = "X-Cerberus-Namespace"
 
 // AuthStatus is the status of a UserAuthResponse
 type AuthStatus string
@@ -65,13 +73,26 @@ func (e ErrorResponse) Error() string {
 
 // IAMAuthResponse represents a response from the iam-principal authentication endpoint
 type IAMAuthResponse struct {
-	Token     string `json:"client_token"`
-	Policies  []string
-	Metadata  map[string]string
+	Token    string `json:"client_token"`
+	Policies []string
+	Metadata map[string]string
+	// Duration is the lease duration in seconds.
+	//
+	// Deprecated: use LeaseDuration, which returns a time.Duration
 	Duration  int `json:"lease_duration"`
 	Renewable bool
 }
 
+// LeaseDuration returns how long the token is valid for
+func (r IAMAuthResponse) LeaseDuration() time.Duration {
+	return leaseDuration(r.Duration)
+}
+
+// ExpiresAt returns when the token expires if it was issued at the given time
+func (r IAMAuthResponse) ExpiresAt(issued time.Time) time.Time {
+	return issued.Add(r.LeaseDuration())
+}
+
 // UserAuthResponse represents the response from the /v2/auth/user
 type UserAuthResponse struct {
 	Status AuthStatus
@@ -92,8 +113,26 @@ type UserClientToken struct {
 	ClientToken string `json:"client_token"`
 	Policies    []string
 	Metadata    UserMetadata
-	Duration    int `json:"lease_duration"`
-	Renewable   bool
+	// Duration is the lease duration in seconds.
+	//
+	// Deprecated: use LeaseDuration, which returns a time.Duration
+	Duration  int `json:"lease_duration"`
+	Renewable bool
+}
+
+// LeaseDuration returns how long the token is valid for
+func (t UserClientToken) LeaseDuration() time.Duration {
+	return leaseDuration(t.Duration)
+}
+
+// ExpiresAt returns when the token expires if it was issued at the given time
+func (t UserClientToken) ExpiresAt(issued time.Time) time.Time {
+	return issued.Add(t.LeaseDuration())
+}
+
+// leaseDuration converts a lease duration in seconds, as returned by the API, to a time.Duration
+func leaseDuration(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
 }
 
 // MFADevice represents a user method for providing a token
@@ -109,6 +148,92 @@ type UserMetadata struct {
 	Groups   string
 }
 
+// TokenInfo is the metadata Cerberus returns with a token: its policies, and who it was issued
+// to with their groups and admin status. It is only as current as the last authentication or
+// refresh, and Cerberus still enforces permissions on every request
+type TokenInfo struct {
+	Policies []string `json:"policies,omitempty"`
+	// Username is the user the token was issued to, if it was issued to a user
+	Username string `json:"username,omitempty"`
+	// IAMPrincipal is the IAM principal ARN the token was issued to, if it was issued to one
+	IAMPrincipal string   `json:"iam_principal_arn,omitempty"`
+	Groups       []string `json:"groups,omitempty"`
+	IsAdmin      bool     `json:"is_admin"`
+	// Metadata is all of the metadata returned with the token
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// TokenInfo returns the metadata returned with the token
+func (r IAMAuthResponse) TokenInfo() TokenInfo {
+	return newTokenInfo(r.Policies, r.Metadata)
+}
+
+// TokenInfo returns the metadata returned with the token
+func (t UserClientToken) TokenInfo() TokenInfo {
+	return newTokenInfo(t.Policies, map[string]string{
+		"username": t.Metadata.Username,
+		"is_admin": t.Metadata.IsAdmin,
+		"groups":   t.Metadata.Groups,
+	})
+}
+
+// HasPolicy returns whether the token has the given policy
+func (i TokenInfo) HasPolicy(policy string) bool {
+	return contains(i.Policies, policy)
+}
+
+// InGroup returns whether the principal the token was issued to is a member of the given group
+func (i TokenInfo) InGroup(group string) bool {
+	return contains(i.Groups, group)
+}
+
+// Copy returns a deep copy of i, which can be modified without changing i
+func (i TokenInfo) Copy() TokenInfo {
+	c := i
+	c.Policies = append([]string(nil), i.Policies...)
+	c.Groups = append([]string(nil), i.Groups...)
+	if i.Metadata != nil {
+		c.Metadata = make(map[string]string, len(i.Metadata))
+		for k, v := range i.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return c
+}
+
+// newTokenInfo parses the metadata of a token, where groups are a comma separated list and
+// is_admin is a boolean as a string. Empty metadata values are left out
+func newTokenInfo(policies []string, metadata map[string]string) TokenInfo {
+	info := TokenInfo{
+		Policies:     append([]string(nil), policies...),
+		Username:     metadata["username"],
+		IAMPrincipal: metadata["iam_principal_arn"],
+		Metadata:     map[string]string{},
+	}
+	info.IsAdmin, _ = strconv.ParseBool(metadata["is_admin"])
+	for _, group := range strings.Split(metadata["groups"], ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			info.Groups = append(info.Groups, group)
+		}
+	}
+	for k, v := range metadata {
+		if v != "" {
+			info.Metadata[k] = v
+		}
+	}
+	return info
+}
+
+// contains returns whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // SafeDepositBox represents a safe deposit box API object
 type SafeDepositBox struct {
 	ID                      string                `json:"id,omitempty"`
@@ -156,6 +281,31 @@ type Category struct {
 	LastUpdatedBy string    `json:"last_updated_by"`
 }
 
+// Page is the pagination information of a list response. All paginated responses can
+// return one using their Page method
+type Page struct {
+	Limit      int
+	Offset     int
+	Total      int
+	HasNext    bool
+	NextOffset int
+}
+
+// PageOpts are the pagination values passed to a paginated list call
+type PageOpts struct {
+	Limit  uint
+	Offset uint
+}
+
+// NextOpts returns the options to fetch the page after this one, using the same limit.
+// It returns false if this is the last page
+func (p Page) NextOpts() (PageOpts, bool) {
+	if !p.HasNext {
+		return PageOpts{}, false
+	}
+	return PageOpts{Limit: uint(p.Limit), Offset: uint(p.NextOffset)}, true
+}
+
 // MetadataResponse is an object that wraps a list of SDBMetadata for convenience with pagination
 type MetadataResponse struct {
 	HasNext     bool `json:"has_next"`
@@ -167,6 +317,17 @@ type MetadataResponse struct {
 	Metadata    []SDBMetadata `json:"safe_deposit_box_metadata"`
 }
 
+// Page returns the pagination information of the response
+func (m *MetadataResponse) Page() Page {
+	return Page{
+		Limit:      m.Limit,
+		Offset:     m.Offset,
+		Total:      m.TotalCount,
+		HasNext:    m.HasNext,
+		NextOffset: m.NextOffset,
+	}
+}
+
 // SDBMetadata represents the metadata of a specific SDB
 type SDBMetadata struct {
 	Id                   string
@@ -181,6 +342,8 @@ type SDBMetadata struct {
 	LastUpdatedBy        string            `json:"last_updated_by"`
 	UserGroupPermissions map[string]string `json:"user_group_permissions"`
 	IAMRolePermissions   map[string]string `json:"iam_role_permissions"`
+	// Data holds the secrets of the SDB keyed by path, when they are part of a backup
+	Data map[string]map[string]interface{} `json:"data,omitempty"`
 }
 
 // SecureFileSummary represents the metadata of a specific secure-file
@@ -208,3 +371,128 @@ type SecureFilesResponse struct {
 	TotalCount  int                 `json:"total_file_count"`
 	Summaries   []SecureFileSummary `json:"secure_file_summaries"`
 }
+
+// Page returns the pagination information of the response
+func (s *SecureFilesResponse) Page() Page {
+	return Page{
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+		Total:      s.TotalCount,
+		HasNext:    s.HasNext,
+		NextOffset: s.NextOffset,
+	}
+}
+
+// SecretVersionSummary describes one version of a secret or secure file
+type SecretVersionSummary struct {
+	ID    string `json:"id"`
+	SDBID string `json:"sdbox_id"`
+	Path  string `json:"path"`
+	// Type is "OBJECT" for secrets and "FILE" for secure files
+	Type string `json:"type"`
+	// Action is what created the version: "CREATE", "UPDATE" or "DELETE"
+	Action           string    `json:"action"`
+	Size             int       `json:"size_in_bytes"`
+	VersionCreatedBy string    `json:"version_created_by"`
+	VersionCreated   time.Time `json:"version_created_ts"`
+	ActionPrincipal  string    `json:"action_principal"`
+	ActionTime       time.Time `json:"action_ts"`
+}
+
+// SecretVersionsResponse is an object that wraps a list of SecretVersionSummary for convenience with pagination
+type SecretVersionsResponse struct {
+	HasNext    bool `json:"has_next"`
+	NextOffset int  `json:"next_offset"`
+	Limit      int
+	Offset     int
+
+	ResultCount int                    `json:"version_count_in_result"`
+	TotalCount  int                    `json:"total_version_count"`
+	Summaries   []SecretVersionSummary `json:"secure_data_version_summaries"`
+}
+
+// Page returns the pagination information of the response
+func (s *SecretVersionsResponse) Page() Page {
+	return Page{
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+		Total:      s.TotalCount,
+		HasNext:    s.HasNext,
+		NextOffset: s.NextOffset,
+	}
+}
+
+// WriteResult describes a completed secret write
+type WriteResult struct {
+	// Path is the path the secret was written to, without the "secret/" prefix
+	Path string
+	// RequestID is the request ID returned by the server, if any
+	RequestID string
+	// Timestamp is when the write completed
+	Timestamp time.Time
+	// Version is the version of the secret after the write, or 0 if the server
+	// didn't return one
+	Version int
+	// Queued is true if Cerberus couldn't be reached and the write was queued to be
+	// replayed later instead. See cerberus.WriteQueue
+	Queued bool
+}
+
+// DownloadInfo describes a downloaded secure file, taken from the response headers
+type DownloadInfo struct {
+	// Filename is the original name of the file
+	Filename string
+	// Size is the size of the file in bytes, or -1 if it is unknown
+	Size int64
+	// ContentType is the content type of the file
+	ContentType string
+	// SHA256 is the hex SHA-256 digest of the file, if the server reports it in the
+	// Repr-Digest or Digest header. It is empty otherwise
+	SHA256 string
+}
+
+// TokenScope describes the access a token created by a token exchange is limited to
+type TokenScope struct {
+	// Policies limits the new token to a subset of the policies of the current token
+	Policies []string `json:"policies,omitempty"`
+	// SDBPaths limits the new token to the given safe deposit box paths, such as "app/my-sdb/"
+	SDBPaths []string `json:"sdb_paths,omitempty"`
+	// TTL is the lifetime of the new token in seconds. The server default is used if it is 0
+	TTL int `json:"ttl,omitempty"`
+}
+
+// IssuedToken describes an active token issued by Cerberus, as returned by the token
+// management endpoints. It never contains the token itself
+type IssuedToken struct {
+	// ID identifies the token for revoking it. It is not the token
+	ID string `json:"id"`
+	// Principal is the IAM principal ARN or username the token was issued to
+	Principal     string        `json:"principal"`
+	PrincipalType PrincipalType `json:"principal_type"`
+	Policies      []string      `json:"policies"`
+	Created       time.Time     `json:"created_ts"`
+	Expires       time.Time     `json:"expires_ts"`
+	// LastUsed is when the token was last used, or the zero time if the server doesn't track it
+	LastUsed time.Time `json:"last_used_ts"`
+}
+
+// PrincipalType is the kind of principal a permission is granted to
+type PrincipalType string
+
+var (
+	// PrincipalUserGroup is a user group, such as an AD group
+	PrincipalUserGroup PrincipalType = "user_group"
+	// PrincipalIAM is an IAM principal ARN
+	PrincipalIAM PrincipalType = "iam_principal"
+)
+
+// Permission is a single role granted to a principal on an SDB, as used in permission reports
+type Permission struct {
+	SDB           string        `json:"sdb"`
+	Path          string        `json:"path"`
+	Principal     string        `json:"principal"`
+	PrincipalType PrincipalType `json:"principal_type"`
+	Role          string        `json:"role"`
+	LastUpdated   time.Time     `json:"last_updated_ts"`
+	LastUpdatedBy string        `json:"last_updated_by"`
+}
@@ -18,6 +18,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -42,3 +43,92 @@ func TestErrorResponse(t *testing.T) {
 		})
 	})
 }
+
+func TestPage(t *testing.T) {
+	Convey("A page with more results", t, func() {
+		resp := &MetadataResponse{HasNext: true, NextOffset: 200, Limit: 100, Offset: 100, TotalCount: 250}
+		page := resp.Page()
+		Convey("Should have the pagination values", func() {
+			So(page, ShouldResemble, Page{Limit: 100, Offset: 100, Total: 250, HasNext: true, NextOffset: 200})
+		})
+		Convey("Should return the options for the next page", func() {
+			opts, ok := page.NextOpts()
+			So(ok, ShouldBeTrue)
+			So(opts, ShouldResemble, PageOpts{Limit: 100, Offset: 200})
+		})
+	})
+	Convey("The last page", t, func() {
+		resp := &SecureFilesResponse{Limit: 100, Offset: 200, TotalCount: 250}
+		Convey("Should not return options for a next page", func() {
+			opts, ok := resp.Page().NextOpts()
+			So(ok, ShouldBeFalse)
+			So(opts, ShouldResemble, PageOpts{})
+		})
+	})
+}
+
+func TestLeaseDuration(t *testing.T) {
+	issued := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	Convey("An IAM auth response", t, func() {
+		r := IAMAuthResponse{Duration: 3600}
+		Convey("Should convert the lease duration from seconds", func() {
+			So(r.LeaseDuration(), ShouldEqual, time.Hour)
+			So(r.ExpiresAt(issued), ShouldEqual, issued.Add(time.Hour))
+		})
+	})
+	Convey("A user client token", t, func() {
+		tok := UserClientToken{Duration: 90}
+		Convey("Should convert the lease duration from seconds", func() {
+			So(tok.LeaseDuration(), ShouldEqual, 90*time.Second)
+			So(tok.ExpiresAt(issued), ShouldEqual, issued.Add(90*time.Second))
+		})
+		Convey("Should be zero without a lease", func() {
+			So(UserClientToken{}.LeaseDuration(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestTokenInfo(t *testing.T) {
+	Convey("An IAM auth response", t, func() {
+		r := IAMAuthResponse{
+			Policies: []string{"foo-bar-read", "lookup-self"},
+			Metadata: map[string]string{
+				"iam_principal_arn": "arn:aws:iam::111111111:role/fake-role",
+				"is_admin":          "true",
+				"groups":            "registered-iam-principals",
+				"aws_region":        "",
+			},
+		}
+		info := r.TokenInfo()
+		Convey("Should parse the metadata", func() {
+			So(info.Policies, ShouldResemble, []string{"foo-bar-read", "lookup-self"})
+			So(info.IAMPrincipal, ShouldEqual, "arn:aws:iam::111111111:role/fake-role")
+			So(info.IsAdmin, ShouldBeTrue)
+			So(info.Groups, ShouldResemble, []string{"registered-iam-principals"})
+			So(info.HasPolicy("lookup-self"), ShouldBeTrue)
+			So(info.HasPolicy("admin"), ShouldBeFalse)
+			So(info.Metadata, ShouldNotContainKey, "aws_region")
+		})
+		Convey("Should not share slices with a copy", func() {
+			c := info.Copy()
+			c.Policies[0] = "changed"
+			c.Metadata["groups"] = "changed"
+			So(info.Policies[0], ShouldEqual, "foo-bar-read")
+			So(info.Metadata["groups"], ShouldEqual, "registered-iam-principals")
+		})
+	})
+	Convey("A user client token", t, func() {
+		tok := UserClientToken{
+			Policies: []string{"web"},
+			Metadata: UserMetadata{Username: "john.doe@nike.com", IsAdmin: "false", Groups: "Lst-one, Lst-two,"},
+		}
+		info := tok.TokenInfo()
+		Convey("Should split the groups", func() {
+			So(info.Username, ShouldEqual, "john.doe@nike.com")
+			So(info.IsAdmin, ShouldBeFalse)
+			So(info.Groups, ShouldResemble, []string{"Lst-one", "Lst-two"})
+			So(info.InGroup("Lst-two"), ShouldBeTrue)
+			So(info.InGroup("Lst-three"), ShouldBeFalse)
+		})
+	})
+}
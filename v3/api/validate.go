@@ -0,0 +1,79 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxUserGroupLength is the longest user group name Cerberus accepts
+const maxUserGroupLength = 255
+
+// iamPrincipalARN matches the IAM principal ARNs Cerberus accepts: account roots, and
+// roles, users, groups, or assumed roles in the standard, China, and GovCloud partitions
+var iamPrincipalARN = regexp.MustCompile(`^arn:aws(-cn|-us-gov)?:(iam|sts)::\d{12}:(root|(role|user|group|assumed-role)/[\w+=,.@/-]+)$`)
+
+// ValidateIAMPrincipalARN returns an error if the given string is not a valid IAM principal ARN
+func ValidateIAMPrincipalARN(arn string) error {
+	if !iamPrincipalARN.MatchString(arn) {
+		return fmt.Errorf("%q is not a valid IAM principal ARN", arn)
+	}
+	return nil
+}
+
+// ValidateUserGroup returns an error if the given string is not a valid user group name
+func ValidateUserGroup(name string) error {
+	switch {
+	case len(name) == 0:
+		return fmt.Errorf("User group name cannot be empty")
+	case strings.TrimSpace(name) != name:
+		return fmt.Errorf("User group %q has leading or trailing whitespace", name)
+	case len(name) > maxUserGroupLength:
+		return fmt.Errorf("User group %q is longer than %d characters", name, maxUserGroupLength)
+	case strings.Contains(name, ","):
+		// Cerberus joins group names with commas in token metadata
+		return fmt.Errorf("User group %q cannot contain a comma", name)
+	}
+	return nil
+}
+
+// Validate checks the owner, user group permissions, and IAM principal permissions of the
+// SDB. It returns an ErrorValidation listing every invalid entry, or nil if all are valid
+func (s *SafeDepositBox) Validate() error {
+	var problems []string
+	if s.Owner != "" {
+		if err := ValidateUserGroup(s.Owner); err != nil {
+			problems = append(problems, "owner: "+err.Error())
+		}
+	}
+	for i, p := range s.UserGroupPermissions {
+		if err := ValidateUserGroup(p.Name); err != nil {
+			problems = append(problems, fmt.Sprintf("user_group_permissions[%d]: %v", i, err))
+		}
+	}
+	for i, p := range s.IAMPrincipalPermissions {
+		if err := ValidateIAMPrincipalARN(p.IAMPrincipalARN); err != nil {
+			problems = append(problems, fmt.Sprintf("iam_principal_permissions[%d]: %v", i, err))
+		}
+	}
+	if len(problems) > 0 {
+		return ErrorValidation{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	Convey("Valid IAM principal ARNs", t, func() {
+		for _, arn := range []string{
+			"arn:aws:iam::111111111111:role/role-name",
+			"arn:aws:iam::111111111111:role/path/to/role-name",
+			"arn:aws:iam::111111111111:user/john.doe@nike.com",
+			"arn:aws:iam::111111111111:root",
+			"arn:aws:sts::111111111111:assumed-role/role-name/session",
+			"arn:aws-cn:iam::111111111111:role/role-name",
+			"arn:aws-us-gov:iam::111111111111:role/role-name",
+		} {
+			So(ValidateIAMPrincipalARN(arn), ShouldBeNil)
+		}
+	})
+	Convey("Invalid IAM principal ARNs", t, func() {
+		for _, arn := range []string{
+			"",
+			"role-name",
+			"arn:aws:iam::1111:role/role-name",
+			"arn:aws:s3:::bucket",
+			"arn:aws:iam::111111111111:role/",
+			"arn:aws:iam::111111111111:role/has space",
+		} {
+			So(ValidateIAMPrincipalARN(arn), ShouldNotBeNil)
+		}
+	})
+	Convey("User group names", t, func() {
+		So(ValidateUserGroup("Lst-CDT.CloudPlatformEngine.FTE"), ShouldBeNil)
+		So(ValidateUserGroup(""), ShouldNotBeNil)
+		So(ValidateUserGroup(" Lst-group"), ShouldNotBeNil)
+		So(ValidateUserGroup("Lst-a,Lst-b"), ShouldNotBeNil)
+		So(ValidateUserGroup(strings.Repeat("a", 256)), ShouldNotBeNil)
+	})
+	Convey("An SDB with invalid entries", t, func() {
+		sdb := &SafeDepositBox{
+			Owner: "Lst-owner",
+			UserGroupPermissions: []UserGroupPermission{
+				{Name: "Lst-valid"},
+				{Name: "Lst-a,Lst-b"},
+			},
+			IAMPrincipalPermissions: []IAMPrincipal{
+				{IAMPrincipalARN: "not-an-arn"},
+				{IAMPrincipalARN: "arn:aws:iam::111111111111:role/role-name"},
+			},
+		}
+		Convey("Should list each invalid entry", func() {
+			err := sdb.Validate()
+			So(err, ShouldHaveSameTypeAs, ErrorValidation{})
+			problems := err.(ErrorValidation).Problems
+			So(problems, ShouldHaveLength, 2)
+			So(problems[0], ShouldStartWith, "user_group_permissions[1]")
+			So(problems[1], ShouldStartWith, "iam_principal_permissions[0]")
+		})
+	})
+	Convey("A valid SDB", t, func() {
+		sdb := &SafeDepositBox{Name: "Stage", Owner: "Lst-owner"}
+		So(sdb.Validate(), ShouldBeNil)
+	})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// ClientVersion is the version of the client. It should be updated on version bumps, and is
+// only used when the version can't be read from the build information of the binary
+const ClientVersion = "3.1.0"
+
+// modulePath is the module path of the client, used to find its version in the build information
+const modulePath = "github.com/Nike-Inc/cerberus-go-client/v3"
+
+// features lists the optional Cerberus features this client supports
+var features = []string{"namespace", "token-exchange"}
+
+// BuildInfo describes the client and how it was built
+type BuildInfo struct {
+	// Version is the version of the client, without a leading "v"
+	Version string
+	// GoVersion is the version of Go the client was built with
+	GoVersion string
+	// Features lists the optional Cerberus features the client supports
+	Features []string
+}
+
+// Header returns the value of the X-Cerberus-Client header for this client. If application
+// isn't empty, it is appended so requests can be traced back to the application making them
+func (b BuildInfo) Header(application string) string {
+	header := "CerberusGoClient/" + b.Version + " (" + b.GoVersion
+	if len(b.Features) > 0 {
+		header += "; " + strings.Join(b.Features, ", ")
+	}
+	header += ")"
+	if application != "" {
+		header += " " + application
+	}
+	return header
+}
+
+var (
+	buildInfo     BuildInfo
+	buildInfoOnce sync.Once
+)
+
+// ClientInfo returns the version of the client, the Go version it was built with and the
+// features it supports. The version comes from the build information of the binary when
+// the client is used as a module dependency, and from ClientVersion otherwise
+func ClientInfo() BuildInfo {
+	buildInfoOnce.Do(func() {
+		buildInfo = BuildInfo{
+			Version:   moduleVersion(),
+			GoVersion: runtime.Version(),
+			Features:  features,
+		}
+	})
+	// Copy the features so callers can't change them
+	info := buildInfo
+	info.Features = append([]string(nil), buildInfo.Features...)
+	return info
+}
+
+// moduleVersion returns the version of the client module from the build information, or
+// ClientVersion if it isn't available, such as in tests or when built from source
+func moduleVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ClientVersion
+	}
+	modules := append([]*debug.Module{&bi.Main}, bi.Deps...)
+	for _, m := range modules {
+		if m.Path != modulePath {
+			continue
+		}
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		if strings.HasPrefix(m.Version, "v") {
+			return strings.TrimPrefix(m.Version, "v")
+		}
+	}
+	return ClientVersion
+}
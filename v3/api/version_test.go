@@ -0,0 +1,43 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"runtime"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClientInfo(t *testing.T) {
+	Convey("The client info", t, func() {
+		info := ClientInfo()
+		Convey("Should fall back to ClientVersion when built from source", func() {
+			So(info.Version, ShouldEqual, ClientVersion)
+			So(info.GoVersion, ShouldEqual, runtime.Version())
+			So(info.Features, ShouldContain, "namespace")
+		})
+		Convey("Should build the client header", func() {
+			So(info.Header(""), ShouldStartWith, ClientHeader+" ("+runtime.Version()+"; ")
+			So(info.Header("my-service/1.4.2"), ShouldEndWith, ") my-service/1.4.2")
+		})
+		Convey("Should not be changed by callers", func() {
+			info.Features[0] = "changed"
+			So(ClientInfo().Features[0], ShouldEqual, "namespace")
+		})
+	})
+}
@@ -20,6 +20,7 @@ limitations under the License.
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -30,9 +31,38 @@ import (
 	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
 )
 
-// expiryDelta is the amount of time to subtract from the expiry time to compensate for
-// network request time and clock skew
-const expiryDelta time.Duration = 60 * time.Second
+// DefaultExpiryDelta is the default amount of time to subtract from the expiry time to
+// compensate for network request time and clock skew
+const DefaultExpiryDelta time.Duration = 60 * time.Second
+
+// DefaultSTSTimeout is how long an STSAuth waits for Cerberus to answer an authentication
+// request, unless it is given a timeout or an HTTP client
+const DefaultSTSTimeout = 10 * time.Second
+
+// expiryWithDelta returns expiresAt less delta. At most half of the lifetime left from now is
+// subtracted, so a token that lives shorter than the delta can still be used
+func expiryWithDelta(now, expiresAt time.Time, delta time.Duration) time.Time {
+	if lifetime := expiresAt.Sub(now); lifetime > 0 && delta > lifetime/2 {
+		delta = lifetime / 2
+	}
+	return expiresAt.Add(-delta)
+}
+
+// clockSkew returns how far the server's clock is ahead of the local clock, using the
+// Date header of the given response and the local time now. It returns 0 if the header is
+// missing or invalid
+func clockSkew(resp *http.Response, now time.Time) time.Duration {
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+	// The Date header only has second precision, so ignore anything smaller
+	skew := serverTime.Sub(now)
+	if skew > -time.Second && skew < time.Second {
+		return 0
+	}
+	return skew
+}
 
 // The Auth interface describes the methods that all authentication providers must satisfy
 type Auth interface {
@@ -56,18 +86,118 @@ type Auth interface {
 	GetExpiry() (time.Time, error)
 }
 
+// ContextRefresher is implemented by auth methods that can refresh a token using a context.
+// The client uses it when a request triggers a token refresh, so the refresh respects the
+// deadline and cancellation of the request that triggered it
+type ContextRefresher interface {
+	RefreshContext(ctx context.Context) error
+}
+
+// ContextAuth is implemented by auth methods whose requests can be bounded and cancelled with
+// a context. Every auth method in this package implements it
+type ContextAuth interface {
+	Auth
+	ContextRefresher
+	// GetTokenContext is the same as GetToken, but authentication requests use the given context
+	GetTokenContext(ctx context.Context, f *os.File) (string, error)
+	// LogoutContext is the same as Logout, but the request uses the given context
+	LogoutContext(ctx context.Context) error
+}
+
+// GetTokenContext gets a token from a, using GetTokenContext if a is a ContextAuth so the
+// context bounds authentication. Other auth methods fall back to GetToken, after checking
+// that the context isn't done already
+func GetTokenContext(ctx context.Context, a Auth, f *os.File) (string, error) {
+	if c, ok := a.(ContextAuth); ok {
+		return c.GetTokenContext(ctx, f)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.GetToken(f)
+}
+
+// PrincipalProvider is implemented by auth methods that know which principal, such as an IAM
+// role ARN or a username, they authenticated as
+type PrincipalProvider interface {
+	Principal() string
+}
+
+// ErrorNoTokenInfo is returned by GetTokenInfo when the auth method has a token, but Cerberus
+// hasn't returned its metadata yet, e.g. for a TokenAuth that hasn't been refreshed
+var ErrorNoTokenInfo = fmt.Errorf("No metadata is known for the current token")
+
+// TokenInfoProvider is implemented by auth methods that keep the metadata Cerberus returns
+// with a token, such as its policies, groups and admin status. GetTokenInfo returns a copy of
+// it, api.ErrorUnauthenticated if there is no token, or ErrorNoTokenInfo if it is not known
+type TokenInfoProvider interface {
+	GetTokenInfo() (api.TokenInfo, error)
+}
+
+// cookieJarHolder is implemented by auth methods that can be given a cookie jar
+type cookieJarHolder interface {
+	cookieJar() http.CookieJar
+}
+
+// cacheKeyer is implemented by auth methods that can tell which principal and namespace their
+// token is for before authenticating, so CachedAuth doesn't hand out the token of another one.
+// An empty key means the auth method can't tell
+type cacheKeyer interface {
+	cacheKey() string
+}
+
+// namespaceKey returns the part of a cache key for the namespace in headers
+func namespaceKey(headers http.Header) string {
+	if namespace := headers.Get(api.NamespaceHeader); namespace != "" {
+		return "namespace=" + namespace
+	}
+	return ""
+}
+
+// httpClientHolder is implemented by auth methods that can be given an HTTP client
+type httpClientHolder interface {
+	authHTTPClient() *http.Client
+}
+
+// newHTTPClient returns the HTTP client for auth requests, which sends headers with every
+// request. It is a copy of base, if one was set with WithHTTPClient, so its timeout, transport
+// and TLS settings are kept. If jar isn't nil it is used for cookies, such as the session of
+// an authenticating proxy in front of Cerberus
+func newHTTPClient(base *http.Client, headers http.Header, jar http.CookieJar) *http.Client {
+	if base == nil {
+		client := utils.NewHttpClient(headers)
+		client.Jar = jar
+		return client
+	}
+	client := *base
+	client.Transport = utils.RoundTripperWithDefaultHeaders(base.Transport, headers)
+	if jar != nil {
+		client.Jar = jar
+	}
+	return &client
+}
+
 // Refresh contains logic for refreshing a token against the API. Because
 // all tokens can be refreshed this way, it is better to keep this in one place
 func Refresh(builtURL url.URL, headers http.Header) (*api.UserAuthResponse, error) {
+	return RefreshContext(context.Background(), builtURL, headers)
+}
+
+// RefreshContext is the same as Refresh, but the request uses the given context
+func RefreshContext(ctx context.Context, builtURL url.URL, headers http.Header) (*api.UserAuthResponse, error) {
+	return refresh(ctx, newHTTPClient(nil, headers, nil), builtURL, headers)
+}
+
+func refresh(ctx context.Context, client *http.Client, builtURL url.URL, headers http.Header) (*api.UserAuthResponse, error) {
 	builtURL.Path = "/v2/auth/user/refresh"
-	req, err := http.NewRequest("GET", builtURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", builtURL.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = headers
-	resp, err := (utils.NewHttpClient(headers)).Do(req)
+	req.Header = headers.Clone()
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Problem while performing request to Cerberus: %v", err)
+		return nil, fmt.Errorf("Problem while performing request to Cerberus: %w", utils.ConnectionError(req.URL.Host, err))
 	}
 	r, checkErr := utils.CheckAndParse(resp)
 	if checkErr != nil {
@@ -78,16 +208,26 @@ func Refresh(builtURL url.URL, headers http.Header) (*api.UserAuthResponse, erro
 
 // Logout takes a set of headers containing a token and a URL and logs out of Cerberus.
 func Logout(builtURL url.URL, headers http.Header) error {
+	return LogoutContext(context.Background(), builtURL, headers)
+}
+
+// LogoutContext is the same as Logout, but the request uses the given context
+func LogoutContext(ctx context.Context, builtURL url.URL, headers http.Header) error {
+	return logout(ctx, newHTTPClient(nil, headers, nil), builtURL, headers)
+}
+
+func logout(ctx context.Context, client *http.Client, builtURL url.URL, headers http.Header) error {
 	builtURL.Path = "/v1/auth"
-	req, err := http.NewRequest("DELETE", builtURL.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", builtURL.String(), nil)
 	if err != nil {
 		return err
 	}
-	req.Header = headers
-	resp, err := (utils.NewHttpClient(headers)).Do(req)
+	req.Header = headers.Clone()
+	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("Problem while performing request to Cerberus: %v", err)
+		return fmt.Errorf("Problem while performing request to Cerberus: %w", utils.ConnectionError(req.URL.Host, err))
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusNoContent {
 		return fmt.Errorf("Unable to log out. Got HTTP response code %d", resp.StatusCode)
 	}
@@ -17,10 +17,15 @@ limitations under the License.
 package auth
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	. "github.com/smartystreets/goconvey/convey"
@@ -158,3 +163,180 @@ func TestLogout(t *testing.T) {
 		})
 	})
 }
+
+func TestClockSkew(t *testing.T) {
+	Convey("A response without a Date header", t, func() {
+		So(clockSkew(&http.Response{Header: http.Header{}}, time.Now()), ShouldEqual, 0)
+	})
+	Convey("A response from a server with a matching clock", t, func() {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		So(clockSkew(resp, time.Now()), ShouldEqual, 0)
+	})
+	Convey("A response from a server with a slow clock", t, func() {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Date", time.Now().Add(-10*time.Minute).UTC().Format(http.TimeFormat))
+		So(clockSkew(resp, time.Now()), ShouldAlmostEqual, -10*time.Minute, 2*time.Second)
+	})
+}
+
+// Every auth method in this package supports contexts
+var (
+	_ ContextAuth = &TokenAuth{}
+	_ ContextAuth = &STSAuth{}
+	_ ContextAuth = &CachedAuth{}
+)
+
+func TestContextAuth(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	Convey("A cancelled context", t, func() {
+		requests = 0
+		Convey("Should stop a TokenAuth", func() {
+			a, _ := NewTokenAuth(ts.URL, "token")
+			_, err := a.GetTokenContext(cancelled, nil)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			err = a.LogoutContext(cancelled)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+			So(requests, ShouldEqual, 0)
+		})
+		Convey("Should stop an STSAuth from authenticating", func() {
+			a, _ := NewSTSAuth(ts.URL, "us-west-2")
+			_, err := a.GetTokenContext(cancelled, nil)
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+			So(requests, ShouldEqual, 0)
+		})
+		Convey("Should stop an Auth without context support", func() {
+			a := &countingAuth{}
+			_, err := GetTokenContext(cancelled, a, nil)
+			So(err, ShouldEqual, context.Canceled)
+			So(a.logins, ShouldEqual, 0)
+		})
+	})
+
+	Convey("A live context", t, func() {
+		requests = 0
+		a, _ := NewTokenAuth(ts.URL, "token")
+		Convey("Should log out", func() {
+			So(a.LogoutContext(context.Background()), ShouldBeNil)
+			So(requests, ShouldEqual, 1)
+			So(a.IsAuthenticated(), ShouldBeFalse)
+		})
+	})
+}
+
+// hammer runs every function from 10 goroutines at once, 20 times each, so the race detector
+// can catch unsynchronized access to shared state
+func hammer(fns ...func()) {
+	var wg sync.WaitGroup
+	for _, fn := range fns {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(fn func()) {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					fn()
+				}
+			}(fn)
+		}
+	}
+	wg.Wait()
+}
+
+// authServer answers STS authentication and refresh requests
+func authServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "sts-identity") {
+			w.Write([]byte(responseBody))
+			return
+		}
+		w.Write([]byte(authResponseBody))
+	}))
+}
+
+func TestExpiryWithDelta(t *testing.T) {
+	now := time.Now()
+	Convey("The expiry of a token", t, func() {
+		Convey("Should have the delta subtracted", func() {
+			So(expiryWithDelta(now, now.Add(time.Hour), time.Minute), ShouldEqual, now.Add(59*time.Minute))
+		})
+		Convey("Should keep half the lifetime of a short-lived token", func() {
+			So(expiryWithDelta(now, now.Add(30*time.Second), time.Minute), ShouldEqual, now.Add(15*time.Second))
+		})
+	})
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.calls++
+	c.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (c *countingTransport) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	ts := authServer()
+	defer ts.Close()
+	clearAWSEnvironment(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	Convey("Auth methods with an HTTP client", t, func() {
+		transport := &countingTransport{}
+		client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+		Convey("Should use it to authenticate with STS", func() {
+			a, err := NewSTSAuth(ts.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			_, err = a.WithHTTPClient(client).GetToken(nil)
+			So(err, ShouldBeNil)
+			So(transport.count(), ShouldEqual, 1)
+			// The test server doesn't answer logouts with a 204, only the request matters
+			a.Logout()
+			So(transport.count(), ShouldEqual, 2)
+		})
+
+		Convey("Should use it to refresh a token", func() {
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			So(tok.WithHTTPClient(client).Refresh(), ShouldBeNil)
+			So(transport.count(), ShouldEqual, 1)
+		})
+
+		Convey("Should pass it on to exchanged tokens", func() {
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			exchanged, err := Exchange(context.Background(), tok.WithHTTPClient(client), api.TokenScope{})
+			So(err, ShouldBeNil)
+			So(transport.count(), ShouldEqual, 1)
+			So(exchanged.authHTTPClient(), ShouldEqual, client)
+		})
+
+		Convey("Should not change the client", func() {
+			base := newHTTPClient(client, http.Header{"X-Cerberus-Token": {"token"}}, nil)
+			So(base.Timeout, ShouldEqual, 5*time.Second)
+			So(base, ShouldNotEqual, client)
+			So(client.Transport, ShouldEqual, transport)
+		})
+	})
+}
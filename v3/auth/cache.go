@@ -0,0 +1,567 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxRefreshes is the default number of times a cached token is refreshed before
+// CachedAuth authenticates again. Cerberus limits how many times a token can be refreshed
+const DefaultMaxRefreshes = 24
+
+// DefaultRefreshWindow is the default time before expiry at which a cached token is refreshed
+const DefaultRefreshWindow = 5 * time.Minute
+
+// DefaultCacheDir is the directory in the home directory where NewDefaultCachedAuth keeps
+// tokens. Other Cerberus tools can use the same files to share a token
+const DefaultCacheDir = ".cerberus"
+
+// CacheDirEnv is the environment variable that overrides the directory used by
+// NewDefaultCachedAuth
+const CacheDirEnv = "CERBERUS_TOKEN_CACHE_DIR"
+
+// cachedToken is the token information persisted between runs
+type cachedToken struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	Expiry    time.Time `json:"expiry"`
+	Refreshes int       `json:"refresh_count"`
+	Principal string    `json:"principal,omitempty"`
+	// Key identifies the principal and namespace of the wrapped Auth the token was issued for
+	Key string `json:"key,omitempty"`
+	// Info is the metadata Cerberus returned with the token, if it is known
+	Info *api.TokenInfo `json:"info,omitempty"`
+	// Fingerprint is the client the token was issued to, if fingerprint binding is on
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"`
+}
+
+// CachedAuth wraps another Auth and keeps its token in a TokenStore so it can be reused across
+// process restarts, which is useful for CLI workflows. When the cached token is close to
+// expiry it is refreshed instead of authenticating again, up to the refresh limit. If the
+// refresh fails or the limit is reached, it falls back to the wrapped Auth.
+type CachedAuth struct {
+	auth  Auth
+	store TokenStore
+	// mu guards cache and loaded, and serializes access to the store
+	mu     sync.Mutex
+	cache  *cachedToken
+	loaded bool
+	login  flight
+	jar    http.CookieJar
+	// fingerprint returns the fingerprint of the client, or is nil if binding is off
+	fingerprint func() Fingerprint
+	// reported is the token a fingerprint mismatch was last logged for
+	reported      string
+	transport     httpSettings
+	clock         Clock
+	hooks         tokenHooks
+	MaxRefreshes  int
+	RefreshWindow time.Duration
+	// ExpiryDelta is subtracted from the expiry of refreshed tokens to compensate for request
+	// time and clock skew, see STSAuth.WithExpiryDelta. Tokens from the wrapped Auth keep the
+	// expiry it reports
+	ExpiryDelta time.Duration
+}
+
+// NewCachedAuth returns a CachedAuth that stores tokens from the given Auth in the file at
+// path. The file and its directory are created when needed
+func NewCachedAuth(a Auth, path string) (*CachedAuth, error) {
+	if a == nil {
+		return nil, fmt.Errorf("Auth cannot be nil")
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("Cache path cannot be empty")
+	}
+	return NewCachedAuthWithStore(a, &FileTokenStore{Path: path})
+}
+
+// NewCachedAuthWithStore returns a CachedAuth that stores tokens from the given Auth in store,
+// e.g. a KeychainTokenStore so tokens aren't kept in plaintext files
+func NewCachedAuthWithStore(a Auth, store TokenStore) (*CachedAuth, error) {
+	if a == nil {
+		return nil, fmt.Errorf("Auth cannot be nil")
+	}
+	if store == nil {
+		return nil, fmt.Errorf("Token store cannot be nil")
+	}
+	return &CachedAuth{
+		auth:          a,
+		store:         store,
+		MaxRefreshes:  DefaultMaxRefreshes,
+		RefreshWindow: DefaultRefreshWindow,
+		ExpiryDelta:   DefaultExpiryDelta,
+	}, nil
+}
+
+// NewDefaultCachedAuth returns a CachedAuth that stores tokens from the given Auth in the
+// file returned by DefaultCachePath. When the Auth can tell which principal and namespace it
+// authenticates as, such as the AWS profile or role of an STSAuth, they get a file of their own
+func NewDefaultCachedAuth(a Auth) (*CachedAuth, error) {
+	if a == nil {
+		return nil, fmt.Errorf("Auth cannot be nil")
+	}
+	path, err := DefaultCachePath(a.GetURL())
+	if err != nil {
+		return nil, err
+	}
+	if key := cacheKey(a); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		path += "-" + hex.EncodeToString(sum[:])[:12]
+	}
+	return NewCachedAuth(a, path)
+}
+
+// cacheKey returns the cache key of a, or an empty string if it doesn't have one
+func cacheKey(a Auth) string {
+	if k, ok := a.(cacheKeyer); ok {
+		return k.cacheKey()
+	}
+	return ""
+}
+
+// DefaultCachePath returns the token cache file for a Cerberus URL. Each Cerberus host gets its
+// own file in the directory set by CacheDirEnv, or in DefaultCacheDir in the home directory
+func DefaultCachePath(cerberusURL *url.URL) (string, error) {
+	if cerberusURL == nil || cerberusURL.Host == "" {
+		return "", fmt.Errorf("Cerberus URL cannot be empty")
+	}
+	dir := os.Getenv(CacheDirEnv)
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("Unable to find the home directory for the token cache: %v", err)
+		}
+		dir = filepath.Join(home, DefaultCacheDir)
+	}
+	// Ports are separated with "_" as ":" isn't allowed in file names on Windows
+	return filepath.Join(dir, "token-"+strings.ReplaceAll(cerberusURL.Host, ":", "_")), nil
+}
+
+// WithClock sets the clock used to decide when the cached token expires or should be
+// refreshed. It defaults to SystemClock and is meant for simulating expiry in tests
+func (c *CachedAuth) WithClock(clock Clock) *CachedAuth {
+	c.clock = clock
+	return c
+}
+
+// WithCookieJar sets the cookie jar used for refresh and logout requests, for when Cerberus is
+// behind an authenticating proxy that uses session cookies. The wrapped Auth needs its own
+// cookie jar, which is usually the same one
+func (c *CachedAuth) WithCookieJar(jar http.CookieJar) *CachedAuth {
+	c.jar = jar
+	return c
+}
+
+// WithHTTPClient sets the HTTP client used for refresh and logout requests, e.g. to control
+// their timeout, transport or TLS settings. The wrapped Auth needs its own HTTP client
+func (c *CachedAuth) WithHTTPClient(client *http.Client) *CachedAuth {
+	c.transport.setClient(client)
+	return c
+}
+
+// WithProxy sets the proxy for the refresh and logout requests to Cerberus, e.g. http.ProxyURL of your egress
+// proxy, or NoProxy to connect directly. Nil, the default, uses the proxy of the HTTP client,
+// which for the default client is HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment.
+// The wrapped Auth needs its own proxy
+func (c *CachedAuth) WithProxy(proxy func(*http.Request) (*url.URL, error)) *CachedAuth {
+	c.transport.setProxy(proxy)
+	return c
+}
+
+// WithTLSConfig sets the TLS config for the refresh and logout requests to Cerberus, e.g. a client
+// certificate for a gateway that requires mutual TLS or a custom CA pool, see
+// utils.ClientTLSConfig. Nil, the default, uses the TLS config of the HTTP client.
+// The wrapped Auth needs its own TLS config
+func (c *CachedAuth) WithTLSConfig(config *tls.Config) *CachedAuth {
+	c.transport.setTLS(config)
+	return c
+}
+
+// WithTimeout bounds each refresh and logout request to Cerberus, including reading the
+// response, and overrides the timeout of the HTTP client. Zero, the default, keeps the timeout
+// of the client, which for the default client is none. The wrapped Auth needs its own timeout
+func (c *CachedAuth) WithTimeout(timeout time.Duration) *CachedAuth {
+	c.transport.setTimeout(timeout)
+	return c
+}
+
+// WithRetry retries the refresh and logout requests to Cerberus that fail with a connection error or a
+// 429, 502, 503 or 504 response, e.g. DefaultRetryPolicy. All attempts together are bounded by
+// the timeout and the context of the request. Requests aren't retried by default. The wrapped
+// Auth needs its own retries
+func (c *CachedAuth) WithRetry(policy RetryPolicy) *CachedAuth {
+	c.transport.setRetry(&policy)
+	return c
+}
+
+// OnTokenChange adds a handler that is called after every authentication, refresh and logout.
+// Loading a token from the cache file is not a change
+func (c *CachedAuth) OnTokenChange(handler TokenChangeHandler) *CachedAuth {
+	c.hooks = append(c.hooks, handler)
+	return c
+}
+
+func (c *CachedAuth) cookieJar() http.CookieJar {
+	return c.jar
+}
+
+func (c *CachedAuth) authHTTPClient() *http.Client {
+	return c.transport.get()
+}
+
+// GetToken returns the cached token if it is valid. A token that expires within the refresh
+// window is refreshed first. If there is no usable token, the wrapped Auth is used to get one
+func (c *CachedAuth) GetToken(f *os.File) (string, error) {
+	return c.GetTokenContext(context.Background(), f)
+}
+
+// GetTokenContext is the same as GetToken, but the refresh request and the wrapped Auth use
+// the given context. Concurrent calls that need a new token share one refresh or login
+func (c *CachedAuth) GetTokenContext(ctx context.Context, f *os.File) (string, error) {
+	if cache := c.current(); c.fresh(cache) {
+		return cache.Token, nil
+	}
+	// Concurrent callers share one refresh or authentication
+	var token string
+	err := c.login.do(ctx, func() error {
+		cache := c.current()
+		if c.fresh(cache) {
+			token = cache.Token
+			return nil
+		}
+		if c.valid(cache) && cache.Refreshes < c.MaxRefreshes {
+			if refreshed, err := c.refresh(ctx, cache); err == nil {
+				token = refreshed.Token
+				return nil
+			}
+		}
+		authenticated, err := c.authenticate(ctx, f)
+		if err != nil {
+			return err
+		}
+		token = authenticated.Token
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		// This caller waited for another one, so use the token it got
+		if cache := c.current(); c.valid(cache) {
+			return cache.Token, nil
+		}
+		return "", api.ErrorUnauthenticated
+	}
+	return token, nil
+}
+
+// fresh returns whether cache holds a token that is valid and outside the refresh window
+func (c *CachedAuth) fresh(cache *cachedToken) bool {
+	return c.valid(cache) && cache.Expiry.Sub(clockOrSystem(c.clock).Now()) > c.RefreshWindow
+}
+
+// IsAuthenticated returns whether there is a cached token that has not expired
+func (c *CachedAuth) IsAuthenticated() bool {
+	return c.valid(c.current())
+}
+
+// Refresh refreshes the cached token, or authenticates again with the wrapped Auth if the
+// refresh limit was reached or the refresh fails
+func (c *CachedAuth) Refresh() error {
+	return c.RefreshContext(context.Background())
+}
+
+// RefreshContext is the same as Refresh, but uses the given context for the refresh request
+// and for the wrapped Auth if it is a ContextRefresher
+func (c *CachedAuth) RefreshContext(ctx context.Context) error {
+	cache := c.current()
+	if !c.valid(cache) {
+		return api.ErrorUnauthenticated
+	}
+	if cache.Refreshes < c.MaxRefreshes {
+		if _, err := c.refresh(ctx, cache); err == nil {
+			return nil
+		}
+	}
+	_, err := c.authenticate(ctx, nil)
+	return err
+}
+
+// Logout logs out the cached token and removes it from the store
+func (c *CachedAuth) Logout() error {
+	return c.LogoutContext(context.Background())
+}
+
+// LogoutContext is the same as Logout, but the request uses the given context
+func (c *CachedAuth) LogoutContext(ctx context.Context) error {
+	headers, err := c.GetHeaders()
+	if err != nil {
+		return err
+	}
+	if err := logout(ctx, newHTTPClient(c.transport.get(), headers, c.jar), *c.GetURL(), headers); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cache = nil
+	err = c.store.Delete()
+	c.mu.Unlock()
+	c.hooks.notify(TokenLoggedOut, "", time.Time{})
+	return err
+}
+
+// GetHeaders returns the headers needed to authenticate against Cerberus. This will
+// return an error if the token is expired or non-existent. The headers are a new copy
+// on every call
+func (c *CachedAuth) GetHeaders() (http.Header, error) {
+	cache := c.current()
+	if !c.valid(cache) {
+		return nil, api.ErrorUnauthenticated
+	}
+	return c.headers(cache), nil
+}
+
+// headers returns the headers for the given cached token
+func (c *CachedAuth) headers(cache *cachedToken) http.Header {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Accept", "application/json")
+	headers.Set("X-Cerberus-Token", cache.Token)
+	// Keep anything else the wrapped Auth sends, such as a namespace
+	if wrapped, err := c.auth.GetHeaders(); err == nil {
+		for k, v := range wrapped {
+			if headers.Get(k) == "" {
+				headers[k] = v
+			}
+		}
+	}
+	return headers
+}
+
+// Principal returns the principal the cached token was issued to, or an empty string if it
+// is not known
+func (c *CachedAuth) Principal() string {
+	if cache := c.current(); cache != nil {
+		return cache.Principal
+	}
+	return ""
+}
+
+// GetTokenInfo returns the policies, groups and other metadata Cerberus returned with the
+// cached token. The metadata is cached with the token, so it is known after a restart too,
+// except for tokens cached by older versions, for which it returns ErrorNoTokenInfo
+func (c *CachedAuth) GetTokenInfo() (api.TokenInfo, error) {
+	cache := c.current()
+	if !c.valid(cache) {
+		return api.TokenInfo{}, api.ErrorUnauthenticated
+	}
+	if cache.Info == nil {
+		return api.TokenInfo{}, ErrorNoTokenInfo
+	}
+	return cache.Info.Copy(), nil
+}
+
+// GetURL returns the Cerberus URL of the wrapped Auth
+func (c *CachedAuth) GetURL() *url.URL {
+	return c.auth.GetURL()
+}
+
+// GetExpiry returns the expiry time of the cached token, or a zero-valued time.Time
+// and an error if there is no token
+func (c *CachedAuth) GetExpiry() (time.Time, error) {
+	cache := c.current()
+	if cache == nil || len(cache.Token) == 0 {
+		return time.Time{}, fmt.Errorf("Expiry time not set")
+	}
+	return cache.Expiry, nil
+}
+
+// valid returns whether cache holds a token that has not expired
+func (c *CachedAuth) valid(cache *cachedToken) bool {
+	return cache != nil && len(cache.Token) > 0 && clockOrSystem(c.clock).Now().Before(cache.Expiry)
+}
+
+// current returns the cached token, loading it from the store on first use. A cachedToken is
+// never modified once it is cached, so it can be used without holding the lock
+func (c *CachedAuth) current() *cachedToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	return c.cache
+}
+
+// refresh uses the refresh endpoint to replace the given token and saves the new one
+func (c *CachedAuth) refresh(ctx context.Context, cache *cachedToken) (*cachedToken, error) {
+	headers := c.headers(cache)
+	if c.fingerprint != nil {
+		c.mu.Lock()
+		c.checkFingerprint(cache)
+		c.mu.Unlock()
+		headers.Set(FingerprintHeader, c.fingerprint().String())
+	}
+	r, err := refresh(ctx, newHTTPClient(c.transport.get(), headers, c.jar), *c.GetURL(), headers)
+	if err != nil {
+		log.Info(fmt.Sprintf("Unable to refresh cached token: %v", err))
+		return nil, err
+	}
+	now := clockOrSystem(c.clock).Now()
+	info := r.Data.ClientToken.TokenInfo()
+	refreshed := &cachedToken{
+		URL:       c.GetURL().String(),
+		Token:     r.Data.ClientToken.ClientToken,
+		Expiry:    expiryWithDelta(now, r.Data.ClientToken.ExpiresAt(now), c.ExpiryDelta),
+		Refreshes: cache.Refreshes + 1,
+		Principal: cache.Principal,
+		Key:       cache.Key,
+		Info:      &info,
+		// Keep the fingerprint of the client the token was first issued to
+		Fingerprint: cache.Fingerprint,
+	}
+	if err := c.save(refreshed); err != nil {
+		return nil, err
+	}
+	c.hooks.notify(TokenRefreshed, refreshed.Token, refreshed.Expiry)
+	return refreshed, nil
+}
+
+// authenticate gets a new token from the wrapped Auth and saves it
+func (c *CachedAuth) authenticate(ctx context.Context, f *os.File) (*cachedToken, error) {
+	if c.auth.IsAuthenticated() {
+		// The wrapped Auth has a token already, so make sure it is a new one
+		var err error
+		if refresher, ok := c.auth.(ContextRefresher); ok {
+			err = refresher.RefreshContext(ctx)
+		} else {
+			err = c.auth.Refresh()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	token, err := GetTokenContext(ctx, c.auth, f)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := c.auth.GetExpiry()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to cache a token without an expiry time: %v", err)
+	}
+	cache := &cachedToken{
+		URL:    c.GetURL().String(),
+		Token:  token,
+		Expiry: expiry,
+		Key:    cacheKey(c.auth),
+	}
+	if p, ok := c.auth.(PrincipalProvider); ok {
+		cache.Principal = p.Principal()
+	}
+	if p, ok := c.auth.(TokenInfoProvider); ok {
+		if info, err := p.GetTokenInfo(); err == nil {
+			cache.Info = &info
+		}
+	}
+	if c.fingerprint != nil {
+		fingerprint := c.fingerprint()
+		cache.Fingerprint = &fingerprint
+	}
+	if err := c.save(cache); err != nil {
+		return nil, err
+	}
+	c.hooks.notify(TokenAuthenticated, cache.Token, cache.Expiry)
+	return cache, nil
+}
+
+// load reads the store once. A missing or unreadable token, or a token for a different
+// Cerberus URL, principal or namespace, is treated as an empty cache. An expired token can't be used or refreshed,
+// so it is removed. The caller must hold c.mu
+func (c *CachedAuth) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	data, err := c.store.Load()
+	if err != nil {
+		log.Info(fmt.Sprintf("Ignoring unreadable token cache %v: %v", c.store, err))
+		return
+	}
+	if data == nil {
+		return
+	}
+	cache := &cachedToken{}
+	if err := json.Unmarshal(data, cache); err != nil {
+		log.Info(fmt.Sprintf("Ignoring invalid token cache %v: %v", c.store, err))
+		return
+	}
+	if cache.URL != c.GetURL().String() {
+		return
+	}
+	if !c.issuedFor(cache) {
+		log.Info(fmt.Sprintf("Ignoring token cache %v for another principal or namespace", c.store))
+		return
+	}
+	if !clockOrSystem(c.clock).Now().Before(cache.Expiry) {
+		if err := c.store.Delete(); err != nil {
+			log.Info(fmt.Sprintf("Unable to remove expired token cache %v: %v", c.store, err))
+		}
+		return
+	}
+	c.checkFingerprint(cache)
+	c.cache = cache
+}
+
+// issuedFor returns whether cache was issued for the principal and namespace of the wrapped
+// Auth, as far as it can tell. Tokens cached without a key, e.g. by older versions, are only
+// used by an Auth without one
+func (c *CachedAuth) issuedFor(cache *cachedToken) bool {
+	if cache.Key != cacheKey(c.auth) {
+		return false
+	}
+	if p, ok := c.auth.(PrincipalProvider); ok {
+		if principal := p.Principal(); principal != "" && cache.Principal != "" && principal != cache.Principal {
+			return false
+		}
+	}
+	return true
+}
+
+// save caches the token and writes it to the store
+func (c *CachedAuth) save(cache *cachedToken) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loaded = true
+	c.cache = cache
+	return c.store.Save(data)
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// countingAuth is an Auth that hands out numbered tokens and counts logins
+type countingAuth struct {
+	baseURL *url.URL
+	token   string
+	expiry  time.Time
+	logins  int
+}
+
+func (a *countingAuth) GetToken(*os.File) (string, error) {
+	if !a.IsAuthenticated() {
+		a.logins++
+		a.token = fmt.Sprintf("login-token-%d", a.logins)
+		a.expiry = time.Now().Add(time.Hour)
+	}
+	return a.token, nil
+}
+
+func (a *countingAuth) IsAuthenticated() bool {
+	return a.token != "" && time.Now().Before(a.expiry)
+}
+
+func (a *countingAuth) Refresh() error {
+	a.token = ""
+	_, err := a.GetToken(nil)
+	return err
+}
+
+func (a *countingAuth) Logout() error {
+	a.token = ""
+	return nil
+}
+
+func (a *countingAuth) GetHeaders() (http.Header, error) {
+	if !a.IsAuthenticated() {
+		return nil, api.ErrorUnauthenticated
+	}
+	return http.Header{"X-Cerberus-Token": []string{a.token}}, nil
+}
+
+func (a *countingAuth) GetURL() *url.URL {
+	return a.baseURL
+}
+
+func (a *countingAuth) GetExpiry() (time.Time, error) {
+	return a.expiry, nil
+}
+
+// keyedAuth is a countingAuth that knows its principal before authenticating
+type keyedAuth struct {
+	countingAuth
+	key       string
+	principal string
+}
+
+func (a *keyedAuth) cacheKey() string {
+	return a.key
+}
+
+func (a *keyedAuth) Principal() string {
+	return a.principal
+}
+
+func writeCache(path string, cache cachedToken) {
+	data, _ := json.Marshal(cache)
+	os.MkdirAll(filepath.Dir(path), 0700)
+	os.WriteFile(path, data, 0600)
+}
+
+func TestNewCachedAuth(t *testing.T) {
+	Convey("A nil Auth", t, func() {
+		c, err := NewCachedAuth(nil, "/tmp/token")
+		So(err, ShouldNotBeNil)
+		So(c, ShouldBeNil)
+	})
+	Convey("An empty path", t, func() {
+		c, err := NewCachedAuth(&countingAuth{}, "")
+		So(err, ShouldNotBeNil)
+		So(c, ShouldBeNil)
+	})
+}
+
+func TestDefaultCachePath(t *testing.T) {
+	u, _ := url.Parse("https://cerberus.example.com:8443")
+	Convey("The default cache path", t, func() {
+		Convey("Should be in the home directory", func() {
+			os.Setenv(CacheDirEnv, "")
+			home, _ := os.UserHomeDir()
+			path, err := DefaultCachePath(u)
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, filepath.Join(home, ".cerberus", "token-cerberus.example.com_8443"))
+		})
+		Convey("Should use the directory from the environment", func() {
+			dir := t.TempDir()
+			os.Setenv(CacheDirEnv, dir)
+			defer os.Unsetenv(CacheDirEnv)
+			c, err := NewDefaultCachedAuth(&countingAuth{baseURL: u})
+			So(err, ShouldBeNil)
+			So(c.store.(*FileTokenStore).Path, ShouldEqual, filepath.Join(dir, "token-cerberus.example.com_8443"))
+		})
+		Convey("Should be separate for each principal and namespace", func() {
+			os.Setenv(CacheDirEnv, t.TempDir())
+			defer os.Unsetenv(CacheDirEnv)
+			a, _ := NewDefaultCachedAuth(&keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "profile=a"})
+			b, _ := NewDefaultCachedAuth(&keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "profile=b"})
+			again, _ := NewDefaultCachedAuth(&keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "profile=a"})
+			So(a.store.(*FileTokenStore).Path, ShouldNotEqual, b.store.(*FileTokenStore).Path)
+			So(a.store.(*FileTokenStore).Path, ShouldEqual, again.store.(*FileTokenStore).Path)
+			So(filepath.Base(a.store.(*FileTokenStore).Path), ShouldStartWith, "token-cerberus.example.com_8443-")
+		})
+		Convey("Should need a URL", func() {
+			_, err := DefaultCachePath(&url.URL{})
+			So(err, ShouldNotBeNil)
+			c, err := NewDefaultCachedAuth(nil)
+			So(err, ShouldNotBeNil)
+			So(c, ShouldBeNil)
+		})
+	})
+}
+
+func TestCachedAuth(t *testing.T) {
+	var refreshes int
+	var refreshFails bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if refreshFails {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		refreshes++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(authResponseBody))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	Convey("A CachedAuth", t, func() {
+		refreshes = 0
+		refreshFails = false
+		path := filepath.Join(t.TempDir(), "cerberus", "token")
+		wrapped := &countingAuth{baseURL: u}
+		c, err := NewCachedAuth(wrapped, path)
+		So(err, ShouldBeNil)
+
+		Convey("Without a cache file should log in and save the token", func() {
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+			So(wrapped.logins, ShouldEqual, 1)
+			Convey("And a new process should reuse it", func() {
+				next, _ := NewCachedAuth(&countingAuth{baseURL: u}, path)
+				tok, err := next.GetToken(nil)
+				So(err, ShouldBeNil)
+				So(tok, ShouldEqual, "login-token-1")
+				So(next.auth.(*countingAuth).logins, ShouldEqual, 0)
+			})
+		})
+
+		Convey("With a token close to expiry should refresh it", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Minute), Refreshes: 2})
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "a-cool-token")
+			So(refreshes, ShouldEqual, 1)
+			So(wrapped.logins, ShouldEqual, 0)
+			So(c.cache.Refreshes, ShouldEqual, 3)
+			So(c.cache.Expiry, ShouldHappenAfter, time.Now().Add(50*time.Minute))
+			Convey("And a new process should know its metadata", func() {
+				next, _ := NewCachedAuth(&countingAuth{baseURL: u}, path)
+				info, err := next.GetTokenInfo()
+				So(err, ShouldBeNil)
+				So(info.Username, ShouldEqual, "john.doe@nike.com")
+				So(info.Policies, ShouldResemble, []string{"web", "stage"})
+			})
+		})
+
+		Convey("With a token cached without metadata should not know it", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Hour)})
+			_, err := c.GetTokenInfo()
+			So(err, ShouldEqual, ErrorNoTokenInfo)
+		})
+
+		Convey("With a token that reached the refresh limit should log in", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Minute), Refreshes: DefaultMaxRefreshes})
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+			So(refreshes, ShouldEqual, 0)
+			So(c.cache.Refreshes, ShouldEqual, 0)
+		})
+
+		Convey("With a failing refresh should fall back to logging in", func() {
+			refreshFails = true
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Minute)})
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+		})
+
+		Convey("With an expired token should log in", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(-time.Minute)})
+			So(c.IsAuthenticated(), ShouldBeFalse)
+			_, err := os.Stat(path)
+			So(os.IsNotExist(err), ShouldBeTrue)
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+		})
+
+		Convey("With a token for a different URL should log in", func() {
+			writeCache(path, cachedToken{URL: "https://other.example.com", Token: "cached", Expiry: time.Now().Add(time.Hour)})
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+		})
+
+		Convey("Should return headers with the cached token", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Hour)})
+			headers, err := c.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get("X-Cerberus-Token"), ShouldEqual, "cached")
+			expiry, err := c.GetExpiry()
+			So(err, ShouldBeNil)
+			So(expiry, ShouldHappenAfter, time.Now())
+		})
+
+		Convey("Should call token change handlers", func() {
+			var changes []TokenChange
+			c.OnTokenChange(func(change TokenChange) { changes = append(changes, change) })
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Minute)})
+			_, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(changes, ShouldHaveLength, 1)
+			So(changes[0].Reason, ShouldEqual, TokenRefreshed)
+			So(changes[0].Token, ShouldEqual, "a-cool-token")
+		})
+
+		Convey("Should only reuse a token of the same principal and namespace", func() {
+			role := &keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "role=a"}
+			a, _ := NewCachedAuth(role, path)
+			_, err := a.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(role.logins, ShouldEqual, 1)
+
+			other := &keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "role=b"}
+			b, _ := NewCachedAuth(other, path)
+			So(b.IsAuthenticated(), ShouldBeFalse)
+			_, err = b.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(other.logins, ShouldEqual, 1)
+
+			same := &keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "role=b"}
+			next, _ := NewCachedAuth(same, path)
+			So(next.IsAuthenticated(), ShouldBeTrue)
+		})
+
+		Convey("Should not reuse a token issued to another principal", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Hour), Principal: "arn:aws:iam::1:role/a"})
+			next, _ := NewCachedAuth(&keyedAuth{countingAuth: countingAuth{baseURL: u}, principal: "arn:aws:iam::1:role/b"}, path)
+			So(next.IsAuthenticated(), ShouldBeFalse)
+		})
+
+		Convey("Should not reuse a token cached without a key by a keyed auth method", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Hour)})
+			next, _ := NewCachedAuth(&keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "profile=default"}, path)
+			So(next.IsAuthenticated(), ShouldBeFalse)
+		})
+
+		Convey("When unauthenticated should error", func() {
+			So(c.Refresh(), ShouldEqual, api.ErrorUnauthenticated)
+			So(c.Logout(), ShouldEqual, api.ErrorUnauthenticated)
+			headers, err := c.GetHeaders()
+			So(err, ShouldEqual, api.ErrorUnauthenticated)
+			So(headers, ShouldBeNil)
+		})
+	})
+}
+
+func TestConcurrentCachedAuth(t *testing.T) {
+	ts := authServer()
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	Convey("A CachedAuth refreshed while it is used", t, func() {
+		path := filepath.Join(t.TempDir(), "token")
+		writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: time.Now().Add(time.Hour)})
+		c, _ := NewCachedAuth(&countingAuth{baseURL: u}, path)
+		c.MaxRefreshes = 1000
+		hammer(
+			func() { c.Refresh() },
+			func() {
+				headers, _ := c.GetHeaders()
+				headers.Set("X-Cerberus-Token", "changed")
+			},
+			func() { c.GetExpiry() },
+		)
+		Convey("Should have a refreshed token", func() {
+			headers, err := c.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get("X-Cerberus-Token"), ShouldEqual, "a-cool-token")
+		})
+	})
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// ChainAuth tries a list of auth methods in order and uses the first one that authenticates,
+// for tools that run both on a laptop and in AWS. If refreshing the token of the method in
+// use fails, the methods after it are tried, so a stale token can fall back to STS:
+//
+//	tokenAuth, _ := auth.NewTokenAuth(cerberusURL, savedToken)
+//	stsAuth, _ := auth.NewSTSAuth(cerberusURL, "us-west-2")
+//	authMethod, _ := auth.NewChainAuth(tokenAuth, stsAuth)
+//
+// A ChainAuth is safe for concurrent use if the auth methods in it are
+type ChainAuth struct {
+	auths []Auth
+	// mu guards current, the index of the auth method in use, which is -1 before one
+	// authenticates
+	mu      sync.Mutex
+	current int
+}
+
+// NewChainAuth returns a ChainAuth that tries the given auth methods in order
+func NewChainAuth(auths ...Auth) (*ChainAuth, error) {
+	if len(auths) == 0 {
+		return nil, fmt.Errorf("At least one auth method is required")
+	}
+	for i, a := range auths {
+		if a == nil {
+			return nil, fmt.Errorf("Auth method %d cannot be nil", i)
+		}
+	}
+	return &ChainAuth{auths: auths, current: -1}, nil
+}
+
+// Current returns the auth method in use, or nil if none has authenticated yet
+func (c *ChainAuth) Current() Auth {
+	a, _ := c.active()
+	return a
+}
+
+// active returns the auth method in use and its index, or nil and -1
+func (c *ChainAuth) active() (Auth, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current < 0 {
+		return nil, -1
+	}
+	return c.auths[c.current], c.current
+}
+
+// GetToken returns the token of the auth method in use. If there is none, or its token
+// expired, the auth methods are tried in order and the first to return a token is used
+func (c *ChainAuth) GetToken(f *os.File) (string, error) {
+	return c.GetTokenContext(context.Background(), f)
+}
+
+// GetTokenContext is the same as GetToken, but the auth methods use the given context
+func (c *ChainAuth) GetTokenContext(ctx context.Context, f *os.File) (string, error) {
+	if a, _ := c.active(); a != nil && a.IsAuthenticated() {
+		return GetTokenContext(ctx, a, f)
+	}
+	return c.authenticate(ctx, f, 0)
+}
+
+// authenticate tries the auth methods starting at start and switches to the first one that
+// returns a token. If none do, the returned error includes the error of each one
+func (c *ChainAuth) authenticate(ctx context.Context, f *os.File, start int) (string, error) {
+	var failures []string
+	for i := start; i < len(c.auths); i++ {
+		token, err := GetTokenContext(ctx, c.auths[i], f)
+		if err == nil {
+			c.mu.Lock()
+			c.current = i
+			c.mu.Unlock()
+			return token, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		failures = append(failures, fmt.Sprintf("%T: %v", c.auths[i], err))
+	}
+	c.mu.Lock()
+	c.current = -1
+	c.mu.Unlock()
+	return "", fmt.Errorf("No auth method in the chain authenticated: %s", strings.Join(failures, "; "))
+}
+
+// IsAuthenticated returns whether the auth method in use has a valid token
+func (c *ChainAuth) IsAuthenticated() bool {
+	a, _ := c.active()
+	return a != nil && a.IsAuthenticated()
+}
+
+// Refresh refreshes the token of the auth method in use. If that fails, the auth methods
+// after it are tried in order
+func (c *ChainAuth) Refresh() error {
+	return c.RefreshContext(context.Background())
+}
+
+// RefreshContext is the same as Refresh, but the auth methods use the given context
+func (c *ChainAuth) RefreshContext(ctx context.Context) error {
+	a, i := c.active()
+	if a == nil {
+		return api.ErrorUnauthenticated
+	}
+	var err error
+	if refresher, ok := a.(ContextRefresher); ok {
+		err = refresher.RefreshContext(ctx)
+	} else {
+		err = a.Refresh()
+	}
+	if err == nil {
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if _, chainErr := c.authenticate(ctx, nil, i+1); chainErr != nil {
+		return fmt.Errorf("Unable to refresh token: %v. %v", err, chainErr)
+	}
+	return nil
+}
+
+// Logout logs out of the auth method in use. The next call to GetToken tries the auth
+// methods from the start again
+func (c *ChainAuth) Logout() error {
+	return c.LogoutContext(context.Background())
+}
+
+// LogoutContext is the same as Logout, but the request uses the given context
+func (c *ChainAuth) LogoutContext(ctx context.Context) error {
+	a, _ := c.active()
+	if a == nil {
+		return api.ErrorUnauthenticated
+	}
+	var err error
+	if l, ok := a.(ContextAuth); ok {
+		err = l.LogoutContext(ctx)
+	} else {
+		err = a.Logout()
+	}
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.current = -1
+	c.mu.Unlock()
+	return nil
+}
+
+// GetHeaders returns the headers of the auth method in use, or api.ErrorUnauthenticated if
+// none has authenticated
+func (c *ChainAuth) GetHeaders() (http.Header, error) {
+	a, _ := c.active()
+	if a == nil {
+		return nil, api.ErrorUnauthenticated
+	}
+	return a.GetHeaders()
+}
+
+// GetURL returns the Cerberus URL of the auth method in use, or of the first one if none
+// has authenticated
+func (c *ChainAuth) GetURL() *url.URL {
+	if a, _ := c.active(); a != nil {
+		return a.GetURL()
+	}
+	return c.auths[0].GetURL()
+}
+
+// GetExpiry returns the expiry time of the token of the auth method in use
+func (c *ChainAuth) GetExpiry() (time.Time, error) {
+	a, _ := c.active()
+	if a == nil {
+		return time.Time{}, api.ErrorUnauthenticated
+	}
+	return a.GetExpiry()
+}
+
+// Principal returns the principal of the auth method in use, if it knows it
+func (c *ChainAuth) Principal() string {
+	if p, ok := c.Current().(PrincipalProvider); ok {
+		return p.Principal()
+	}
+	return ""
+}
+
+// cacheKey combines the cache keys of all auth methods, as any of them may have issued a
+// cached token
+func (c *ChainAuth) cacheKey() string {
+	var keys []string
+	for _, a := range c.auths {
+		if k, ok := a.(cacheKeyer); ok {
+			keys = append(keys, k.cacheKey())
+		} else {
+			keys = append(keys, "")
+		}
+	}
+	if strings.Join(keys, "") == "" {
+		return ""
+	}
+	return strings.Join(keys, "; ")
+}
+
+// GetTokenInfo returns the token metadata of the auth method in use, or ErrorNoTokenInfo if
+// it doesn't keep it
+func (c *ChainAuth) GetTokenInfo() (api.TokenInfo, error) {
+	a, _ := c.active()
+	if a == nil {
+		return api.TokenInfo{}, api.ErrorUnauthenticated
+	}
+	if p, ok := a.(TokenInfoProvider); ok {
+		return p.GetTokenInfo()
+	}
+	return api.TokenInfo{}, ErrorNoTokenInfo
+}
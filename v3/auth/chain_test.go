@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// failingAuth is a countingAuth that can't get a token
+type failingAuth struct {
+	countingAuth
+	attempts int
+}
+
+func (a *failingAuth) GetToken(*os.File) (string, error) {
+	a.attempts++
+	return "", fmt.Errorf("no credentials")
+}
+
+func TestNewChainAuth(t *testing.T) {
+	Convey("An empty chain", t, func() {
+		c, err := NewChainAuth()
+		So(err, ShouldNotBeNil)
+		So(c, ShouldBeNil)
+	})
+	Convey("A chain with a nil auth method", t, func() {
+		c, err := NewChainAuth(&countingAuth{}, nil)
+		So(err, ShouldNotBeNil)
+		So(c, ShouldBeNil)
+	})
+}
+
+func TestChainAuth(t *testing.T) {
+	u, _ := url.Parse("https://cerberus.example.com")
+	Convey("A ChainAuth", t, func() {
+		first := &failingAuth{countingAuth: countingAuth{baseURL: u}}
+		second := &countingAuth{baseURL: u}
+		c, err := NewChainAuth(first, second)
+		So(err, ShouldBeNil)
+		So(c, ShouldImplement, (*ContextAuth)(nil))
+		So(c, ShouldImplement, (*PrincipalProvider)(nil))
+		So(c, ShouldImplement, (*TokenInfoProvider)(nil))
+
+		Convey("Should not be authenticated before getting a token", func() {
+			So(c.IsAuthenticated(), ShouldBeFalse)
+			So(c.Current(), ShouldBeNil)
+			So(c.GetURL(), ShouldEqual, u)
+			_, err := c.GetHeaders()
+			So(err, ShouldEqual, api.ErrorUnauthenticated)
+			_, err = c.GetExpiry()
+			So(err, ShouldEqual, api.ErrorUnauthenticated)
+			So(c.Refresh(), ShouldEqual, api.ErrorUnauthenticated)
+			So(c.Logout(), ShouldEqual, api.ErrorUnauthenticated)
+			_, err = c.GetTokenInfo()
+			So(err, ShouldEqual, api.ErrorUnauthenticated)
+		})
+
+		Convey("Should use the first auth method that authenticates", func() {
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+			So(c.Current(), ShouldEqual, second)
+			So(c.IsAuthenticated(), ShouldBeTrue)
+			headers, err := c.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get("X-Cerberus-Token"), ShouldEqual, "login-token-1")
+			_, err = c.GetTokenInfo()
+			So(err, ShouldEqual, ErrorNoTokenInfo)
+			Convey("And keep using it while it is authenticated", func() {
+				_, err := c.GetToken(nil)
+				So(err, ShouldBeNil)
+				So(first.attempts, ShouldEqual, 1)
+				So(second.logins, ShouldEqual, 1)
+			})
+			Convey("And start over after logging out", func() {
+				So(c.Logout(), ShouldBeNil)
+				So(c.Current(), ShouldBeNil)
+				_, err := c.GetToken(nil)
+				So(err, ShouldBeNil)
+				So(first.attempts, ShouldEqual, 2)
+			})
+		})
+
+		Convey("Should return every error if none authenticate", func() {
+			c, _ := NewChainAuth(first, &failingAuth{})
+			_, err := c.GetToken(nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "no credentials; *auth.failingAuth: no credentials")
+			So(c.Current(), ShouldBeNil)
+		})
+
+		Convey("Should stop when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := c.GetTokenContext(ctx, nil)
+			So(err, ShouldEqual, context.Canceled)
+			So(second.logins, ShouldEqual, 0)
+		})
+	})
+
+	Convey("A ChainAuth whose token can't be refreshed", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer ts.Close()
+		tokenAuth, _ := NewTokenAuth(ts.URL, "stale-token")
+		fallback := &countingAuth{baseURL: u}
+		c, _ := NewChainAuth(tokenAuth, fallback)
+		tok, err := c.GetToken(nil)
+		So(err, ShouldBeNil)
+		So(tok, ShouldEqual, "stale-token")
+		Convey("Should fall back to the next auth method", func() {
+			So(c.Refresh(), ShouldBeNil)
+			So(c.Current(), ShouldEqual, fallback)
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+		})
+		Convey("Should fail if there is nothing to fall back to", func() {
+			c, _ := NewChainAuth(tokenAuth)
+			c.GetToken(nil)
+			So(c.Refresh(), ShouldNotBeNil)
+			So(c.Current(), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeClock is a Clock that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+func TestSystemClock(t *testing.T) {
+	Convey("The system clock", t, func() {
+		So(SystemClock.Now(), ShouldHappenWithin, time.Second, time.Now())
+	})
+	Convey("A nil clock", t, func() {
+		So(clockOrSystem(nil), ShouldResemble, SystemClock)
+	})
+}
+
+func TestClockSTS(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(responseBody))
+	}))
+	defer ts.Close()
+
+	Convey("An STSAuth with a fake clock", t, func() {
+		clock := &fakeClock{now: time.Now()}
+		a, err := NewSTSAuth(ts.URL, "us-west-2")
+		So(err, ShouldBeNil)
+		So(a.WithClock(clock), ShouldEqual, a)
+		os.Setenv("AWS_ACCESS_KEY_ID", "access")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		_, err = a.GetToken(nil)
+		So(err, ShouldBeNil)
+		Convey("Should compute the expiry from the clock", func() {
+			So(a.expiry, ShouldEqual, clock.now.Add(time.Hour-DefaultExpiryDelta))
+		})
+		Convey("Should use a configured expiry delta", func() {
+			So(a.WithExpiryDelta(5*time.Minute), ShouldEqual, a)
+			So(a.Refresh(), ShouldBeNil)
+			So(a.expiry, ShouldEqual, clock.now.Add(time.Hour-5*time.Minute))
+			a.WithExpiryDelta(-time.Minute)
+			So(a.Refresh(), ShouldBeNil)
+			So(a.expiry, ShouldEqual, clock.now.Add(time.Hour))
+		})
+		Convey("Should be authenticated until the clock passes the expiry", func() {
+			clock.Advance(time.Hour - DefaultExpiryDelta - time.Second)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+			clock.Advance(time.Second)
+			So(a.IsAuthenticated(), ShouldBeFalse)
+		})
+	})
+}
+
+func TestClockCachedAuth(t *testing.T) {
+	var refreshes int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(authResponseBody))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	Convey("A CachedAuth with a fake clock", t, func() {
+		refreshes = 0
+		clock := &fakeClock{now: time.Now()}
+		path := filepath.Join(t.TempDir(), "token")
+		writeCache(path, cachedToken{URL: ts.URL, Token: "cached", Expiry: clock.now.Add(time.Hour)})
+		c, err := NewCachedAuth(&countingAuth{baseURL: u}, path)
+		So(err, ShouldBeNil)
+		So(c.WithClock(clock), ShouldEqual, c)
+		Convey("Should use the cached token outside the refresh window", func() {
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "cached")
+			So(refreshes, ShouldEqual, 0)
+		})
+		Convey("Should refresh once the clock enters the refresh window", func() {
+			clock.Advance(time.Hour - DefaultRefreshWindow + time.Second)
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "a-cool-token")
+			So(refreshes, ShouldEqual, 1)
+			So(c.cache.Expiry, ShouldHappenAfter, clock.now.Add(50*time.Minute))
+		})
+		Convey("Should not be authenticated once the clock passes the expiry", func() {
+			clock.Advance(time.Hour)
+			So(c.IsAuthenticated(), ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	log "github.com/sirupsen/logrus"
+)
+
+// The environment variables that configure web identity credentials, as set by EKS for IAM
+// roles for service accounts
+const (
+	webIdentityTokenFileEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	roleARNEnv              = "AWS_ROLE_ARN"
+	roleSessionNameEnv      = "AWS_ROLE_SESSION_NAME"
+)
+
+// The environment variables that point at the credentials endpoint of ECS for the task role
+const (
+	ecsRelativeURIEnv = "AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"
+	ecsFullURIEnv     = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+)
+
+// imdsV1DisabledEnv turns off the fallback to IMDSv1 when an IMDSv2 session token can't be
+// fetched from the EC2 metadata service
+const imdsV1DisabledEnv = "AWS_EC2_METADATA_V1_DISABLED"
+
+// imdsTokenHeader is the header that carries the IMDSv2 session token
+const imdsTokenHeader = "X-Aws-Ec2-Metadata-Token"
+
+// ec2MetadataEndpoint overrides the address of the EC2 metadata service. It is only set in
+// tests
+var ec2MetadataEndpoint string
+
+// ecsCredentialsEndpoint is the address of the ECS credentials endpoint, which the relative
+// URI is appended to. It is only changed in tests
+var ecsCredentialsEndpoint = "http://169.254.170.2"
+
+// credentialsExpiryWindow is how long before they expire temporary credentials are renewed, so
+// a signature never uses credentials that expire while the request is in flight
+const credentialsExpiryWindow = 5 * time.Minute
+
+// awsEndpoint overrides the endpoint of the AWS clients used to get credentials. It is only
+// set in tests
+var awsEndpoint string
+
+// creds returns the credentials used by an STSAuth in the given region when none are set. It
+// is the default AWS credential chain of environment variables, the shared credentials file
+// and the ECS or EC2 role, with web identity credentials after the environment variables when
+// AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN are set. The credentials are renewed shortly
+// before they expire
+func creds(region string) *credentials.Credentials {
+	cfg := defaults.Get().Config
+	providers := defaults.CredProviders(cfg, defaults.Handlers())
+	if ecs := ecsProvider(); ecs != nil {
+		// Replace the remote provider, which doesn't renew the task credentials early
+		providers[len(providers)-1] = ecs
+	} else if os.Getenv(ecsFullURIEnv) == "" {
+		providers[len(providers)-1] = ec2Provider()
+	}
+	if web, err := webIdentityProvider(region); err != nil {
+		log.Warn(fmt.Sprintf("Unable to use web identity credentials: %v", err))
+	} else if web != nil {
+		providers = append(providers[:1], append([]credentials.Provider{web}, providers[1:]...)...)
+	}
+	return credentials.NewCredentials(&credentials.ChainProvider{
+		VerboseErrors: true,
+		Providers:     providers,
+	})
+}
+
+// webIdentityProvider returns a provider that exchanges the web identity token in
+// AWS_WEB_IDENTITY_TOKEN_FILE for credentials of the role in AWS_ROLE_ARN, or nil if they
+// aren't set. The token file is read again every time the credentials are renewed, as EKS
+// rotates it
+func webIdentityProvider(region string) (credentials.Provider, error) {
+	tokenFile := os.Getenv(webIdentityTokenFileEnv)
+	roleARN := os.Getenv(roleARNEnv)
+	if tokenFile == "" || roleARN == "" {
+		return nil, nil
+	}
+	sessionName := os.Getenv(roleSessionNameEnv)
+	if sessionName == "" {
+		sessionName = defaultSessionName()
+	}
+	svc, err := newSTSClient(region, credentials.AnonymousCredentials)
+	if err != nil {
+		return nil, err
+	}
+	return stscreds.NewWebIdentityRoleProviderWithOptions(svc, roleARN, sessionName,
+		stscreds.FetchTokenPath(tokenFile), func(p *stscreds.WebIdentityRoleProvider) {
+			p.ExpiryWindow = credentialsExpiryWindow
+		}), nil
+}
+
+// assumeRoleCredentials returns credentials for roleARN, assumed with the source credentials.
+// externalID is only sent if it isn't empty. The role is assumed again shortly before its
+// credentials expire
+func assumeRoleCredentials(region, roleARN, externalID string, source *credentials.Credentials) (*credentials.Credentials, error) {
+	if !strings.HasPrefix(roleARN, "arn:") {
+		return nil, fmt.Errorf("Role ARN %q is not a valid ARN", roleARN)
+	}
+	svc, err := newSTSClient(region, source)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewCredentials(&stscreds.AssumeRoleProvider{
+		Client:          svc,
+		RoleARN:         roleARN,
+		RoleSessionName: defaultSessionName(),
+		ExternalID:      externalIDValue(externalID),
+		Duration:        stscreds.DefaultDuration,
+		ExpiryWindow:    credentialsExpiryWindow,
+	}), nil
+}
+
+// profileCredentials returns the credentials of the named profile in the shared AWS config and
+// credentials files, the same as setting AWS_PROFILE. Profiles that assume a role, with
+// role_arn and source_profile or credential_source, are supported
+func profileCredentials(region, profile string) (*credentials.Credentials, error) {
+	if profile == "" {
+		return nil, fmt.Errorf("Profile cannot be empty")
+	}
+	// The SDK falls back to the instance role for a profile that doesn't exist, which would
+	// authenticate as a different principal than the one asked for
+	if !profileExists(profile) {
+		return nil, fmt.Errorf("AWS profile %q not found in %s", profile, strings.Join(sharedConfigFiles(), " or "))
+	}
+	cfg := aws.Config{
+		Region:              aws.String(region),
+		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+	}
+	if awsEndpoint != "" {
+		cfg.Endpoint = aws.String(awsEndpoint)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            cfg,
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load AWS profile %q: %w", profile, err)
+	}
+	return sess.Config.Credentials, nil
+}
+
+// profileExists reports whether the shared config or credentials file has a section for the
+// named profile. Sections are "[profile name]" in the config file, and "[name]" in the
+// credentials file and for the default profile
+func profileExists(profile string) bool {
+	for _, filename := range sharedConfigFiles() {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+				continue
+			}
+			fields := strings.Fields(line[1 : len(line)-1])
+			if len(fields) == 2 && fields[0] == "profile" {
+				fields = fields[1:]
+			}
+			if len(fields) == 1 && fields[0] == profile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sharedConfigFiles returns the paths of the shared config and credentials files, which can be
+// changed with AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE
+func sharedConfigFiles() []string {
+	config := os.Getenv("AWS_CONFIG_FILE")
+	if config == "" {
+		config = defaults.SharedConfigFilename()
+	}
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = defaults.SharedCredentialsFilename()
+	}
+	return []string{config, credentialsFile}
+}
+
+// externalIDValue returns a pointer to externalID, or nil if it is empty so it isn't sent
+func externalIDValue(externalID string) *string {
+	if externalID == "" {
+		return nil
+	}
+	return aws.String(externalID)
+}
+
+// defaultSessionName returns a unique name for the session of an assumed role
+func defaultSessionName() string {
+	return fmt.Sprintf("cerberus-go-client-%d", time.Now().UnixNano())
+}
+
+// ecsProvider returns a provider for the credentials of the ECS task role, or nil when not
+// running in an ECS task. ECS rotates the credentials well before they expire, and they are
+// fetched again when they are about to
+func ecsProvider() credentials.Provider {
+	uri := os.Getenv(ecsRelativeURIEnv)
+	if uri == "" || os.Getenv(ecsFullURIEnv) != "" {
+		// The default remote provider handles full URIs, which take precedence
+		return nil
+	}
+	cfg := defaults.Get().Config
+	return endpointcreds.NewProviderClient(*cfg, defaults.Handlers(), ecsCredentialsEndpoint+uri,
+		func(p *endpointcreds.Provider) {
+			p.ExpiryWindow = credentialsExpiryWindow
+		})
+}
+
+// ec2Provider returns a provider for the credentials of the EC2 instance role. The metadata
+// service is called with an IMDSv2 session token, so it works on instances that require one.
+// If the token can't be fetched it falls back to IMDSv1, unless AWS_EC2_METADATA_V1_DISABLED
+// is true. Containers need a hop limit of at least 2 on the instance to get a token
+func ec2Provider() credentials.Provider {
+	return &ec2rolecreds.EC2RoleProvider{
+		Client:       ec2MetadataClient(),
+		ExpiryWindow: credentialsExpiryWindow,
+	}
+}
+
+// ec2MetadataClient returns a client for the EC2 metadata service that uses an IMDSv2 session
+// token, and doesn't fall back to IMDSv1 when AWS_EC2_METADATA_V1_DISABLED is true
+func ec2MetadataClient() *ec2metadata.EC2Metadata {
+	cfg := defaults.Get().Config
+	endpoint := ec2MetadataEndpoint
+	if endpoint == "" {
+		e, _ := endpoints.DefaultResolver().EndpointFor(endpoints.Ec2metadataServiceID, "")
+		endpoint = e.URL
+	}
+	client := ec2metadata.NewClient(*cfg, defaults.Handlers(), endpoint, "")
+	if strings.EqualFold(os.Getenv(imdsV1DisabledEnv), "true") {
+		client.Handlers.Sign.PushBackNamed(requireIMDSToken)
+	}
+	return client
+}
+
+// detectRegion returns the AWS region to use when none is given. It is the first of
+// AWS_REGION, AWS_DEFAULT_REGION, the region of the profile in the shared config file, and the
+// region of the EC2 instance from the metadata service. profile is the profile to read the
+// region of, or empty for AWS_PROFILE or the default profile
+func detectRegion(profile string) (string, error) {
+	if region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"); region != "" {
+		return region, nil
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err == nil && aws.StringValue(sess.Config.Region) != "" {
+		return aws.StringValue(sess.Config.Region), nil
+	}
+	region, imdsErr := ec2MetadataClient().Region()
+	if imdsErr != nil {
+		return "", fmt.Errorf("Unable to detect the AWS region. Set AWS_REGION or pass a region: %v", imdsErr)
+	}
+	log.Debug(fmt.Sprintf("Using the region of the EC2 instance, %s", region))
+	return region, nil
+}
+
+// requireIMDSToken fails requests to the EC2 metadata service that would be sent without an
+// IMDSv2 session token, instead of letting them fall back to IMDSv1
+var requireIMDSToken = request.NamedHandler{
+	Name: "cerberus.RequireIMDSToken",
+	Fn: func(r *request.Request) {
+		if r.Error != nil || r.Operation.Name == "GetToken" {
+			return
+		}
+		if r.HTTPRequest.Header.Get(imdsTokenHeader) == "" {
+			r.Error = awserr.New("IMDSv2TokenRequired",
+				"unable to get an IMDSv2 session token from the EC2 metadata service and "+imdsV1DisabledEnv+" is set", nil)
+		}
+	},
+}
+
+// newSTSClient returns an AWS STS client in the given region that uses the given credentials
+func newSTSClient(region string, c *credentials.Credentials) (*sts.STS, error) {
+	cfg := &aws.Config{
+		Region:      aws.String(region),
+		Credentials: c,
+		// Use the regional endpoint, which also works in partitions without a global one
+		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+	}
+	if awsEndpoint != "" {
+		cfg.Endpoint = aws.String(awsEndpoint)
+	}
+	sess, err := session.NewSession(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create AWS session: %w", err)
+	}
+	return sts.New(sess), nil
+}
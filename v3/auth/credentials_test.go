@@ -0,0 +1,458 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// assumeRoleResponse is the body of an AssumeRoleWithWebIdentity response. The access key
+// and expiry are filled in
+var assumeRoleResponse = `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>%s</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`
+
+// assumeRoleChainResponse is the body of an AssumeRole response. The access key and expiry are
+// filled in
+var assumeRoleChainResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>%s</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>role-session</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+
+// fakeAWS points the AWS clients used for credentials at handler for the test
+func fakeAWS(t *testing.T, handler http.HandlerFunc) {
+	ts := httptest.NewServer(handler)
+	awsEndpoint = ts.URL
+	t.Cleanup(func() {
+		awsEndpoint = ""
+		ts.Close()
+	})
+}
+
+// clearAWSEnvironment unsets the AWS credentials in the environment for the test
+func clearAWSEnvironment(t *testing.T) {
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		webIdentityTokenFileEnv, roleARNEnv, roleSessionNameEnv, ecsRelativeURIEnv, ecsFullURIEnv,
+		imdsV1DisabledEnv, "AWS_EC2_METADATA_DISABLED", "AWS_PROFILE", STSEndpointEnv, "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+}
+
+func TestWebIdentityCredentials(t *testing.T) {
+	Convey("An environment with a web identity token", t, func() {
+		clearAWSEnvironment(t)
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		os.WriteFile(tokenFile, []byte("first-jwt"), 0600)
+		t.Setenv(webIdentityTokenFileEnv, tokenFile)
+		t.Setenv(roleARNEnv, "arn:aws:iam::111111111:role/pod-role")
+		t.Setenv(roleSessionNameEnv, "my-pod")
+
+		var calls int
+		var form map[string]string
+		// The credentials expire within the expiry window, so every Get renews them
+		expiry := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		fakeAWS(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			r.ParseForm()
+			form = map[string]string{}
+			for k := range r.PostForm {
+				form[k] = r.PostForm.Get(k)
+			}
+			fmt.Fprintf(w, assumeRoleResponse, fmt.Sprintf("AKIDWEB%d", calls), expiry)
+		})
+
+		Convey("Should assume the role with the token", func() {
+			value, err := creds("us-west-2").Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDWEB1")
+			So(form["Action"], ShouldEqual, "AssumeRoleWithWebIdentity")
+			So(form["RoleArn"], ShouldEqual, "arn:aws:iam::111111111:role/pod-role")
+			So(form["RoleSessionName"], ShouldEqual, "my-pod")
+			So(form["WebIdentityToken"], ShouldEqual, "first-jwt")
+		})
+
+		Convey("Should renew the credentials with the rotated token", func() {
+			c := creds("us-west-2")
+			c.Get()
+			os.WriteFile(tokenFile, []byte("second-jwt"), 0600)
+			value, err := c.Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDWEB2")
+			So(form["WebIdentityToken"], ShouldEqual, "second-jwt")
+		})
+
+		Convey("Should prefer credentials in the environment", func() {
+			t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+			t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+			value, err := creds("us-west-2").Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDENV")
+			So(calls, ShouldEqual, 0)
+		})
+
+		Convey("Should sign Cerberus requests", func() {
+			a, _ := NewSTSAuth("https://cerberus.example.com", "us-west-2")
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=AKIDWEB")
+			So(headers.Get("X-Amz-Security-Token"), ShouldEqual, "session")
+		})
+	})
+
+	Convey("An environment without a web identity token", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv(roleARNEnv, "arn:aws:iam::111111111:role/pod-role")
+		p, err := webIdentityProvider("us-west-2")
+		So(err, ShouldBeNil)
+		So(p, ShouldBeNil)
+	})
+}
+
+func TestECSCredentials(t *testing.T) {
+	Convey("An ECS task", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv(ecsRelativeURIEnv, "/v2/credentials/task-id")
+		var calls int
+		var path string
+		// The credentials expire within the expiry window, so every Get renews them
+		expiry := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			path = r.URL.Path
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"AccessKeyId": "AKIDTASK%d", "SecretAccessKey": "secret", "Token": "session", "Expiration": %q}`, calls, expiry)
+		}))
+		defer ts.Close()
+		ecsCredentialsEndpoint = ts.URL
+		defer func() { ecsCredentialsEndpoint = "http://169.254.170.2" }()
+
+		Convey("Should get the task role credentials", func() {
+			value, err := creds("us-west-2").Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDTASK1")
+			So(value.SessionToken, ShouldEqual, "session")
+			So(path, ShouldEqual, "/v2/credentials/task-id")
+		})
+
+		Convey("Should get the rotated credentials before they expire", func() {
+			c := creds("us-west-2")
+			c.Get()
+			value, err := c.Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDTASK2")
+		})
+
+		Convey("Should keep credentials that aren't about to expire", func() {
+			expiry = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+			c := creds("us-west-2")
+			c.Get()
+			value, err := c.Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDTASK1")
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("Should leave full URIs to the default provider", func() {
+			t.Setenv(ecsFullURIEnv, "http://127.0.0.1/creds")
+			So(ecsProvider(), ShouldBeNil)
+		})
+	})
+}
+
+func TestEC2Credentials(t *testing.T) {
+	Convey("An EC2 instance", t, func() {
+		clearAWSEnvironment(t)
+		var tokenStatus int
+		var gets, tokenless int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+				if tokenStatus != http.StatusOK {
+					w.WriteHeader(tokenStatus)
+					return
+				}
+				w.Header().Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds"))
+				fmt.Fprint(w, "imds-token")
+				return
+			}
+			gets++
+			if r.Header.Get(imdsTokenHeader) != "imds-token" {
+				tokenless++
+				if tokenStatus == http.StatusOK {
+					// IMDSv1 is disabled on the instance
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+			}
+			switch r.URL.Path {
+			case "/latest/meta-data/iam/security-credentials/":
+				fmt.Fprint(w, "instance-role")
+			case "/latest/meta-data/iam/security-credentials/instance-role":
+				fmt.Fprintf(w, `{"Code": "Success", "AccessKeyId": "AKIDEC2", "SecretAccessKey": "secret", "Token": "session", "Expiration": %q}`,
+					time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		ec2MetadataEndpoint = ts.URL
+		defer func() { ec2MetadataEndpoint = "" }()
+
+		Convey("That requires IMDSv2 should get the role credentials with a session token", func() {
+			tokenStatus = http.StatusOK
+			value, err := creds("us-west-2").Get()
+			So(err, ShouldBeNil)
+			So(value.AccessKeyID, ShouldEqual, "AKIDEC2")
+			So(gets, ShouldEqual, 2)
+			So(tokenless, ShouldEqual, 0)
+		})
+
+		Convey("Without IMDSv2", func() {
+			tokenStatus = http.StatusForbidden
+			Convey("Should fall back to IMDSv1", func() {
+				value, err := creds("us-west-2").Get()
+				So(err, ShouldBeNil)
+				So(value.AccessKeyID, ShouldEqual, "AKIDEC2")
+				So(tokenless, ShouldEqual, 2)
+			})
+			Convey("Should not fall back when IMDSv1 is disabled", func() {
+				t.Setenv(imdsV1DisabledEnv, "true")
+				_, err := creds("us-west-2").Get()
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "IMDSv2TokenRequired")
+				So(gets, ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestAssumeRoleCredentials(t *testing.T) {
+	Convey("An STSAuth with a role", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIDSOURCE")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		var calls int
+		var form map[string]string
+		var signedWith string
+		fakeAWS(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			signedWith = r.Header.Get("Authorization")
+			r.ParseForm()
+			form = map[string]string{}
+			for k := range r.PostForm {
+				form[k] = r.PostForm.Get(k)
+			}
+			fmt.Fprintf(w, assumeRoleChainResponse, "AKIDROLE", time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		})
+
+		Convey("Should sign Cerberus requests with the assumed role", func() {
+			a, err := NewSTSAuthWithRole("https://cerberus.example.com", "us-west-2", "arn:aws:iam::111111111:role/reader", "external-id")
+			So(err, ShouldBeNil)
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=AKIDROLE")
+			So(headers.Get("X-Amz-Security-Token"), ShouldEqual, "role-session")
+			So(signedWith, ShouldContainSubstring, "Credential=AKIDSOURCE")
+			So(form["Action"], ShouldEqual, "AssumeRole")
+			So(form["RoleArn"], ShouldEqual, "arn:aws:iam::111111111:role/reader")
+			So(form["ExternalId"], ShouldEqual, "external-id")
+			So(form["RoleSessionName"], ShouldStartWith, "cerberus-go-client-")
+		})
+
+		Convey("Should keep the role credentials until they are about to expire", func() {
+			a, _ := NewSTSAuthWithRole("https://cerberus.example.com", "us-west-2", "arn:aws:iam::111111111:role/reader", "")
+			a.sign()
+			a.sign()
+			So(calls, ShouldEqual, 1)
+			_, sent := form["ExternalId"]
+			So(sent, ShouldBeFalse)
+		})
+
+		Convey("Should be created by NewIAMAuth", func() {
+			a, err := NewIAMAuth("https://cerberus.example.com", "us-west-2", "arn:aws:iam::111111111:role/reader")
+			So(err, ShouldBeNil)
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=AKIDROLE")
+			Convey("Or sign with the current identity without a role", func() {
+				calls = 0
+				a, err := NewIAMAuth("https://cerberus.example.com", "us-west-2", "")
+				So(err, ShouldBeNil)
+				headers, err := a.sign()
+				So(err, ShouldBeNil)
+				So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=AKIDSOURCE")
+				So(calls, ShouldEqual, 0)
+			})
+		})
+
+		Convey("Should need a valid role ARN", func() {
+			a, err := NewSTSAuthWithRole("https://cerberus.example.com", "us-west-2", "reader", "")
+			So(err, ShouldNotBeNil)
+			So(a, ShouldBeNil)
+		})
+	})
+}
+
+func TestProfileCredentials(t *testing.T) {
+	Convey("A shared AWS config with profiles", t, func() {
+		clearAWSEnvironment(t)
+		// Credentials in the environment must not win over the profile
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		configFile := filepath.Join(t.TempDir(), "config")
+		os.WriteFile(configFile, []byte(`[profile dev]
+aws_access_key_id = AKIDDEV
+aws_secret_access_key = secret
+
+[profile reader]
+role_arn = arn:aws:iam::111111111:role/reader
+source_profile = dev
+`), 0600)
+		t.Setenv("AWS_CONFIG_FILE", configFile)
+		var signedWith string
+		fakeAWS(t, func(w http.ResponseWriter, r *http.Request) {
+			signedWith = r.Header.Get("Authorization")
+			fmt.Fprintf(w, assumeRoleChainResponse, "AKIDREADER", time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		})
+
+		Convey("Should sign with the credentials of the profile", func() {
+			a, err := NewSTSAuthWithProfile("https://cerberus.example.com", "us-west-2", "dev")
+			So(err, ShouldBeNil)
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=AKIDDEV")
+			So(os.Getenv("AWS_PROFILE"), ShouldBeEmpty)
+		})
+
+		Convey("Should assume the role of a profile", func() {
+			a, err := NewSTSAuthWithProfile("https://cerberus.example.com", "us-west-2", "reader")
+			So(err, ShouldBeNil)
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=AKIDREADER")
+			So(signedWith, ShouldContainSubstring, "Credential=AKIDDEV")
+		})
+
+		Convey("Should fail for a profile that doesn't exist", func() {
+			a, err := NewSTSAuthWithProfile("https://cerberus.example.com", "us-west-2", "missing")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "missing")
+			So(a, ShouldBeNil)
+		})
+	})
+}
+
+func TestDetectRegion(t *testing.T) {
+	Convey("Detecting the region", t, func() {
+		clearAWSEnvironment(t)
+		var documents int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/latest/api/token":
+				fmt.Fprint(w, "imds-token")
+			case "/latest/dynamic/instance-identity/document":
+				documents++
+				fmt.Fprint(w, `{"region": "eu-central-1", "instanceId": "i-1234"}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		ec2MetadataEndpoint = ts.URL
+		defer func() { ec2MetadataEndpoint = "" }()
+		configFile := os.Getenv("AWS_CONFIG_FILE")
+
+		Convey("Should use AWS_REGION first", func() {
+			t.Setenv("AWS_DEFAULT_REGION", "us-east-2")
+			t.Setenv("AWS_REGION", "us-west-1")
+			a, err := NewSTSAuth("https://cerberus.example.com", "")
+			So(err, ShouldBeNil)
+			So(a.region, ShouldEqual, "us-west-1")
+		})
+
+		Convey("Should use AWS_DEFAULT_REGION", func() {
+			t.Setenv("AWS_DEFAULT_REGION", "us-east-2")
+			region, err := detectRegion("")
+			So(err, ShouldBeNil)
+			So(region, ShouldEqual, "us-east-2")
+		})
+
+		Convey("With a shared config", func() {
+			os.WriteFile(configFile, []byte(`[default]
+region = ap-southeast-2
+
+[profile dev]
+region = ca-central-1
+aws_access_key_id = AKIDDEV
+aws_secret_access_key = secret
+`), 0600)
+			Convey("Should use the region of the default profile", func() {
+				region, err := detectRegion("")
+				So(err, ShouldBeNil)
+				So(region, ShouldEqual, "ap-southeast-2")
+			})
+			Convey("Should use the region of AWS_PROFILE", func() {
+				t.Setenv("AWS_PROFILE", "dev")
+				region, err := detectRegion("")
+				So(err, ShouldBeNil)
+				So(region, ShouldEqual, "ca-central-1")
+			})
+			Convey("Should use the region of the profile of NewSTSAuthWithProfile", func() {
+				a, err := NewSTSAuthWithProfile("https://cerberus.example.com", "", "dev")
+				So(err, ShouldBeNil)
+				So(a.region, ShouldEqual, "ca-central-1")
+			})
+			So(documents, ShouldEqual, 0)
+		})
+
+		Convey("Should use the region of the EC2 instance", func() {
+			region, err := detectRegion("")
+			So(err, ShouldBeNil)
+			So(region, ShouldEqual, "eu-central-1")
+			So(documents, ShouldEqual, 1)
+		})
+
+		Convey("Should error when the region can't be found", func() {
+			ts.Close()
+			a, err := NewSTSAuth("https://cerberus.example.com", "")
+			So(err, ShouldNotBeNil)
+			So(a, ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// The environment variables read by NewAuthFromEnvironment
+const (
+	// URLEnv is the Cerberus URL. It is required
+	URLEnv = "CERBERUS_URL"
+	// TokenEnv is an existing Cerberus token. If it is set, a TokenAuth is used
+	TokenEnv = "CERBERUS_TOKEN"
+	// RegionEnv is the AWS region to authenticate in. AWS_REGION and AWS_DEFAULT_REGION are
+	// used if it isn't set
+	RegionEnv = "CERBERUS_REGION"
+	// NamespaceEnv is the namespace sent with every request, if any
+	NamespaceEnv = "CERBERUS_NAMESPACE"
+	// CacheTokenEnv turns on caching the STS token in the default cache location when it is
+	// "true" or "1"
+	CacheTokenEnv = "CERBERUS_CACHE_TOKEN"
+	// UsernameEnv is recognized only to report that user authentication isn't supported
+	UsernameEnv = "CERBERUS_USERNAME"
+)
+
+// ErrorNoAuthEnvironment is returned by NewAuthFromEnvironment when neither a token nor an
+// AWS region is set
+var ErrorNoAuthEnvironment = fmt.Errorf("No Cerberus credentials in the environment. Set %s, or %s or AWS_REGION to use AWS credentials", TokenEnv, RegionEnv)
+
+// NewAuthFromEnvironment returns an auth method configured by environment variables, so
+// applications don't each have to pick one. The Cerberus URL comes from CERBERUS_URL and
+// the method is chosen in this order:
+//
+// 1. CERBERUS_TOKEN set: a TokenAuth with that token
+// 2. CERBERUS_REGION, AWS_REGION or AWS_DEFAULT_REGION set: an STSAuth in that region, using
+// the AWS credentials found by the AWS SDK (environment, shared config or instance role).
+// If CERBERUS_CACHE_TOKEN is true the token is cached with NewDefaultCachedAuth
+//
+// CERBERUS_NAMESPACE sets the namespace of either method. Username and password
+// authentication isn't supported by this client, so CERBERUS_USERNAME on its own is an error
+func NewAuthFromEnvironment() (Auth, error) {
+	cerberusURL := os.Getenv(URLEnv)
+	if cerberusURL == "" {
+		return nil, fmt.Errorf("%s must be set", URLEnv)
+	}
+	namespace := os.Getenv(NamespaceEnv)
+
+	if token := os.Getenv(TokenEnv); token != "" {
+		a, err := NewTokenAuth(cerberusURL, token)
+		if err != nil {
+			return nil, err
+		}
+		if namespace != "" {
+			a.WithNamespace(namespace)
+		}
+		return a, nil
+	}
+
+	region := firstEnv(RegionEnv, "AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		if os.Getenv(UsernameEnv) != "" {
+			return nil, fmt.Errorf("%s is set, but username and password authentication isn't supported. Set %s or an AWS region instead", UsernameEnv, TokenEnv)
+		}
+		return nil, ErrorNoAuthEnvironment
+	}
+	a, err := NewSTSAuth(cerberusURL, region)
+	if err != nil {
+		return nil, err
+	}
+	if namespace != "" {
+		a.WithNamespace(namespace)
+	}
+	if cache := os.Getenv(CacheTokenEnv); cache != "" {
+		enabled, err := strconv.ParseBool(cache)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value %q for %s: %w", cache, CacheTokenEnv, err)
+		}
+		if enabled {
+			return NewDefaultCachedAuth(a)
+		}
+	}
+	return a, nil
+}
+
+// firstEnv returns the value of the first of the environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
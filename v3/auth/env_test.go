@@ -0,0 +1,104 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// clearAuthEnvironment unsets every variable NewAuthFromEnvironment reads for the test
+func clearAuthEnvironment(t *testing.T) {
+	for _, name := range []string{URLEnv, TokenEnv, RegionEnv, NamespaceEnv, CacheTokenEnv, UsernameEnv, "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestNewAuthFromEnvironment(t *testing.T) {
+	Convey("An environment with a token", t, func() {
+		clearAuthEnvironment(t)
+		t.Setenv(URLEnv, "https://cerberus.example.com")
+		t.Setenv(TokenEnv, "a-cool-token")
+		t.Setenv("AWS_REGION", "us-west-2")
+		t.Setenv(NamespaceEnv, "team")
+		Convey("Should use a TokenAuth", func() {
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldBeNil)
+			So(a, ShouldHaveSameTypeAs, &TokenAuth{})
+			headers, err := a.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get("X-Cerberus-Token"), ShouldEqual, "a-cool-token")
+			So(headers.Get(api.NamespaceHeader), ShouldEqual, "team")
+		})
+	})
+
+	Convey("An environment with an AWS region", t, func() {
+		clearAuthEnvironment(t)
+		t.Setenv(URLEnv, "https://cerberus.example.com")
+		t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+		Convey("Should use an STSAuth", func() {
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldBeNil)
+			So(a, ShouldHaveSameTypeAs, &STSAuth{})
+			So(a.(*STSAuth).region, ShouldEqual, "us-east-1")
+		})
+		Convey("Should prefer the Cerberus region", func() {
+			t.Setenv(RegionEnv, "us-west-2")
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldBeNil)
+			So(a.(*STSAuth).region, ShouldEqual, "us-west-2")
+		})
+		Convey("Should cache the token if asked to", func() {
+			t.Setenv(CacheDirEnv, t.TempDir())
+			t.Setenv(CacheTokenEnv, "true")
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldBeNil)
+			So(a, ShouldHaveSameTypeAs, &CachedAuth{})
+		})
+		Convey("Should reject an invalid cache setting", func() {
+			t.Setenv(CacheTokenEnv, "sometimes")
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldNotBeNil)
+			So(a, ShouldBeNil)
+		})
+	})
+
+	Convey("An incomplete environment", t, func() {
+		clearAuthEnvironment(t)
+		Convey("Should need a URL", func() {
+			t.Setenv(TokenEnv, "a-cool-token")
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldNotBeNil)
+			So(a, ShouldBeNil)
+		})
+		Convey("Should need credentials", func() {
+			t.Setenv(URLEnv, "https://cerberus.example.com")
+			a, err := NewAuthFromEnvironment()
+			So(err, ShouldEqual, ErrorNoAuthEnvironment)
+			So(a, ShouldBeNil)
+		})
+		Convey("Should say user authentication isn't supported", func() {
+			t.Setenv(URLEnv, "https://cerberus.example.com")
+			t.Setenv(UsernameEnv, "me")
+			_, err := NewAuthFromEnvironment()
+			So(err, ShouldNotBeNil)
+			So(err, ShouldNotEqual, ErrorNoAuthEnvironment)
+		})
+	})
+}
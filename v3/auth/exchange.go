@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
+)
+
+// ErrorExchangeNotSupported is returned when the Cerberus deployment doesn't support token exchange
+var ErrorExchangeNotSupported = fmt.Errorf("Token exchange is not supported by this Cerberus deployment")
+
+// Exchange uses the current token of the given Auth to get a new short-lived token that is
+// limited to the given scope. The new token can be handed to a subprocess or sidecar instead
+// of sharing the full token. Returns ErrorExchangeNotSupported if the server doesn't have
+// the exchange endpoint
+func Exchange(ctx context.Context, a Auth, scope api.TokenScope) (*TokenAuth, error) {
+	if !a.IsAuthenticated() {
+		return nil, api.ErrorUnauthenticated
+	}
+	headers, err := a.GetHeaders()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(scope)
+	if err != nil {
+		return nil, err
+	}
+	builtURL := *a.GetURL()
+	builtURL.Path = "/v2/auth/token/exchange"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, builtURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	var jar http.CookieJar
+	if holder, ok := a.(cookieJarHolder); ok {
+		jar = holder.cookieJar()
+	}
+	var client *http.Client
+	if holder, ok := a.(httpClientHolder); ok {
+		client = holder.authHTTPClient()
+	}
+	resp, err := newHTTPClient(client, headers, jar).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Problem while performing request to Cerberus: %w", utils.ConnectionError(req.URL.Host, err))
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, ErrorExchangeNotSupported
+	}
+	r, err := utils.CheckAndParse(resp)
+	if err != nil {
+		return nil, err
+	}
+	exchanged, err := NewTokenAuth(a.GetURL().String(), r.Data.ClientToken.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	exchanged.WithCookieJar(jar).WithHTTPClient(client)
+	info := r.Data.ClientToken.TokenInfo()
+	exchanged.info = &info
+	// Keep the namespace so the new token is used the same way
+	if namespace := headers.Get(api.NamespaceHeader); namespace != "" {
+		exchanged.WithNamespace(namespace)
+	}
+	return exchanged, nil
+}
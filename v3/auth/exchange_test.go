@@ -0,0 +1,97 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExchange(t *testing.T) {
+	var expectedHeaders = map[string]string{
+		"X-Cerberus-Token": "full-token",
+	}
+	scope := api.TokenScope{SDBPaths: []string{"app/my-sdb/"}, TTL: 300}
+
+	Convey("A valid exchange request", t, TestingServer(http.StatusOK, "/v2/auth/token/exchange", http.MethodPost, authResponseBody, expectedHeaders, func(ts *httptest.Server) {
+		parent, _ := NewTokenAuth(ts.URL, "full-token")
+		parent.WithNamespace("team-a")
+		Convey("Should return a TokenAuth with the new token", func() {
+			exchanged, err := Exchange(context.Background(), parent, scope)
+			So(err, ShouldBeNil)
+			tok, _ := exchanged.GetToken(nil)
+			So(tok, ShouldEqual, "a-cool-token")
+			So(exchanged.GetURL().String(), ShouldEqual, ts.URL)
+			Convey("And keep the namespace", func() {
+				headers, _ := exchanged.GetHeaders()
+				So(headers.Get(api.NamespaceHeader), ShouldEqual, "team-a")
+			})
+			Convey("And leave the original token alone", func() {
+				tok, _ := parent.GetToken(nil)
+				So(tok, ShouldEqual, "full-token")
+			})
+		})
+	}))
+
+	Convey("An exchange request", t, func(c C) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent := api.TokenScope{}
+			c.So(json.NewDecoder(r.Body).Decode(&sent), ShouldBeNil)
+			c.So(sent, ShouldResemble, scope)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(authResponseBody))
+		}))
+		defer ts.Close()
+		parent, _ := NewTokenAuth(ts.URL, "full-token")
+		Convey("Should send the scope", func() {
+			_, err := Exchange(context.Background(), parent, scope)
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("A server without token exchange", t, TestingServer(http.StatusNotFound, "/v2/auth/token/exchange", http.MethodPost, "", expectedHeaders, func(ts *httptest.Server) {
+		parent, _ := NewTokenAuth(ts.URL, "full-token")
+		Convey("Should return ErrorExchangeNotSupported", func() {
+			exchanged, err := Exchange(context.Background(), parent, scope)
+			So(err, ShouldEqual, ErrorExchangeNotSupported)
+			So(exchanged, ShouldBeNil)
+		})
+	}))
+
+	Convey("An exchange that is not allowed", t, TestingServer(http.StatusForbidden, "/v2/auth/token/exchange", http.MethodPost, "", expectedHeaders, func(ts *httptest.Server) {
+		parent, _ := NewTokenAuth(ts.URL, "full-token")
+		Convey("Should return ErrorUnauthorized", func() {
+			_, err := Exchange(context.Background(), parent, scope)
+			So(err, ShouldEqual, api.ErrorUnauthorized)
+		})
+	}))
+
+	Convey("An unauthenticated Auth", t, func() {
+		parent, _ := NewTokenAuth("https://test.example.com", "full-token")
+		parent.token = ""
+		Convey("Should error", func() {
+			_, err := Exchange(context.Background(), parent, scope)
+			So(err, ShouldEqual, api.ErrorUnauthenticated)
+		})
+	})
+}
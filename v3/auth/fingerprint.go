@@ -0,0 +1,81 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// FingerprintHeader is the header refresh requests send the Fingerprint of the client in when
+// fingerprint binding is on, so Cerberus can log where tokens are used
+const FingerprintHeader = "X-Cerberus-Client-Fingerprint"
+
+// Fingerprint identifies the client a token was issued to
+type Fingerprint struct {
+	Host    string `json:"host"`
+	Process string `json:"process"`
+	Version string `json:"version"`
+}
+
+// CurrentFingerprint returns the Fingerprint of this process: the hostname, the name of the
+// executable and the version of the client
+func CurrentFingerprint() Fingerprint {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return Fingerprint{
+		Host:    host,
+		Process: filepath.Base(os.Args[0]),
+		Version: api.ClientInfo().Version,
+	}
+}
+
+// String returns the fingerprint as it is sent in the FingerprintHeader
+func (f Fingerprint) String() string {
+	return fmt.Sprintf("host=%s; process=%s; version=%s", f.Host, f.Process, f.Version)
+}
+
+// WithFingerprintBinding records the Fingerprint of the client with every token the wrapped
+// Auth issues, and sends it in the FingerprintHeader of refresh requests. A warning is logged
+// when a token is loaded or refreshed on a different host or by a different process, e.g.
+// because a cache file was copied out of a CI job. The token is still used, so this only
+// helps to detect stolen tokens
+func (c *CachedAuth) WithFingerprintBinding() *CachedAuth {
+	c.fingerprint = CurrentFingerprint
+	return c
+}
+
+// checkFingerprint logs a warning once per token if cache was issued to a different client.
+// The caller must hold c.mu
+func (c *CachedAuth) checkFingerprint(cache *cachedToken) {
+	if c.fingerprint == nil || cache == nil || cache.Fingerprint == nil || c.reported == cache.Token {
+		return
+	}
+	recorded, current := *cache.Fingerprint, c.fingerprint()
+	if recorded.Host == current.Host && recorded.Process == current.Process {
+		return
+	}
+	c.reported = cache.Token
+	log.Warn(fmt.Sprintf("The cached Cerberus token for %s was issued to %s and is used by %s. "+
+		"If this is unexpected, the token may have been copied from another machine", cache.URL, recorded, current))
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFingerprintBinding(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, r.Header.Get(FingerprintHeader))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(authResponseBody))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	ci := Fingerprint{Host: "ci-runner-7", Process: "deploy", Version: "3.0.0"}
+	laptop := Fingerprint{Host: "laptop", Process: "deploy", Version: "3.0.0"}
+
+	Convey("A CachedAuth with fingerprint binding", t, func() {
+		sent = nil
+		path := filepath.Join(t.TempDir(), "token")
+		c, err := NewCachedAuth(&countingAuth{baseURL: u}, path)
+		So(err, ShouldBeNil)
+		c.WithFingerprintBinding()
+		c.fingerprint = func() Fingerprint { return ci }
+
+		Convey("Should record the fingerprint with new tokens", func() {
+			_, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(*c.current().Fingerprint, ShouldResemble, ci)
+			So(c.reported, ShouldBeEmpty)
+		})
+
+		Convey("Should send the fingerprint when refreshing and keep the recorded one", func() {
+			_, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(c.Refresh(), ShouldBeNil)
+			So(sent, ShouldResemble, []string{ci.String()})
+			So(*c.current().Fingerprint, ShouldResemble, ci)
+		})
+
+		Convey("Should report a token loaded on a different host once", func() {
+			writeCache(path, cachedToken{URL: ts.URL, Token: "stolen", Expiry: time.Now().Add(time.Hour), Fingerprint: &laptop})
+			token, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(token, ShouldEqual, "stolen")
+			So(c.reported, ShouldEqual, "stolen")
+		})
+
+		Convey("Should report a token refreshed on a different host", func() {
+			_, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			c.fingerprint = func() Fingerprint { return laptop }
+			token := c.current().Token
+			So(c.Refresh(), ShouldBeNil)
+			So(c.reported, ShouldEqual, token)
+			So(sent, ShouldResemble, []string{laptop.String()})
+		})
+	})
+
+	Convey("A CachedAuth without fingerprint binding", t, func() {
+		sent = nil
+		path := filepath.Join(t.TempDir(), "token")
+		c, _ := NewCachedAuth(&countingAuth{baseURL: u}, path)
+
+		Convey("Should not record or send a fingerprint", func() {
+			_, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(c.Refresh(), ShouldBeNil)
+			So(sent, ShouldResemble, []string{""})
+			var cache map[string]interface{}
+			data, _ := c.store.Load()
+			json.Unmarshal(data, &cache)
+			So(cache, ShouldNotContainKey, "fingerprint")
+		})
+	})
+
+	Convey("The current fingerprint", t, func() {
+		fingerprint := CurrentFingerprint()
+		So(fingerprint.Host, ShouldNotBeEmpty)
+		So(fingerprint.Process, ShouldNotBeEmpty)
+		So(fingerprint.String(), ShouldStartWith, "host="+fingerprint.Host+"; ")
+	})
+}
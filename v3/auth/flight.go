@@ -0,0 +1,73 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flight makes concurrent callers share one run of a function, so a pool of workers that all
+// find the token expired authenticate once instead of once each
+type flight struct {
+	mu   sync.Mutex
+	call *flightCall
+}
+
+// flightCall is a run of a function that callers can wait for
+type flightCall struct {
+	done chan struct{}
+	err  error
+}
+
+// do runs fn, or waits for the run already in progress and returns its error. A caller that
+// gives up on ctx returns its context error without stopping the run. If the run failed
+// because the context of the caller that started it was canceled, the other callers run fn
+// again with their own context
+func (f *flight) do(ctx context.Context, fn func() error) error {
+	for {
+		f.mu.Lock()
+		call := f.call
+		if call == nil {
+			call = &flightCall{done: make(chan struct{})}
+			f.call = call
+			f.mu.Unlock()
+			call.err = fn()
+			f.mu.Lock()
+			f.call = nil
+			f.mu.Unlock()
+			close(call.done)
+			return call.err
+		}
+		f.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if isContextError(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.err
+	}
+}
+
+// isContextError returns whether err is from a canceled or expired context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFlight(t *testing.T) {
+	Convey("A flight", t, func() {
+		var f flight
+		var runs int32
+		release := make(chan struct{})
+		fn := func() error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return nil
+		}
+
+		Convey("Should run once for concurrent callers", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					f.do(context.Background(), fn)
+				}()
+			}
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+			So(atomic.LoadInt32(&runs), ShouldEqual, 1)
+			Convey("And again once it is done", func() {
+				So(f.do(context.Background(), fn), ShouldBeNil)
+				So(atomic.LoadInt32(&runs), ShouldEqual, 2)
+			})
+		})
+
+		Convey("Should let a waiting caller give up", func() {
+			go f.do(context.Background(), fn)
+			time.Sleep(10 * time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			So(f.do(ctx, fn), ShouldEqual, context.Canceled)
+			close(release)
+		})
+
+		Convey("Should run again when the first caller was canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			go f.do(ctx, func() error {
+				<-release
+				return ctx.Err()
+			})
+			time.Sleep(10 * time.Millisecond)
+			done := make(chan error)
+			go func() { done <- f.do(context.Background(), func() error { return nil }) }()
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			close(release)
+			So(<-done, ShouldBeNil)
+		})
+	})
+}
+
+func TestConcurrentLogin(t *testing.T) {
+	var logins int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "sts-identity") {
+			atomic.AddInt32(&logins, 1)
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(responseBody))
+	}))
+	defer ts.Close()
+
+	Convey("An STSAuth used by a pool of workers", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv("AWS_ACCESS_KEY_ID", "access")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		a, _ := NewSTSAuth(ts.URL, "us-west-2")
+		var changes int32
+		a.OnTokenChange(func(TokenChange) { atomic.AddInt32(&changes, 1) })
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tok, err := a.GetToken(nil)
+				if err != nil || tok != "token" {
+					t.Errorf("GetToken returned %q, %v", tok, err)
+				}
+			}()
+		}
+		wg.Wait()
+		Convey("Should authenticate once", func() {
+			So(atomic.LoadInt32(&logins), ShouldEqual, 1)
+			So(atomic.LoadInt32(&changes), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// SessionEnv is the environment variable conventionally used to pass an exported session to a
+// child process
+const SessionEnv = "CERBERUS_SESSION"
+
+// ExportSession returns the token of an authenticated Auth, with its expiry and principal, as
+// an encrypted string that ResumeSession can use in another process. This lets a successor,
+// such as the new process of a blue/green restart or a child process, start with the token
+// instead of authenticating again. The key must be 16, 24 or 32 bytes long and be shared with
+// the other process, e.g. a data key from KMS. Anyone with the string and the key can use the
+// token until it expires, so pass it over a private channel like SessionEnv or a file only
+// the service can read
+func ExportSession(a Auth, key []byte) (string, error) {
+	aead, err := sessionCipher(key)
+	if err != nil {
+		return "", err
+	}
+	headers, err := a.GetHeaders()
+	if err != nil {
+		return "", err
+	}
+	token := headers.Get("X-Cerberus-Token")
+	if token == "" {
+		return "", api.ErrorUnauthenticated
+	}
+	expiry, err := a.GetExpiry()
+	if err != nil {
+		return "", fmt.Errorf("Unable to export a session without an expiry time: %v", err)
+	}
+	session := cachedToken{
+		URL:    a.GetURL().String(),
+		Token:  token,
+		Expiry: expiry,
+		Key:    cacheKey(a),
+	}
+	if p, ok := a.(PrincipalProvider); ok {
+		session.Principal = p.Principal()
+	}
+	if p, ok := a.(TokenInfoProvider); ok {
+		if info, err := p.GetTokenInfo(); err == nil {
+			session.Info = &info
+		}
+	}
+	plain, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+// ResumeSession returns a CachedAuth that starts with the token of a session exported by
+// ExportSession, and uses a once it expires or can't be refreshed. The session must be for the
+// same Cerberus URL as a, and a must use the same credentials and namespace as the exported
+// Auth. The token is only kept in memory. A session that expired since it was
+// exported is ignored, so a authenticates instead
+func ResumeSession(a Auth, session string, key []byte) (*CachedAuth, error) {
+	if a == nil {
+		return nil, fmt.Errorf("Auth cannot be nil")
+	}
+	aead, err := sessionCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(session)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode session: %v", err)
+	}
+	size := aead.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("Unable to decode session: session is truncated")
+	}
+	plain, err := aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt session, the key may be wrong: %v", err)
+	}
+	token := cachedToken{}
+	if err := json.Unmarshal(plain, &token); err != nil {
+		return nil, fmt.Errorf("Unable to parse session: %v", err)
+	}
+	if token.URL != a.GetURL().String() {
+		return nil, fmt.Errorf("Session is for %s, not %s", token.URL, a.GetURL())
+	}
+	if token.Key != cacheKey(a) {
+		return nil, fmt.Errorf("Session is for another principal or namespace")
+	}
+	return NewCachedAuthWithStore(a, &memoryTokenStore{data: plain})
+}
+
+// sessionCipher returns the AES-GCM cipher for a session key
+func sessionCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid session key: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid session key: %v", err)
+	}
+	return aead, nil
+}
+
+// memoryTokenStore keeps the token of a resumed session in memory
+type memoryTokenStore struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (s *memoryTokenStore) Load() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data, nil
+}
+
+func (s *memoryTokenStore) Save(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+	return nil
+}
+
+func (s *memoryTokenStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = nil
+	return nil
+}
+
+func (s *memoryTokenStore) String() string {
+	return "resumed session"
+}
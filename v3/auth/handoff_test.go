@@ -0,0 +1,123 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSessionHandoff(t *testing.T) {
+	var logins int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&logins, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(responseBody))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	key := bytes.Repeat([]byte{9}, 32)
+
+	Convey("An authenticated STSAuth", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv("AWS_ACCESS_KEY_ID", "access")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		a, _ := NewSTSAuth(ts.URL, "us-west-2")
+		_, err := a.GetToken(nil)
+		So(err, ShouldBeNil)
+		session, err := ExportSession(a, key)
+		So(err, ShouldBeNil)
+		So(session, ShouldNotContainSubstring, "token")
+		atomic.StoreInt32(&logins, 0)
+
+		Convey("Should be resumed without authenticating", func() {
+			successor := &keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "access-key=access"}
+			resumed, err := ResumeSession(successor, session, key)
+			So(err, ShouldBeNil)
+			tok, err := resumed.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "token")
+			So(successor.logins, ShouldEqual, 0)
+			So(resumed.Principal(), ShouldEqual, "arn:aws:iam::111111111:role/fake-role")
+			expiry, err := resumed.GetExpiry()
+			So(err, ShouldBeNil)
+			original, _ := a.GetExpiry()
+			So(expiry.Equal(original), ShouldBeTrue)
+		})
+
+		Convey("Should be resumed by an STSAuth with the same credentials", func() {
+			successor, _ := NewSTSAuth(ts.URL, "us-west-2")
+			resumed, err := ResumeSession(successor, session, key)
+			So(err, ShouldBeNil)
+			tok, err := resumed.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "token")
+			So(atomic.LoadInt32(&logins), ShouldEqual, 0)
+		})
+
+		Convey("Should not be resumed by an Auth with other credentials", func() {
+			t.Setenv("AWS_ACCESS_KEY_ID", "other")
+			successor, _ := NewSTSAuth(ts.URL, "us-west-2")
+			_, err := ResumeSession(successor, session, key)
+			So(err, ShouldNotBeNil)
+			_, err = ResumeSession(&countingAuth{baseURL: u}, session, key)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should authenticate once the session expires", func() {
+			successor := &keyedAuth{countingAuth: countingAuth{baseURL: u}, key: "access-key=access"}
+			resumed, _ := ResumeSession(successor, session, key)
+			resumed.WithClock(&fakeClock{now: time.Now().Add(2 * time.Hour)})
+			tok, err := resumed.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "login-token-1")
+			So(successor.logins, ShouldEqual, 1)
+		})
+
+		Convey("Should not be resumed with the wrong key", func() {
+			_, err := ResumeSession(&countingAuth{baseURL: u}, session, bytes.Repeat([]byte{8}, 32))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should not be resumed for another Cerberus", func() {
+			other, _ := url.Parse("https://other.example.com")
+			_, err := ResumeSession(&countingAuth{baseURL: other}, session, key)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("An unauthenticated Auth", t, func() {
+		_, err := ExportSession(&countingAuth{baseURL: u}, key)
+		So(err, ShouldEqual, api.ErrorUnauthenticated)
+	})
+
+	Convey("An invalid key", t, func() {
+		_, err := ExportSession(&countingAuth{baseURL: u}, []byte("short"))
+		So(err, ShouldNotBeNil)
+		_, err = ResumeSession(&countingAuth{baseURL: u}, "", []byte("short"))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import "time"
+
+// TokenChangeReason says why the token of an auth method changed
+type TokenChangeReason string
+
+const (
+	// TokenAuthenticated is a token from a new authentication
+	TokenAuthenticated TokenChangeReason = "authenticated"
+	// TokenRefreshed is a token that replaced an existing one, e.g. when Cerberus asked for
+	// a refresh with the X-Refresh-Token header
+	TokenRefreshed TokenChangeReason = "refreshed"
+	// TokenLoggedOut means the token was revoked and there is no token anymore
+	TokenLoggedOut TokenChangeReason = "logged_out"
+)
+
+// TokenChange describes a change of the X-Cerberus-Token of an auth method
+type TokenChange struct {
+	Reason TokenChangeReason
+	// Token is the new token, or empty after a logout
+	Token string
+	// Expiry is when the new token expires, or the zero time if it is not known
+	Expiry time.Time
+}
+
+// TokenChangeHandler is called after the token of an auth method changes. It is called
+// synchronously from the goroutine that changed the token, so it should return quickly
+type TokenChangeHandler func(TokenChange)
+
+// tokenHooks are the handlers registered on an auth method
+type tokenHooks []TokenChangeHandler
+
+// notify calls every handler with the change
+func (h tokenHooks) notify(reason TokenChangeReason, token string, expiry time.Time) {
+	change := TokenChange{Reason: reason, Token: token, Expiry: expiry}
+	for _, handler := range h {
+		handler(change)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOnTokenChangeSTS(t *testing.T) {
+	Convey("An STSAuth with a token change handler", t, TestingServer(http.StatusOK, "/v2/auth/sts-identity",
+		http.MethodPost, responseBody, map[string]string{"X-Amz-Date": "date",
+			"Authorization": "authorization"}, func(ts *httptest.Server) {
+			os.Setenv("AWS_ACCESS_KEY_ID", "access")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+			var changes []TokenChange
+			a, err := NewSTSAuth(ts.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			a.headers.Set("X-Cerberus-Client", api.ClientHeader)
+			a.OnTokenChange(func(c TokenChange) { changes = append(changes, c) })
+
+			Convey("Should be told about the first authentication", func() {
+				_, err := a.GetToken(nil)
+				So(err, ShouldBeNil)
+				So(changes, ShouldHaveLength, 1)
+				So(changes[0].Reason, ShouldEqual, TokenAuthenticated)
+				So(changes[0].Token, ShouldEqual, "token")
+				So(changes[0].Expiry, ShouldEqual, a.expiry)
+				Convey("But not when the token is reused", func() {
+					a.GetToken(nil)
+					So(changes, ShouldHaveLength, 1)
+				})
+				Convey("And about refreshes", func() {
+					So(a.Refresh(), ShouldBeNil)
+					So(changes, ShouldHaveLength, 2)
+					So(changes[1].Reason, ShouldEqual, TokenRefreshed)
+				})
+			})
+		}))
+}
+
+func TestOnTokenChangeToken(t *testing.T) {
+	expectedHeaders := map[string]string{
+		"X-Cerberus-Token":  "finn",
+		"X-Cerberus-Client": api.ClientHeader,
+	}
+	Convey("A TokenAuth with token change handlers", t, TestingServer(http.StatusOK, "/v2/auth/user/refresh", http.MethodGet, authResponseBody, expectedHeaders, func(ts *httptest.Server) {
+		var first, second []TokenChange
+		tok, err := NewTokenAuth(ts.URL, "finn")
+		So(err, ShouldBeNil)
+		tok.OnTokenChange(func(c TokenChange) { first = append(first, c) }).
+			OnTokenChange(func(c TokenChange) { second = append(second, c) })
+		Convey("Should call every handler on refresh", func() {
+			So(tok.Refresh(), ShouldBeNil)
+			So(first, ShouldResemble, []TokenChange{{Reason: TokenRefreshed, Token: "a-cool-token"}})
+			So(second, ShouldResemble, first)
+		})
+	}))
+	Convey("A failed refresh should not call the handler", t, TestingServer(http.StatusInternalServerError, "/v2/auth/user/refresh", http.MethodGet, "", expectedHeaders, func(ts *httptest.Server) {
+		called := false
+		tok, _ := NewTokenAuth(ts.URL, "finn")
+		tok.OnTokenChange(func(TokenChange) { called = true })
+		So(tok.Refresh(), ShouldNotBeNil)
+		So(called, ShouldBeFalse)
+	}))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryPolicy is how authentication, refresh and logout requests are retried when Cerberus or
+// a load balancer in front of it is briefly unavailable. Connection errors and 429, 502, 503
+// and 504 responses are retried, with exponential backoff between attempts
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first one. Values below 2 turn
+	// retries off
+	MaxAttempts int
+	// InitialInterval is the wait before the first retry. It doubles for every retry after that
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between attempts, including one asked for by a Retry-After header
+	MaxInterval time.Duration
+	// Jitter randomizes each wait by up to this fraction of it, e.g. 0.5 for +/- 50%, so many
+	// clients failing at once don't retry in lockstep
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries twice, after about 200ms and 400ms. It is only used when passed to
+// WithRetry, as retries by default would multiply the load of a fleet on a struggling server
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	InitialInterval: 200 * time.Millisecond,
+	MaxInterval:     2 * time.Second,
+	Jitter:          0.5,
+}
+
+// retryTransport retries the requests of an auth method according to a RetryPolicy. Waiting
+// stops when the context of the request is done, so the timeout of the HTTP client and the
+// context of the caller bound all attempts together
+type retryTransport struct {
+	rt     http.RoundTripper
+	policy RetryPolicy
+}
+
+// retryableStatus reports whether a response is worth retrying
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A body that can't be sent again is only sent once
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.rt.RoundTrip(req)
+	}
+	wait := &backoff.ExponentialBackOff{
+		InitialInterval:     t.policy.InitialInterval,
+		RandomizationFactor: t.policy.Jitter,
+		Multiplier:          2,
+		MaxInterval:         t.policy.MaxInterval,
+		Clock:               backoff.SystemClock,
+	}
+	wait.Reset()
+	for attempt := 1; ; attempt++ {
+		try := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			try = req.Clone(req.Context())
+			try.Body = body
+		}
+		resp, err := t.rt.RoundTrip(try)
+		if attempt >= t.policy.MaxAttempts || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		delay := wait.NextBackOff()
+		if resp != nil {
+			delay = retryAfter(resp, delay, t.policy.MaxInterval)
+			// The response of a retried attempt is discarded
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			log.Debug(fmt.Sprintf("Cerberus returned %d for %s %s, retrying in %v", resp.StatusCode, req.Method, req.URL.Path, delay))
+		} else {
+			log.Debug(fmt.Sprintf("Request %s %s failed, retrying in %v: %v", req.Method, req.URL.Path, delay, err))
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			if err == nil {
+				err = req.Context().Err()
+			}
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAfter returns the wait asked for by the Retry-After header of resp in seconds, capped at
+// max, or delay if there is none
+func retryAfter(resp *http.Response, delay, max time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return delay
+	}
+	if after := time.Duration(seconds) * time.Second; after < max {
+		return after
+	}
+	return max
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWithRetry(t *testing.T) {
+	var calls int32
+	var failures int32
+	var bodies []string
+	var retryAfterHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if n <= atomic.LoadInt32(&failures) {
+			if retryAfterHeader != "" {
+				w.Header().Set("Retry-After", retryAfterHeader)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "sts-identity") {
+			w.Write([]byte(responseBody))
+			return
+		}
+		w.Write([]byte(authResponseBody))
+	}))
+	defer ts.Close()
+	clearAWSEnvironment(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	fast := RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond, MaxInterval: 10 * time.Millisecond, Jitter: 0.5}
+
+	Convey("Auth methods with retries", t, func() {
+		atomic.StoreInt32(&calls, 0)
+		atomic.StoreInt32(&failures, 2)
+		bodies = nil
+		retryAfterHeader = ""
+
+		Convey("Should retry a briefly unavailable authentication with the same body", func() {
+			a, err := NewSTSAuth(ts.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			tok, err := a.WithRetry(fast).GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "token")
+			So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+			So(bodies[2], ShouldEqual, bodies[0])
+			So(bodies[0], ShouldContainSubstring, "GetCallerIdentity")
+		})
+
+		Convey("Should retry a refresh", func() {
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			So(tok.WithRetry(fast).Refresh(), ShouldBeNil)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+		})
+
+		Convey("Should give up after the last attempt", func() {
+			atomic.StoreInt32(&failures, 5)
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			So(tok.WithRetry(fast).Refresh(), ShouldNotBeNil)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+		})
+
+		Convey("Should not retry by default", func() {
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			So(tok.Refresh(), ShouldNotBeNil)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+		})
+
+		Convey("Should cap the wait asked for by Retry-After", func() {
+			retryAfterHeader = "3600"
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			start := time.Now()
+			So(tok.WithRetry(fast).Refresh(), ShouldBeNil)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+
+		Convey("Should stop waiting when the context is done", func() {
+			atomic.StoreInt32(&failures, 5)
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			tok.WithRetry(RetryPolicy{MaxAttempts: 5, InitialInterval: time.Hour, MaxInterval: time.Hour})
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			So(tok.RefreshContext(ctx), ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+			So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeychainService is the service name tokens are stored under in the OS keychain. Other
+// Cerberus tools can use the same name to share a token
+const KeychainService = "cerberus"
+
+// TokenStore persists the token of a CachedAuth between runs
+type TokenStore interface {
+	// Load returns the stored data, or nil if nothing is stored
+	Load() ([]byte, error)
+	// Save replaces the stored data
+	Save(data []byte) error
+	// Delete removes the stored data. Deleting when nothing is stored is not an error
+	Delete() error
+}
+
+// FileTokenStore keeps the token in a plaintext file that only the current user can read.
+// It is the store used by NewCachedAuth
+type FileTokenStore struct {
+	Path string
+}
+
+// Load reads the file, returning nil if it doesn't exist
+func (s *FileTokenStore) Load() ([]byte, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Save writes the file, creating its directory when needed
+func (s *FileTokenStore) Save(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("Unable to create token cache directory: %v", err)
+	}
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		return fmt.Errorf("Unable to write token cache: %v", err)
+	}
+	return nil
+}
+
+// Delete removes the file
+func (s *FileTokenStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to remove token cache: %v", err)
+	}
+	return nil
+}
+
+func (s *FileTokenStore) String() string {
+	return s.Path
+}
+
+// KeychainTokenStore keeps the token in the OS keychain: the Keychain on macOS, the Credential
+// Manager on Windows, and the Secret Service (e.g. GNOME Keyring or KWallet) on Linux, which
+// needs a D-Bus session
+type KeychainTokenStore struct {
+	Service string
+	Account string
+}
+
+// NewKeychainTokenStore returns a KeychainTokenStore for the given account under
+// KeychainService. Use a different account per Cerberus environment, e.g. its host name
+func NewKeychainTokenStore(account string) (*KeychainTokenStore, error) {
+	if len(account) == 0 {
+		return nil, fmt.Errorf("Account cannot be empty")
+	}
+	return &KeychainTokenStore{Service: KeychainService, Account: account}, nil
+}
+
+// Load reads the keychain entry, returning nil if there is none
+func (s *KeychainTokenStore) Load() ([]byte, error) {
+	data, err := keyring.Get(s.Service, s.Account)
+	if err == keyring.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read token from the keychain: %v", err)
+	}
+	return []byte(data), nil
+}
+
+// Save writes the keychain entry
+func (s *KeychainTokenStore) Save(data []byte) error {
+	if err := keyring.Set(s.Service, s.Account, string(data)); err != nil {
+		return fmt.Errorf("Unable to write token to the keychain: %v", err)
+	}
+	return nil
+}
+
+// Delete removes the keychain entry
+func (s *KeychainTokenStore) Delete() error {
+	if err := keyring.Delete(s.Service, s.Account); err != nil && err != keyring.ErrNotFound {
+		return fmt.Errorf("Unable to remove token from the keychain: %v", err)
+	}
+	return nil
+}
+
+func (s *KeychainTokenStore) String() string {
+	return "keychain " + s.Service + "/" + s.Account
+}
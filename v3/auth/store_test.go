@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/zalando/go-keyring"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	Convey("A FileTokenStore", t, func() {
+		s := &FileTokenStore{Path: filepath.Join(t.TempDir(), "cerberus", "token")}
+		Convey("Should load nothing when there is no file", func() {
+			data, err := s.Load()
+			So(err, ShouldBeNil)
+			So(data, ShouldBeNil)
+			So(s.Delete(), ShouldBeNil)
+		})
+		Convey("Should save a file only the user can read", func() {
+			So(s.Save([]byte("token")), ShouldBeNil)
+			info, err := os.Stat(s.Path)
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+			data, err := s.Load()
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "token")
+			Convey("And delete it", func() {
+				So(s.Delete(), ShouldBeNil)
+				data, _ := s.Load()
+				So(data, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestKeychainTokenStore(t *testing.T) {
+	keyring.MockInit()
+	Convey("An empty account should error", t, func() {
+		s, err := NewKeychainTokenStore("")
+		So(err, ShouldNotBeNil)
+		So(s, ShouldBeNil)
+	})
+	Convey("A KeychainTokenStore", t, func() {
+		s, err := NewKeychainTokenStore("cerberus.example.com")
+		So(err, ShouldBeNil)
+		So(s.Service, ShouldEqual, KeychainService)
+		Convey("Should load nothing when there is no entry", func() {
+			data, err := s.Load()
+			So(err, ShouldBeNil)
+			So(data, ShouldBeNil)
+			So(s.Delete(), ShouldBeNil)
+		})
+		Convey("Should save, load and delete an entry", func() {
+			So(s.Save([]byte("token")), ShouldBeNil)
+			data, err := s.Load()
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "token")
+			So(s.Delete(), ShouldBeNil)
+			data, _ = s.Load()
+			So(data, ShouldBeNil)
+		})
+		Convey("Should keep the token of a CachedAuth", func() {
+			u, _ := url.Parse("https://cerberus.example.com")
+			c, err := NewCachedAuthWithStore(&countingAuth{baseURL: u}, s)
+			So(err, ShouldBeNil)
+			tok, err := c.GetToken(nil)
+			So(err, ShouldBeNil)
+			next, _ := NewCachedAuthWithStore(&countingAuth{baseURL: u}, s)
+			reused, err := next.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(reused, ShouldEqual, tok)
+			So(next.auth.(*countingAuth).logins, ShouldEqual, 0)
+			s.Delete()
+		})
+	})
+	Convey("A CachedAuth without a store should error", t, func() {
+		c, err := NewCachedAuthWithStore(&countingAuth{}, nil)
+		So(err, ShouldNotBeNil)
+		So(c, ShouldBeNil)
+	})
+}
@@ -18,38 +18,62 @@ package auth
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/defaults"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/signer/v4"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
+// STSEndpointEnv overrides the STS endpoint that STSAuth signs its requests for, as with the
+// AWS SDKs
+const STSEndpointEnv = "AWS_ENDPOINT_URL_STS"
+
 // STSAuth uses AWS V4 signing authenticate to Cerberus.
 type STSAuth struct {
-	token   string
-	region  string
-	expiry  time.Time
-	baseURL *url.URL
-	headers http.Header
+	// mu guards token, principal, info, expiry, skew and headers, which change when authenticating
+	mu sync.RWMutex
+	// login makes concurrent authentications share one request
+	login       flight
+	token       string
+	principal   string
+	info        *api.TokenInfo
+	region      string
+	expiry      time.Time
+	skew        time.Duration
+	baseURL     *url.URL
+	headers     http.Header
 	credentials *credentials.Credentials
+	// identity describes who credentials are for, such as a profile or role, for the cache
+	// key. It is empty for credentials given to WithCredentials
+	identity    string
+	stsEndpoint string
+	// expiryDelta is subtracted from the expiry of the token, see WithExpiryDelta
+	expiryDelta time.Duration
+	// refreshAhead is how long before expiry GetToken authenticates again
+	refreshAhead time.Duration
+	jar          http.CookieJar
+	transport    httpSettings
+	clock        Clock
+	hooks        tokenHooks
 }
 
 // NewSTSAuth returns an STSAuth given a valid URL and region.
 // Valid AWS credentials configured either by environment or through a credentials
-// config file are also required.
+// config file are also required. An empty region is detected from AWS_REGION,
+// AWS_DEFAULT_REGION, the shared config file or the EC2 instance metadata, in that order
 func NewSTSAuth(cerberusURL, region string) (*STSAuth, error) {
-	if len(region) == 0 {
-		return nil, fmt.Errorf("Region cannot be empty")
-	}
 	if len(cerberusURL) == 0 {
 		return nil, fmt.Errorf("Cerberus URL cannot be empty")
 	}
@@ -57,8 +81,11 @@ func NewSTSAuth(cerberusURL, region string) (*STSAuth, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err != nil {
-		return nil, fmt.Errorf("Unable to create AWS session: %s", err)
+	if len(region) == 0 {
+		region, err = detectRegion("")
+		if err != nil {
+			return nil, err
+		}
 	}
 	return &STSAuth{
 		region:  region,
@@ -66,41 +93,344 @@ func NewSTSAuth(cerberusURL, region string) (*STSAuth, error) {
 		headers: http.Header{
 			"Content-Type": []string{"application/json"},
 		},
-		credentials: creds(),
+		credentials: creds(region),
+		identity:    defaultIdentity(),
+		expiryDelta: DefaultExpiryDelta,
 	}, nil
 }
 
-//WithCredentials sets credentials for the STSAuth
+// defaultIdentity describes who the default AWS credential chain authenticates as, using the
+// same environment variables as the chain: static credentials, then web identity, then the
+// profile. Instance and container roles can't change within a host, so they need no more
+func defaultIdentity() string {
+	if key := os.Getenv("AWS_ACCESS_KEY_ID"); key != "" {
+		return "access-key=" + key
+	}
+	if role := os.Getenv(roleARNEnv); role != "" {
+		return "role=" + role
+	}
+	for _, name := range []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE"} {
+		if profile := os.Getenv(name); profile != "" {
+			return "profile=" + profile
+		}
+	}
+	return "profile=default"
+}
+
+// NewSTSAuthWithCredentials returns an STSAuth that signs its requests with the given
+// credentials instead of the default AWS credential chain, e.g. credentials for an assumed
+// role, from SSO, or static ones in tests
+func NewSTSAuthWithCredentials(cerberusURL, region string, c *credentials.Credentials) (*STSAuth, error) {
+	if c == nil {
+		return nil, fmt.Errorf("Credentials cannot be nil")
+	}
+	a, err := NewSTSAuth(cerberusURL, region)
+	if err != nil {
+		return nil, err
+	}
+	return a.WithCredentials(c), nil
+}
+
+// NewSTSAuthWithProfile returns an STSAuth that signs its requests with the credentials of the
+// named profile from ~/.aws/config and ~/.aws/credentials, the same as setting AWS_PROFILE but
+// without changing the environment of the process
+func NewSTSAuthWithProfile(cerberusURL, region, profile string) (*STSAuth, error) {
+	if region == "" && profile != "" {
+		// Prefer the region of the profile over the one of AWS_PROFILE
+		detected, err := detectRegion(profile)
+		if err != nil {
+			return nil, err
+		}
+		region = detected
+	}
+	a, err := NewSTSAuth(cerberusURL, region)
+	if err != nil {
+		return nil, err
+	}
+	c, err := profileCredentials(a.region, profile)
+	if err != nil {
+		return nil, err
+	}
+	a.WithCredentials(c)
+	if profile != "" {
+		a.identity = "profile=" + profile
+	} else {
+		a.identity = defaultIdentity()
+	}
+	return a, nil
+}
+
+// NewSTSAuthWithRole returns an STSAuth that first assumes roleARN with the default AWS
+// credential chain, then signs its requests with the credentials of the role. Cerberus sees the
+// role as the principal, so a single process can authenticate as several IAM principals.
+// externalID is optional and only sent if it isn't empty
+func NewSTSAuthWithRole(cerberusURL, region, roleARN, externalID string) (*STSAuth, error) {
+	a, err := NewSTSAuth(cerberusURL, region)
+	if err != nil {
+		return nil, err
+	}
+	assumed, err := assumeRoleCredentials(a.region, roleARN, externalID, a.credentials)
+	if err != nil {
+		return nil, err
+	}
+	a.WithCredentials(assumed)
+	a.identity = "role=" + roleARN
+	return a, nil
+}
+
+// NewIAMAuth returns an STSAuth for an IAM principal. With an empty roleARN it authenticates
+// as the current identity of the default AWS credential chain, like NewSTSAuth, and otherwise
+// as the given role, assumed with that identity, like NewSTSAuthWithRole. Either way the
+// Cerberus token is sent in the X-Cerberus-Token header and GetExpiry returns its expiry. An
+// empty region is detected like in NewSTSAuth
+func NewIAMAuth(cerberusURL, region, roleARN string) (*STSAuth, error) {
+	if roleARN == "" {
+		return NewSTSAuth(cerberusURL, region)
+	}
+	return NewSTSAuthWithRole(cerberusURL, region, roleARN, "")
+}
+
+// WithCredentials sets credentials for the STSAuth. Nil goes back to the default AWS
+// credential chain
 func (a *STSAuth) WithCredentials(c *credentials.Credentials) *STSAuth {
+	a.identity = ""
+	if c == nil {
+		c = creds(a.region)
+		a.identity = defaultIdentity()
+	}
 	a.credentials = c
 	return a
 }
 
+// WithCredentialsProvider sets the provider of the credentials for the STSAuth, such as a
+// stscreds.AssumeRoleProvider. The credentials it returns are cached until they expire
+func (a *STSAuth) WithCredentialsProvider(p credentials.Provider) *STSAuth {
+	if p == nil {
+		return a.WithCredentials(nil)
+	}
+	return a.WithCredentials(credentials.NewCredentials(p))
+}
+
+// WithSTSEndpoint sets the STS endpoint the authentication request is signed for, such as a VPC
+// interface endpoint, a FIPS endpoint or localstack in tests. The region is still used for the
+// signature. Cerberus sends the signed request on to STS, so the endpoint must also be one the
+// Cerberus server calls. Empty goes back to AWS_ENDPOINT_URL_STS or the regional endpoint
+func (a *STSAuth) WithSTSEndpoint(endpoint string) *STSAuth {
+	a.stsEndpoint = endpoint
+	return a
+}
+
+// WithExpiryDelta sets how long before the expiry reported by Cerberus the token is considered
+// expired, to compensate for request time and clock skew. It defaults to DefaultExpiryDelta.
+// At most half the lifetime of a token is subtracted, so very short-lived tokens still work.
+// Negative values are treated as zero. It applies from the next authentication
+func (a *STSAuth) WithExpiryDelta(delta time.Duration) *STSAuth {
+	if delta < 0 {
+		delta = 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expiryDelta = delta
+	return a
+}
+
+// WithRefreshAhead makes GetToken authenticate again once the token is within window of its
+// expiry, instead of returning a token that may expire during a long running operation. If
+// authenticating fails while the current token is still valid, the current token is returned
+// and the next call tries again. Zero, the default, only authenticates again after expiry
+func (a *STSAuth) WithRefreshAhead(window time.Duration) *STSAuth {
+	a.refreshAhead = window
+	return a
+}
+
+// WithClock sets the clock used to decide when the token expires. It defaults to SystemClock
+// and is meant for simulating expiry in tests
+func (a *STSAuth) WithClock(clock Clock) *STSAuth {
+	a.clock = clock
+	return a
+}
+
+// WithCookieJar sets the cookie jar used for authentication and logout requests, for when
+// Cerberus is behind an authenticating proxy that uses session cookies
+func (a *STSAuth) WithCookieJar(jar http.CookieJar) *STSAuth {
+	a.jar = jar
+	return a
+}
+
+// WithHTTPClient sets the HTTP client used for authentication and logout requests, e.g. to
+// control their timeout, transport or TLS settings. Without one, authentication requests time
+// out after DefaultSTSTimeout. The client is copied for each request
+func (a *STSAuth) WithHTTPClient(client *http.Client) *STSAuth {
+	a.transport.setClient(client)
+	return a
+}
+
+// WithProxy sets the proxy for the authentication and logout requests to Cerberus, e.g. http.ProxyURL of your egress
+// proxy, or NoProxy to connect directly. Nil, the default, uses the proxy of the HTTP client,
+// which for the default client is HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment.
+// Requests of the AWS SDK, such as assuming a role, use the proxy from the environment
+func (a *STSAuth) WithProxy(proxy func(*http.Request) (*url.URL, error)) *STSAuth {
+	a.transport.setProxy(proxy)
+	return a
+}
+
+// WithTLSConfig sets the TLS config for the authentication and logout requests to Cerberus, e.g. a client
+// certificate for a gateway that requires mutual TLS or a custom CA pool, see
+// utils.ClientTLSConfig. Nil, the default, uses the TLS config of the HTTP client
+func (a *STSAuth) WithTLSConfig(config *tls.Config) *STSAuth {
+	a.transport.setTLS(config)
+	return a
+}
+
+// WithTimeout bounds each authentication and logout request to Cerberus, including reading
+// the response, and overrides the timeout of the HTTP client. Zero goes back to the timeout of
+// the client, or DefaultSTSTimeout for authentication requests without a client
+func (a *STSAuth) WithTimeout(timeout time.Duration) *STSAuth {
+	a.transport.setTimeout(timeout)
+	return a
+}
+
+// WithRetry retries the authentication and logout requests to Cerberus that fail with a connection error or a
+// 429, 502, 503 or 504 response, e.g. DefaultRetryPolicy. All attempts together are bounded by
+// the timeout and the context of the request. Requests aren't retried by default
+func (a *STSAuth) WithRetry(policy RetryPolicy) *STSAuth {
+	a.transport.setRetry(&policy)
+	return a
+}
+
+// OnTokenChange adds a handler that is called after every authentication, refresh and logout,
+// e.g. to update caches or metrics when the client swaps its token
+func (a *STSAuth) OnTokenChange(handler TokenChangeHandler) *STSAuth {
+	a.hooks = append(a.hooks, handler)
+	return a
+}
+
+func (a *STSAuth) cookieJar() http.CookieJar {
+	return a.jar
+}
+
+func (a *STSAuth) authHTTPClient() *http.Client {
+	return a.transport.get()
+}
+
+// WithNamespace sets the namespace sent with the authentication request and every
+// request made using this STSAuth
+func (a *STSAuth) WithNamespace(namespace string) *STSAuth {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.headers.Set(api.NamespaceHeader, namespace)
+	return a
+}
+
+// cacheKey identifies the principal by how its credentials are configured, or by their
+// access key for credentials given to WithCredentials, and the namespace
+func (a *STSAuth) cacheKey() string {
+	a.mu.RLock()
+	identity := a.identity
+	namespace := namespaceKey(a.headers)
+	a.mu.RUnlock()
+	if identity == "" {
+		value, err := a.credentials.Get()
+		if err != nil {
+			return ""
+		}
+		identity = "access-key=" + value.AccessKeyID
+	}
+	if namespace != "" {
+		return identity + " " + namespace
+	}
+	return identity
+}
+
 // GetToken returns a token if it already exists and is not expired. Otherwise,
 // it authenticates using the provided URL and region and then returns the token.
-func (a *STSAuth) GetToken(*os.File) (string, error) {
-	if a.IsAuthenticated() {
-		return a.token, nil
+func (a *STSAuth) GetToken(f *os.File) (string, error) {
+	return a.GetTokenContext(context.Background(), f)
+}
+
+// GetTokenContext is the same as GetToken, but the authentication request uses the given context.
+// Concurrent calls that find no valid token share a single authentication
+func (a *STSAuth) GetTokenContext(ctx context.Context, f *os.File) (string, error) {
+	token, _, ok := a.state()
+	if ok && a.fresh() {
+		return token, nil
 	}
-	err := a.authenticate()
-	return a.token, err
+	if ok {
+		return a.refreshAheadOfExpiry(ctx, token)
+	}
+	err := a.login.do(ctx, func() error {
+		if a.IsAuthenticated() {
+			// Another goroutine authenticated while this one waited
+			return nil
+		}
+		if err := a.authenticate(ctx); err != nil {
+			return err
+		}
+		token, expiry, _ := a.state()
+		a.hooks.notify(TokenAuthenticated, token, expiry)
+		return nil
+	})
+	token, _, _ = a.state()
+	return token, err
+}
+
+// refreshAheadOfExpiry authenticates again for a token that is valid but within the refresh
+// ahead window, keeping the current token if that fails
+func (a *STSAuth) refreshAheadOfExpiry(ctx context.Context, current string) (string, error) {
+	err := a.login.do(ctx, func() error {
+		if a.fresh() {
+			return nil
+		}
+		if err := a.authenticate(ctx); err != nil {
+			return err
+		}
+		token, expiry, _ := a.state()
+		a.hooks.notify(TokenRefreshed, token, expiry)
+		return nil
+	})
+	if err != nil {
+		log.Warn(fmt.Sprintf("Unable to refresh the Cerberus token before it expires, using the current one: %v", err))
+		return current, nil
+	}
+	token, _, _ := a.state()
+	return token, nil
+}
+
+// state returns the current token, its expiry and whether it is valid
+func (a *STSAuth) state() (string, time.Time, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.token, a.expiry, a.valid()
+}
+
+// fresh returns whether the token is valid and outside the refresh ahead window
+func (a *STSAuth) fresh() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.valid() && a.now().Add(a.refreshAhead).Before(a.expiry)
 }
 
 // GetExpiry returns the expiry time of the token if it already exists. Otherwise,
 // it returns a zero-valued time.Time struct and an error.
 func (a *STSAuth) GetExpiry() (time.Time, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	if len(a.token) > 0 {
 		return a.expiry, nil
 	}
 	return time.Time{}, fmt.Errorf("Expiry time not set.")
 }
 
-func (a *STSAuth) authenticate() error {
+func (a *STSAuth) authenticate(ctx context.Context) error {
+	// Signing can fetch credentials, so don't start if the caller has given up already
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	builtURL := *a.baseURL
 	builtURL.Path = "v2/auth/sts-identity"
 	body := bytes.NewReader([]byte("Action=GetCallerIdentity&Version=2011-06-15"))
 
-	request, err := http.NewRequest("POST", builtURL.String(), body)
+	request, err := http.NewRequestWithContext(ctx, "POST", builtURL.String(), body)
 	if err != nil {
 		return fmt.Errorf("Problem while creating request to Cerberus: %v", err)
 	}
@@ -112,14 +442,33 @@ func (a *STSAuth) authenticate() error {
 	for k, v := range headers {
 		request.Header.Set(k, v[0])
 	}
+	a.mu.RLock()
+	namespace := a.headers.Get(api.NamespaceHeader)
+	a.mu.RUnlock()
+	if namespace != "" {
+		request.Header.Set(api.NamespaceHeader, namespace)
+	}
 
-	client := http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: DefaultSTSTimeout, Jar: a.jar}
+	if base := a.transport.get(); base != nil {
+		client = newHTTPClient(base, nil, a.jar)
+		if a.transport.client == nil && a.transport.timeout == 0 {
+			client.Timeout = DefaultSTSTimeout
+		}
+	}
 	response, err := client.Do(request)
 	if err != nil {
-		return fmt.Errorf("Problem while performing request to Cerberus: %v", err)
+		return fmt.Errorf("Problem while performing request to Cerberus: %w", utils.ConnectionError(request.URL.Host, err))
 	}
 	defer response.Body.Close()
 
+	if err := utils.ServiceUnavailable(response); err != nil {
+		return err
+	}
+	// A 403 page from a WAF would otherwise look like invalid credentials
+	if err := utils.UnexpectedResponse(response); err != nil {
+		return err
+	}
 	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
 		return fmt.Errorf("Invalid credentials given. Verify that the role you are currently using is valid " +
 			"with the AWS CLI ($ aws sts get-caller-identity) or with gimme-aws-creds.")
@@ -129,11 +478,11 @@ func (a *STSAuth) authenticate() error {
 		return fmt.Errorf("Error while trying to authenticate. Got HTTP response code %d\n%v", response.StatusCode, apiErr)
 	}
 
-	decoder := json.NewDecoder(response.Body)
+	decoder := json.NewDecoder(utils.LimitReader(response.Body, utils.MaxResponseSize))
 	authResponse := &api.IAMAuthResponse{}
 	dErr := decoder.Decode(authResponse)
 	if dErr != nil {
-		return fmt.Errorf("Error while trying to parse response from Cerberus: %v", err)
+		return fmt.Errorf("Error while trying to parse response from Cerberus: %w", dErr)
 	}
 
 	metadata := authResponse.Metadata
@@ -144,20 +493,79 @@ func (a *STSAuth) authenticate() error {
 		identity = username
 	}
 	log.Info(fmt.Sprintf("Successfully authenticated with Cerberus as %v\n", identity))
+	skew := clockSkew(response, clockOrSystem(a.clock).Now())
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if identity != "unknown" {
+		a.principal = identity
+	}
 	a.token = authResponse.Token
+	info := authResponse.TokenInfo()
+	a.info = &info
 	a.headers.Set("X-Cerberus-Token", authResponse.Token)
-	a.expiry = time.Now().Add((time.Duration(authResponse.Duration) * time.Second) - expiryDelta)
+	a.skew = skew
+	// Cerberus only returns the lease duration, so the expiry is in local time and doesn't
+	// depend on the skew. Callers compare it with their local clock
+	now := a.now()
+	a.expiry = expiryWithDelta(now, authResponse.ExpiresAt(now), a.expiryDelta)
 	return nil
 }
 
+// Principal returns the IAM principal ARN or username Cerberus reported during the last
+// authentication, or an empty string if it is not known
+func (a *STSAuth) Principal() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.principal
+}
+
+// GetTokenInfo returns the policies, groups and other metadata Cerberus returned with the
+// current token, or api.ErrorUnauthenticated if there is no valid token
+func (a *STSAuth) GetTokenInfo() (api.TokenInfo, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.valid() {
+		return api.TokenInfo{}, api.ErrorUnauthenticated
+	}
+	return a.info.Copy(), nil
+}
+
+// ClockSkew returns how far the Cerberus server's clock was ahead of the local clock
+// during the last authentication, based on the Date header of the response. A negative
+// value means the local clock is ahead. It is for converting absolute server timestamps;
+// GetExpiry is already in local time.
+func (a *STSAuth) ClockSkew() time.Duration {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.skew
+}
+
+// now returns the current local time, using the clock set with WithClock
+func (a *STSAuth) now() time.Time {
+	return clockOrSystem(a.clock).Now()
+}
+
 // IsAuthenticated returns whether or not the current token is set and is not expired.
 func (a *STSAuth) IsAuthenticated() bool {
-	return len(a.token) > 0 && time.Now().Before(a.expiry)
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.valid()
+}
+
+// valid returns whether the token is set and not expired. The caller must hold a.mu
+func (a *STSAuth) valid() bool {
+	return len(a.token) > 0 && a.now().Before(a.expiry)
 }
 
 // Refresh refreshes the current token by reauthenticating against the API.
 func (a *STSAuth) Refresh() error {
+	return a.RefreshContext(context.Background())
+}
+
+// RefreshContext refreshes the current token by reauthenticating against the API using
+// the given context. A refresh while another goroutine authenticates waits for that token
+func (a *STSAuth) RefreshContext(ctx context.Context) error {
 	if !a.IsAuthenticated() {
 		return api.ErrorUnauthenticated
 	}
@@ -169,32 +577,53 @@ func (a *STSAuth) Refresh() error {
 	// operations. This is less than ideal but better than having an arbitary
 	// bound on the number of refreshes and having to track how many have been
 	// done.
-	return a.authenticate()
+	// Concurrent refreshes share one authentication, as any new token will do
+	return a.login.do(ctx, func() error {
+		if err := a.authenticate(ctx); err != nil {
+			return err
+		}
+		token, expiry, _ := a.state()
+		a.hooks.notify(TokenRefreshed, token, expiry)
+		return nil
+	})
 }
 
 // Logout deauthorizes the current valid token. This will return an error if the token
 // is expired or non-existent.
 func (a *STSAuth) Logout() error {
-	if !a.IsAuthenticated() {
-		return api.ErrorUnauthenticated
+	return a.LogoutContext(context.Background())
+}
+
+// LogoutContext is the same as Logout, but the request uses the given context
+func (a *STSAuth) LogoutContext(ctx context.Context) error {
+	headers, err := a.GetHeaders()
+	if err != nil {
+		return err
 	}
 	// Use a copy of the base URL
-	if err := Logout(*a.baseURL, a.headers); err != nil {
+	if err := logout(ctx, newHTTPClient(a.transport.get(), headers, a.jar), *a.baseURL, headers); err != nil {
 		return err
 	}
 	// Reset the token and header
+	a.mu.Lock()
 	a.token = ""
+	a.info = nil
 	a.headers.Del("X-Cerberus-Token")
+	a.mu.Unlock()
+	a.hooks.notify(TokenLoggedOut, "", time.Time{})
 	return nil
 }
 
 // GetHeaders returns the headers needed to authenticate against Cerberus. This will
-// return an error if the token is expired or non-existent.
+// return an error if the token is expired or non-existent. The headers are a new copy on
+// every call, so they can be modified and are not changed by a later authentication
 func (a *STSAuth) GetHeaders() (http.Header, error) {
-	if !a.IsAuthenticated() {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.valid() {
 		return nil, api.ErrorUnauthenticated
 	}
-	return a.headers, nil
+	return a.headers.Clone(), nil
 }
 
 // GetURL returns the configured Cerberus URL.
@@ -203,11 +632,6 @@ func (a *STSAuth) GetURL() *url.URL {
 }
 
 // credentials obtains default AWS credentials.
-func creds() *credentials.Credentials {
-	creds := defaults.Get().Config.Credentials
-	return creds
-}
-
 // signer returns a V4 signer for signing a request.
 func signer(creds *credentials.Credentials) (*v4.Signer, error) {
 	_, err := creds.Get()
@@ -218,27 +642,53 @@ func signer(creds *credentials.Credentials) (*v4.Signer, error) {
 	return signer, nil
 }
 
+// stsDNSSuffixes are the DNS suffixes of the regional STS endpoints in each supported AWS
+// partition, keyed by partition ID
+var stsDNSSuffixes = map[string]string{
+	endpoints.AwsPartitionID:      "amazonaws.com",
+	endpoints.AwsCnPartitionID:    "amazonaws.com.cn",
+	endpoints.AwsUsGovPartitionID: "amazonaws.com",
+}
+
 // request creates an STS Auth request.
 func (a *STSAuth) request() (*http.Request, error) {
-
-	var chinaRegions = make(map[string]struct{})
-	chinaRegions["cn-north-1"] = struct{}{}
-	chinaRegions["cn-northwest-1"] = struct{}{}
+	endpoint := a.stsEndpoint
+	if endpoint == "" {
+		endpoint = os.Getenv(STSEndpointEnv)
+	}
+	if endpoint != "" {
+		return customSTSRequest(endpoint)
+	}
 
 	_, err := endpoints.DefaultResolver().EndpointFor("sts", a.region, endpoints.StrictMatchingOption)
 	if err != nil {
 		return nil, fmt.Errorf("Endpoint could not be created. "+
 			"Confirm that region, %v, is a valid AWS region : %v", a.region, err)
 	}
-	method := "POST"
-	url := "https://sts." + a.region + ".amazonaws.com"
-	if _, ok := chinaRegions[a.region]; ok {
-		url += ".cn"
+	partition, _ := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), a.region)
+	suffix, ok := stsDNSSuffixes[partition.ID()]
+	if !ok {
+		return nil, fmt.Errorf("Region %v is in the %v partition, which is not supported", a.region, partition.ID())
 	}
+	method := "POST"
+	url := "https://sts." + a.region + "." + suffix
 	request, _ := http.NewRequest(method, url, nil)
 	return request, nil
 }
 
+// customSTSRequest creates an STS Auth request for a custom endpoint. An endpoint without a
+// scheme uses https
+func customSTSRequest(endpoint string) (*http.Request, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return nil, fmt.Errorf("STS endpoint %q is not a valid URL", endpoint)
+	}
+	return http.NewRequest("POST", parsed.String(), nil)
+}
+
 // sign signs a AWS v4 request and returns the signed headers.
 func (a *STSAuth) sign() (http.Header, error) {
 	signer, signErr := signer(a.credentials)
@@ -252,6 +702,7 @@ func (a *STSAuth) sign() (http.Header, error) {
 	service := "sts"
 	body := bytes.NewReader([]byte("Action=GetCallerIdentity&Version=2011-06-15"))
 
+	// Signatures are checked by AWS against the real time, so this doesn't use the clock
 	_, signerErr := signer.Sign(request, body, service, a.region, time.Now())
 	if signerErr != nil {
 		return nil, signerErr
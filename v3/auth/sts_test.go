@@ -17,6 +17,7 @@ limitations under the License.
 package auth
 
 import (
+	"errors"
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	. "github.com/smartystreets/goconvey/convey"
@@ -56,7 +57,10 @@ func TestNewSTSAuth(t *testing.T) {
 			So(a, ShouldBeNil)
 		})
 	})
-	Convey("An empty region", t, func() {
+	Convey("An empty region that can't be detected", t, func() {
+		clearAWSEnvironment(t)
+		ec2MetadataEndpoint = "http://127.0.0.1:1"
+		defer func() { ec2MetadataEndpoint = "" }()
 		a, err := NewSTSAuth("https://test.example.com", "")
 		Convey("Should error", func() {
 			So(err, ShouldNotBeNil)
@@ -86,7 +90,88 @@ func TestWithCredentials(t *testing.T) {
 		Convey("Should result in changed credentials", func() {
 			So(a.credentials, ShouldEqual, c)
 		})
+		Convey("Should go back to the default credentials when nil", func() {
+			a.WithCredentials(nil)
+			So(a.credentials, ShouldNotBeNil)
+			So(a.credentials, ShouldNotEqual, c)
+		})
+	})
+
+	Convey("Setting a credentials provider", t, func() {
+		a, _ := NewSTSAuth("https://test.example.com", "us-east-1")
+		a.WithCredentialsProvider(&credentials.StaticProvider{Value: credentials.Value{
+			AccessKeyID: "provided", SecretAccessKey: "secret"}})
+		Convey("Should sign with its credentials", func() {
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "Credential=provided/")
+		})
 	})
+
+	Convey("A new STSAuth with credentials", t, func() {
+		c := credentials.NewStaticCredentials("access", "secret", "")
+		a, err := NewSTSAuthWithCredentials("https://test.example.com", "us-east-1", c)
+		So(err, ShouldBeNil)
+		So(a.credentials, ShouldEqual, c)
+		Convey("Should need credentials", func() {
+			a, err := NewSTSAuthWithCredentials("https://test.example.com", "us-east-1", nil)
+			So(err, ShouldNotBeNil)
+			So(a, ShouldBeNil)
+		})
+		Convey("Should need a region", func() {
+			a, err := NewSTSAuthWithCredentials("https://test.example.com", "", c)
+			So(err, ShouldNotBeNil)
+			So(a, ShouldBeNil)
+		})
+	})
+}
+
+func TestCacheKeySTS(t *testing.T) {
+	Convey("The cache key of an STSAuth", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv("AWS_PROFILE", "dev")
+		a, err := NewSTSAuth("https://test.example.com", "us-west-2")
+		So(err, ShouldBeNil)
+		Convey("Should identify the profile", func() {
+			So(a.cacheKey(), ShouldEqual, "profile=dev")
+		})
+		Convey("Should identify the namespace", func() {
+			a.WithNamespace("team-a")
+			So(a.cacheKey(), ShouldEqual, "profile=dev namespace=team-a")
+		})
+		Convey("Should identify static credentials from the environment", func() {
+			t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+			b, _ := NewSTSAuth("https://test.example.com", "us-west-2")
+			So(b.cacheKey(), ShouldEqual, "access-key=AKIAEXAMPLE")
+		})
+		Convey("Should identify given credentials by their access key", func() {
+			a.WithCredentials(credentials.NewStaticCredentials("AKIAOTHER", "secret", ""))
+			So(a.cacheKey(), ShouldEqual, "access-key=AKIAOTHER")
+			a.WithCredentials(nil)
+			So(a.cacheKey(), ShouldEqual, "profile=dev")
+		})
+	})
+}
+
+func TestWithNamespaceSTS(t *testing.T) {
+	Convey("An STSAuth with a namespace", t, TestingServer(http.StatusOK, "/v2/auth/sts-identity",
+		http.MethodPost, responseBody, map[string]string{api.NamespaceHeader: "team-a"}, func(ts *httptest.Server) {
+			a, err := NewSTSAuth(ts.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			a.WithNamespace("team-a")
+
+			os.Setenv("AWS_ACCESS_KEY_ID", "access")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+			Convey("Should send the namespace when authenticating", func() {
+				_, err := a.GetToken(nil)
+				So(err, ShouldBeNil)
+				Convey("And keep it for later requests", func() {
+					headers, err := a.GetHeaders()
+					So(err, ShouldBeNil)
+					So(headers.Get(api.NamespaceHeader), ShouldEqual, "team-a")
+				})
+			})
+		}))
 }
 
 func TestGetTokenSTS(t *testing.T) {
@@ -109,6 +194,16 @@ func TestGetTokenSTS(t *testing.T) {
 				Convey("And should have a valid expiry time", func() {
 					So(a.expiry, ShouldHappenOnOrBefore, time.Now().Add(1*time.Hour))
 				})
+				Convey("And should know the principal it authenticated as", func() {
+					So(a.Principal(), ShouldEqual, "arn:aws:iam::111111111:role/fake-role")
+				})
+				Convey("And should keep the metadata of the token", func() {
+					info, err := a.GetTokenInfo()
+					So(err, ShouldBeNil)
+					So(info.Policies, ShouldResemble, []string{"foo-bar-read", "lookup-self"})
+					So(info.Groups, ShouldResemble, []string{"registered-iam-principals"})
+					So(info.IsAdmin, ShouldBeFalse)
+				})
 			})
 		}))
 	Convey("A valid STSAuth", t, TestingServer(http.StatusOK, "/v2/auth/sts-identity",
@@ -134,6 +229,11 @@ func TestGetTokenSTS(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(tok, ShouldEqual, "test-token")
 		})
+		Convey("Should not have token metadata once the token expired", func() {
+			a.expiry = time.Now().Add(-time.Second)
+			_, err := a.GetTokenInfo()
+			So(err, ShouldEqual, api.ErrorUnauthenticated)
+		})
 	})
 	Convey("A valid STSAuth", t, TestingServer(http.StatusUnauthorized, "/v2/auth/sts-identity",
 		http.MethodPost, "", map[string]string{"X-Amz-Date": "date",
@@ -161,6 +261,35 @@ func TestGetTokenSTS(t *testing.T) {
 				So(tok, ShouldBeEmpty)
 			})
 		}))
+	Convey("An STSAuth during maintenance", t, TestingServer(http.StatusServiceUnavailable, "/v2/auth/sts-identity",
+		http.MethodPost, "<html><body>Down for maintenance</body></html>", map[string]string{}, func(ts *httptest.Server) {
+			a, err := NewSTSAuth(ts.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			So(a, ShouldNotBeNil)
+			Convey("Should return a typed error", func() {
+				tok, err := a.GetToken(nil)
+				So(err, ShouldResemble, api.ErrorServiceUnavailable{})
+				So(tok, ShouldBeEmpty)
+			})
+		}))
+	Convey("An STSAuth behind a WAF that blocks the request", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<html><body>Request blocked</body></html>"))
+		}))
+		defer ts.Close()
+		a, err := NewSTSAuth(ts.URL, "us-west-2")
+		So(err, ShouldBeNil)
+		Convey("Should return a typed error instead of invalid credentials", func() {
+			tok, err := a.GetToken(nil)
+			var unexpected api.ErrorUnexpectedResponse
+			So(errors.As(err, &unexpected), ShouldBeTrue)
+			So(unexpected.StatusCode, ShouldEqual, http.StatusForbidden)
+			So(unexpected.Snippet, ShouldEqual, "<html><body>Request blocked</body></html>")
+			So(tok, ShouldBeEmpty)
+		})
+	})
 	Convey("An STSAuth with an invalid region", t, TestingServer(http.StatusOK, "/v2/auth/sts-identity",
 		http.MethodPost, "{", map[string]string{"X-Amz-Date": "date", "X-Amz-Security-Token": "token",
 			"Authorization": "authorization"}, func(ts *httptest.Server) {
@@ -175,6 +304,42 @@ func TestGetTokenSTS(t *testing.T) {
 		}))
 }
 
+func TestClockSkewSTS(t *testing.T) {
+	Convey("A server whose clock is an hour ahead", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(responseBody))
+		}))
+		defer ts.Close()
+		a, err := NewSTSAuth(ts.URL, "us-west-2")
+		So(err, ShouldBeNil)
+		os.Setenv("AWS_ACCESS_KEY_ID", "access")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		_, err = a.GetToken(nil)
+		So(err, ShouldBeNil)
+		Convey("Should record the skew", func() {
+			So(a.ClockSkew(), ShouldAlmostEqual, time.Hour, 2*time.Second)
+		})
+		Convey("Should compute the expiry in local time", func() {
+			So(a.expiry, ShouldHappenWithin, 2*time.Second, time.Now().Add(time.Hour-DefaultExpiryDelta))
+			expiry, err := a.GetExpiry()
+			So(err, ShouldBeNil)
+			So(expiry, ShouldEqual, a.expiry)
+		})
+		Convey("Should be authenticated", func() {
+			So(a.IsAuthenticated(), ShouldBeTrue)
+		})
+		Convey("Should be authenticated until the local time is past the expiry", func() {
+			a.expiry = time.Now().Add(30 * time.Minute)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+			a.expiry = time.Now().Add(-time.Second)
+			So(a.IsAuthenticated(), ShouldBeFalse)
+		})
+	})
+}
+
 func TestGetExpiry(t *testing.T) {
 	Convey("A valid STSAuth", t, func() {
 		a, err := NewSTSAuth("https://test.example.com", "us-west-2")
@@ -342,7 +507,7 @@ func TestSigner(t *testing.T) {
 	Convey("A signer with credentials", t, func() {
 		os.Setenv("AWS_ACCESS_KEY_ID", "access")
 		os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
-		a, e := signer(creds())
+		a, e := signer(creds("us-west-2"))
 		Convey("Should return a signer", func() {
 			So(a, ShouldNotBeNil)
 			So(e, ShouldBeNil)
@@ -394,6 +559,65 @@ func TestRequest(t *testing.T) {
 			So(r.Host, ShouldEqual, "sts.cn-northwest-1.amazonaws.com.cn")
 		})
 	})
+	Convey("A valid request call to GovCloud", t, func() {
+		for _, region := range []string{"us-gov-west-1", "us-gov-east-1"} {
+			a, err := NewSTSAuth("https://test.example.com", region)
+			So(err, ShouldBeNil)
+			r, e := a.request()
+			So(e, ShouldBeNil)
+			So(r.Method, ShouldEqual, "POST")
+			So(r.Host, ShouldEqual, "sts."+region+".amazonaws.com")
+		}
+	})
+	Convey("A request call in an unsupported partition", t, func() {
+		a, err := NewSTSAuth("https://test.example.com", "us-iso-east-1")
+		So(err, ShouldBeNil)
+		r, e := a.request()
+		Convey("Should error instead of using the wrong host", func() {
+			So(e, ShouldNotBeNil)
+			So(e.Error(), ShouldContainSubstring, "aws-iso")
+			So(r, ShouldBeNil)
+		})
+	})
+}
+
+func TestCustomSTSEndpoint(t *testing.T) {
+	Convey("An STSAuth with a custom STS endpoint", t, func() {
+		t.Setenv(STSEndpointEnv, "")
+		a, _ := NewSTSAuthWithCredentials("https://test.example.com", "us-west-2", credentials.NewStaticCredentials("AKIDSTATIC", "secret", ""))
+		a.WithSTSEndpoint("https://vpce-0abc.sts.us-west-2.vpce.amazonaws.com")
+		Convey("Should sign the request for that endpoint and the region", func() {
+			r, err := a.request()
+			So(err, ShouldBeNil)
+			So(r.URL.String(), ShouldEqual, "https://vpce-0abc.sts.us-west-2.vpce.amazonaws.com")
+			headers, err := a.sign()
+			So(err, ShouldBeNil)
+			So(headers.Get("Authorization"), ShouldContainSubstring, "/us-west-2/sts/aws4_request")
+			So(headers.Get("Authorization"), ShouldContainSubstring, "SignedHeaders=host;")
+		})
+		Convey("Should use https for an endpoint without a scheme", func() {
+			r, err := a.WithSTSEndpoint("localhost:4566").request()
+			So(err, ShouldBeNil)
+			So(r.URL.String(), ShouldEqual, "https://localhost:4566")
+		})
+		Convey("Should not need a known region", func() {
+			b, _ := NewSTSAuth("https://test.example.com", "test-region")
+			r, err := b.WithSTSEndpoint("http://localhost:4566").request()
+			So(err, ShouldBeNil)
+			So(r.Host, ShouldEqual, "localhost:4566")
+		})
+		Convey("Should reject an invalid endpoint", func() {
+			r, err := a.WithSTSEndpoint("ftp://sts.example.com").request()
+			So(err, ShouldNotBeNil)
+			So(r, ShouldBeNil)
+		})
+		Convey("Should use the endpoint from the environment", func() {
+			t.Setenv(STSEndpointEnv, "https://sts-fips.us-west-2.amazonaws.com")
+			r, err := a.WithSTSEndpoint("").request()
+			So(err, ShouldBeNil)
+			So(r.Host, ShouldEqual, "sts-fips.us-west-2.amazonaws.com")
+		})
+	})
 }
 
 func TestSign(t *testing.T) {
@@ -423,3 +647,88 @@ func TestSign(t *testing.T) {
 		})
 	})
 }
+
+func TestConcurrentRefreshSTS(t *testing.T) {
+	ts := authServer()
+	defer ts.Close()
+	os.Setenv("AWS_ACCESS_KEY_ID", "access")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	Convey("An STSAuth refreshed while it is used", t, func() {
+		a, _ := NewSTSAuth(ts.URL, "us-west-2")
+		_, err := a.GetToken(nil)
+		So(err, ShouldBeNil)
+		hammer(
+			func() { a.Refresh() },
+			func() {
+				headers, _ := a.GetHeaders()
+				headers.Set("X-Cerberus-Token", "changed")
+			},
+			func() { a.GetToken(nil) },
+			func() { a.GetExpiry(); a.Principal(); a.ClockSkew() },
+		)
+		Convey("Should keep its own headers", func() {
+			headers, err := a.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get("X-Cerberus-Token"), ShouldEqual, "token")
+		})
+	})
+}
+
+func TestRefreshAhead(t *testing.T) {
+	var logins int
+	var fail bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		logins++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(responseBody))
+	}))
+	defer ts.Close()
+
+	Convey("An STSAuth that refreshes ahead of expiry", t, func() {
+		clearAWSEnvironment(t)
+		t.Setenv("AWS_ACCESS_KEY_ID", "access")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		logins = 0
+		fail = false
+		clock := &fakeClock{now: time.Now()}
+		a, err := NewSTSAuth(ts.URL, "us-west-2")
+		So(err, ShouldBeNil)
+		So(a.WithClock(clock).WithRefreshAhead(10*time.Minute), ShouldEqual, a)
+		_, err = a.GetToken(nil)
+		So(err, ShouldBeNil)
+		So(logins, ShouldEqual, 1)
+
+		Convey("Should reuse the token outside the window", func() {
+			clock.Advance(30 * time.Minute)
+			_, err := a.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(logins, ShouldEqual, 1)
+		})
+
+		Convey("Should authenticate again inside the window", func() {
+			var changes []TokenChange
+			a.OnTokenChange(func(change TokenChange) { changes = append(changes, change) })
+			clock.Advance(time.Hour - DefaultExpiryDelta - 5*time.Minute)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+			_, err := a.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(logins, ShouldEqual, 2)
+			So(changes, ShouldHaveLength, 1)
+			So(changes[0].Reason, ShouldEqual, TokenRefreshed)
+		})
+
+		Convey("Should keep the current token if authenticating fails", func() {
+			clock.Advance(time.Hour - DefaultExpiryDelta - 5*time.Minute)
+			fail = true
+			tok, err := a.GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldNotBeEmpty)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+		})
+	})
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package auth
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
@@ -29,9 +32,15 @@ import (
 
 // TokenAuth uses a preexisting token to authenticate to Cerberus
 type TokenAuth struct {
-	token   string
-	headers http.Header
-	baseURL *url.URL
+	// mu guards token, info and headers, which change on refresh and logout
+	mu        sync.RWMutex
+	token     string
+	info      *api.TokenInfo
+	headers   http.Header
+	baseURL   *url.URL
+	jar       http.CookieJar
+	transport httpSettings
+	hooks     tokenHooks
 }
 
 // NewTokenAuth takes a Cerberus URL and valid token and returns a new TokenAuth.
@@ -62,58 +71,187 @@ func NewTokenAuth(cerberusURL, token string) (*TokenAuth, error) {
 	}, nil
 }
 
+// WithNamespace sets the namespace sent with every request made using this TokenAuth
+func (t *TokenAuth) WithNamespace(namespace string) *TokenAuth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.headers.Set(api.NamespaceHeader, namespace)
+	return t
+}
+
+// WithCookieJar sets the cookie jar used for refresh and logout requests, for when Cerberus is
+// behind an authenticating proxy that uses session cookies
+func (t *TokenAuth) WithCookieJar(jar http.CookieJar) *TokenAuth {
+	t.jar = jar
+	return t
+}
+
+// WithHTTPClient sets the HTTP client used for refresh and logout requests, e.g. to control
+// their timeout, transport or TLS settings. The client is copied for each request
+func (t *TokenAuth) WithHTTPClient(client *http.Client) *TokenAuth {
+	t.transport.setClient(client)
+	return t
+}
+
+// WithProxy sets the proxy for the refresh and logout requests to Cerberus, e.g. http.ProxyURL of your egress
+// proxy, or NoProxy to connect directly. Nil, the default, uses the proxy of the HTTP client,
+// which for the default client is HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment
+func (t *TokenAuth) WithProxy(proxy func(*http.Request) (*url.URL, error)) *TokenAuth {
+	t.transport.setProxy(proxy)
+	return t
+}
+
+// WithTLSConfig sets the TLS config for the refresh and logout requests to Cerberus, e.g. a client
+// certificate for a gateway that requires mutual TLS or a custom CA pool, see
+// utils.ClientTLSConfig. Nil, the default, uses the TLS config of the HTTP client
+func (t *TokenAuth) WithTLSConfig(config *tls.Config) *TokenAuth {
+	t.transport.setTLS(config)
+	return t
+}
+
+// WithTimeout bounds each refresh and logout request to Cerberus, including reading the
+// response, and overrides the timeout of the HTTP client. Zero, the default, keeps the timeout
+// of the client, which for the default client is none
+func (t *TokenAuth) WithTimeout(timeout time.Duration) *TokenAuth {
+	t.transport.setTimeout(timeout)
+	return t
+}
+
+// WithRetry retries the refresh and logout requests to Cerberus that fail with a connection error or a
+// 429, 502, 503 or 504 response, e.g. DefaultRetryPolicy. All attempts together are bounded by
+// the timeout and the context of the request. Requests aren't retried by default
+func (t *TokenAuth) WithRetry(policy RetryPolicy) *TokenAuth {
+	t.transport.setRetry(&policy)
+	return t
+}
+
+// OnTokenChange adds a handler that is called after every refresh and logout, e.g. to update
+// caches or metrics when the client swaps its token
+func (t *TokenAuth) OnTokenChange(handler TokenChangeHandler) *TokenAuth {
+	t.hooks = append(t.hooks, handler)
+	return t
+}
+
+func (t *TokenAuth) cookieJar() http.CookieJar {
+	return t.jar
+}
+
+func (t *TokenAuth) authHTTPClient() *http.Client {
+	return t.transport.get()
+}
+
 // GetToken returns the token passed when creating the TokenAuth. Nil should
 // be passed as the argument to the function. The argument exists for compatibility
 // with the Auth interface
 func (t *TokenAuth) GetToken(f *os.File) (string, error) {
-	if !t.IsAuthenticated() {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.token == "" {
 		return "", api.ErrorUnauthenticated
 	}
 	return t.token, nil
 }
 
+// GetTokenContext is the same as GetToken. It makes no requests, so the context is only
+// checked for cancellation
+func (t *TokenAuth) GetTokenContext(ctx context.Context, f *os.File) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return t.GetToken(f)
+}
+
 // IsAuthenticated always returns true if there is a token. If Logout has been
 // called, it will return false
 func (t *TokenAuth) IsAuthenticated() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.token != ""
 }
 
 // Refresh attempts to refresh the token
 func (t *TokenAuth) Refresh() error {
-	if !t.IsAuthenticated() {
-		return api.ErrorUnauthenticated
+	return t.RefreshContext(context.Background())
+}
+
+// RefreshContext attempts to refresh the token using the given context
+func (t *TokenAuth) RefreshContext(ctx context.Context) error {
+	headers, err := t.GetHeaders()
+	if err != nil {
+		return err
 	}
-	r, err := Refresh(*t.baseURL, t.headers)
+	r, err := refresh(ctx, newHTTPClient(t.transport.get(), headers, t.jar), *t.baseURL, headers)
 	if err != nil {
 		return err
 	}
-	t.token = r.Data.ClientToken.ClientToken
-	t.headers.Set("X-Cerberus-Token", r.Data.ClientToken.ClientToken)
+	token := r.Data.ClientToken.ClientToken
+	info := r.Data.ClientToken.TokenInfo()
+	t.mu.Lock()
+	t.token = token
+	t.info = &info
+	t.headers.Set("X-Cerberus-Token", token)
+	t.mu.Unlock()
+	t.hooks.notify(TokenRefreshed, token, time.Time{})
 	return nil
 }
 
 // Logout logs the current token out and removes it from the authentication type
 func (t *TokenAuth) Logout() error {
-	if !t.IsAuthenticated() {
-		return api.ErrorUnauthenticated
+	return t.LogoutContext(context.Background())
+}
+
+// LogoutContext is the same as Logout, but the request uses the given context
+func (t *TokenAuth) LogoutContext(ctx context.Context) error {
+	headers, err := t.GetHeaders()
+	if err != nil {
+		return err
 	}
 	// Use a copy of the base URL
-	if err := Logout(*t.baseURL, t.headers); err != nil {
+	if err := logout(ctx, newHTTPClient(t.transport.get(), headers, t.jar), *t.baseURL, headers); err != nil {
 		return err
 	}
 	// Reset the token and header
+	t.mu.Lock()
 	t.token = ""
+	t.info = nil
 	t.headers.Del("X-Cerberus-Token")
+	t.mu.Unlock()
+	t.hooks.notify(TokenLoggedOut, "", time.Time{})
 	return nil
 }
 
 // GetHeaders returns HTTP headers used for requests if the method is currently authenticated.
-// Returns an error otherwise
+// Returns an error otherwise. The headers are a new copy on every call, so they can be
+// modified and are not changed by a later refresh
 func (t *TokenAuth) GetHeaders() (http.Header, error) {
-	if !t.IsAuthenticated() {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.token == "" {
 		return nil, api.ErrorUnauthenticated
 	}
-	return t.headers, nil
+	return t.headers.Clone(), nil
+}
+
+// GetTokenInfo returns the policies, groups and other metadata Cerberus returned with the
+// token. A token given to NewTokenAuth comes without metadata, so until the first refresh it
+// returns ErrorNoTokenInfo
+func (t *TokenAuth) GetTokenInfo() (api.TokenInfo, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.token == "" {
+		return api.TokenInfo{}, api.ErrorUnauthenticated
+	}
+	if t.info == nil {
+		return api.TokenInfo{}, ErrorNoTokenInfo
+	}
+	return t.info.Copy(), nil
+}
+
+// cacheKey only identifies the namespace, as a refreshed token stands for the same principal
+func (t *TokenAuth) cacheKey() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return namespaceKey(t.headers)
 }
 
 // GetURL returns the URL for cerberus
@@ -17,8 +17,11 @@ limitations under the License.
 package auth
 
 import (
+	"context"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
@@ -63,6 +66,19 @@ func TestNewTokenAuth(t *testing.T) {
 	})
 }
 
+func TestWithNamespaceToken(t *testing.T) {
+	Convey("A TokenAuth with a namespace", t, func() {
+		tok, err := NewTokenAuth("https://test.example.com", "kylo-ren")
+		So(err, ShouldBeNil)
+		tok.WithNamespace("first-order")
+		Convey("Should send the namespace header", func() {
+			headers, err := tok.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get(api.NamespaceHeader), ShouldEqual, "first-order")
+		})
+	})
+}
+
 func TestGetToken(t *testing.T) {
 	Convey("A valid TokenAuth", t, func() {
 		tok, err := NewTokenAuth("https://test.example.com", "rey")
@@ -121,6 +137,10 @@ func TestRefreshToken(t *testing.T) {
 	Convey("A valid TokenAuth", t, TestingServer(http.StatusOK, "/v2/auth/user/refresh", http.MethodGet, authResponseBody, expectedHeaders, func(ts *httptest.Server) {
 		tok, err := NewTokenAuth(ts.URL, testToken)
 		So(err, ShouldBeNil)
+		Convey("Should not know the metadata of the token before a refresh", func() {
+			_, err := tok.GetTokenInfo()
+			So(err, ShouldEqual, ErrorNoTokenInfo)
+		})
 		Convey("Should not error on refresh", func() {
 			err := tok.Refresh()
 			So(err, ShouldBeNil)
@@ -128,6 +148,13 @@ func TestRefreshToken(t *testing.T) {
 				// See the authResponseBody definition for the location of the new token
 				So(tok.token, ShouldEqual, "a-cool-token")
 			})
+			Convey("And should keep the metadata of the new token", func() {
+				info, err := tok.GetTokenInfo()
+				So(err, ShouldBeNil)
+				So(info.Username, ShouldEqual, "john.doe@nike.com")
+				So(info.Policies, ShouldResemble, []string{"web", "stage"})
+				So(info.InGroup("Lst-digital.platform-tools.internal"), ShouldBeTrue)
+			})
 		})
 	}))
 
@@ -253,3 +280,76 @@ func TestGetExpiryToken(t *testing.T) {
 		})
 	})
 }
+
+func TestRefreshContextToken(t *testing.T) {
+	Convey("A TokenAuth refreshed with a cancelled context", t, TestingServer(http.StatusOK, "/v2/auth/user/refresh", http.MethodGet, authResponseBody,
+		map[string]string{}, func(ts *httptest.Server) {
+			tok, _ := NewTokenAuth(ts.URL, "token")
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Convey("Should error and keep the token", func() {
+				err := tok.RefreshContext(ctx)
+				So(err, ShouldNotBeNil)
+				So(tok.token, ShouldEqual, "token")
+			})
+		}))
+	Convey("A TokenAuth refreshed with a context", t, TestingServer(http.StatusOK, "/v2/auth/user/refresh", http.MethodGet, authResponseBody,
+		map[string]string{}, func(ts *httptest.Server) {
+			tok, _ := NewTokenAuth(ts.URL, "token")
+			Convey("Should refresh the token", func() {
+				err := tok.RefreshContext(context.Background())
+				So(err, ShouldBeNil)
+				So(tok.token, ShouldEqual, "a-cool-token")
+			})
+		}))
+}
+
+func TestCookieJarToken(t *testing.T) {
+	var cookies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("proxy-session"); err == nil {
+			cookies = append(cookies, cookie.Value)
+		}
+		http.SetCookie(w, &http.Cookie{Name: "proxy-session", Value: "renewed", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(authResponseBody))
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	Convey("A TokenAuth with a cookie jar", t, func() {
+		cookies = nil
+		jar, _ := cookiejar.New(nil)
+		jar.SetCookies(u, []*http.Cookie{{Name: "proxy-session", Value: "first", Path: "/"}})
+		tok, err := NewTokenAuth(ts.URL, "finn")
+		So(err, ShouldBeNil)
+		tok.WithCookieJar(jar)
+		Convey("Should send and renew the proxy session when refreshing", func() {
+			So(tok.Refresh(), ShouldBeNil)
+			So(tok.Refresh(), ShouldBeNil)
+			So(cookies, ShouldResemble, []string{"first", "renewed"})
+		})
+	})
+}
+
+func TestConcurrentRefreshToken(t *testing.T) {
+	ts := authServer()
+	defer ts.Close()
+	Convey("A TokenAuth refreshed while it is used", t, func() {
+		tok, _ := NewTokenAuth(ts.URL, "finn")
+		hammer(
+			func() { tok.Refresh() },
+			func() {
+				headers, _ := tok.GetHeaders()
+				// Callers own the returned headers
+				headers.Set("X-Cerberus-Token", "changed")
+			},
+			func() { tok.GetToken(nil) },
+		)
+		Convey("Should keep its own headers", func() {
+			headers, err := tok.GetHeaders()
+			So(err, ShouldBeNil)
+			So(headers.Get("X-Cerberus-Token"), ShouldEqual, "a-cool-token")
+		})
+	})
+}
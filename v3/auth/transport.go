@@ -0,0 +1,127 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// NoProxy can be passed to WithProxy to connect to Cerberus directly, even if HTTPS_PROXY is set
+func NoProxy(*http.Request) (*url.URL, error) {
+	return nil, nil
+}
+
+// httpSettings are the HTTP client, timeout, proxy, TLS config and retries an auth method uses
+// for its requests to Cerberus
+type httpSettings struct {
+	// client is the client set with WithHTTPClient
+	client *http.Client
+	// proxy is the proxy set with WithProxy, or nil for the proxy of the client
+	proxy func(*http.Request) (*url.URL, error)
+	// tls is the TLS config set with WithTLSConfig, or nil for the TLS config of the client
+	tls *tls.Config
+	// timeout is the timeout set with WithTimeout, or 0 for the timeout of the client
+	timeout time.Duration
+	// retry is the policy set with WithRetry, or nil to not retry
+	retry *RetryPolicy
+	// built is client with the other settings applied. It is built when any of them changes,
+	// so requests share the connections of one transport
+	built *http.Client
+}
+
+func (h *httpSettings) setClient(client *http.Client) {
+	h.client = client
+	h.build()
+}
+
+func (h *httpSettings) setProxy(proxy func(*http.Request) (*url.URL, error)) {
+	h.proxy = proxy
+	h.build()
+}
+
+func (h *httpSettings) setTLS(config *tls.Config) {
+	h.tls = config
+	h.build()
+}
+
+func (h *httpSettings) setRetry(policy *RetryPolicy) {
+	h.retry = policy
+	h.build()
+}
+
+func (h *httpSettings) setTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	h.timeout = timeout
+	h.build()
+}
+
+// get returns the client for requests to Cerberus, or nil for the default client
+func (h *httpSettings) get() *http.Client {
+	return h.built
+}
+
+func (h *httpSettings) build() {
+	retry := h.retry != nil && h.retry.MaxAttempts > 1
+	if h.proxy == nil && h.tls == nil && h.timeout == 0 && !retry {
+		h.built = h.client
+		return
+	}
+	built := &http.Client{}
+	if h.client != nil {
+		*built = *h.client
+	}
+	if h.timeout > 0 {
+		built.Timeout = h.timeout
+	}
+	transport := built.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if h.proxy != nil || h.tls != nil {
+		transport = h.configure(transport)
+	}
+	if retry {
+		transport = retryTransport{rt: transport, policy: *h.retry}
+	}
+	built.Transport = transport
+	h.built = built
+}
+
+// configure returns a copy of transport with the proxy and TLS config applied
+func (h *httpSettings) configure(transport http.RoundTripper) http.RoundTripper {
+	base, ok := transport.(*http.Transport)
+	if !ok {
+		log.Warn(fmt.Sprintf("Unable to set a proxy or TLS config on a transport of type %T, the settings of the transport are used", transport))
+		return transport
+	}
+	configured := base.Clone()
+	if h.proxy != nil {
+		configured.Proxy = h.proxy
+	}
+	if h.tls != nil {
+		configured.TLSClientConfig = h.tls
+	}
+	return configured
+}
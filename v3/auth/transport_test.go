@@ -0,0 +1,177 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeProxy is a forward proxy that answers every request like Cerberus and records the hosts
+// the requests were for
+func fakeProxy() (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var hosts []string
+	upstream := authServer()
+	// Only the handler of the upstream server is used
+	upstream.Close()
+	handler := upstream.Config.Handler
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hosts = append(hosts, r.URL.Host)
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	return ts, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, hosts...)
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	proxy, proxied := fakeProxy()
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	direct := authServer()
+	defer direct.Close()
+	clearAWSEnvironment(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	Convey("Auth methods with a proxy", t, func() {
+		Convey("Should send authentication requests through it", func() {
+			a, err := NewSTSAuth("http://cerberus.example.com", "us-west-2")
+			So(err, ShouldBeNil)
+			tok, err := a.WithProxy(http.ProxyURL(proxyURL)).GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "token")
+			So(proxied(), ShouldContain, "cerberus.example.com")
+		})
+
+		Convey("Should send refresh requests through it", func() {
+			tok, err := NewTokenAuth("http://refresh.example.com", "finn")
+			So(err, ShouldBeNil)
+			So(tok.WithProxy(http.ProxyURL(proxyURL)).Refresh(), ShouldBeNil)
+			So(proxied(), ShouldContain, "refresh.example.com")
+		})
+
+		Convey("Should keep the settings of the HTTP client", func() {
+			transport := &http.Transport{MaxIdleConns: 3}
+			tok, err := NewTokenAuth("http://client.example.com", "finn")
+			So(err, ShouldBeNil)
+			tok.WithHTTPClient(&http.Client{Transport: transport}).WithProxy(http.ProxyURL(proxyURL))
+			So(tok.Refresh(), ShouldBeNil)
+			So(proxied(), ShouldContain, "client.example.com")
+			So(tok.authHTTPClient().Transport.(*http.Transport).MaxIdleConns, ShouldEqual, 3)
+			So(transport.Proxy, ShouldBeNil)
+		})
+
+		Convey("Should connect directly with NoProxy", func() {
+			t.Setenv("HTTP_PROXY", proxy.URL)
+			tok, err := NewTokenAuth(direct.URL, "finn")
+			So(err, ShouldBeNil)
+			before := len(proxied())
+			So(tok.WithProxy(NoProxy).Refresh(), ShouldBeNil)
+			So(proxied(), ShouldHaveLength, before)
+		})
+	})
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	upstream := authServer()
+	upstream.Close()
+	ts := httptest.NewTLSServer(upstream.Config.Handler)
+	defer ts.Close()
+	config := ts.Client().Transport.(*http.Transport).TLSClientConfig
+	clearAWSEnvironment(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	Convey("Auth methods for a server with a private CA", t, func() {
+		Convey("Should fail without a TLS config", func() {
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			So(tok.Refresh(), ShouldNotBeNil)
+		})
+
+		Convey("Should authenticate with the TLS config", func() {
+			a, err := NewSTSAuth(ts.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			tok, err := a.WithTLSConfig(config).GetToken(nil)
+			So(err, ShouldBeNil)
+			So(tok, ShouldEqual, "token")
+		})
+
+		Convey("Should refresh with the TLS config and a proxy", func() {
+			tok, err := NewTokenAuth(ts.URL, "finn")
+			So(err, ShouldBeNil)
+			So(tok.WithTLSConfig(config).WithProxy(NoProxy).Refresh(), ShouldBeNil)
+			transport := tok.authHTTPClient().Transport.(*http.Transport)
+			So(transport.TLSClientConfig, ShouldEqual, config)
+			So(transport.Proxy, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	clearAWSEnvironment(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	Convey("Auth methods with a timeout", t, func() {
+		Convey("Should give up on a slow authentication", func() {
+			a, err := NewSTSAuth(slow.URL, "us-west-2")
+			So(err, ShouldBeNil)
+			start := time.Now()
+			_, err = a.WithTimeout(50 * time.Millisecond).GetToken(nil)
+			So(err, ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, DefaultSTSTimeout)
+		})
+
+		Convey("Should give up on a slow refresh", func() {
+			tok, err := NewTokenAuth(slow.URL, "finn")
+			So(err, ShouldBeNil)
+			start := time.Now()
+			So(tok.WithTimeout(50*time.Millisecond).Refresh(), ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+
+		Convey("Should override the timeout of the HTTP client", func() {
+			tok, err := NewTokenAuth(slow.URL, "finn")
+			So(err, ShouldBeNil)
+			client := &http.Client{Timeout: time.Hour}
+			tok.WithHTTPClient(client).WithTimeout(time.Minute)
+			So(tok.authHTTPClient().Timeout, ShouldEqual, time.Minute)
+			So(client.Timeout, ShouldEqual, time.Hour)
+			tok.WithTimeout(0)
+			So(tok.authHTTPClient(), ShouldEqual, client)
+		})
+	})
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultRenewBefore is how long before expiry a TokenWatcher renews the token by default
+const DefaultRenewBefore = 5 * time.Minute
+
+// DefaultRetryInterval is how long a TokenWatcher waits before trying again after a failed
+// renewal, by default
+const DefaultRetryInterval = 30 * time.Second
+
+// RenewOutput is sent on the RenewCh of a TokenWatcher after every renewal
+type RenewOutput struct {
+	// RenewedAt is when the token was renewed
+	RenewedAt time.Time
+	// Expiry is the expiry time of the new token
+	Expiry time.Time
+	// Reauthenticated is true if the token expired and a new one was requested, rather
+	// than the current one being refreshed
+	Reauthenticated bool
+}
+
+// TokenWatcher keeps the token of an auth method valid in the background for long running
+// services, like the LifetimeWatcher of the Vault client. It renews the token shortly before
+// it expires, using GetExpiry to schedule it, and authenticates again if the token expired.
+// Start it in a goroutine and read DoneCh to learn when it stops:
+//
+//	watcher, _ := auth.NewTokenWatcher(authMethod)
+//	go watcher.Start()
+//	defer watcher.Stop()
+//	err := <-watcher.DoneCh()
+//
+// The auth method needs an expiry time, so TokenAuth can't be watched
+type TokenWatcher struct {
+	auth   Auth
+	renew  chan RenewOutput
+	done   chan error
+	stop   chan struct{}
+	once   sync.Once
+	cancel context.CancelFunc
+	ctx    context.Context
+
+	// RenewBefore is how long before expiry the token is renewed. For tokens that expire
+	// sooner, it is renewed halfway to expiry instead
+	RenewBefore time.Duration
+	// RetryInterval is how long to wait before trying again after a failed renewal
+	RetryInterval time.Duration
+}
+
+// NewTokenWatcher returns a TokenWatcher for the given auth method
+func NewTokenWatcher(a Auth) (*TokenWatcher, error) {
+	if a == nil {
+		return nil, fmt.Errorf("Auth cannot be nil")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TokenWatcher{
+		auth:          a,
+		renew:         make(chan RenewOutput, 5),
+		done:          make(chan error, 1),
+		stop:          make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+		RenewBefore:   DefaultRenewBefore,
+		RetryInterval: DefaultRetryInterval,
+	}, nil
+}
+
+// DoneCh returns a channel that gets nil when the watcher is stopped, or the error that
+// stopped it if the token couldn't be renewed before it expired
+func (w *TokenWatcher) DoneCh() <-chan error {
+	return w.done
+}
+
+// RenewCh returns a channel that gets a RenewOutput after every renewal. Renewals are
+// dropped if nobody reads them
+func (w *TokenWatcher) RenewCh() <-chan RenewOutput {
+	return w.renew
+}
+
+// Stop stops the watcher. It is safe to call more than once
+func (w *TokenWatcher) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+		w.cancel()
+	})
+}
+
+// Start renews the token until Stop is called or the token can't be renewed. It blocks, so
+// it is usually called in a goroutine. If there is no token yet, it authenticates first
+func (w *TokenWatcher) Start() {
+	w.done <- w.run()
+}
+
+func (w *TokenWatcher) run() error {
+	if !w.auth.IsAuthenticated() {
+		if _, err := GetTokenContext(w.ctx, w.auth, nil); err != nil {
+			return w.stopped(err)
+		}
+	}
+	for {
+		expiry, err := w.auth.GetExpiry()
+		if err != nil {
+			return fmt.Errorf("Unable to watch a token without an expiry time: %v", err)
+		}
+		if !w.wait(w.renewIn(expiry)) {
+			return nil
+		}
+		output, err := w.renewToken()
+		for err != nil {
+			if !w.auth.IsAuthenticated() {
+				return w.stopped(fmt.Errorf("Unable to renew token before it expired: %w", err))
+			}
+			if !w.wait(w.RetryInterval) {
+				return nil
+			}
+			output, err = w.renewToken()
+		}
+		select {
+		case w.renew <- output:
+		default:
+		}
+	}
+}
+
+// stopped returns nil instead of err if the watcher was stopped, as err is then most likely
+// caused by the cancelled context
+func (w *TokenWatcher) stopped(err error) error {
+	select {
+	case <-w.stop:
+		return nil
+	default:
+		return err
+	}
+}
+
+// renewIn returns how long to wait before renewing a token that expires at expiry
+func (w *TokenWatcher) renewIn(expiry time.Time) time.Duration {
+	remaining := time.Until(expiry)
+	wait := remaining - w.RenewBefore
+	if wait < remaining/2 {
+		wait = remaining / 2
+	}
+	return wait
+}
+
+// renewToken refreshes the token, or authenticates again if it expired
+func (w *TokenWatcher) renewToken() (RenewOutput, error) {
+	output := RenewOutput{Reauthenticated: !w.auth.IsAuthenticated()}
+	var err error
+	if output.Reauthenticated {
+		_, err = GetTokenContext(w.ctx, w.auth, nil)
+	} else if refresher, ok := w.auth.(ContextRefresher); ok {
+		err = refresher.RefreshContext(w.ctx)
+	} else {
+		err = w.auth.Refresh()
+	}
+	if err != nil {
+		return output, err
+	}
+	output.RenewedAt = time.Now()
+	output.Expiry, _ = w.auth.GetExpiry()
+	return output, nil
+}
+
+// wait waits for d, returning false if the watcher was stopped first
+func (w *TokenWatcher) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// shortLivedAuth is an Auth with tokens that expire quickly
+type shortLivedAuth struct {
+	mu          sync.Mutex
+	lifetime    time.Duration
+	token       string
+	expiry      time.Time
+	logins      int
+	refreshes   int
+	refreshFail bool
+	loginFail   bool
+}
+
+func (a *shortLivedAuth) GetToken(*os.File) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expiry) {
+		return a.token, nil
+	}
+	if a.loginFail {
+		return "", fmt.Errorf("login failed")
+	}
+	a.logins++
+	a.token = fmt.Sprintf("login-%d", a.logins)
+	a.expiry = time.Now().Add(a.lifetime)
+	return a.token, nil
+}
+
+func (a *shortLivedAuth) IsAuthenticated() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.token != "" && time.Now().Before(a.expiry)
+}
+
+func (a *shortLivedAuth) Refresh() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.refreshFail {
+		return fmt.Errorf("refresh failed")
+	}
+	a.refreshes++
+	a.token = fmt.Sprintf("refresh-%d", a.refreshes)
+	a.expiry = time.Now().Add(a.lifetime)
+	return nil
+}
+
+func (a *shortLivedAuth) Logout() error { return nil }
+
+func (a *shortLivedAuth) GetHeaders() (http.Header, error) {
+	if !a.IsAuthenticated() {
+		return nil, api.ErrorUnauthenticated
+	}
+	return http.Header{}, nil
+}
+
+func (a *shortLivedAuth) GetURL() *url.URL {
+	return &url.URL{Scheme: "https", Host: "cerberus.example.com"}
+}
+
+func (a *shortLivedAuth) GetExpiry() (time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == "" {
+		return time.Time{}, fmt.Errorf("Expiry time not set")
+	}
+	return a.expiry, nil
+}
+
+func (a *shortLivedAuth) set(f func(a *shortLivedAuth)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f(a)
+}
+
+func TestTokenWatcher(t *testing.T) {
+	Convey("A nil Auth", t, func() {
+		w, err := NewTokenWatcher(nil)
+		So(err, ShouldNotBeNil)
+		So(w, ShouldBeNil)
+	})
+
+	Convey("A watcher for short lived tokens", t, func() {
+		a := &shortLivedAuth{lifetime: 200 * time.Millisecond}
+		w, err := NewTokenWatcher(a)
+		So(err, ShouldBeNil)
+		w.RenewBefore = 100 * time.Millisecond
+		w.RetryInterval = 20 * time.Millisecond
+		go w.Start()
+		defer w.Stop()
+
+		Convey("Should log in and keep refreshing the token before it expires", func() {
+			for i := 0; i < 2; i++ {
+				select {
+				case output := <-w.RenewCh():
+					So(output.Reauthenticated, ShouldBeFalse)
+					So(output.Expiry, ShouldHappenAfter, time.Now())
+				case err := <-w.DoneCh():
+					t.Fatalf("watcher stopped: %v", err)
+				}
+			}
+			So(a.logins, ShouldEqual, 1)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+		})
+
+		Convey("Should log in again when refreshing fails until the token expires", func() {
+			a.set(func(a *shortLivedAuth) { a.refreshFail = true })
+			output := <-w.RenewCh()
+			So(output.Reauthenticated, ShouldBeTrue)
+			So(a.IsAuthenticated(), ShouldBeTrue)
+		})
+
+		Convey("Should stop with an error when it can't get a new token", func() {
+			a.set(func(a *shortLivedAuth) {
+				a.refreshFail = true
+				a.loginFail = true
+			})
+			select {
+			case err := <-w.DoneCh():
+				So(err, ShouldNotBeNil)
+			case <-time.After(2 * time.Second):
+				t.Fatal("watcher didn't stop")
+			}
+		})
+
+		Convey("Should stop without an error when stopped", func() {
+			w.Stop()
+			w.Stop()
+			So(<-w.DoneCh(), ShouldBeNil)
+		})
+	})
+
+	Convey("A watcher for an auth method without an expiry", t, func() {
+		w, _ := NewTokenWatcher(&TokenAuth{token: "a-token", headers: http.Header{}})
+		go w.Start()
+		Convey("Should stop with an error", func() {
+			So(<-w.DoneCh(), ShouldNotBeNil)
+		})
+	})
+}
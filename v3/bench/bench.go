@@ -0,0 +1,275 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bench drives read, write and secure file workloads against a Cerberus environment and
+// reports latency percentiles and error rates, so retry, backoff and connection pool settings can
+// be validated before a production rollout. The cerberus-bench command is a ready to run wrapper.
+//
+// Writes replace the secret at the write path, so point them at a path used only for testing.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+)
+
+// Operations run by a workload
+const (
+	Read  = "read"
+	Write = "write"
+	File  = "file"
+)
+
+// Workload describes the operations to run. Each operation is picked at random in proportion
+// to its weight, and operations with a weight of 0 or without paths are never run
+type Workload struct {
+	// ReadWeight, WriteWeight and FileWeight are the relative frequency of each operation
+	ReadWeight  int
+	WriteWeight int
+	FileWeight  int
+	// ReadPaths are the secret paths to read, picked at random
+	ReadPaths []string
+	// WritePath is the secret path that is written to. Its secret is replaced
+	WritePath string
+	// FilePaths are the secure file paths to download, picked at random
+	FilePaths []string
+}
+
+// Options configure a run
+type Options struct {
+	Workload Workload
+	// Concurrency is the number of workers making requests at the same time. Defaults to 1
+	Concurrency int
+	// Duration is how long to run for. The run also stops after Requests requests if that is
+	// set, and at least one of the two is required
+	Duration time.Duration
+	// Requests is the total number of requests to make
+	Requests int
+}
+
+// Stats are the results of one operation
+type Stats struct {
+	Operation string
+	Count     int
+	Errors    int
+	// Latencies are the percentiles of every request, including failed ones
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+	// FirstError is an example of the errors, to tell what went wrong
+	FirstError error
+
+	latencies []time.Duration
+}
+
+// ErrorRate returns the fraction of requests that failed
+func (s *Stats) ErrorRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count)
+}
+
+// Report is the result of a run
+type Report struct {
+	Elapsed time.Duration
+	// Stats has one entry per operation that ran, in the order read, write, file
+	Stats []*Stats
+}
+
+// Throughput returns the requests per second over the whole run
+func (r *Report) Throughput() float64 {
+	total := 0
+	for _, s := range r.Stats {
+		total += s.Count
+	}
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(total) / r.Elapsed.Seconds()
+}
+
+// String formats the report as a table
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-6s %8s %7s %10s %10s %10s %10s\n", "op", "requests", "errors", "p50", "p90", "p99", "max")
+	for _, s := range r.Stats {
+		fmt.Fprintf(&b, "%-6s %8d %6.2f%% %10v %10v %10v %10v\n", s.Operation, s.Count, 100*s.ErrorRate(),
+			s.P50.Round(time.Microsecond), s.P90.Round(time.Microsecond), s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	fmt.Fprintf(&b, "%.1f requests/s over %v\n", r.Throughput(), r.Elapsed.Round(time.Millisecond))
+	for _, s := range r.Stats {
+		if s.FirstError != nil {
+			fmt.Fprintf(&b, "first %s error: %v\n", s.Operation, s.FirstError)
+		}
+	}
+	return b.String()
+}
+
+// Run runs the workload with the given client until the duration passes, the number of
+// requests is reached, or ctx is done
+func Run(ctx context.Context, client *cerberus.Client, opts Options) (*Report, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Client cannot be nil")
+	}
+	if opts.Duration <= 0 && opts.Requests <= 0 {
+		return nil, fmt.Errorf("A duration or a number of requests is required")
+	}
+	ops := opts.Workload.operations()
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("The workload has no operations to run")
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	r := &run{client: client, workload: opts.Workload, ops: ops, limit: opts.Requests, stats: map[string]*Stats{}}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r.worker(ctx, rand.New(rand.NewSource(seed)))
+		}(start.UnixNano() + int64(i))
+	}
+	wg.Wait()
+	return r.report(time.Since(start)), nil
+}
+
+// operations returns the operations to pick from, each repeated by its weight
+func (w Workload) operations() []string {
+	var ops []string
+	add := func(op string, weight int, ok bool) {
+		for i := 0; ok && i < weight; i++ {
+			ops = append(ops, op)
+		}
+	}
+	add(Read, w.ReadWeight, len(w.ReadPaths) > 0)
+	add(Write, w.WriteWeight, w.WritePath != "")
+	add(File, w.FileWeight, len(w.FilePaths) > 0)
+	return ops
+}
+
+// run is the state shared by the workers of a run
+type run struct {
+	client   *cerberus.Client
+	workload Workload
+	ops      []string
+	limit    int
+
+	mu      sync.Mutex
+	started int
+	stats   map[string]*Stats
+}
+
+func (r *run) worker(ctx context.Context, rnd *rand.Rand) {
+	for ctx.Err() == nil && r.next() {
+		op := r.ops[rnd.Intn(len(r.ops))]
+		start := time.Now()
+		err := r.do(op, rnd)
+		r.record(op, time.Since(start), err)
+	}
+}
+
+// next reserves a request, returning false once the request limit is reached
+func (r *run) next() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limit > 0 && r.started >= r.limit {
+		return false
+	}
+	r.started++
+	return true
+}
+
+func (r *run) do(op string, rnd *rand.Rand) error {
+	switch op {
+	case Read:
+		_, err := r.client.Secret().Read(r.workload.ReadPaths[rnd.Intn(len(r.workload.ReadPaths))])
+		return err
+	case Write:
+		_, err := r.client.Secret().Write(r.workload.WritePath, map[string]interface{}{
+			"value": fmt.Sprintf("%d", rnd.Int63()),
+		})
+		return err
+	default:
+		err := r.client.SecureFile().Get(r.workload.FilePaths[rnd.Intn(len(r.workload.FilePaths))], io.Discard)
+		return err
+	}
+}
+
+func (r *run) record(op string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.stats[op]
+	if !ok {
+		s = &Stats{Operation: op}
+		r.stats[op] = s
+	}
+	s.Count++
+	s.latencies = append(s.latencies, latency)
+	if err != nil {
+		s.Errors++
+		if s.FirstError == nil {
+			s.FirstError = err
+		}
+	}
+}
+
+func (r *run) report(elapsed time.Duration) *Report {
+	report := &Report{Elapsed: elapsed}
+	for _, op := range []string{Read, Write, File} {
+		s, ok := r.stats[op]
+		if !ok {
+			continue
+		}
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		s.P50 = percentile(s.latencies, 50)
+		s.P90 = percentile(s.latencies, 90)
+		s.P99 = percentile(s.latencies, 99)
+		s.Max = s.latencies[len(s.latencies)-1]
+		report.Stats = append(report.Stats, s)
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// staticAuth is an Auth with a fixed token
+type staticAuth struct {
+	baseURL *url.URL
+}
+
+func (a *staticAuth) GetToken(*os.File) (string, error) { return "a-cool-token", nil }
+func (a *staticAuth) IsAuthenticated() bool             { return true }
+func (a *staticAuth) Refresh() error                    { return nil }
+func (a *staticAuth) Logout() error                     { return nil }
+func (a *staticAuth) GetURL() *url.URL                  { return a.baseURL }
+func (a *staticAuth) GetExpiry() (time.Time, error) {
+	return time.Time{}, fmt.Errorf("Expiry time not set")
+}
+func (a *staticAuth) GetHeaders() (http.Header, error) {
+	return http.Header{"X-Cerberus-Token": []string{"a-cool-token"}}, nil
+}
+
+func TestPercentile(t *testing.T) {
+	Convey("Percentiles", t, func() {
+		var sorted []time.Duration
+		for i := 1; i <= 100; i++ {
+			sorted = append(sorted, time.Duration(i)*time.Millisecond)
+		}
+		So(percentile(sorted, 50), ShouldEqual, 50*time.Millisecond)
+		So(percentile(sorted, 99), ShouldEqual, 99*time.Millisecond)
+		So(percentile(sorted[:1], 90), ShouldEqual, time.Millisecond)
+		So(percentile(nil, 50), ShouldEqual, 0)
+	})
+}
+
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/secure-file/"):
+			w.Write([]byte("file"))
+		case r.URL.Path == "/v1/secret/app/sdb/missing-access":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"data": {"key": "value"}}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	client, _ := cerberus.NewClient(&staticAuth{baseURL: u}, nil)
+	client.VaultClient().SetMaxRetries(0)
+
+	Convey("A run", t, func() {
+		requests = map[string]int{}
+		Convey("Should make the requested number of requests", func() {
+			report, err := Run(context.Background(), client, Options{
+				Concurrency: 4,
+				Requests:    40,
+				Workload: Workload{
+					ReadWeight: 1, WriteWeight: 1, FileWeight: 1,
+					ReadPaths: []string{"app/sdb/config"}, WritePath: "app/sdb/bench", FilePaths: []string{"app/sdb/cert.pem"},
+				},
+			})
+			So(err, ShouldBeNil)
+			total := 0
+			for _, s := range report.Stats {
+				total += s.Count
+				So(s.Errors, ShouldEqual, 0)
+				So(s.P50, ShouldBeLessThanOrEqualTo, s.P99)
+				So(s.P99, ShouldBeLessThanOrEqualTo, s.Max)
+			}
+			So(total, ShouldEqual, 40)
+			So(requests["PUT /v1/secret/app/sdb/bench"]+requests["GET /v1/secret/app/sdb/config"]+requests["GET /v1/secure-file/app/sdb/cert.pem"], ShouldEqual, 40)
+			So(report.String(), ShouldContainSubstring, "requests/s")
+		})
+		Convey("Should only run operations that have paths", func() {
+			report, err := Run(context.Background(), client, Options{
+				Requests: 10,
+				Workload: Workload{ReadWeight: 1, WriteWeight: 1, ReadPaths: []string{"app/sdb/config"}},
+			})
+			So(err, ShouldBeNil)
+			So(report.Stats, ShouldHaveLength, 1)
+			So(report.Stats[0].Operation, ShouldEqual, Read)
+		})
+		Convey("Should count errors", func() {
+			report, err := Run(context.Background(), client, Options{
+				Requests: 5,
+				Workload: Workload{ReadWeight: 1, ReadPaths: []string{"app/sdb/missing-access"}},
+			})
+			So(err, ShouldBeNil)
+			So(report.Stats[0].Errors, ShouldEqual, 5)
+			So(report.Stats[0].ErrorRate(), ShouldEqual, 1)
+			So(report.Stats[0].FirstError, ShouldNotBeNil)
+		})
+		Convey("Should stop after the duration", func() {
+			report, err := Run(context.Background(), client, Options{
+				Duration: 50 * time.Millisecond,
+				Workload: Workload{ReadWeight: 1, ReadPaths: []string{"app/sdb/config"}},
+			})
+			So(err, ShouldBeNil)
+			So(report.Elapsed, ShouldBeLessThan, time.Second)
+			So(report.Stats[0].Count, ShouldBeGreaterThan, 0)
+		})
+		Convey("Should validate the options", func() {
+			_, err := Run(context.Background(), nil, Options{Requests: 1})
+			So(err, ShouldNotBeNil)
+			_, err = Run(context.Background(), client, Options{Workload: Workload{ReadWeight: 1, ReadPaths: []string{"a"}}})
+			So(err, ShouldNotBeNil)
+			_, err = Run(context.Background(), client, Options{Requests: 1})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
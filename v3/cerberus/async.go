@@ -0,0 +1,135 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"sync/atomic"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// DefaultWorkers is how many asynchronous operations run at once in the worker pool shared
+// by every client that wasn't given its own with WithWorkers
+const DefaultWorkers = 10
+
+// workerPool runs functions in goroutines, with at most a fixed number running at once.
+// Submitting waits for a free slot before starting the goroutine, so a busy pool holds back
+// its callers instead of piling up goroutines
+type workerPool struct {
+	slots chan struct{}
+}
+
+func newWorkerPool(workers int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &workerPool{slots: make(chan struct{}, workers)}
+}
+
+func (p *workerPool) submit(fn func()) {
+	p.slots <- struct{}{}
+	go func() {
+		defer func() { <-p.slots }()
+		fn()
+	}()
+}
+
+// sharedWorkers is the pool of clients that don't have their own
+var sharedWorkers = newWorkerPool(DefaultWorkers)
+
+// WithWorkers returns a shallow copy of the client whose asynchronous operations run in a
+// pool of their own with the given number of workers, rather than the pool shared by every
+// client. Copies made from it share its pool
+func (c *Client) WithWorkers(workers int) *Client {
+	scoped := c.copy()
+	scoped.workers = newWorkerPool(workers)
+	return scoped
+}
+
+// pool returns the worker pool of the client
+func (c *Client) pool() *workerPool {
+	if c.workers == nil {
+		return sharedWorkers
+	}
+	return c.workers
+}
+
+// ReadResult is the outcome of an asynchronous read. Secret is nil if there is no secret at
+// the path, as with Read
+type ReadResult struct {
+	Path   string
+	Secret *vault.Secret
+	Err    error
+}
+
+// AsyncWriteResult is the outcome of an asynchronous write
+type AsyncWriteResult struct {
+	Path   string
+	Result *api.WriteResult
+	Err    error
+}
+
+// ReadAsync reads the secret at the given path in the worker pool of the client. It waits for
+// a free worker if they are all busy. The channel receives one result and is then closed. It
+// is buffered, so the read finishes even if the result is never received
+func (s *Secret) ReadAsync(path string) <-chan ReadResult {
+	results := make(chan ReadResult, 1)
+	s.c.pool().submit(func() {
+		secret, err := s.Read(path)
+		results <- ReadResult{Path: path, Secret: secret, Err: err}
+		close(results)
+	})
+	return results
+}
+
+// ReadAllAsync reads the secrets at the given paths in the worker pool of the client, and
+// returns once the last read has started. The channel receives a result for each path in the
+// order the reads finish, and is closed after the last one. It is buffered, so the reads finish
+// even if the results are never received
+func (s *Secret) ReadAllAsync(paths ...string) <-chan ReadResult {
+	results := make(chan ReadResult, len(paths))
+	if len(paths) == 0 {
+		close(results)
+		return results
+	}
+	remaining := int32(len(paths))
+	for _, path := range paths {
+		path := path
+		s.c.pool().submit(func() {
+			secret, err := s.Read(path)
+			results <- ReadResult{Path: path, Secret: secret, Err: err}
+			if atomic.AddInt32(&remaining, -1) == 0 {
+				close(results)
+			}
+		})
+	}
+	return results
+}
+
+// WriteAsync writes the secret at the given path in the worker pool of the client. It waits
+// for a free worker if they are all busy. The channel receives one result and is then closed.
+// It is buffered, so the write finishes even if the result is never received
+func (s *Secret) WriteAsync(path string, data map[string]interface{}) <-chan AsyncWriteResult {
+	results := make(chan AsyncWriteResult, 1)
+	s.c.pool().submit(func() {
+		result, err := s.WriteWithResult(path, data)
+		results <- AsyncWriteResult{Path: path, Result: result, Err: err}
+		close(results)
+	})
+	return results
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAsync(t *testing.T) {
+	var running, maxRunning int32
+	var mu sync.Mutex
+	written := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		if n > maxRunning {
+			maxRunning = n
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		p := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+		switch {
+		case r.Method == http.MethodPut:
+			mu.Lock()
+			written[p] = true
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case strings.HasSuffix(p, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		default:
+			fmt.Fprintf(w, `{"data": {"path": %q}}`, p)
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client with asynchronous reads", t, func() {
+		maxRunning = 0
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithWorkers(3)
+
+		Convey("Should read a secret", func() {
+			result := <-cl.Secret().ReadAsync("app/foo/bar")
+			So(result.Err, ShouldBeNil)
+			So(result.Path, ShouldEqual, "app/foo/bar")
+			So(result.Secret.Data["path"], ShouldEqual, "app/foo/bar")
+		})
+
+		Convey("Should read many secrets with at most the pool size at once", func() {
+			var paths []string
+			for i := 0; i < 12; i++ {
+				paths = append(paths, fmt.Sprintf("app/foo/%d", i))
+			}
+			paths = append(paths, "app/foo/missing")
+			var got []string
+			for result := range cl.Secret().ReadAllAsync(paths...) {
+				So(result.Err, ShouldBeNil)
+				if result.Secret == nil {
+					So(result.Path, ShouldEqual, "app/foo/missing")
+					continue
+				}
+				got = append(got, result.Secret.Data["path"].(string))
+			}
+			So(got, ShouldHaveLength, 12)
+			sort.Strings(got)
+			So(got[0], ShouldEqual, "app/foo/0")
+			So(maxRunning, ShouldBeLessThanOrEqualTo, 3)
+			So(maxRunning, ShouldBeGreaterThan, 1)
+		})
+
+		Convey("Should close the channel for no paths", func() {
+			_, ok := <-cl.Secret().ReadAllAsync()
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Should write a secret", func() {
+			result := <-cl.Secret().WriteAsync("app/foo/written", map[string]interface{}{"foo": "bar"})
+			So(result.Err, ShouldBeNil)
+			So(result.Result.Path, ShouldEqual, "app/foo/written")
+			mu.Lock()
+			So(written["app/foo/written"], ShouldBeTrue)
+			mu.Unlock()
+		})
+
+		Convey("Should use the shared pool by default", func() {
+			So(base.pool(), ShouldEqual, sharedWorkers)
+			So(cl.WithNamespace("team").pool(), ShouldEqual, cl.pool())
+		})
+	})
+}
+
+func TestWorkerPool(t *testing.T) {
+	Convey("A full worker pool", t, func() {
+		pool := newWorkerPool(2)
+		release := make(chan struct{})
+		for i := 0; i < 2; i++ {
+			pool.submit(func() { <-release })
+		}
+
+		Convey("Should hold back submissions until a worker is free", func() {
+			submitted := make(chan struct{})
+			go func() {
+				pool.submit(func() {})
+				close(submitted)
+			}()
+			started := false
+			select {
+			case <-submitted:
+				started = true
+			case <-time.After(20 * time.Millisecond):
+			}
+			So(started, ShouldBeFalse)
+			close(release)
+			select {
+			case <-submitted:
+				started = true
+			case <-time.After(time.Second):
+			}
+			So(started, ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// EntryType is the type of a BrowseEntry
+type EntryType string
+
+const (
+	// EntryFolder is a path prefix with secrets, secure files or other folders under it
+	EntryFolder EntryType = "folder"
+	// EntrySecret is a secret
+	EntrySecret EntryType = "secret"
+	// EntryFile is a secure file
+	EntryFile EntryType = "file"
+)
+
+// BrowseEntry is a node in the tree returned by Browse
+type BrowseEntry struct {
+	// Name is the last segment of the path
+	Name string `json:"name"`
+	// Path is the full path of the entry. Folder paths end with "/"
+	Path string    `json:"path"`
+	Type EntryType `json:"type"`
+	// File is the summary of a secure file. It is only set for files
+	File *api.SecureFileSummary `json:"file,omitempty"`
+	// Children are the entries in a folder, folders first, then sorted by name
+	Children []*BrowseEntry `json:"children,omitempty"`
+}
+
+// Browse returns the secrets and secure files under path, such as an SDB path like
+// "app/my-sdb/", as a tree of folders. Secrets are listed folder by folder and secure files are
+// listed page by page, so large SDBs take several requests. A secret and a folder can have the
+// same name, e.g. "db" and "db/". The root of the tree is a folder for path
+func (c *Client) Browse(path string) (*BrowseEntry, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("Path cannot be empty")
+	}
+	root := &BrowseEntry{Name: lastSegment(path), Path: path + "/", Type: EntryFolder}
+	if err := c.browseSecrets(root); err != nil {
+		return nil, err
+	}
+	if err := c.browseFiles(root); err != nil {
+		return nil, err
+	}
+	sortEntries(root)
+	return root, nil
+}
+
+// browseSecrets adds the secrets under a folder, and the folders they are in, to the tree
+func (c *Client) browseSecrets(folder *BrowseEntry) error {
+	list, err := c.Secret().List(strings.TrimSuffix(folder.Path, "/"))
+	if err != nil {
+		return fmt.Errorf("Unable to list secrets under %s: %w", folder.Path, err)
+	}
+	if list == nil {
+		return nil
+	}
+	keys, _ := list.Data["keys"].([]interface{})
+	for _, k := range keys {
+		key, ok := k.(string)
+		if !ok || key == "" {
+			continue
+		}
+		if strings.HasSuffix(key, "/") {
+			child := folder.folder(strings.TrimSuffix(key, "/"))
+			if err := c.browseSecrets(child); err != nil {
+				return err
+			}
+			continue
+		}
+		folder.Children = append(folder.Children, &BrowseEntry{Name: key, Path: folder.Path + key, Type: EntrySecret})
+	}
+	return nil
+}
+
+// browseFiles adds the secure files under the root folder, and the folders they are in, to
+// the tree
+func (c *Client) browseFiles(root *BrowseEntry) error {
+	opts := api.PageOpts{}
+	for {
+		page, err := c.SecureFile().ListPage(strings.TrimSuffix(root.Path, "/"), opts)
+		if err != nil {
+			return fmt.Errorf("Unable to list secure files under %s: %w", root.Path, err)
+		}
+		for i := range page.Summaries {
+			summary := page.Summaries[i]
+			// Summaries have the full path of the file, but fall back to the name
+			relative := summary.Name
+			full := strings.TrimPrefix(summary.Path, "/")
+			if strings.HasPrefix(full, root.Path) && len(full) > len(root.Path) {
+				relative = full[len(root.Path):]
+			}
+			segments := strings.Split(relative, "/")
+			folder := root
+			for _, segment := range segments[:len(segments)-1] {
+				folder = folder.folder(segment)
+			}
+			name := segments[len(segments)-1]
+			folder.Children = append(folder.Children, &BrowseEntry{
+				Name: name,
+				Path: folder.Path + name,
+				Type: EntryFile,
+				File: &summary,
+			})
+		}
+		if !page.HasNext || page.NextOffset <= int(opts.Offset) {
+			return nil
+		}
+		opts.Offset = uint(page.NextOffset)
+	}
+}
+
+// folder returns the child folder with the given name, adding it if needed
+func (e *BrowseEntry) folder(name string) *BrowseEntry {
+	for _, child := range e.Children {
+		if child.Type == EntryFolder && child.Name == name {
+			return child
+		}
+	}
+	child := &BrowseEntry{Name: name, Path: e.Path + name + "/", Type: EntryFolder}
+	e.Children = append(e.Children, child)
+	return child
+}
+
+// sortEntries sorts the tree, folders first, then by name
+func sortEntries(e *BrowseEntry) {
+	sort.SliceStable(e.Children, func(i, j int) bool {
+		a, b := e.Children[i], e.Children[j]
+		if (a.Type == EntryFolder) != (b.Type == EntryFolder) {
+			return a.Type == EntryFolder
+		}
+		return a.Name < b.Name
+	})
+	for _, child := range e.Children {
+		sortEntries(child)
+	}
+}
+
+// lastSegment returns the part of path after the last "/"
+func lastSegment(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
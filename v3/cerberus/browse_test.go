@@ -0,0 +1,90 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBrowse(t *testing.T) {
+	secrets := &fakeSecrets{secrets: map[string]map[string]interface{}{
+		"app/sdb/config":        {"key": "value"},
+		"app/sdb/db/password":   {"password": "hunter2"},
+		"app/sdb/db":            {"host": "db.example.com"},
+		"app/other/not-browsed": {"key": "value"},
+	}}
+	var pages []string
+	mux := http.NewServeMux()
+	mux.Handle("/v1/secret/", secrets)
+	mux.HandleFunc("/v1/secure-files/app/sdb/", func(w http.ResponseWriter, r *http.Request) {
+		pages = append(pages, r.URL.Query().Get("offset"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("offset") == "0" {
+			fmt.Fprint(w, `{"has_next": true, "next_offset": 1, "secure_file_summaries": [
+				{"name": "cert.pem", "path": "app/sdb/certs/cert.pem", "size_in_bytes": 10}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"has_next": false, "secure_file_summaries": [
+			{"name": "README.md", "path": "app/sdb/README.md", "size_in_bytes": 5}]}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	Convey("Browsing an SDB", t, func() {
+		pages = nil
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		root, err := cl.Browse("app/sdb/")
+		So(err, ShouldBeNil)
+		So(pages, ShouldResemble, []string{"0", "1"})
+
+		Convey("Should return secrets and files in one tree", func() {
+			So(root.Path, ShouldEqual, "app/sdb/")
+			So(root.Name, ShouldEqual, "sdb")
+			var names []string
+			for _, child := range root.Children {
+				names = append(names, string(child.Type)+" "+child.Path)
+			}
+			So(names, ShouldResemble, []string{
+				"folder app/sdb/certs/",
+				"folder app/sdb/db/",
+				"file app/sdb/README.md",
+				"secret app/sdb/config",
+				"secret app/sdb/db",
+			})
+			certs := root.Children[0]
+			So(certs.Children, ShouldHaveLength, 1)
+			So(certs.Children[0].Path, ShouldEqual, "app/sdb/certs/cert.pem")
+			So(certs.Children[0].File.Size, ShouldEqual, 10)
+			db := root.Children[1]
+			So(db.Children, ShouldHaveLength, 1)
+			So(db.Children[0].Type, ShouldEqual, EntrySecret)
+			So(db.Children[0].Path, ShouldEqual, "app/sdb/db/password")
+		})
+	})
+
+	Convey("Browsing an empty path", t, func() {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		root, err := cl.Browse("/")
+		So(err, ShouldNotBeNil)
+		So(root, ShouldBeNil)
+	})
+}
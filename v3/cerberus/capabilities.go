@@ -0,0 +1,182 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Feature is a capability of the Cerberus server that older deployments don't have
+type Feature string
+
+const (
+	// FeatureSecretVersions is the version history of secrets
+	FeatureSecretVersions Feature = "secret-versions"
+	// FeatureFileVersions is the version history of secure files
+	FeatureFileVersions Feature = "file-versions"
+)
+
+// knownFeatures are the features the client detects
+var knownFeatures = []Feature{FeatureSecretVersions, FeatureFileVersions}
+
+// versionFeatures are the features that depend on the secret versions endpoint, which lists
+// the versions of both secrets and secure files
+var versionFeatures = []Feature{FeatureSecretVersions, FeatureFileVersions}
+
+// CapabilitiesHeader is the response header a Cerberus server can use to announce the features
+// it supports, as a comma separated list. When it is sent, features that aren't listed are
+// treated as unsupported without probing for them
+const CapabilitiesHeader = "X-Cerberus-Capabilities"
+
+// ErrorNotSupportedByServer is matched by errors.Is for every NotSupportedError
+var ErrorNotSupportedByServer = fmt.Errorf("Not supported by this Cerberus deployment")
+
+// NotSupportedError is returned when a feature of the client needs an endpoint that the
+// Cerberus server doesn't have, instead of the confusing error an older deployment returns for it
+type NotSupportedError struct {
+	Feature Feature
+}
+
+func (e *NotSupportedError) Error() string {
+	return fmt.Sprintf("Feature %q is not supported by this Cerberus deployment", e.Feature)
+}
+
+// Is makes errors.Is(err, ErrorNotSupportedByServer) true for any feature
+func (e *NotSupportedError) Is(target error) bool {
+	return target == ErrorNotSupportedByServer
+}
+
+// capabilities are the features a Cerberus server was found to support. They are shared by
+// copies of a Client, so each feature is only detected once
+type capabilities struct {
+	mu       sync.RWMutex
+	features map[Feature]bool
+}
+
+func newCapabilities() *capabilities {
+	return &capabilities{features: map[Feature]bool{}}
+}
+
+func (c *capabilities) get(feature Feature) (supported, known bool) {
+	if c == nil {
+		return false, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	supported, known = c.features[feature]
+	return supported, known
+}
+
+func (c *capabilities) set(supported bool, features ...Feature) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, feature := range features {
+		c.features[feature] = supported
+	}
+}
+
+// observe records the features announced in the CapabilitiesHeader of a response, if any
+func (c *capabilities) observe(header http.Header) {
+	if c == nil || len(header.Values(CapabilitiesHeader)) == 0 {
+		return
+	}
+	listed := map[Feature]bool{}
+	for _, value := range header.Values(CapabilitiesHeader) {
+		for _, name := range strings.Split(value, ",") {
+			listed[Feature(strings.ToLower(strings.TrimSpace(name)))] = true
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, feature := range knownFeatures {
+		c.features[feature] = listed[feature]
+	}
+}
+
+// Supports reports whether the Cerberus server supports feature. known is false until the
+// feature was detected, either from the CapabilitiesHeader of a response or by using it
+func (c *Client) Supports(feature Feature) (supported, known bool) {
+	return c.capabilities.get(feature)
+}
+
+// endpointMissing reports whether a status code means that the server doesn't have an
+// endpoint at all, as opposed to an error from an endpoint it has
+func endpointMissing(statusCode int) bool {
+	return statusCode == http.StatusNotFound || statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented
+}
+
+// unsupported returns a NotSupportedError if the server is known not to support feature
+func (c *Client) unsupported(feature Feature) error {
+	if supported, known := c.Supports(feature); known && !supported {
+		return &NotSupportedError{Feature: feature}
+	}
+	return nil
+}
+
+// detectVersions records whether the server has the secret versions endpoint from one of its
+// responses, and returns a NotSupportedError for feature if it doesn't. A 404 is also returned
+// for a path that doesn't exist, so only a 405 or 501 marks the endpoint as missing
+func (c *Client) detectVersions(feature Feature, resp *http.Response) error {
+	if resp == nil {
+		return nil
+	}
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		c.capabilities.set(false, versionFeatures...)
+		return &NotSupportedError{Feature: feature}
+	}
+	if resp.StatusCode/100 == 2 {
+		c.capabilities.set(true, versionFeatures...)
+	}
+	return nil
+}
+
+// requireVersions returns a NotSupportedError if the server doesn't support feature. If that
+// isn't known yet, the versions of path are listed to find out
+func (c *Client) requireVersions(feature Feature, path string) error {
+	if supported, known := c.Supports(feature); known {
+		if !supported {
+			return &NotSupportedError{Feature: feature}
+		}
+		return nil
+	}
+	resp, err := c.Do(&Request{
+		Method: http.MethodGet,
+		Path:   escapePath(secretVersionsBasePath, path),
+		Params: url.Values{"limit": []string{"1"}},
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if unsupported := c.detectVersions(feature, resp); unsupported != nil {
+		return unsupported
+	}
+	if err != nil {
+		return fmt.Errorf("Error while checking for %s support: %w", feature, err)
+	}
+	// Without an answer, using the feature could silently return the current version instead
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Error while checking for %s support. Got HTTP status code %d", feature, resp.StatusCode)
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ package cerberus
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 )
@@ -30,14 +31,28 @@ type Category struct {
 
 var categoryBasePath = "/v1/category"
 
-// List returns a list of roles that can be granted
+// ErrorCategoryNotFound is returned when a category with the given ID doesn't exist
+var ErrorCategoryNotFound = fmt.Errorf("Unable to find category")
+
+// categoryRequest is the body sent when creating or updating a category. Only the
+// fields that can be set by the client are included
+type categoryRequest struct {
+	DisplayName string `json:"display_name"`
+	Path        string `json:"path"`
+}
+
+func newCategoryRequest(c *api.Category) *categoryRequest {
+	return &categoryRequest{DisplayName: c.DisplayName, Path: c.Path}
+}
+
+// List returns a list of the categories an SDB can have, in the order of the server
 func (r *Category) List() ([]*api.Category, error) {
 	resp, err := r.c.DoRequest(http.MethodGet, categoryBasePath, map[string]string{}, nil)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("Error while trying to get categories: %v", err)
+		return nil, fmt.Errorf("Error while trying to get categories: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -50,3 +65,85 @@ func (r *Category) List() ([]*api.Category, error) {
 	}
 	return categoryList, nil
 }
+
+// Create creates a new category from the DisplayName and Path of the given one and returns it.
+// Only admin tokens are allowed to do this
+func (r *Category) Create(category *api.Category) (*api.Category, error) {
+	created := &api.Category{}
+	resp, err := r.c.DoRequest(http.MethodPost, categoryBasePath, map[string]string{}, newCategoryRequest(category))
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		// Bad requests and non-admin tokens both come back with an API error
+		if resp != nil {
+			return nil, apiError(resp, "creating category")
+		}
+		return nil, fmt.Errorf("Error while creating category: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, apiError(resp, "creating category")
+	}
+	err = parseResponse(resp.Body, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update updates the category with the given ID and returns it.
+// Only admin tokens are allowed to do this
+func (r *Category) Update(id string, category *api.Category) (*api.Category, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrorCategoryNotFound
+	}
+	updated := &api.Category{}
+	resp, err := r.c.DoRequest(http.MethodPut, escapeID(categoryBasePath, id), map[string]string{}, newCategoryRequest(category))
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, ErrorCategoryNotFound
+			}
+			return nil, apiError(resp, "updating category")
+		}
+		return nil, fmt.Errorf("Error while updating category: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp, "updating category")
+	}
+	err = parseResponse(resp.Body, updated)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete deletes the category with the given ID.
+// Only admin tokens are allowed to do this
+func (r *Category) Delete(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ErrorCategoryNotFound
+	}
+	resp, err := r.c.DoRequest(http.MethodDelete, escapeID(categoryBasePath, id), map[string]string{}, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == http.StatusNotFound {
+				return ErrorCategoryNotFound
+			}
+			return apiError(resp, "deleting category")
+		}
+		return fmt.Errorf("Error while deleting category: %w", err)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return apiError(resp, "deleting category")
+	}
+	return nil
+}
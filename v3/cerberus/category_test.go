@@ -101,3 +101,87 @@ func TestListCategory(t *testing.T) {
 		})
 	})
 }
+
+var createdCategory = `{
+    "id": "a7ff85a0-faaa-11e5-a8a9-7fa3b294cd46",
+    "display_name": "Platform",
+    "path": "platform",
+    "created_ts": "2016-04-05T04:19:51Z",
+    "last_updated_ts": "2016-04-05T04:19:51Z",
+    "created_by": "admin",
+    "last_updated_by": "admin"
+}`
+
+func TestCategoryWrites(t *testing.T) {
+	newCategory := &api.Category{DisplayName: "Platform", Path: "platform"}
+
+	Convey("A call to Create", t, WithServer(http.StatusCreated, false, "/v1/category", http.MethodPost, `{"display_name":"Platform","path":"platform"}`, nil, nil, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should only send the writable fields", func() {
+			_, err := cl.Category().Create(newCategory)
+			So(err, ShouldBeNil)
+		})
+	}))
+
+	Convey("A valid call to Create", t, WithTestServer(http.StatusCreated, "/v1/category", http.MethodPost, createdCategory, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the created category", func() {
+			category, err := cl.Category().Create(newCategory)
+			So(err, ShouldBeNil)
+			So(category.ID, ShouldEqual, "a7ff85a0-faaa-11e5-a8a9-7fa3b294cd46")
+			So(category.Path, ShouldEqual, "platform")
+		})
+	}))
+
+	Convey("A Create with a non-admin token", t, WithTestServer(http.StatusForbidden, "/v1/category", http.MethodPost, errorResponse, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the API error", func() {
+			category, err := cl.Category().Create(newCategory)
+			So(err, ShouldHaveSameTypeAs, api.ErrorResponse{})
+			So(category, ShouldBeNil)
+		})
+	}))
+
+	Convey("A valid call to Update", t, WithTestServer(http.StatusOK, "/v1/category/a7ff85a0", http.MethodPut, createdCategory, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the updated category", func() {
+			category, err := cl.Category().Update("a7ff85a0", newCategory)
+			So(err, ShouldBeNil)
+			So(category.DisplayName, ShouldEqual, "Platform")
+		})
+		Convey("Should error on an empty ID", func() {
+			category, err := cl.Category().Update(" ", newCategory)
+			So(err, ShouldEqual, ErrorCategoryNotFound)
+			So(category, ShouldBeNil)
+		})
+	}))
+
+	Convey("An Update of a missing category", t, WithTestServer(http.StatusNotFound, "/v1/category/a7ff85a0", http.MethodPut, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return ErrorCategoryNotFound", func() {
+			_, err := cl.Category().Update("a7ff85a0", newCategory)
+			So(err, ShouldEqual, ErrorCategoryNotFound)
+		})
+	}))
+
+	Convey("A valid call to Delete", t, WithTestServer(http.StatusNoContent, "/v1/category/a7ff85a0", http.MethodDelete, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should not error", func() {
+			So(cl.Category().Delete("a7ff85a0"), ShouldBeNil)
+		})
+	}))
+
+	Convey("A Delete of a missing category", t, WithTestServer(http.StatusNotFound, "/v1/category/a7ff85a0", http.MethodDelete, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return ErrorCategoryNotFound", func() {
+			So(cl.Category().Delete("a7ff85a0"), ShouldEqual, ErrorCategoryNotFound)
+		})
+	}))
+}
@@ -18,8 +18,11 @@ package cerberus
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
 	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
 	"github.com/cenkalti/backoff"
@@ -30,16 +33,64 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 )
 
 // Client is the main client for interacting with Cerberus
 type Client struct {
-	Authentication auth.Auth
-	CerberusURL    *url.URL
-	vaultClient    *vault.Client
-	httpClient     *http.Client
-	defaultHeaders http.Header
+	Authentication  auth.Auth
+	CerberusURL     *url.URL
+	vaultClient     *vault.Client
+	httpClient      *http.Client
+	defaultHeaders  http.Header
+	namespace       string
+	noRetry         bool
+	retryClassifier RetryClassifier
+	validate        bool
+	timeout         time.Duration
+	timeouts        Timeouts
+	responseLimit   int64
+	events          EventHandler
+	application     string
+	secretCache     *secretCache
+	negativeCache   *negativeCache
+	prechecks       []Precheck
+	workers         *workerPool
+	writeQueue      *WriteQueue
+	snapshot        *Snapshot
+	codecs          map[string]Codec
+	transforms      []pathTransform
+	referenceDepth  int
+	deprecations    *deprecations
+	history         *history
+	capabilities    *capabilities
+	limits          *limits
+	limitPause      int
+	pauseForLimits  bool
+	subclients      *subclients
+}
+
+// subclients holds the subclients of a Client. Each one is created on first use and then
+// reused, so state kept on a subclient lives as long as the Client. Copies of a Client made
+// by the With methods get their own subclients, as those point back at the copy
+type subclients struct {
+	mu         sync.Mutex
+	sdb        *SDB
+	secret     *Secret
+	role       *Role
+	category   *Category
+	metadata   *Metadata
+	secureFile *SecureFile
+	tokens     *Tokens
+	versions   *SecretVersions
+}
+
+// copy returns a shallow copy of the client with its own subclients
+func (c *Client) copy() *Client {
+	scoped := *c
+	scoped.subclients = &subclients{}
+	return &scoped
 }
 
 // NewClient creates a new Client given an Authentication method.
@@ -66,9 +117,17 @@ func NewClient(authMethod auth.Auth, otpFile *os.File) (*Client, error) {
 		CerberusURL:    authMethod.GetURL(),
 		vaultClient:    vclient,
 		httpClient:     utils.DefaultHttpClient(),
+		deprecations:   &deprecations{seen: map[string]bool{}},
+		history:        newHistory(),
+		capabilities:   newCapabilities(),
+		limits:         &limits{},
+		subclients:     &subclients{},
 	}, nil
 }
 
+// NewClientWithHeaders is the same as NewClient, but sends defaultHeaders with every request
+// of the client. The headers are copied, so changing them afterwards doesn't affect the client
+// or any other client created with different headers
 func NewClientWithHeaders(authMethod auth.Auth, otpFile *os.File, defaultHeaders http.Header) (*Client, error) {
 	// Get the token and authenticate
 	token, loginErr := authMethod.GetToken(otpFile)
@@ -90,70 +149,453 @@ func NewClientWithHeaders(authMethod auth.Auth, otpFile *os.File, defaultHeaders
 		CerberusURL:    authMethod.GetURL(),
 		vaultClient:    vclient,
 		httpClient:     utils.NewHttpClient(defaultHeaders),
+		defaultHeaders: defaultHeaders.Clone(),
+		deprecations:   &deprecations{seen: map[string]bool{}},
+		history:        newHistory(),
+		capabilities:   newCapabilities(),
+		limits:         &limits{},
+		subclients:     &subclients{},
 	}, nil
 }
 
+// WithNamespace returns a shallow copy of the client that sends the given namespace
+// with every request, including secret requests. It can be used once to configure a
+// client or for a single call, e.g. client.WithNamespace("team-b").SDB().List().
+// Authentication requests use the namespace configured on the auth method
+func (c *Client) WithNamespace(namespace string) *Client {
+	scoped := c.copy()
+	scoped.namespace = namespace
+	return scoped
+}
+
+// WithNoRetry returns a shallow copy of the client that doesn't retry server errors, so
+// calls fail immediately. This is useful for interactive tools and latency sensitive code.
+// Secret requests use the retry settings of the Vault client instead
+func (c *Client) WithNoRetry() *Client {
+	scoped := c.copy()
+	scoped.noRetry = true
+	return scoped
+}
+
+// WithTimeout returns a shallow copy of the client where every request, including its
+// retries and any token refresh it triggers, must finish within the given duration.
+// The timeout is applied on top of any deadline on the request context
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	scoped := c.copy()
+	scoped.timeout = timeout
+	return scoped
+}
+
+// WithCookieJar returns a shallow copy of the client that uses jar for cookies on API and
+// secret requests, for when Cerberus is behind an authenticating proxy that uses session
+// cookies. Set the same jar on the auth method so authentication requests share the session.
+// The copy gets its own Vault client, built from the settings of the current one
+func (c *Client) WithCookieJar(jar http.CookieJar) (*Client, error) {
+	config := c.vaultClient.CloneConfig()
+	config.HttpClient.Jar = jar
+	vaultClient, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("Error while setting up vault client: %v", err)
+	}
+	vaultClient.SetHeaders(c.vaultClient.Headers())
+	scoped := c.WithVaultClient(vaultClient)
+	httpClient := *c.httpClient
+	httpClient.Jar = jar
+	scoped.httpClient = &httpClient
+	return scoped, nil
+}
+
+// WithTransport returns a shallow copy of the client that sends API and secret requests with
+// rt, such as an HTTP/3 transport wrapped in utils.FallbackTransport. The default headers of the
+// client are still added. The copy gets its own Vault client, built from the settings of the
+// current one
+func (c *Client) WithTransport(rt http.RoundTripper) (*Client, error) {
+	config := c.vaultClient.CloneConfig()
+	config.HttpClient.Transport = rt
+	vaultClient, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("Error while setting up vault client: %v", err)
+	}
+	vaultClient.SetHeaders(c.vaultClient.Headers())
+	scoped := c.WithVaultClient(vaultClient)
+	httpClient := *c.httpClient
+	httpClient.Transport = utils.RoundTripperWithDefaultHeaders(rt, c.defaultHeaders)
+	scoped.httpClient = &httpClient
+	return scoped, nil
+}
+
+// WithTLSConfig returns a shallow copy of the client that sends API and secret requests with
+// config, e.g. a client certificate for a gateway that requires mutual TLS or a custom CA pool,
+// see utils.ClientTLSConfig. It replaces the transport of the client with a copy of
+// http.DefaultTransport. For a custom transport, set its TLS config and use WithTransport
+func (c *Client) WithTLSConfig(config *tls.Config) (*Client, error) {
+	return c.WithTransport(utils.TLSTransport(config))
+}
+
+// WithApplication returns a shallow copy of the client that appends the given application
+// identifier, such as "my-service/1.4.2", to the X-Cerberus-Client header of its requests.
+// Secret requests use the headers of the Vault client instead
+func (c *Client) WithApplication(application string) *Client {
+	scoped := c.copy()
+	scoped.application = application
+	return scoped
+}
+
+// Version returns the version of the client
+func Version() string {
+	return api.ClientInfo().Version
+}
+
+// WithValidation returns a shallow copy of the client that validates the owner, user groups,
+// and IAM principal ARNs of SDBs before creating or updating them. Invalid SDBs are not sent
+// and an api.ErrorValidation listing each invalid entry is returned instead
+func (c *Client) WithValidation() *Client {
+	scoped := c.copy()
+	scoped.validate = true
+	return scoped
+}
+
+// WithResponseLimit returns a copy of the client that errors with an api.ErrorResponseTooLarge
+// when a response body is larger than limit bytes, for services with little memory to spare.
+// The limit can only be lowered from the default of utils.MaxResponseSize, and zero or a
+// negative limit restores the default. Secure file downloads are streamed, so they aren't
+// limited, and secret requests are read by the Vault client
+func (c *Client) WithResponseLimit(limit int64) *Client {
+	scoped := c.copy()
+	scoped.responseLimit = limit
+	return scoped
+}
+
+// VaultClient returns the underlying Vault client used for secret requests. It can be
+// used to change Vault specific settings such as timeouts, rate limits, or the HTTP client.
+// Cerberus keeps the token of this client up to date, so it should not be changed
+func (c *Client) VaultClient() *vault.Client {
+	return c.vaultClient
+}
+
+// WithVaultClient returns a shallow copy of the client that uses the given Vault client
+// for secret requests instead of the one built from vault.DefaultConfig. The Vault client
+// should be configured with the Cerberus URL as its address. Its token is set to the
+// current Cerberus token and is updated whenever the token is refreshed
+func (c *Client) WithVaultClient(vaultClient *vault.Client) *Client {
+	vaultClient.SetToken(c.vaultClient.Token())
+	scoped := c.copy()
+	scoped.vaultClient = vaultClient
+	return scoped
+}
+
+// WithToken returns a shallow copy of the client that authenticates with the given token
+// instead of its own, such as a user token forwarded by an API gateway, so a service can
+// access Cerberus on behalf of its caller. The copy shares the transport and settings of the
+// client and keeps its namespace, but doesn't share its secret cache, so secrets read with one
+// token are never returned to another. A new copy should be made for each token
+func (c *Client) WithToken(token string) (*Client, error) {
+	tokenAuth, err := auth.NewTokenAuth(c.CerberusURL.String(), token)
+	if err != nil {
+		return nil, err
+	}
+	if namespace := c.currentNamespace(); namespace != "" {
+		tokenAuth.WithNamespace(namespace)
+	}
+	scoped := c.copy()
+	scoped.Authentication = tokenAuth
+	scoped.secretCache = nil
+	// WithNamespace is the Vault client's way of making a shallow copy, which shares the
+	// HTTP client but has its own token
+	scoped.vaultClient = c.vaultClient.WithNamespace("")
+	scoped.vaultClient.SetToken(token)
+	return scoped, nil
+}
+
+// currentNamespace returns the namespace set on the client, falling back to the
+// one configured on the auth method
+func (c *Client) currentNamespace() string {
+	if c.namespace != "" {
+		return c.namespace
+	}
+	headers, err := c.Authentication.GetHeaders()
+	if err != nil {
+		return ""
+	}
+	return headers.Get(api.NamespaceHeader)
+}
+
 // SDB returns the SDB client
 func (c *Client) SDB() *SDB {
-	return &SDB{
-		c: c,
+	if c.subclients == nil {
+		return &SDB{c: c}
 	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.sdb == nil {
+		c.subclients.sdb = &SDB{c: c}
+	}
+	return c.subclients.sdb
 }
 
 // Secret returns the Secret client
 func (c *Client) Secret() *Secret {
+	if c.subclients == nil {
+		return c.newSecret()
+	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.secret == nil {
+		c.subclients.secret = c.newSecret()
+	}
+	return c.subclients.secret
+}
+
+// newSecret creates a Secret client that sends the namespace of the client, if any
+func (c *Client) newSecret() *Secret {
+	vaultClient := c.vaultClient
+	namespace := c.currentNamespace()
+	if namespace != "" {
+		// WithNamespace is the Vault client's way of making a shallow copy with its own
+		// headers. The Vault namespace itself is cleared as Cerberus doesn't use it
+		vaultClient = vaultClient.WithNamespace("")
+		headers := vaultClient.Headers()
+		if headers == nil {
+			headers = http.Header{}
+		}
+		headers.Set(api.NamespaceHeader, namespace)
+		vaultClient.SetHeaders(headers)
+	}
+	if c.pauseForLimits {
+		// Also a shallow copy, so the callback isn't added to a Vault client set by the caller
+		vaultClient = vaultClient.WithResponseCallbacks(c.limits.observeResponse)
+	}
 	return &Secret{
-		v: c.vaultClient.Logical(),
+		c:              c,
+		v:              vaultClient.Logical(),
+		raw:            vaultClient,
+		namespace:      namespace,
+		cache:          c.secretCache,
+		negatives:      c.negativeCache,
+		queue:          c.writeQueue,
+		snapshot:       c.snapshot,
+		codecs:         c.codecs,
+		transforms:     c.transforms,
+		referenceDepth: c.referenceDepth,
 	}
 }
 
 // Role returns the Role client
 func (c *Client) Role() *Role {
-	return &Role{
-		c: c,
+	if c.subclients == nil {
+		return &Role{c: c}
 	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.role == nil {
+		c.subclients.role = &Role{c: c}
+	}
+	return c.subclients.role
 }
 
 // Category returns the Category client
 func (c *Client) Category() *Category {
-	return &Category{
-		c: c,
+	if c.subclients == nil {
+		return &Category{c: c}
+	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.category == nil {
+		c.subclients.category = &Category{c: c}
 	}
+	return c.subclients.category
 }
 
 // Metadata returns the Metadata client
 func (c *Client) Metadata() *Metadata {
-	return &Metadata{
-		c: c,
+	if c.subclients == nil {
+		return &Metadata{c: c}
 	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.metadata == nil {
+		c.subclients.metadata = &Metadata{c: c}
+	}
+	return c.subclients.metadata
 }
 
 // SecureFile returns the SecureFile client
 func (c *Client) SecureFile() *SecureFile {
-	return &SecureFile{
-		c: c,
+	if c.subclients == nil {
+		return &SecureFile{c: c}
+	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.secureFile == nil {
+		c.subclients.secureFile = &SecureFile{c: c}
+	}
+	return c.subclients.secureFile
+}
+
+// SecretVersions returns the SecretVersions client
+func (c *Client) SecretVersions() *SecretVersions {
+	if c.subclients == nil {
+		return &SecretVersions{c: c}
+	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.versions == nil {
+		c.subclients.versions = &SecretVersions{c: c}
+	}
+	return c.subclients.versions
+}
+
+// Tokens returns the Tokens client
+func (c *Client) Tokens() *Tokens {
+	if c.subclients == nil {
+		return &Tokens{c: c}
 	}
+	c.subclients.mu.Lock()
+	defer c.subclients.mu.Unlock()
+	if c.subclients.tokens == nil {
+		c.subclients.tokens = &Tokens{c: c}
+	}
+	return c.subclients.tokens
 }
 
 // ErrorBodyNotReturned is an error indicating that the server did not return error details (in case of a non-successful status).
-// This likely means that there is some sort of server error that is occurring
-var ErrorBodyNotReturned = fmt.Errorf("No error body returned from server")
+// This likely means that there is some sort of server error that is occurring. It is the same
+// error returned by utils.ParseAPIError so the two can be compared
+var ErrorBodyNotReturned = utils.ErrorBodyNotReturned
+
+// Request describes a single call to the Cerberus API. It is used with Do for calls
+// that need more than DoRequest offers, such as repeated query parameters or a raw body
+type Request struct {
+	// Method is any HTTP method, including PATCH and HEAD
+	Method string
+	// Path is the API path, for example "/v2/safe-deposit-box". Percent-encoded
+	// segments are sent as is, so an escaped '/' stays part of its segment
+	Path string
+	// Params are added to the query string. A key may have multiple values
+	Params url.Values
+	// ContentType is set as the Content-Type header if it is not empty
+	ContentType string
+	// Body is sent as is. It can be nil for requests without a body
+	Body io.Reader
+	// Namespace overrides the namespace of the client for this request only
+	Namespace string
+	// NoRetry disables the automatic retries of server errors for this request only
+	NoRetry bool
+	// Context is used for the request and for any token refresh it triggers. If it is
+	// nil, context.Background() is used
+	Context context.Context
+	// Timeout bounds the whole request, including retries and token refreshes. It
+	// overrides the timeout of the client
+	Timeout time.Duration
+	// MaxResponseSize overrides the response limit of the client for this request only. A
+	// negative value disables it, for responses that are streamed rather than read into memory
+	MaxResponseSize int64
+	// Header is added to the request after the auth headers. It overrides the default headers
+	// of the client for this request only. A header with no values, as set by
+	// utils.RemoveHeader, removes the default header from the request
+	Header http.Header
+	// kind picks the default timeout from the Timeouts of the client
+	kind operationKind
+}
 
-// DoRequestWithBody executes a request with provided body
+// DoRequestWithBody executes a request with provided body, which is sent as is with the given
+// content type
 func (c *Client) DoRequestWithBody(method, path string, params map[string]string, contentType string, body io.Reader) (*http.Response, error) {
+	return c.Do(newRequestWithBody(method, path, params, contentType, body))
+}
+
+func newRequestWithBody(method, path string, params map[string]string, contentType string, body io.Reader) *Request {
+	var values = url.Values{}
+	for k, v := range params {
+		values.Add(k, v)
+	}
+	return &Request{
+		Method:      method,
+		Path:        path,
+		Params:      values,
+		ContentType: contentType,
+		Body:        body,
+	}
+}
+
+// Do executes the given Request. All other request methods on the client end up here,
+// so it handles authentication headers, retries, and token refreshes
+func (c *Client) Do(r *Request) (resp *http.Response, err error) {
+	start := time.Now()
+	defer func() { c.emitResponse(r, start, resp, err) }()
+	defer func() { c.checkDeprecation(r.Method, r.Path, resp) }()
+	defer func() { c.limitBody(r, resp) }()
+	if err := c.precheck(r.Method, r.Path, r.Namespace); err != nil {
+		return nil, err
+	}
 	// Get a copy of the base URL and add the path
 	var baseURL = *c.CerberusURL
-	baseURL.Path = path
+	setPath(&baseURL, r.Path)
 	p := baseURL.Query()
 	// Add the params in to the request
-	for k, v := range params {
-		p.Add(k, v)
+	for k, values := range r.Params {
+		for _, v := range values {
+			p.Add(k, v)
+		}
 	}
 	baseURL.RawQuery = p.Encode()
-	var req *http.Request
-	var err error
 
-	req, err = http.NewRequest(method, baseURL.String(), body)
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// The timeout covers the whole operation, including retries and token refreshes
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = c.timeoutFor(r.kind)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		resp, err := c.do(ctx, r, baseURL)
+		if resp != nil {
+			// The body is read after Do returns, so the context is cancelled when it is closed
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		} else {
+			cancel()
+		}
+		return resp, err
+	}
+	return c.do(ctx, r, baseURL)
+}
+
+// limitBody limits how much of the response body can be read to the limit of the request or
+// the client
+func (c *Client) limitBody(r *Request, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	if r.MaxResponseSize < 0 {
+		return
+	}
+	limit := c.responseLimit
+	if limit <= 0 || limit > utils.MaxResponseSize {
+		limit = utils.MaxResponseSize
+	}
+	if r.MaxResponseSize > 0 {
+		limit = r.MaxResponseSize
+	}
+	resp.Body = utils.LimitBody(resp.Body, limit)
+}
+
+// cancelOnClose cancels a context when the response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (c *Client) do(ctx context.Context, r *Request, baseURL url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, baseURL.String(), r.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -161,24 +603,37 @@ func (c *Client) DoRequestWithBody(method, path string, params map[string]string
 	if headerErr != nil {
 		return nil, headerErr
 	}
-	req.Header = headers
+	// Copy the headers so per request values don't leak back into the auth method
+	req.Header = headers.Clone()
+
+	namespace := r.Namespace
+	if namespace == "" {
+		namespace = c.namespace
+	}
+	if namespace != "" {
+		req.Header.Set(api.NamespaceHeader, namespace)
+	}
+
+	if c.application != "" {
+		req.Header.Set("X-Cerberus-Client", api.ClientInfo().Header(c.application))
+	}
 
 	// Add content type if present
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+	if r.ContentType != "" {
+		req.Header.Set("Content-Type", r.ContentType)
+	}
+	for k, v := range r.Header {
+		req.Header[http.CanonicalHeaderKey(k)] = append([]string{}, v...)
+	}
+	if err := c.pace(ctx); err != nil {
+		return nil, err
+	}
+	resp, respErr := c.send(req, !r.NoRetry && !c.noRetry)
+	if resp != nil {
+		c.capabilities.observe(resp.Header)
+		c.limits.observe(resp.Header)
+		log.Debug(fmt.Sprintf("Cerberus returned %d for %s %s over %s", resp.StatusCode, r.Method, r.Path, resp.Proto))
 	}
-	var resp *http.Response
-	retryClient := httpbackoff.Client{
-		BackOffSettings: &backoff.ExponentialBackOff{
-			InitialInterval:     100 * time.Millisecond,
-			RandomizationFactor: 0,
-			Multiplier:          2,
-			MaxInterval:         600 * time.Millisecond,
-			MaxElapsedTime:      600 * time.Millisecond,
-			Clock:               backoff.SystemClock,
-		},
-	}
-	resp, _, respErr := retryClient.ClientDo(c.httpClient, req)
 	if respErr != nil {
 		if resp != nil {
 			log.Info(fmt.Sprintf("Cerberus returned an error, when executing a call. \nstatus code: %v \nmsg: %v)", resp.StatusCode, respErr))
@@ -186,14 +641,28 @@ func (c *Client) DoRequestWithBody(method, path string, params map[string]string
 			log.Info(fmt.Sprintf("An error was thrown when executing a call to Cerberus.\nmsg: %v)", respErr))
 		}
 
+		// HTML error pages from a load balancer or WAF get a typed error instead of a JSON
+		// decode error from whoever parses the body. 503 pages are left to the maintenance
+		// mode handling
+		if utils.ServiceUnavailable(resp) == nil {
+			if unexpected := utils.UnexpectedResponse(resp); unexpected != nil {
+				return resp, unexpected
+			}
+		}
 		// We may get an actual response for redirect error
 		return resp, respErr
 	}
+	// Maintenance mode responses are usually HTML, so return a typed error instead of
+	// letting the caller try to parse the body. The retry client returns 5xx responses
+	// without an error once it runs out of retries, so this is checked here
+	if unavailable := utils.ServiceUnavailable(resp); unavailable != nil {
+		return resp, unavailable
+	}
 	// Cerberus uses a refresh token header. If that header is sent with a value of "true,"
 	// refresh the token before returning
 	if resp.Header.Get("X-Refresh-Token") == "true" {
-		if err := c.Authentication.Refresh(); err != nil {
-			return resp, fmt.Errorf("Error refreshing token: %v", err)
+		if err := c.refresh(ctx); err != nil {
+			return resp, fmt.Errorf("Error refreshing token: %w", err)
 		}
 		tok, err := c.Authentication.GetToken(nil)
 		if err != nil {
@@ -205,12 +674,118 @@ func (c *Client) DoRequestWithBody(method, path string, params map[string]string
 	return resp, nil
 }
 
+// refresh refreshes the token with the given context if the auth method supports it
+func (c *Client) refresh(ctx context.Context) error {
+	if refresher, ok := c.Authentication.(auth.ContextRefresher); ok {
+		return refresher.RefreshContext(ctx)
+	}
+	return c.Authentication.Refresh()
+}
+
+// send executes the request, retrying server errors with backoff if retry is true. Without
+// retries, non-2xx responses are returned with the same error the retry client would return
+func (c *Client) send(req *http.Request, retry bool) (*http.Response, error) {
+	if !retry {
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, utils.ConnectionError(req.URL.Host, err)
+		}
+		if resp.StatusCode/100 != 2 {
+			return resp, badResponseCode(resp.StatusCode)
+		}
+		return resp, nil
+	}
+	ctx := req.Context()
+	retryClient := httpbackoff.Client{
+		BackOffSettings: retryBudget(ctx),
+	}
+	// ClientDo rebuilds the request for every attempt without its context, so the attempts
+	// are made here instead to keep the deadline and cancellation of the caller
+	getBody := req.GetBody
+	if req.Body != nil && getBody == nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		getBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+	var previous *http.Response
+	resp, _, err := retryClient.Retry(func() (*http.Response, error, error) {
+		// The response of a retried attempt is discarded
+		if previous != nil {
+			previous.Body.Close()
+		}
+		attempt := req.Clone(ctx)
+		if getBody != nil {
+			body, err := getBody()
+			if err != nil {
+				return nil, nil, err
+			}
+			attempt.Body = body
+		}
+		resp, err := c.httpClient.Do(attempt)
+		previous = resp
+		if ctx.Err() != nil {
+			// There is no time left, so don't retry
+			return resp, nil, err
+		}
+		tempErr, permErr := c.classify(resp, err)
+		return resp, tempErr, permErr
+	})
+	if resp == nil {
+		return nil, utils.ConnectionError(req.URL.Host, err)
+	}
+	return resp, err
+}
+
+// retryBudget returns the backoff settings for retrying a request. Retries stop once the
+// default budget is used up or the deadline of the context is reached, whichever is first
+func retryBudget(ctx context.Context) *backoff.ExponentialBackOff {
+	b := &backoff.ExponentialBackOff{
+		InitialInterval:     100 * time.Millisecond,
+		RandomizationFactor: 0,
+		Multiplier:          2,
+		MaxInterval:         600 * time.Millisecond,
+		MaxElapsedTime:      600 * time.Millisecond,
+		Clock:               backoff.SystemClock,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining < b.MaxElapsedTime {
+			b.MaxElapsedTime = remaining
+		}
+		// Don't sleep past the deadline between attempts
+		if remaining < b.MaxInterval {
+			b.MaxInterval = remaining
+		}
+		if b.MaxInterval <= 0 {
+			b.MaxInterval = time.Nanosecond
+		}
+		if b.InitialInterval > b.MaxInterval {
+			b.InitialInterval = b.MaxInterval
+		}
+	}
+	return b
+}
+
 // DoRequest is used to perform an HTTP request with the given method and path
-// This method is what is called by other parts of the client and is exposed for advanced usage
+// This method is what is called by other parts of the client and is exposed for advanced usage.
+// Data is encoded as JSON. Use DoRequestWithBody or Do to send a body as is
 func (c *Client) DoRequest(method, path string, params map[string]string, data interface{}) (*http.Response, error) {
+	r, err := newRequest(method, path, params, data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(r)
+}
+
+// newRequest builds the Request for DoRequest
+func newRequest(method, path string, params map[string]string, data interface{}) (*Request, error) {
 	var body io.ReadWriter
 	var contentType string
-
 	if data != nil {
 		body = &bytes.Buffer{}
 		contentType = "application/json"
@@ -220,12 +795,52 @@ func (c *Client) DoRequest(method, path string, params map[string]string, data i
 		}
 	}
 
-	return c.DoRequestWithBody(method, path, params, contentType, body)
+	return newRequestWithBody(method, path, params, contentType, body), nil
+}
+
+// bufferPool holds the buffers used to read response bodies so they can be reused across calls
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// maxPooledBuffer is the largest buffer put back in bufferPool. Larger ones, grown by an
+// unusually large response, are left to the garbage collector so the pool doesn't pin them
+const maxPooledBuffer = 64 << 10
+
+// putBuffer returns buf to bufferPool, unless it has grown larger than maxPooledBuffer
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
 }
 
 // parseResponse marshals the given body into the given interface. It should be used just like
-// json.Marshal in that you pass a pointer to the function.
+// json.Marshal in that you pass a pointer to the function. The body is read once into a pooled
+// buffer, and at most utils.MaxResponseSize bytes are read
 func parseResponse(r io.Reader, parseTo interface{}) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := utils.ReadLimited(buf, r); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return io.EOF
+	}
 	// Decode the body into the provided interface
-	return json.NewDecoder(r).Decode(parseTo)
+	return json.Unmarshal(buf.Bytes(), parseTo)
+}
+
+// apiError returns the API error in the body of an unsuccessful response. It is the one place
+// the subclients read error bodies. If the server didn't send error details and action is set,
+// the error says what failed and the status code instead of only ErrorBodyNotReturned
+func apiError(resp *http.Response, action string) error {
+	apiErr := utils.ParseAPIError(resp.Body)
+	if apiErr == ErrorBodyNotReturned && action != "" {
+		return fmt.Errorf("Error while %s. Got HTTP status code %d. %v", action, resp.StatusCode, apiErr)
+	}
+	return apiErr
 }
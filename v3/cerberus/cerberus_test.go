@@ -18,16 +18,25 @@ package cerberus
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
+	vault "github.com/hashicorp/vault/api"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -172,6 +181,35 @@ func TestSubclients(t *testing.T) {
 		Convey("Should return a valid Metadata client", func() {
 			So(c.Metadata(), ShouldNotBeNil)
 		})
+		Convey("Should return the same subclients on every call", func() {
+			So(c.SDB(), ShouldEqual, c.SDB())
+			So(c.Secret(), ShouldEqual, c.Secret())
+			So(c.Role(), ShouldEqual, c.Role())
+			So(c.Category(), ShouldEqual, c.Category())
+			So(c.Metadata(), ShouldEqual, c.Metadata())
+			So(c.SecureFile(), ShouldEqual, c.SecureFile())
+		})
+		Convey("Should return subclients for a copy that use the copy", func() {
+			scoped := c.WithNamespace("team-b")
+			So(scoped.SDB(), ShouldNotEqual, c.SDB())
+			So(scoped.SDB().c, ShouldEqual, scoped)
+			So(scoped.Role().c, ShouldEqual, scoped)
+		})
+		Convey("Should create each subclient once when used concurrently", func() {
+			var wg sync.WaitGroup
+			sdbs := make([]*SDB, 10)
+			for i := range sdbs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					sdbs[i] = c.SDB()
+				}(i)
+			}
+			wg.Wait()
+			for _, sdb := range sdbs {
+				So(sdb, ShouldEqual, sdbs[0])
+			}
+		})
 	})
 }
 
@@ -205,6 +243,73 @@ func TestParseResponse(t *testing.T) {
 	})
 }
 
+func TestParseResponseLimits(t *testing.T) {
+	Convey("An empty body", t, func() {
+		obj := &api.MFADevice{}
+		err := parseResponse(bytes.NewBuffer(nil), obj)
+		Convey("Should error", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+	Convey("A body larger than the limit", t, func() {
+		big := bytes.Repeat([]byte(" "), utils.MaxResponseSize+1)
+		obj := &api.MFADevice{}
+		err := parseResponse(bytes.NewReader(big), obj)
+		Convey("Should error", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "larger than")
+		})
+	})
+}
+
+func TestBufferPool(t *testing.T) {
+	Convey("A buffer grown past the pooled size", t, func() {
+		buf := bytes.NewBuffer(make([]byte, 0, maxPooledBuffer+1))
+		putBuffer(buf)
+		Convey("Should not be put back in the pool", func() {
+			for i := 0; i < 10; i++ {
+				So(bufferPool.Get().(*bytes.Buffer), ShouldNotEqual, buf)
+			}
+		})
+	})
+}
+
+func TestAPIError(t *testing.T) {
+	Convey("An error response", t, func() {
+		resp := &http.Response{StatusCode: http.StatusBadRequest}
+		Convey("Should return the API error in the body", func() {
+			resp.Body = ioutil.NopCloser(strings.NewReader(`{"error_id": "id", "errors": [{"code": 99, "message": "bad"}]}`))
+			err := apiError(resp, "creating role")
+			So(err, ShouldHaveSameTypeAs, api.ErrorResponse{})
+			So(err.(api.ErrorResponse).ErrorID, ShouldEqual, "id")
+		})
+		Convey("Should say what failed when the body has no error", func() {
+			resp.Body = ioutil.NopCloser(strings.NewReader(""))
+			err := apiError(resp, "creating role")
+			So(err.Error(), ShouldStartWith, "Error while creating role. Got HTTP status code 400.")
+		})
+		Convey("Should return ErrorBodyNotReturned without an action", func() {
+			resp.Body = ioutil.NopCloser(strings.NewReader(""))
+			So(apiError(resp, ""), ShouldEqual, ErrorBodyNotReturned)
+		})
+	})
+}
+
+var benchmarkSDBList = []byte(`[
+	{"id": "fb013540-fb5f-11e5-ba72-e899458df21a", "name": "Web", "path": "app/web", "category_id": "f7ff85a0-faaa-11e5-a8a9-7fa3b294cd46"},
+	{"id": "06f82494-fb60-11e5-ba72-e899458df21a", "name": "OneLogin", "path": "shared/onelogin", "category_id": "f7ffb890-faaa-11e5-a8a9-7fa3b294cd46"}
+]`)
+
+func BenchmarkParseResponse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var sdbs []*api.SafeDepositBox
+		if err := parseResponse(bytes.NewReader(benchmarkSDBList), &sdbs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func WithServer(returnCode int, shouldRefresh bool, expectedPath, expectedMethod, bodyContains string, expectedParams map[string]string, expectedHeaders http.Header, f func(ts *httptest.Server)) func() {
 	return func() {
 		Convey("http requests should be correct", func(c C) {
@@ -243,7 +348,7 @@ func TestDoRequest(t *testing.T) {
 		"rightOut":                  "5",
 	}
 	expectedHeader := http.Header{}
-	expectedHeader.Set("X-Cerberus-Client", api.ClientHeader)
+	expectedHeader.Set("X-Cerberus-Client", api.ClientInfo().Header(""))
 	Convey("Valid GET request", t, WithServer(http.StatusOK, false, "/v1/blah", http.MethodGet, "", map[string]string{}, expectedHeader, func(ts *httptest.Server) {
 		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
 		So(cl, ShouldNotBeNil)
@@ -320,6 +425,430 @@ func TestDoRequest(t *testing.T) {
 	})
 }
 
+func TestDo(t *testing.T) {
+	Convey("A PATCH request with repeated params", t, func(c C) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.So(r.Method, ShouldEqual, http.MethodPatch)
+			c.So(r.URL.Path, ShouldEqual, "/v1/blah")
+			c.So(r.URL.Query()["tag"], ShouldResemble, []string{"a", "b"})
+			c.So(r.Header.Get("Content-Type"), ShouldEqual, "text/plain")
+			body, _ := ioutil.ReadAll(r.Body)
+			c.So(string(body), ShouldEqual, "raw body")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return a valid response", func() {
+			resp, err := cl.Do(&Request{
+				Method:      http.MethodPatch,
+				Path:        "/v1/blah",
+				Params:      url.Values{"tag": []string{"a", "b"}},
+				ContentType: "text/plain",
+				Body:        bytes.NewReader([]byte("raw body")),
+			})
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
+	})
+
+	Convey("A HEAD request", t, WithServer(http.StatusOK, false, "/v1/blah", http.MethodHead, "", map[string]string{}, http.Header{}, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return a valid response", func() {
+			resp, err := cl.Do(&Request{Method: http.MethodHead, Path: "/v1/blah"})
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
+	}))
+
+	Convey("A DoRequest with bytes", t, WithServer(http.StatusOK, false, "/v1/blah", http.MethodPut, `"bm90IGpzb24="`, map[string]string{}, http.Header{"Content-Type": []string{"application/json"}}, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should encode the body as JSON", func() {
+			resp, err := cl.DoRequest(http.MethodPut, "/v1/blah", map[string]string{}, []byte("not json"))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
+	}))
+
+	Convey("A DoRequestWithBody with raw bytes", t, WithServer(http.StatusOK, false, "/v1/blah", http.MethodPut, "not json", map[string]string{}, http.Header{"Content-Type": []string{"application/octet-stream"}}, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should send the body as is", func() {
+			resp, err := cl.DoRequestWithBody(http.MethodPut, "/v1/blah", map[string]string{}, "application/octet-stream", bytes.NewReader([]byte("not json")))
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
+	}))
+}
+
+func TestNoRetry(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	Convey("A client without retries", t, func() {
+		calls = 0
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should fail after a single call", func() {
+			resp, err := cl.WithNoRetry().DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			So(calls, ShouldEqual, 1)
+		})
+		Convey("Should fail after a single call when set per request", func() {
+			resp, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", NoRetry: true})
+			So(err, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			So(calls, ShouldEqual, 1)
+		})
+		Convey("Should not change the original client", func() {
+			cl.WithNoRetry()
+			cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(calls, ShouldBeGreaterThan, 1)
+		})
+	})
+}
+
+// contextMockAuth is a MockAuth that records the context passed to RefreshContext
+type contextMockAuth struct {
+	*MockAuth
+	refreshCtx context.Context
+}
+
+func (m *contextMockAuth) RefreshContext(ctx context.Context) error {
+	m.refreshCtx = ctx
+	return m.Refresh()
+}
+
+type ctxKey string
+
+func TestRefreshContext(t *testing.T) {
+	Convey("A request that triggers a refresh", t, WithServer(http.StatusOK, true, "/v1/blah", http.MethodGet, "", nil, nil, func(ts *httptest.Server) {
+		m := &contextMockAuth{MockAuth: GenerateMockAuth(ts.URL, "a-cool-token", false, false)}
+		cl, _ := NewClient(m, nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should refresh with the request context", func() {
+			ctx := context.WithValue(context.Background(), ctxKey("trace"), "a-trace-id")
+			_, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", Context: ctx})
+			So(err, ShouldBeNil)
+			So(m.refreshCtx, ShouldNotBeNil)
+			So(m.refreshCtx.Value(ctxKey("trace")), ShouldEqual, "a-trace-id")
+			So(m.token, ShouldEqual, refreshedToken)
+		})
+	}))
+
+	Convey("A request with a cancelled context", t, WithServer(http.StatusOK, false, "/v1/blah", http.MethodGet, "", nil, nil, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should error", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", Context: ctx, NoRetry: true})
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		})
+	}))
+}
+
+func TestTimeout(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/v1/slow":
+			time.Sleep(300 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		case "/v1/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("X-Refresh-Token", "true")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data": {"key": "value"}}`))
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client with a timeout", t, func() {
+		atomic.StoreInt32(&calls, 0)
+		m := &contextMockAuth{MockAuth: GenerateMockAuth(ts.URL, "a-cool-token", false, false)}
+		base, _ := NewClient(m, nil)
+		cl := base.WithTimeout(100 * time.Millisecond)
+		So(cl, ShouldNotBeNil)
+		Convey("Should stop waiting on a slow server at the deadline", func() {
+			start := time.Now()
+			_, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/slow"})
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(time.Since(start), ShouldBeLessThan, 250*time.Millisecond)
+		})
+		Convey("Should stop retrying at the deadline", func() {
+			start := time.Now()
+			cl.Do(&Request{Method: http.MethodGet, Path: "/v1/broken"})
+			So(time.Since(start), ShouldBeLessThan, 250*time.Millisecond)
+			So(atomic.LoadInt32(&calls), ShouldBeLessThan, 3)
+		})
+		Convey("Should use a per request timeout over the client one", func() {
+			start := time.Now()
+			_, err := base.Do(&Request{Method: http.MethodGet, Path: "/v1/slow", Timeout: 50 * time.Millisecond})
+			So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			So(time.Since(start), ShouldBeLessThan, 250*time.Millisecond)
+		})
+		Convey("Should refresh with the same deadline", func() {
+			resp, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/blah"})
+			So(err, ShouldBeNil)
+			_, hasDeadline := m.refreshCtx.Deadline()
+			So(hasDeadline, ShouldBeTrue)
+			Convey("And leave the body readable", func() {
+				defer resp.Body.Close()
+				var body map[string]interface{}
+				So(parseResponse(resp.Body, &body), ShouldBeNil)
+				So(body["data"], ShouldNotBeNil)
+			})
+		})
+		Convey("Should not change the original client", func() {
+			_, err := base.Do(&Request{Method: http.MethodGet, Path: "/v1/slow"})
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func TestConnectionError(t *testing.T) {
+	Convey("A client for an unreachable server", t, func() {
+		cl, _ := NewClient(GenerateMockAuth("http://127.0.0.1:32876", "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return a connection error", func() {
+			_, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", NoRetry: true})
+			var connErr api.ErrorConnection
+			So(errors.As(err, &connErr), ShouldBeTrue)
+			So(connErr.Host, ShouldEqual, "127.0.0.1:32876")
+		})
+		Convey("Should return a connection error after retrying", func() {
+			_, err := cl.SDB().List()
+			var connErr api.ErrorConnection
+			So(errors.As(err, &connErr), ShouldBeTrue)
+		})
+	})
+}
+
+func TestApplication(t *testing.T) {
+	expectedHeader := http.Header{}
+	expectedHeader.Set("X-Cerberus-Client", api.ClientInfo().Header("my-service/1.4.2"))
+	Convey("A client with an application identifier", t, WithServer(http.StatusOK, false, "/v1/blah", http.MethodGet, "", nil, expectedHeader, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should append it to the client header", func() {
+			_, err := cl.WithApplication("my-service/1.4.2").DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+		})
+	}))
+	Convey("The client version", t, func() {
+		So(Version(), ShouldEqual, api.ClientVersion)
+	})
+}
+
+func TestCookieJar(t *testing.T) {
+	var withCookie int
+	// The proxy hands out a session cookie and counts requests that send it back
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("proxy-session"); err == nil && cookie.Value == "abc" {
+			withCookie++
+		}
+		http.SetCookie(w, &http.Cookie{Name: "proxy-session", Value: "abc", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"foo": "bar"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A client with a cookie jar", t, func() {
+		withCookie = 0
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		jar, _ := cookiejar.New(nil)
+		cl, err := base.WithCookieJar(jar)
+		So(err, ShouldBeNil)
+		Convey("Should send the proxy session on API and secret requests", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(withCookie, ShouldEqual, 0)
+			_, err = cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			_, err = cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(withCookie, ShouldEqual, 2)
+		})
+		Convey("Should not change the original client", func() {
+			cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			base.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			base.Secret().Read("app/foo/bar")
+			So(withCookie, ShouldEqual, 0)
+		})
+	})
+}
+
+// countingTransport counts the requests it sends with http.DefaultTransport
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	var clientHeaders []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientHeaders = append(clientHeaders, r.Header.Get("X-Team"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"foo": "bar"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A client with a custom transport", t, func() {
+		clientHeaders = nil
+		base, _ := NewClientWithHeaders(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil, http.Header{"X-Team": []string{"payments"}})
+		rt := &countingTransport{}
+		cl, err := base.WithTransport(rt)
+		So(err, ShouldBeNil)
+		Convey("Should send API and secret requests with it", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			_, err = cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(rt.calls, ShouldEqual, 2)
+			So(clientHeaders[0], ShouldEqual, "payments")
+		})
+		Convey("Should not change the original client", func() {
+			base.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			base.Secret().Read("app/foo/bar")
+			So(rt.calls, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"foo": "bar"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A client for a server with a private CA", t, func() {
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should fail without a TLS config", func() {
+			_, err := base.WithNoRetry().DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("Should send API and secret requests with the TLS config", func() {
+			cl, err := base.WithTLSConfig(ts.Client().Transport.(*http.Transport).TLSClientConfig)
+			So(err, ShouldBeNil)
+			_, err = cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			secret, err := cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+		})
+	})
+}
+
+func TestNamespace(t *testing.T) {
+	var namespaces []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespaces = append(namespaces, r.Header.Get(api.NamespaceHeader))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": {"foo": "bar"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A client with a namespace", t, func() {
+		namespaces = nil
+		m := GenerateMockAuth(ts.URL, "a-cool-token", false, false)
+		cl, _ := NewClient(m, nil)
+		So(cl, ShouldNotBeNil)
+		scoped := cl.WithNamespace("team-a")
+		Convey("Should send the namespace on API requests", func() {
+			_, err := scoped.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(namespaces, ShouldResemble, []string{"team-a"})
+		})
+		Convey("Should send the namespace on secret requests", func() {
+			_, err := scoped.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(namespaces, ShouldResemble, []string{"team-a"})
+		})
+		Convey("Should allow overriding the namespace for a single request", func() {
+			_, err := scoped.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", Namespace: "team-b"})
+			So(err, ShouldBeNil)
+			So(namespaces, ShouldResemble, []string{"team-b"})
+		})
+		Convey("Should not change the original client or the auth headers", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			_, err = cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(namespaces, ShouldResemble, []string{"", ""})
+			So(m.headers.Get(api.NamespaceHeader), ShouldBeEmpty)
+		})
+	})
+
+	Convey("A client whose auth method has a namespace", t, func() {
+		namespaces = nil
+		m := GenerateMockAuth(ts.URL, "a-cool-token", false, false)
+		m.headers.Set(api.NamespaceHeader, "from-auth")
+		cl, _ := NewClient(m, nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should use it for API and secret requests", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			_, err = cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(namespaces, ShouldResemble, []string{"from-auth", "from-auth"})
+		})
+	})
+}
+
+func TestVaultClient(t *testing.T) {
+	var tokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("X-Vault-Token"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": {"foo": "bar"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A valid client", t, func() {
+		tokens = nil
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should expose its Vault client", func() {
+			So(cl.VaultClient(), ShouldNotBeNil)
+			So(cl.VaultClient().Token(), ShouldEqual, "a-cool-token")
+		})
+		Convey("Should use an injected Vault client", func() {
+			config := vault.DefaultConfig()
+			config.Address = ts.URL
+			config.Timeout = 5 * time.Second
+			vclient, err := vault.NewClient(config)
+			So(err, ShouldBeNil)
+			scoped := cl.WithVaultClient(vclient)
+			So(scoped.VaultClient(), ShouldEqual, vclient)
+			So(vclient.Token(), ShouldEqual, "a-cool-token")
+			secret, err := scoped.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+			So(tokens, ShouldResemble, []string{"a-cool-token"})
+			Convey("And should not change the original client", func() {
+				So(cl.VaultClient(), ShouldNotEqual, vclient)
+			})
+		})
+	})
+}
+
 func TestDoRequestWithNewHeader(t *testing.T) {
 	var testParams = map[string]string{
 		"theNumberThouShaltCountTo": "3",
@@ -405,3 +934,195 @@ func TestDoRequestWithNewHeader(t *testing.T) {
 		})
 	})
 }
+
+func TestDefaultHeadersPerClient(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	Convey("Two clients with different default headers", t, func() {
+		first := http.Header{"X-Team": []string{"first"}}
+		a, _ := NewClientWithHeaders(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil, first)
+		b, _ := NewClientWithHeaders(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil, http.Header{"X-Team": []string{"second"}})
+		first.Set("X-Team", "changed")
+		Convey("Should each send their own headers", func() {
+			_, err := a.Do(&Request{Method: http.MethodGet, Path: "/v1/blah"})
+			So(err, ShouldBeNil)
+			So(received.Get("X-Team"), ShouldEqual, "first")
+			_, err = b.Do(&Request{Method: http.MethodGet, Path: "/v1/blah"})
+			So(err, ShouldBeNil)
+			So(received.Get("X-Team"), ShouldEqual, "second")
+			So(a.defaultHeaders.Get("X-Team"), ShouldEqual, "first")
+		})
+		Convey("Should let a request override a default header", func() {
+			_, err := a.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", Header: http.Header{"x-team": []string{"override"}}})
+			So(err, ShouldBeNil)
+			So(received.Get("X-Team"), ShouldEqual, "override")
+		})
+		Convey("Should let a request remove a default header", func() {
+			header := http.Header{}
+			utils.RemoveHeader(header, "X-Team")
+			_, err := a.Do(&Request{Method: http.MethodGet, Path: "/v1/blah", Header: header})
+			So(err, ShouldBeNil)
+			_, ok := received["X-Team"]
+			So(ok, ShouldBeFalse)
+			So(received.Get("X-Cerberus-Token"), ShouldEqual, "a-cool-token")
+		})
+	})
+}
+
+func TestResponseLimit(t *testing.T) {
+	big := fmt.Sprintf(`[{"id": "an-id", "name": "%s"}]`, strings.Repeat("a", 2048))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(big))
+	}))
+	defer ts.Close()
+
+	Convey("A client with a response limit", t, func() {
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithResponseLimit(1024)
+		Convey("Should error for a larger response", func() {
+			sdbs, err := cl.SDB().List()
+			var tooLarge api.ErrorResponseTooLarge
+			So(errors.As(err, &tooLarge), ShouldBeTrue)
+			So(tooLarge.Limit, ShouldEqual, 1024)
+			So(sdbs, ShouldBeNil)
+		})
+		Convey("Should not limit the original client", func() {
+			sdbs, err := base.SDB().List()
+			So(err, ShouldBeNil)
+			So(sdbs, ShouldHaveLength, 1)
+		})
+		Convey("Should not limit secure file downloads", func() {
+			var out bytes.Buffer
+			err := cl.SecureFile().Get("app/my-sdb/big", &out)
+			So(err, ShouldBeNil)
+			So(out.Len(), ShouldEqual, len(big))
+		})
+		Convey("Should let a request override the limit", func() {
+			resp, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v2/safe-deposit-box", MaxResponseSize: 4096})
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			So(err, ShouldBeNil)
+			So(body, ShouldHaveLength, len(big))
+		})
+	})
+}
+
+func TestWithToken(t *testing.T) {
+	var apiTokens, vaultTokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/secret/app/foo/bar" {
+			vaultTokens = append(vaultTokens, r.Header.Get("X-Vault-Token"))
+			w.Write([]byte(`{"data": {"foo": "bar"}}`))
+			return
+		}
+		apiTokens = append(apiTokens, r.Header.Get("X-Cerberus-Token"))
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	Convey("A client bound to a user token", t, func() {
+		apiTokens, vaultTokens = nil, nil
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "service-token", false, false), nil)
+		base = base.WithSecretCache(time.Minute)
+		cl, err := base.WithToken("user-token")
+		So(err, ShouldBeNil)
+		Convey("Should use the user token for API and secret requests", func() {
+			_, err := cl.Category().List()
+			So(err, ShouldBeNil)
+			_, err = cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(apiTokens, ShouldResemble, []string{"user-token"})
+			So(vaultTokens, ShouldResemble, []string{"user-token"})
+		})
+		Convey("Should not change the original client", func() {
+			_, err := base.Category().List()
+			So(err, ShouldBeNil)
+			_, err = base.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(apiTokens, ShouldResemble, []string{"service-token"})
+			So(vaultTokens, ShouldResemble, []string{"service-token"})
+		})
+		Convey("Should not share the secret cache", func() {
+			base.Secret().Read("app/foo/bar")
+			cl.Secret().Read("app/foo/bar")
+			So(vaultTokens, ShouldResemble, []string{"service-token", "user-token"})
+		})
+		Convey("Should keep the namespace", func() {
+			namespaced, err := base.WithNamespace("team-b").WithToken("user-token")
+			So(err, ShouldBeNil)
+			So(namespaced.currentNamespace(), ShouldEqual, "team-b")
+		})
+	})
+
+	Convey("An empty token", t, func() {
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "service-token", false, false), nil)
+		cl, err := base.WithToken("")
+		So(err, ShouldNotBeNil)
+		So(cl, ShouldBeNil)
+	})
+}
+
+func TestConcurrentTokenRefresh(t *testing.T) {
+	var mu sync.Mutex
+	refreshes := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v2/auth/user/refresh":
+			mu.Lock()
+			refreshes++
+			token := fmt.Sprintf("token-%d", refreshes)
+			mu.Unlock()
+			fmt.Fprintf(w, `{"status": "success", "data": {"client_token": {"client_token": %q, "lease_duration": 3600}}}`, token)
+		case strings.HasPrefix(r.URL.Path, "/v1/secret/"):
+			w.Write([]byte(`{"data": {"key": "value"}}`))
+		default:
+			// Ask for a refresh on every API call
+			w.Header().Set("X-Refresh-Token", "true")
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client whose token is refreshed while it is used", t, func() {
+		tokenAuth, _ := auth.NewTokenAuth(ts.URL, "token-0")
+		cl, err := NewClient(tokenAuth, nil)
+		So(err, ShouldBeNil)
+		var wg sync.WaitGroup
+		errs := make(chan error, 100)
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					if _, err := cl.SDB().List(); err != nil {
+						errs <- err
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 5; j++ {
+					if _, err := cl.Secret().Read("app/sdb/config"); err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		Convey("Should not fail any request", func() {
+			for err := range errs {
+				So(err, ShouldBeNil)
+			}
+			So(refreshes, ShouldEqual, 50)
+		})
+	})
+}
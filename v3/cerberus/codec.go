@@ -0,0 +1,146 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// Codec converts between a Go value and the key/value data of a secret, for ReadValue and
+// WriteValue
+type Codec interface {
+	// Encode returns the secret data for v
+	Encode(v interface{}) (map[string]interface{}, error)
+	// Decode stores the secret data in the value pointed to by v
+	Decode(data map[string]interface{}, v interface{}) error
+}
+
+// JSONCodec maps a value to secret data through its JSON encoding, so each field of a struct
+// is a key of the secret. It is used for paths without a registered Codec
+type JSONCodec struct{}
+
+// Encode returns the fields of v as secret data. v must encode as a JSON object
+func (JSONCodec) Encode(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("Value must encode as a JSON object: %v", err)
+	}
+	return data, nil
+}
+
+// Decode stores the secret data in the fields of the value pointed to by v
+func (JSONCodec) Decode(data map[string]interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// BlobCodec stores a whole document, such as YAML or a protobuf message, base64 encoded under
+// a single key of the secret. Marshal and Unmarshal do the actual serialization, e.g.
+// yaml.Marshal and yaml.Unmarshal
+type BlobCodec struct {
+	// Key is the secret key the document is stored under
+	Key       string
+	Marshal   func(v interface{}) ([]byte, error)
+	Unmarshal func(data []byte, v interface{}) error
+}
+
+// Encode serializes v and returns it as the only key of the secret data
+func (b BlobCodec) Encode(v interface{}) (map[string]interface{}, error) {
+	raw, err := b.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{b.Key: base64.StdEncoding.EncodeToString(raw)}, nil
+}
+
+// Decode reads the document from the secret data and unserializes it into v
+func (b BlobCodec) Decode(data map[string]interface{}, v interface{}) error {
+	encoded, ok := data[b.Key].(string)
+	if !ok {
+		return fmt.Errorf("Secret has no %s key with an encoded document", b.Key)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("Unable to decode %s: %v", b.Key, err)
+	}
+	return b.Unmarshal(raw, v)
+}
+
+// WithCodec returns a shallow copy of the client that uses codec for ReadValue and WriteValue
+// on secrets under prefix, e.g. "app/my-sdb/". When several prefixes match a path, the
+// longest one is used
+func (c *Client) WithCodec(prefix string, codec Codec) *Client {
+	scoped := c.copy()
+	scoped.codecs = make(map[string]Codec, len(c.codecs)+1)
+	for p, existing := range c.codecs {
+		scoped.codecs[p] = existing
+	}
+	scoped.codecs[prefix] = codec
+	return scoped
+}
+
+// codecFor returns the codec registered for the longest prefix of path, or JSONCodec
+func (s *Secret) codecFor(path string) Codec {
+	var codec Codec = JSONCodec{}
+	longest := -1
+	for prefix, registered := range s.codecs {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			codec = registered
+			longest = len(prefix)
+		}
+	}
+	return codec
+}
+
+// ReadValue reads the secret at path and decodes it into the value pointed to by v, using the
+// Codec registered for the path. It returns ErrorSecretNotFound if there is no secret. Path
+// should not be prefaced with a "/"
+func (s *Secret) ReadValue(path string, v interface{}) error {
+	secret, err := s.Read(path)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return ErrorSecretNotFound
+	}
+	if err := s.codecFor(path).Decode(secret.Data, v); err != nil {
+		return fmt.Errorf("Unable to decode secret %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteValue encodes v using the Codec registered for path and writes it as the secret at
+// path. Path should not be prefaced with a "/"
+func (s *Secret) WriteValue(path string, v interface{}) (*api.WriteResult, error) {
+	data, err := s.codecFor(path).Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode secret %s: %w", path, err)
+	}
+	return s.WriteWithResult(path, data)
+}
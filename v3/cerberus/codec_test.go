@@ -0,0 +1,94 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type dbConfig struct {
+	XMLName  xml.Name `json:"-" xml:"database"`
+	Host     string   `json:"host" xml:"host"`
+	Port     int      `json:"port" xml:"port"`
+	Password string   `json:"password" xml:"password"`
+}
+
+func TestCodecs(t *testing.T) {
+	fake := &fakeSecrets{}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	xmlCodec := BlobCodec{Key: "document", Marshal: xml.Marshal, Unmarshal: xml.Unmarshal}
+
+	Convey("A client with a codec for a prefix", t, func() {
+		fake.secrets = map[string]map[string]interface{}{}
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithCodec("app/xml/", xmlCodec)
+		config := dbConfig{Host: "db.example.com", Port: 5432, Password: "hunter2"}
+
+		Convey("Should store values as fields by default", func() {
+			_, err := cl.Secret().WriteValue("app/sdb/db", config)
+			So(err, ShouldBeNil)
+			So(fake.secrets["app/sdb/db"]["host"], ShouldEqual, "db.example.com")
+			var read dbConfig
+			So(cl.Secret().ReadValue("app/sdb/db", &read), ShouldBeNil)
+			So(read.Host, ShouldEqual, config.Host)
+			So(read.Port, ShouldEqual, config.Port)
+		})
+
+		Convey("Should store values under the prefix as one encoded document", func() {
+			_, err := cl.Secret().WriteValue("app/xml/db", config)
+			So(err, ShouldBeNil)
+			So(fake.secrets["app/xml/db"], ShouldHaveLength, 1)
+			raw, _ := base64.StdEncoding.DecodeString(fake.secrets["app/xml/db"]["document"].(string))
+			So(string(raw), ShouldStartWith, "<database>")
+			var read dbConfig
+			So(cl.Secret().ReadValue("app/xml/db", &read), ShouldBeNil)
+			So(read.Password, ShouldEqual, "hunter2")
+		})
+
+		Convey("Should use the longest matching prefix", func() {
+			scoped := cl.WithCodec("app/", JSONCodec{})
+			So(scoped.Secret().codecFor("app/xml/db").(BlobCodec).Key, ShouldEqual, "document")
+			So(scoped.Secret().codecFor("app/other/db"), ShouldResemble, JSONCodec{})
+			So(cl.Secret().codecFor("other/db"), ShouldResemble, JSONCodec{})
+			Convey("Without changing the original client", func() {
+				So(cl.codecs, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("Should return ErrorSecretNotFound for a missing secret", func() {
+			var read dbConfig
+			So(cl.Secret().ReadValue("app/xml/missing", &read), ShouldEqual, ErrorSecretNotFound)
+		})
+
+		Convey("Should error when the secret wasn't written by the codec", func() {
+			fake.set("app/xml/plain", map[string]interface{}{"host": "db.example.com"})
+			var read dbConfig
+			So(cl.Secret().ReadValue("app/xml/plain", &read), ShouldNotBeNil)
+		})
+
+		Convey("Should error for values that aren't objects", func() {
+			_, err := cl.Secret().WriteValue("app/sdb/db", "just a string")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// SDBConverter converts between the SDBMetadata returned by the metadata endpoint, which names
+// roles and the category, and the SafeDepositBox used by the SDB endpoints, which refers to them
+// by ID. It holds the roles and categories it was created with, so one converter can convert
+// many SDBs with no further requests
+type SDBConverter struct {
+	roles      []*api.Role
+	categories []*api.Category
+}
+
+// NewSDBConverter returns an SDBConverter for the given roles and categories, for callers that
+// already have them
+func NewSDBConverter(roles []*api.Role, categories []*api.Category) *SDBConverter {
+	return &SDBConverter{roles: roles, categories: categories}
+}
+
+// SDBConverter returns an SDBConverter with the roles and categories currently in Cerberus
+func (c *Client) SDBConverter() (*SDBConverter, error) {
+	roles, err := c.Role().List()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := c.Category().List()
+	if err != nil {
+		return nil, err
+	}
+	return NewSDBConverter(roles, categories), nil
+}
+
+// ToSafeDepositBox converts SDB metadata to a SafeDepositBox, resolving role and category names
+// to their IDs. Permissions are sorted by principal. Returns ErrorRoleNotFound or
+// ErrorCategoryNotFound if a name doesn't match any role or category
+func (v *SDBConverter) ToSafeDepositBox(m api.SDBMetadata) (*api.SafeDepositBox, error) {
+	sdb := &api.SafeDepositBox{
+		ID:          m.Id,
+		Name:        m.Name,
+		Path:        m.Path,
+		Owner:       m.Owner,
+		Description: m.Description,
+	}
+	if m.Category != "" {
+		category, err := v.categoryByName(m.Category)
+		if err != nil {
+			return nil, err
+		}
+		sdb.CategoryID = category.ID
+	}
+	for _, group := range sortedKeys(m.UserGroupPermissions) {
+		role, err := v.roleByName(m.UserGroupPermissions[group])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to convert permission of %s on %s: %w", group, m.Path, err)
+		}
+		sdb.UserGroupPermissions = append(sdb.UserGroupPermissions, api.UserGroupPermission{Name: group, RoleID: role.ID})
+	}
+	for _, arn := range sortedKeys(m.IAMRolePermissions) {
+		role, err := v.roleByName(m.IAMRolePermissions[arn])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to convert permission of %s on %s: %w", arn, m.Path, err)
+		}
+		sdb.IAMPrincipalPermissions = append(sdb.IAMPrincipalPermissions, api.IAMPrincipal{IAMPrincipalARN: arn, RoleID: role.ID})
+	}
+	return sdb, nil
+}
+
+// ToMetadata converts a SafeDepositBox to SDB metadata, resolving role and category IDs to
+// their names. Fields that only the metadata endpoint returns, such as the timestamps, are
+// left empty. Returns ErrorRoleNotFound or ErrorCategoryNotFound if an ID doesn't match any
+// role or category
+func (v *SDBConverter) ToMetadata(sdb *api.SafeDepositBox) (api.SDBMetadata, error) {
+	m := api.SDBMetadata{
+		Id:                   sdb.ID,
+		Name:                 sdb.Name,
+		Path:                 sdb.Path,
+		Owner:                sdb.Owner,
+		Description:          sdb.Description,
+		UserGroupPermissions: map[string]string{},
+		IAMRolePermissions:   map[string]string{},
+	}
+	if sdb.CategoryID != "" {
+		category, err := v.categoryByID(sdb.CategoryID)
+		if err != nil {
+			return api.SDBMetadata{}, err
+		}
+		m.Category = category.DisplayName
+	}
+	for _, p := range sdb.UserGroupPermissions {
+		role, err := v.roleByID(p.RoleID)
+		if err != nil {
+			return api.SDBMetadata{}, fmt.Errorf("Unable to convert permission of %s on %s: %w", p.Name, sdb.Path, err)
+		}
+		m.UserGroupPermissions[p.Name] = role.Name
+	}
+	for _, p := range sdb.IAMPrincipalPermissions {
+		role, err := v.roleByID(p.RoleID)
+		if err != nil {
+			return api.SDBMetadata{}, fmt.Errorf("Unable to convert permission of %s on %s: %w", p.IAMPrincipalARN, sdb.Path, err)
+		}
+		m.IAMRolePermissions[p.IAMPrincipalARN] = role.Name
+	}
+	return m, nil
+}
+
+// roleByName returns the role with the given name, which is not case sensitive
+func (v *SDBConverter) roleByName(name string) (*api.Role, error) {
+	for _, role := range v.roles {
+		if strings.EqualFold(role.Name, name) {
+			return role, nil
+		}
+	}
+	return nil, ErrorRoleNotFound
+}
+
+func (v *SDBConverter) roleByID(id string) (*api.Role, error) {
+	for _, role := range v.roles {
+		if role.ID == id {
+			return role, nil
+		}
+	}
+	return nil, ErrorRoleNotFound
+}
+
+// categoryByName returns the category with the given display name, which is not case
+// sensitive
+func (v *SDBConverter) categoryByName(name string) (*api.Category, error) {
+	for _, category := range v.categories {
+		if strings.EqualFold(category.DisplayName, name) {
+			return category, nil
+		}
+	}
+	return nil, ErrorCategoryNotFound
+}
+
+func (v *SDBConverter) categoryByID(id string) (*api.Category, error) {
+	for _, category := range v.categories {
+		if category.ID == id {
+			return category, nil
+		}
+	}
+	return nil, ErrorCategoryNotFound
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
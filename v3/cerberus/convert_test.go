@@ -0,0 +1,121 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var convertedMetadata = api.SDBMetadata{
+	Id:                   "sdb-id",
+	Name:                 "my sdb",
+	Path:                 "app/my-sdb/",
+	Category:             "Applications",
+	Owner:                "Lst-owners",
+	Description:          "An SDB",
+	UserGroupPermissions: map[string]string{"Lst-readers": "read", "Lst-auditors": "READ"},
+	IAMRolePermissions:   map[string]string{"arn:aws:iam::111111111:role/app": "owner"},
+}
+
+var convertedSDB = &api.SafeDepositBox{
+	ID:          "sdb-id",
+	Name:        "my sdb",
+	Path:        "app/my-sdb/",
+	CategoryID:  "f7ff85a0-faaa-11e5-a8a9-7fa3b294cd46",
+	Owner:       "Lst-owners",
+	Description: "An SDB",
+	UserGroupPermissions: []api.UserGroupPermission{
+		{Name: "Lst-auditors", RoleID: "f800558e-faaa-11e5-a8a9-7fa3b294cd46"},
+		{Name: "Lst-readers", RoleID: "f800558e-faaa-11e5-a8a9-7fa3b294cd46"},
+	},
+	IAMPrincipalPermissions: []api.IAMPrincipal{
+		{IAMPrincipalARN: "arn:aws:iam::111111111:role/app", RoleID: "f7fff4d6-faaa-11e5-a8a9-7fa3b294cd46"},
+	},
+}
+
+func TestSDBConverter(t *testing.T) {
+	v := NewSDBConverter(expectedList, expectedResponseList)
+
+	Convey("Converting metadata to an SDB", t, func() {
+		Convey("Should resolve the role and category names", func() {
+			sdb, err := v.ToSafeDepositBox(convertedMetadata)
+			So(err, ShouldBeNil)
+			So(sdb, ShouldResemble, convertedSDB)
+		})
+		Convey("Should error for an unknown role", func() {
+			m := convertedMetadata
+			m.UserGroupPermissions = map[string]string{"Lst-writers": "write"}
+			_, err := v.ToSafeDepositBox(m)
+			So(errors.Is(err, ErrorRoleNotFound), ShouldBeTrue)
+		})
+		Convey("Should error for an unknown category", func() {
+			m := convertedMetadata
+			m.Category = "Unknown"
+			_, err := v.ToSafeDepositBox(m)
+			So(err, ShouldEqual, ErrorCategoryNotFound)
+		})
+	})
+
+	Convey("Converting an SDB to metadata", t, func() {
+		Convey("Should resolve the role and category IDs", func() {
+			m, err := v.ToMetadata(convertedSDB)
+			So(err, ShouldBeNil)
+			expected := convertedMetadata
+			expected.UserGroupPermissions = map[string]string{"Lst-readers": "read", "Lst-auditors": "read"}
+			So(m, ShouldResemble, expected)
+		})
+		Convey("Should error for an unknown role", func() {
+			sdb := *convertedSDB
+			sdb.IAMPrincipalPermissions = []api.IAMPrincipal{{IAMPrincipalARN: "arn", RoleID: "unknown"}}
+			_, err := v.ToMetadata(&sdb)
+			So(errors.Is(err, ErrorRoleNotFound), ShouldBeTrue)
+		})
+		Convey("Should error for an unknown category", func() {
+			sdb := *convertedSDB
+			sdb.CategoryID = "unknown"
+			_, err := v.ToMetadata(&sdb)
+			So(err, ShouldEqual, ErrorCategoryNotFound)
+		})
+	})
+
+	Convey("A converter from the client", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/v1/role":
+				w.Write([]byte(listResponse))
+			case "/v1/category":
+				w.Write([]byte(categoryResponse))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		converter, err := cl.SDBConverter()
+		So(err, ShouldBeNil)
+		sdb, err := converter.ToSafeDepositBox(convertedMetadata)
+		So(err, ShouldBeNil)
+		So(sdb, ShouldResemble, convertedSDB)
+	})
+}
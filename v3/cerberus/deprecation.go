@@ -0,0 +1,107 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Deprecation is a notice from Cerberus that an endpoint is deprecated, sent with the
+// Deprecation and Sunset response headers
+type Deprecation struct {
+	Method string
+	Path   string
+	// Deprecation is the value of the Deprecation header, which is "true" or when the
+	// endpoint was deprecated. It is empty if the server only sent a Sunset header
+	Deprecation string
+	// Sunset is when the endpoint will stop working, or the zero time if the server didn't say
+	Sunset time.Time
+	// Link is the value of the Link header, which usually points to documentation about
+	// the deprecation
+	Link string
+}
+
+// DeprecationHandler is called the first time Cerberus says an endpoint is deprecated
+type DeprecationHandler func(Deprecation)
+
+// deprecations remembers which endpoints have been reported as deprecated, so each one is
+// only reported once. It is shared by copies of a Client
+type deprecations struct {
+	mu      sync.Mutex
+	handler DeprecationHandler
+	seen    map[string]bool
+}
+
+// WithDeprecationHandler returns a copy of the client that calls handler the first time
+// Cerberus says an endpoint is deprecated, in addition to logging a warning. The handler
+// is called synchronously
+func (c *Client) WithDeprecationHandler(handler DeprecationHandler) *Client {
+	scoped := c.copy()
+	scoped.deprecations = &deprecations{handler: handler, seen: map[string]bool{}}
+	return scoped
+}
+
+// checkDeprecation logs a warning and calls the deprecation handler if the response says
+// the endpoint is deprecated and it hasn't been reported yet
+func (c *Client) checkDeprecation(method, path string, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	deprecated := resp.Header.Get("Deprecation")
+	sunset := resp.Header.Get("Sunset")
+	if deprecated == "" && sunset == "" {
+		return
+	}
+
+	var handler DeprecationHandler
+	if c.deprecations != nil {
+		c.deprecations.mu.Lock()
+		key := method + " " + path
+		reported := c.deprecations.seen[key]
+		c.deprecations.seen[key] = true
+		handler = c.deprecations.handler
+		c.deprecations.mu.Unlock()
+		if reported {
+			return
+		}
+	}
+
+	d := Deprecation{
+		Method:      method,
+		Path:        path,
+		Deprecation: deprecated,
+		Link:        resp.Header.Get("Link"),
+	}
+	if t, err := http.ParseTime(sunset); err == nil {
+		d.Sunset = t
+	}
+	fields := log.Fields{"method": d.Method, "path": d.Path}
+	if !d.Sunset.IsZero() {
+		fields["sunset"] = d.Sunset.Format(time.RFC3339)
+	}
+	if d.Link != "" {
+		fields["link"] = d.Link
+	}
+	log.WithFields(fields).Warn("Cerberus endpoint is deprecated")
+	if handler != nil {
+		handler(d)
+	}
+}
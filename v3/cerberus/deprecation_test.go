@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeprecations(t *testing.T) {
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/old" {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Sunset", sunset.Format(http.TimeFormat))
+			w.Header().Set("Link", `<https://example.com/migrate>; rel="deprecation"`)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	Convey("A client with a deprecation handler", t, func() {
+		var notices []Deprecation
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithDeprecationHandler(func(d Deprecation) { notices = append(notices, d) })
+		Convey("Should report a deprecated endpoint once", func() {
+			for i := 0; i < 3; i++ {
+				resp, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/old"})
+				So(err, ShouldBeNil)
+				resp.Body.Close()
+			}
+			So(notices, ShouldHaveLength, 1)
+			So(notices[0].Method, ShouldEqual, http.MethodGet)
+			So(notices[0].Path, ShouldEqual, "/v1/old")
+			So(notices[0].Deprecation, ShouldEqual, "true")
+			So(notices[0].Sunset.Equal(sunset), ShouldBeTrue)
+			So(notices[0].Link, ShouldEqual, `<https://example.com/migrate>; rel="deprecation"`)
+			Convey("Including from copies of the client", func() {
+				resp, err := cl.WithNamespace("team-b").Do(&Request{Method: http.MethodGet, Path: "/v1/old"})
+				So(err, ShouldBeNil)
+				resp.Body.Close()
+				So(notices, ShouldHaveLength, 1)
+			})
+		})
+		Convey("Should not report other endpoints", func() {
+			resp, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/new"})
+			So(err, ShouldBeNil)
+			resp.Body.Close()
+			So(notices, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
+)
+
+// historySize is how many recent operations a client keeps for Diagnostics
+const historySize = 50
+
+// maxErrorLength is the longest error message kept in the history. Longer messages, such as
+// ones that include a dump of the response, are truncated
+const maxErrorLength = 200
+
+// healthCheckPath is the Cerberus endpoint that Diagnostics uses to check the server
+const healthCheckPath = "/healthcheck"
+
+// Diagnostics is a support bundle describing a client and its recent activity, meant to be
+// attached to a support ticket when the client can't reach or use Cerberus. It has no tokens,
+// secrets or header values, and can be encoded as JSON
+type Diagnostics struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Client      DiagnosticsClient `json:"client"`
+	Auth        DiagnosticsAuth   `json:"auth"`
+	Server      DiagnosticsServer `json:"server"`
+	// RecentRequests are the last operations made by the client and its copies, oldest first
+	RecentRequests []DiagnosticsRequest `json:"recent_requests"`
+}
+
+// DiagnosticsClient is the configuration of the client
+type DiagnosticsClient struct {
+	Version     string   `json:"version"`
+	GoVersion   string   `json:"go_version"`
+	Features    []string `json:"features"`
+	URL         string   `json:"url"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Application string   `json:"application,omitempty"`
+	// Headers are the names of the default headers. Their values are left out
+	Headers       []string      `json:"headers,omitempty"`
+	NoRetry       bool          `json:"no_retry"`
+	Timeout       time.Duration `json:"timeout,omitempty"`
+	Timeouts      Timeouts      `json:"timeouts"`
+	ResponseLimit int64         `json:"response_limit,omitempty"`
+	SecretCache   bool          `json:"secret_cache"`
+	QueuedWrites  int           `json:"queued_writes,omitempty"`
+}
+
+// DiagnosticsAuth is the state of the auth method
+type DiagnosticsAuth struct {
+	// Type is the Go type of the auth method, such as *auth.STSAuth
+	Type          string    `json:"type"`
+	Authenticated bool      `json:"authenticated"`
+	Expiry        time.Time `json:"expiry,omitempty"`
+	Principal     string    `json:"principal,omitempty"`
+}
+
+// DiagnosticsServer is the result of a health check against Cerberus
+type DiagnosticsServer struct {
+	Reachable  bool          `json:"reachable"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// DiagnosticsRequest is an operation made by the client
+type DiagnosticsRequest struct {
+	Operation  string        `json:"operation"`
+	Path       string        `json:"path"`
+	Namespace  string        `json:"namespace,omitempty"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Protocol   string        `json:"protocol,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// JSON returns the indented JSON encoding of the bundle
+func (d *Diagnostics) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// history keeps the most recent operations of a client. It is shared by copies of a Client
+type history struct {
+	mu      sync.Mutex
+	entries []DiagnosticsRequest
+	next    int
+}
+
+func newHistory() *history {
+	return &history{entries: make([]DiagnosticsRequest, 0, historySize)}
+}
+
+func (h *history) add(event Event) {
+	entry := DiagnosticsRequest{
+		Operation:  event.Operation,
+		Path:       event.Path,
+		Namespace:  event.Namespace,
+		StatusCode: event.StatusCode,
+		Protocol:   event.Protocol,
+		Start:      event.Start,
+		Duration:   event.Duration,
+	}
+	if event.Err != nil {
+		entry.Error = redactError(event.Err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.entries) < historySize {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % historySize
+}
+
+// list returns the entries oldest first
+func (h *history) list() []DiagnosticsRequest {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append(append([]DiagnosticsRequest(nil), h.entries[h.next:]...), h.entries[:h.next]...)
+}
+
+// redactError keeps the first line of an error message, as some errors include a dump of
+// the response, and truncates it
+func redactError(err error) string {
+	msg := strings.SplitN(err.Error(), "\n", 2)[0]
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength] + "..."
+	}
+	return msg
+}
+
+// Diagnostics gathers the configuration of the client, the state of its auth method, the
+// result of a health check against Cerberus and the last operations made by the client and
+// its copies into a support bundle. The health check uses the given context
+func (c *Client) Diagnostics(ctx context.Context) *Diagnostics {
+	info := api.ClientInfo()
+	d := &Diagnostics{
+		GeneratedAt: time.Now(),
+		Client: DiagnosticsClient{
+			Version:       info.Version,
+			GoVersion:     info.GoVersion,
+			Features:      info.Features,
+			URL:           c.CerberusURL.Redacted(),
+			Namespace:     c.currentNamespace(),
+			Application:   c.application,
+			NoRetry:       c.noRetry,
+			Timeout:       c.timeout,
+			Timeouts:      c.timeouts,
+			ResponseLimit: c.responseLimit,
+			SecretCache:   c.secretCache != nil,
+		},
+		Auth: DiagnosticsAuth{
+			Type:          fmt.Sprintf("%T", c.Authentication),
+			Authenticated: c.Authentication.IsAuthenticated(),
+		},
+		Server: c.healthCheck(ctx),
+	}
+	for name := range c.defaultHeaders {
+		d.Client.Headers = append(d.Client.Headers, name)
+	}
+	sort.Strings(d.Client.Headers)
+	if c.writeQueue != nil {
+		d.Client.QueuedWrites = c.writeQueue.Len()
+	}
+	if expiry, err := c.Authentication.GetExpiry(); err == nil {
+		d.Auth.Expiry = expiry
+	}
+	if p, ok := c.Authentication.(auth.PrincipalProvider); ok {
+		d.Auth.Principal = p.Principal()
+	}
+	if c.history != nil {
+		d.RecentRequests = c.history.list()
+	}
+	return d
+}
+
+// healthCheck checks that Cerberus is reachable. It doesn't authenticate or retry, so the
+// result reflects the network path to the server
+func (c *Client) healthCheck(ctx context.Context) DiagnosticsServer {
+	healthURL := *c.CerberusURL
+	setPath(&healthURL, healthCheckPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL.String(), nil)
+	if err != nil {
+		return DiagnosticsServer{Error: redactError(err)}
+	}
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	server := DiagnosticsServer{Latency: time.Since(start)}
+	if err != nil {
+		server.Error = redactError(utils.ConnectionError(req.URL.Host, err))
+		return server
+	}
+	resp.Body.Close()
+	server.Reachable = true
+	server.StatusCode = resp.StatusCode
+	return server
+}
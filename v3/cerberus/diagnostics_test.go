@@ -0,0 +1,123 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiagnostics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case healthCheckPath:
+			w.Write([]byte("ok"))
+		case "/v1/secret/app/sdb/config":
+			w.Write([]byte(`{"data": {"password": "hunter2"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client that made some requests", t, func() {
+		headers := http.Header{"X-Api-Key": []string{"a-secret-key"}}
+		cl, err := NewClientWithHeaders(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil, headers)
+		So(err, ShouldBeNil)
+		cl = cl.WithApplication("my-app").WithTimeouts(Timeouts{Read: time.Second})
+		_, err = cl.Secret().Read("app/sdb/config")
+		So(err, ShouldBeNil)
+		_, err = cl.SDB().Get("missing")
+		So(err, ShouldNotBeNil)
+		d := cl.Diagnostics(context.Background())
+
+		Convey("Should describe the client", func() {
+			So(d.Client.Version, ShouldNotBeEmpty)
+			So(d.Client.URL, ShouldEqual, ts.URL)
+			So(d.Client.Application, ShouldEqual, "my-app")
+			So(d.Client.Headers, ShouldResemble, []string{"X-Api-Key"})
+			So(d.Client.Timeouts.Read, ShouldEqual, time.Second)
+		})
+
+		Convey("Should describe the auth method", func() {
+			So(d.Auth.Type, ShouldEqual, "*cerberus.MockAuth")
+			So(d.Auth.Authenticated, ShouldBeTrue)
+		})
+
+		Convey("Should check the server", func() {
+			So(d.Server.Reachable, ShouldBeTrue)
+			So(d.Server.StatusCode, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should list the recent requests, oldest first", func() {
+			So(d.RecentRequests, ShouldHaveLength, 2)
+			So(d.RecentRequests[0].Operation, ShouldEqual, "read")
+			So(d.RecentRequests[0].Path, ShouldEqual, "secret/app/sdb/config")
+			So(d.RecentRequests[1].StatusCode, ShouldEqual, http.StatusNotFound)
+			So(d.RecentRequests[1].Error, ShouldNotBeEmpty)
+			So(d.RecentRequests[1].Error, ShouldNotContainSubstring, "\n")
+		})
+
+		Convey("Should encode as JSON without secrets", func() {
+			data, err := d.JSON()
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, `"recent_requests"`)
+			So(string(data), ShouldNotContainSubstring, "a-cool-token")
+			So(string(data), ShouldNotContainSubstring, "a-secret-key")
+			So(string(data), ShouldNotContainSubstring, "hunter2")
+		})
+	})
+
+	Convey("A client that can't reach Cerberus", t, func() {
+		down := httptest.NewServer(http.NotFoundHandler())
+		down.Close()
+		cl, _ := NewClient(GenerateMockAuth(down.URL, "a-cool-token", false, false), nil)
+		d := cl.Diagnostics(context.Background())
+		Convey("Should report the connection error", func() {
+			So(d.Server.Reachable, ShouldBeFalse)
+			So(d.Server.Error, ShouldStartWith, "Unable to connect to Cerberus")
+		})
+	})
+}
+
+func TestHistory(t *testing.T) {
+	Convey("A full history", t, func() {
+		h := newHistory()
+		for i := 0; i < historySize+10; i++ {
+			h.add(Event{Operation: "read", Path: fmt.Sprintf("secret/%d", i)})
+		}
+		Convey("Should keep the most recent operations, oldest first", func() {
+			entries := h.list()
+			So(entries, ShouldHaveLength, historySize)
+			So(entries[0].Path, ShouldEqual, "secret/10")
+			So(entries[historySize-1].Path, ShouldEqual, fmt.Sprintf("secret/%d", historySize+9))
+		})
+	})
+	Convey("A long error", t, func() {
+		msg := redactError(fmt.Errorf("HTTP response code 500\nHTTP/1.1 500 Internal Server Error\r\n\r\nbody"))
+		Convey("Should only keep the first line", func() {
+			So(msg, ShouldEqual, "HTTP response code 500")
+		})
+	})
+}
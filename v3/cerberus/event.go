@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Event describes a single operation made by a Client. Events are meant for audit logging,
+// e.g. to correlate secret access in an application with the Cerberus server logs
+type Event struct {
+	// Operation is the HTTP method for API requests, or one of "read", "list", "write" and
+	// "delete" for secrets
+	Operation string
+	// Path is the API path, or the secret path including the "secret/" prefix
+	Path string
+	// Namespace is the namespace the operation was made in, if any
+	Namespace string
+	// Principal is the IAM principal ARN or username the client is authenticated as. It is
+	// only set if the auth method implements auth.PrincipalProvider
+	Principal string
+	// StatusCode is the HTTP status code of the response, or 0 if there was none. For secrets
+	// it is inferred from the result, as the Vault client doesn't expose the response
+	StatusCode int
+	// Protocol is the protocol of the response, such as "HTTP/1.1", "HTTP/2.0" or "HTTP/3.0".
+	// It is empty for secrets and when there was no response
+	Protocol string
+	// Err is the error returned to the caller, if any
+	Err error
+	// Start is when the operation started
+	Start time.Time
+	// Duration is how long the operation took, including retries
+	Duration time.Duration
+}
+
+// EventHandler is called with an Event after every operation. It is called synchronously
+// from the goroutine making the call, so it should return quickly, e.g. by sending the
+// event on a buffered channel
+type EventHandler func(Event)
+
+// WithEventHandler returns a shallow copy of the client that calls handler after every API
+// request and secret operation
+func (c *Client) WithEventHandler(handler EventHandler) *Client {
+	scoped := c.copy()
+	scoped.events = handler
+	return scoped
+}
+
+// emit sends an event for an operation that started at start to the event handler, if any
+func (c *Client) emit(operation, path, namespace, protocol string, start time.Time, statusCode int, err error) {
+	if c == nil || (c.events == nil && c.history == nil) {
+		return
+	}
+	if namespace == "" {
+		namespace = c.currentNamespace()
+	}
+	var principal string
+	if p, ok := c.Authentication.(auth.PrincipalProvider); ok {
+		principal = p.Principal()
+	}
+	event := Event{
+		Operation:  operation,
+		Path:       path,
+		Namespace:  namespace,
+		Principal:  principal,
+		StatusCode: statusCode,
+		Protocol:   protocol,
+		Err:        err,
+		Start:      start,
+		Duration:   time.Since(start),
+	}
+	// Keep the operation for Diagnostics
+	if c.history != nil {
+		c.history.add(event)
+	}
+	if c.events != nil {
+		c.events(event)
+	}
+}
+
+// emitResponse sends an event for an API request
+func (c *Client) emitResponse(r *Request, start time.Time, resp *http.Response, err error) {
+	var statusCode int
+	var protocol string
+	if resp != nil {
+		statusCode = resp.StatusCode
+		protocol = resp.Proto
+	}
+	c.emit(r.Method, r.Path, r.Namespace, protocol, start, statusCode, err)
+}
+
+// emitSecret sends an event for a secret operation
+func (c *Client) emitSecret(operation, path string, start time.Time, secret *vault.Secret, err error) {
+	statusCode := http.StatusOK
+	var respErr *vault.ResponseError
+	switch {
+	case errors.As(err, &respErr):
+		statusCode = respErr.StatusCode
+	case err != nil:
+		statusCode = 0
+	case secret == nil && operation != "delete" && operation != "write":
+		// Vault returns no secret and no error for a 404
+		statusCode = http.StatusNotFound
+	}
+	c.emit(operation, pathPrefix+path, "", "", start, statusCode, err)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// principalMockAuth is a MockAuth that knows which principal it is authenticated as
+type principalMockAuth struct {
+	*MockAuth
+}
+
+func (m *principalMockAuth) Principal() string {
+	return "arn:aws:iam::123456789012:role/my-role"
+}
+
+func TestEvents(t *testing.T) {
+	Convey("A client with an event handler", t, WithTestServer(http.StatusOK, "/v", http.MethodGet, `{"data": {"foo": "bar"}}`, func(ts *httptest.Server) {
+		var events []Event
+		base, _ := NewClient(&principalMockAuth{GenerateMockAuth(ts.URL, "a-cool-token", false, false)}, nil)
+		cl := base.WithEventHandler(func(e Event) { events = append(events, e) })
+		So(cl, ShouldNotBeNil)
+		Convey("Should emit an event for an API request", func() {
+			_, err := cl.WithNamespace("team-b").Do(&Request{Method: http.MethodGet, Path: "/v1/category"})
+			So(err, ShouldBeNil)
+			So(events, ShouldHaveLength, 1)
+			So(events[0].Operation, ShouldEqual, http.MethodGet)
+			So(events[0].Path, ShouldEqual, "/v1/category")
+			So(events[0].Namespace, ShouldEqual, "team-b")
+			So(events[0].Principal, ShouldEqual, "arn:aws:iam::123456789012:role/my-role")
+			So(events[0].StatusCode, ShouldEqual, http.StatusOK)
+			So(events[0].Protocol, ShouldEqual, "HTTP/1.1")
+			So(events[0].Err, ShouldBeNil)
+			So(events[0].Start.IsZero(), ShouldBeFalse)
+		})
+		Convey("Should emit an event for a secret read", func() {
+			_, err := cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(events, ShouldHaveLength, 1)
+			So(events[0].Operation, ShouldEqual, "read")
+			So(events[0].Path, ShouldEqual, "secret/app/foo/bar")
+			So(events[0].StatusCode, ShouldEqual, http.StatusOK)
+		})
+		Convey("Should not emit events for the original client", func() {
+			base.Do(&Request{Method: http.MethodGet, Path: "/v1/category"})
+			base.Secret().Read("app/foo/bar")
+			So(events, ShouldBeEmpty)
+		})
+	}))
+
+	Convey("A client with an event handler and a failing server", t, WithTestServer(http.StatusForbidden, "/v", http.MethodGet, `{"errors": ["permission denied"]}`, func(ts *httptest.Server) {
+		var events []Event
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithEventHandler(func(e Event) { events = append(events, e) })
+		Convey("Should include the error and status code", func() {
+			_, err := cl.Secret().Read("app/foo/bar")
+			So(err, ShouldNotBeNil)
+			So(events, ShouldHaveLength, 1)
+			So(events[0].Err, ShouldEqual, err)
+			So(events[0].StatusCode, ShouldEqual, http.StatusForbidden)
+			So(events[0].Principal, ShouldBeEmpty)
+		})
+	}))
+}
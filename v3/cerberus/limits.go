@@ -0,0 +1,160 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Limits is the rate limit state of the Cerberus server, as reported by the rate limit
+// headers of its last response. Limit and Remaining are -1 when the server didn't send them
+type Limits struct {
+	// Limit is the number of requests allowed in the current window
+	Limit int
+	// Remaining is the number of requests left in the current window
+	Remaining int
+	// Reset is when the current window ends, or the zero time if it wasn't reported
+	Reset time.Time
+	// Observed is when the response with these limits was received
+	Observed time.Time
+}
+
+// limits is the latest Limits reported by the server. It is shared by copies of a Client,
+// as they all count against the same limits
+type limits struct {
+	mu      sync.RWMutex
+	current Limits
+	known   bool
+}
+
+// limitHeader returns the first of the X-RateLimit-* and RateLimit-* variants of a header
+func limitHeader(header http.Header, name string) string {
+	if value := header.Get("X-RateLimit-" + name); value != "" {
+		return value
+	}
+	return header.Get("RateLimit-" + name)
+}
+
+// parseLimits reads the rate limit headers of a response. Reset is accepted both as a
+// number of seconds and as a unix timestamp, which is what larger values are taken to be
+func parseLimits(header http.Header, now time.Time) (Limits, bool) {
+	parsed := Limits{Limit: -1, Remaining: -1, Observed: now}
+	found := false
+	if limit, err := strconv.Atoi(limitHeader(header, "Limit")); err == nil {
+		parsed.Limit = limit
+		found = true
+	}
+	if remaining, err := strconv.Atoi(limitHeader(header, "Remaining")); err == nil {
+		parsed.Remaining = remaining
+		found = true
+	}
+	if reset, err := strconv.ParseInt(limitHeader(header, "Reset"), 10, 64); err == nil && reset >= 0 {
+		// Delta seconds are at most a day in practice, unix timestamps are far larger
+		if reset > 24*60*60 {
+			parsed.Reset = time.Unix(reset, 0)
+		} else {
+			parsed.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+		found = true
+	}
+	return parsed, found
+}
+
+// observe records the limits reported by a response, if any
+func (l *limits) observe(header http.Header) {
+	if l == nil {
+		return
+	}
+	parsed, found := parseLimits(header, time.Now())
+	if !found {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.current = parsed
+	l.known = true
+}
+
+func (l *limits) get() (Limits, bool) {
+	if l == nil {
+		return Limits{}, false
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current, l.known
+}
+
+// observeResponse records the limits reported by a response of the Vault client
+func (l *limits) observeResponse(resp *vault.Response) {
+	if resp != nil && resp.Response != nil {
+		l.observe(resp.Header)
+	}
+}
+
+// Limits returns the rate limits reported by the latest Cerberus response. ok is false
+// if no response reported any. Secret requests use the Vault client and are only observed
+// by a client that pauses for limits, see WithLimitPause
+func (c *Client) Limits() (current Limits, ok bool) {
+	return c.limits.get()
+}
+
+// WithLimitPause returns a shallow copy of the client that pauses before a request while
+// the server reports threshold or fewer remaining requests, until the limits reset. Secret
+// requests are paced as well, and the limits they report are observed. It keeps bulk
+// operations such as Browse or ExportSnapshot from running into 429 responses
+func (c *Client) WithLimitPause(threshold int) *Client {
+	scoped := c.copy()
+	scoped.limitPause = threshold
+	scoped.pauseForLimits = true
+	return scoped
+}
+
+// pace waits for the limits if the client pauses for them
+func (c *Client) pace(ctx context.Context) error {
+	if !c.pauseForLimits {
+		return nil
+	}
+	return c.WaitForLimits(ctx, c.limitPause)
+}
+
+// WaitForLimits blocks while the server reports that threshold or fewer requests remain,
+// until the limits reset or ctx is done. It returns straight away if the limits are unknown
+// or their reset time has passed, and can be used to pace custom bulk loops
+func (c *Client) WaitForLimits(ctx context.Context, threshold int) error {
+	current, ok := c.Limits()
+	if !ok || current.Remaining < 0 || current.Remaining > threshold || current.Reset.IsZero() {
+		return nil
+	}
+	wait := time.Until(current.Reset)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
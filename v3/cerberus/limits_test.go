@@ -0,0 +1,169 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseLimits(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	Convey("Parsing rate limit headers", t, func() {
+		Convey("Should read the X-RateLimit headers with a reset in seconds", func() {
+			header := http.Header{}
+			header.Set("X-RateLimit-Limit", "100")
+			header.Set("X-RateLimit-Remaining", "42")
+			header.Set("X-RateLimit-Reset", "30")
+			parsed, ok := parseLimits(header, now)
+			So(ok, ShouldBeTrue)
+			So(parsed.Limit, ShouldEqual, 100)
+			So(parsed.Remaining, ShouldEqual, 42)
+			So(parsed.Reset, ShouldEqual, now.Add(30*time.Second))
+			So(parsed.Observed, ShouldEqual, now)
+		})
+
+		Convey("Should read the RateLimit headers with a reset timestamp", func() {
+			header := http.Header{}
+			header.Set("RateLimit-Remaining", "0")
+			header.Set("RateLimit-Reset", "1600000120")
+			parsed, ok := parseLimits(header, now)
+			So(ok, ShouldBeTrue)
+			So(parsed.Limit, ShouldEqual, -1)
+			So(parsed.Remaining, ShouldEqual, 0)
+			So(parsed.Reset, ShouldEqual, now.Add(2*time.Minute))
+		})
+
+		Convey("Should report responses without limits", func() {
+			header := http.Header{}
+			header.Set("X-RateLimit-Remaining", "lots")
+			_, ok := parseLimits(header, now)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestLimits(t *testing.T) {
+	var remaining int
+	var reset string
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if remaining >= 0 {
+			w.Header().Set("X-RateLimit-Limit", "10")
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-RateLimit-Reset", reset)
+		}
+		w.WriteHeader(http.StatusOK)
+		if strings.HasPrefix(r.URL.Path, "/v1/secret/") {
+			w.Write([]byte(`{"data": {}}`))
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client talking to a rate limited server", t, func() {
+		calls = 0
+		remaining = 5
+		reset = "60"
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+
+		Convey("Should not know the limits before a request", func() {
+			_, ok := cl.Limits()
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Should expose the limits of the latest response to all copies", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			current, ok := cl.WithNamespace("team-b").Limits()
+			So(ok, ShouldBeTrue)
+			So(current.Limit, ShouldEqual, 10)
+			So(current.Remaining, ShouldEqual, 5)
+			So(current.Reset, ShouldHappenWithin, time.Minute+time.Second, time.Now())
+		})
+
+		Convey("Should keep the limits when a response has none", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			remaining = -1
+			_, err = cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			current, ok := cl.Limits()
+			So(ok, ShouldBeTrue)
+			So(current.Remaining, ShouldEqual, 5)
+		})
+
+		Convey("Should pause once the threshold is reached", func() {
+			remaining = 1
+			reset = "1"
+			paused := cl.WithLimitPause(1)
+			_, err := paused.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			start := time.Now()
+			_, err = paused.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(time.Since(start), ShouldBeGreaterThan, 500*time.Millisecond)
+			So(calls, ShouldEqual, 2)
+		})
+
+		Convey("Should observe and pace secret requests when pausing", func() {
+			remaining = 1
+			reset = "1"
+			_, err := cl.Secret().Read("app/sdb/a")
+			So(err, ShouldBeNil)
+			_, ok := cl.Limits()
+			So(ok, ShouldBeFalse)
+			paused := cl.WithLimitPause(1)
+			_, err = paused.Secret().Read("app/sdb/a")
+			So(err, ShouldBeNil)
+			current, ok := paused.Limits()
+			So(ok, ShouldBeTrue)
+			So(current.Remaining, ShouldEqual, 1)
+			start := time.Now()
+			_, err = paused.Secret().List("app/sdb/")
+			So(err, ShouldBeNil)
+			So(time.Since(start), ShouldBeGreaterThan, 500*time.Millisecond)
+			So(calls, ShouldEqual, 3)
+		})
+
+		Convey("Should not pause above the threshold", func() {
+			paused := cl.WithLimitPause(1)
+			_, err := paused.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			start := time.Now()
+			_, err = paused.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(time.Since(start), ShouldBeLessThan, 500*time.Millisecond)
+		})
+
+		Convey("Should stop waiting when the context is done", func() {
+			remaining = 0
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			So(cl.WaitForLimits(ctx, 0), ShouldResemble, context.DeadlineExceeded)
+		})
+	})
+}
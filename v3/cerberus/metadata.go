@@ -21,7 +21,6 @@ import (
 	"net/http"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
-	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
 )
 
 // Metadata is a subclient for accessing the metadata endpoint
@@ -29,14 +28,14 @@ type Metadata struct {
 	c *Client
 }
 
-// MetadataOpts is used for passing pagination values to the list function
-type MetadataOpts struct {
-	Limit  uint
-	Offset uint
-}
+// MetadataOpts is used for passing pagination values to the list function. It is the
+// same type as api.PageOpts, so the result of Page().NextOpts() can be passed to List
+type MetadataOpts = api.PageOpts
 
 var metadataBasePath = "/v1/metadata"
 
+var restoreBasePath = "/v1/restore-sdb"
+
 // List returns a MetadataResponse which is a wrapper containing pagination data and an array of metadata objects
 func (m *Metadata) List(opts MetadataOpts) (*api.MetadataResponse, error) {
 	// Set the limit opt to default if it isn't set
@@ -47,7 +46,9 @@ func (m *Metadata) List(opts MetadataOpts) (*api.MetadataResponse, error) {
 	var params = map[string]string{}
 	params["limit"] = fmt.Sprintf("%d", opts.Limit)
 	params["offset"] = fmt.Sprintf("%d", opts.Offset)
-	resp, err := m.c.DoRequest(http.MethodGet, metadataBasePath, params, nil)
+	r := newRequestWithBody(http.MethodGet, metadataBasePath, params, "", nil)
+	r.kind = kindAdmin
+	resp, err := m.c.Do(r)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -55,9 +56,9 @@ func (m *Metadata) List(opts MetadataOpts) (*api.MetadataResponse, error) {
 		// Check if it is a bad request (improperly set params)
 		if resp != nil && resp.StatusCode == http.StatusBadRequest {
 			// Return the API error to the user
-			return nil, utils.ParseAPIError(resp.Body)
+			return nil, apiError(resp, "")
 		}
-		return nil, fmt.Errorf("Error while trying to get roles: %v", err)
+		return nil, fmt.Errorf("Error while trying to get roles: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("Error while trying to GET metadata. Got HTTP status code %d", resp.StatusCode)
@@ -69,3 +70,28 @@ func (m *Metadata) List(opts MetadataOpts) (*api.MetadataResponse, error) {
 	}
 	return metadataResp, nil
 }
+
+// Restore restores an SDB, including its permissions and any secrets in Data, from a metadata
+// backup such as one returned by List. Only admin tokens are allowed to do this, and not every
+// Cerberus deployment has the restore endpoint
+func (m *Metadata) Restore(metadata *api.SDBMetadata) error {
+	r, err := newRequest(http.MethodPut, restoreBasePath, nil, metadata)
+	if err != nil {
+		return err
+	}
+	r.kind = kindAdmin
+	resp, err := m.c.Do(r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			return apiError(resp, "restoring SDB metadata")
+		}
+		return fmt.Errorf("Error while restoring SDB metadata: %w", err)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return apiError(resp, "restoring SDB metadata")
+	}
+	return nil
+}
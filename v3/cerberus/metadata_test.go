@@ -159,3 +159,39 @@ func TestListMetadata(t *testing.T) {
 		})
 	})
 }
+
+func TestRestoreMetadata(t *testing.T) {
+	backup := &api.SDBMetadata{
+		Name:     "my-sdb",
+		Path:     "app/my-sdb/",
+		Category: "Applications",
+		Owner:    "Lst-owner",
+		Data: map[string]map[string]interface{}{
+			"app/my-sdb/config": {"password": "hunter2"},
+		},
+	}
+
+	Convey("A valid call to Restore", t, WithServer(http.StatusNoContent, false, "/v1/restore-sdb", http.MethodPut, `"data":{"app/my-sdb/config":{"password":"hunter2"}}`, nil, nil, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should send the backup including its secrets", func() {
+			So(cl.Metadata().Restore(backup), ShouldBeNil)
+		})
+	}))
+
+	Convey("A Restore with a non-admin token", t, WithTestServer(http.StatusForbidden, "/v1/restore-sdb", http.MethodPut, errorResponse, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the API error", func() {
+			So(cl.Metadata().Restore(backup), ShouldResemble, expectedError)
+		})
+	}))
+
+	Convey("A Restore on a deployment without the endpoint", t, WithTestServer(http.StatusNotFound, "/v1/restore-sdb", http.MethodPut, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should error", func() {
+			So(cl.Metadata().Restore(backup), ShouldNotBeNil)
+		})
+	}))
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"sort"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// SDBOrder is the order of the SDBs returned by SDB().ListSorted
+type SDBOrder int
+
+const (
+	// SDBOrderServer keeps the order of the server, which can change between calls
+	SDBOrderServer SDBOrder = iota
+	// SDBOrderName sorts by name
+	SDBOrderName
+	// SDBOrderPath sorts by path
+	SDBOrderPath
+	// SDBOrderCategory sorts by category ID, and by name within a category
+	SDBOrderCategory
+)
+
+// SortSDBs sorts sdbs in place. SDBs that are equal in the given order are sorted by path and
+// then by ID, so the result is the same for every call with the same SDBs, whatever order the
+// server returned them in
+func SortSDBs(sdbs []*api.SafeDepositBox, order SDBOrder) {
+	if order == SDBOrderServer {
+		return
+	}
+	sort.SliceStable(sdbs, func(i, j int) bool {
+		a, b := sdbs[i], sdbs[j]
+		switch order {
+		case SDBOrderName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case SDBOrderCategory:
+			if a.CategoryID != b.CategoryID {
+				return a.CategoryID < b.CategoryID
+			}
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.ID < b.ID
+	})
+}
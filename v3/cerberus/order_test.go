@@ -0,0 +1,57 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSortSDBs(t *testing.T) {
+	web := &api.SafeDepositBox{ID: "1", Name: "Web", Path: "app/web", CategoryID: "app"}
+	api2 := &api.SafeDepositBox{ID: "2", Name: "API", Path: "app/api", CategoryID: "app"}
+	shared := &api.SafeDepositBox{ID: "3", Name: "Keys", Path: "shared/keys", CategoryID: "shared"}
+	renamed := &api.SafeDepositBox{ID: "4", Name: "Keys", Path: "app/keys", CategoryID: "app"}
+	sorted := func(order SDBOrder, sdbs ...*api.SafeDepositBox) []*api.SafeDepositBox {
+		SortSDBs(sdbs, order)
+		return sdbs
+	}
+
+	Convey("Sorting SDBs", t, func() {
+		Convey("Should keep the server order", func() {
+			So(sorted(SDBOrderServer, web, shared, api2), ShouldResemble, []*api.SafeDepositBox{web, shared, api2})
+		})
+
+		Convey("Should sort by name, then by path", func() {
+			So(sorted(SDBOrderName, shared, web, renamed, api2), ShouldResemble, []*api.SafeDepositBox{api2, renamed, shared, web})
+		})
+
+		Convey("Should sort by path", func() {
+			So(sorted(SDBOrderPath, shared, web, renamed, api2), ShouldResemble, []*api.SafeDepositBox{api2, renamed, web, shared})
+		})
+
+		Convey("Should sort by category, then by name", func() {
+			So(sorted(SDBOrderCategory, shared, web, renamed, api2), ShouldResemble, []*api.SafeDepositBox{api2, renamed, web, shared})
+		})
+
+		Convey("Should give the same order whatever the server order", func() {
+			So(sorted(SDBOrderName, web, renamed, shared, api2), ShouldResemble, sorted(SDBOrderName, api2, shared, renamed, web))
+		})
+	})
+}
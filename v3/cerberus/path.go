@@ -0,0 +1,57 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// escapeID appends a single path segment (such as an SDB ID) to base. The segment is
+// escaped, so a '/', '?', '#' or space in it can't change the meaning of the path
+func escapeID(base, id string) string {
+	return base + "/" + url.PathEscape(id)
+}
+
+// escapePath appends a slash separated path (such as a secure file path) to base.
+// The path is cleaned the same way path.Join would clean it and then each segment
+// is escaped on its own, so only the '/' characters act as separators
+func escapePath(base, p string) string {
+	cleaned := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if cleaned == "" {
+		return base
+	}
+	segments := strings.Split(cleaned, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return base + "/" + strings.Join(segments, "/")
+}
+
+// setPath sets the path of u. Paths built with escapeID or escapePath are
+// already escaped, so they are kept as the raw path to avoid escaping them twice
+func setPath(u *url.URL, p string) {
+	unescaped, err := url.PathUnescape(p)
+	if err != nil || unescaped == p {
+		u.Path = p
+		u.RawPath = ""
+		return
+	}
+	u.Path = unescaped
+	u.RawPath = p
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEscapeID(t *testing.T) {
+	Convey("An ID with reserved characters", t, func() {
+		p := escapeID(sdbBasePath, "my id/../?a=b#frag")
+		Convey("Should be escaped as a single segment", func() {
+			So(p, ShouldEqual, "/v2/safe-deposit-box/my%20id%2F..%2F%3Fa=b%23frag")
+		})
+	})
+	Convey("A plain ID", t, func() {
+		Convey("Should not be changed", func() {
+			So(escapeID(sdbBasePath, "abc-123"), ShouldEqual, "/v2/safe-deposit-box/abc-123")
+		})
+	})
+}
+
+func TestEscapePath(t *testing.T) {
+	Convey("A path with reserved characters", t, func() {
+		p := escapePath(secureFileBasePath, "/app/my sdb/file#1?.txt")
+		Convey("Should escape each segment but keep the separators", func() {
+			So(p, ShouldEqual, "/v1/secure-file/app/my%20sdb/file%231%3F.txt")
+		})
+	})
+	Convey("A path with dot segments", t, func() {
+		Convey("Should be cleaned", func() {
+			So(escapePath(secureFileBasePath, "app/../../etc//passwd"), ShouldEqual, "/v1/secure-file/etc/passwd")
+		})
+	})
+	Convey("An empty path", t, func() {
+		Convey("Should return the base", func() {
+			So(escapePath(secureFileListBasePath, ""), ShouldEqual, secureFileListBasePath)
+		})
+	})
+}
+
+func TestSetPath(t *testing.T) {
+	Convey("An escaped path", t, func() {
+		u := &url.URL{Scheme: "https", Host: "example.com"}
+		setPath(u, "/v2/safe-deposit-box/a%2Fb%3F")
+		Convey("Should be sent as is", func() {
+			So(u.Path, ShouldEqual, "/v2/safe-deposit-box/a/b?")
+			So(u.String(), ShouldEqual, "https://example.com/v2/safe-deposit-box/a%2Fb%3F")
+		})
+	})
+	Convey("An unescaped path", t, func() {
+		u := &url.URL{Scheme: "https", Host: "example.com"}
+		setPath(u, "/v1/blah blah")
+		Convey("Should be escaped once", func() {
+			So(u.String(), ShouldEqual, "https://example.com/v1/blah%20blah")
+		})
+	})
+}
+
+func TestHostileNames(t *testing.T) {
+	var requested string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.EscapedPath()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+
+	Convey("Deleting an SDB with a hostile ID", t, func() {
+		err := cl.SDB().Delete("a b#c?d/e")
+		Convey("Should request the escaped ID", func() {
+			So(err, ShouldBeNil)
+			So(requested, ShouldEqual, "/v2/safe-deposit-box/a%20b%23c%3Fd%2Fe")
+		})
+	})
+	Convey("Uploading a secure file with a hostile name", t, func() {
+		err := cl.SecureFile().Put("app/sdb/my file#1?.txt", "my file#1?.txt", bytes.NewBufferString("hi"))
+		Convey("Should request the escaped path", func() {
+			So(err, ShouldBeNil)
+			So(requested, ShouldEqual, "/v1/secure-file/app/sdb/my%20file%231%3F.txt")
+		})
+	})
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// PermissionFormat is the output format of ExportPermissions
+type PermissionFormat string
+
+var (
+	// PermissionsCSV writes the permissions as CSV with a header row
+	PermissionsCSV PermissionFormat = "csv"
+	// PermissionsJSON writes the permissions as a JSON array
+	PermissionsJSON PermissionFormat = "json"
+)
+
+// permissionsHeader is the header row of the CSV export
+var permissionsHeader = []string{"sdb", "path", "principal", "principal_type", "role", "last_updated_ts", "last_updated_by"}
+
+// Permissions walks the metadata of every SDB and returns one entry per permission, including
+// the owner group of each SDB. Entries are sorted by SDB path, principal type and principal.
+// This requires an admin token
+func (m *Metadata) Permissions() ([]api.Permission, error) {
+	permissions := []api.Permission{}
+	opts := MetadataOpts{}
+	for {
+		resp, err := m.List(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, sdb := range resp.Metadata {
+			permissions = append(permissions, sdbPermissions(sdb)...)
+		}
+		next, ok := resp.Page().NextOpts()
+		if !ok {
+			break
+		}
+		opts = next
+	}
+	sort.SliceStable(permissions, func(i, j int) bool {
+		a, b := permissions[i], permissions[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.PrincipalType != b.PrincipalType {
+			// User groups, including the owner, come before IAM principals
+			return a.PrincipalType > b.PrincipalType
+		}
+		return a.Principal < b.Principal
+	})
+	return permissions, nil
+}
+
+// ExportPermissions writes the permissions of every SDB to w in the given format. This is the
+// same as writing the result of Permissions with WritePermissions
+func (m *Metadata) ExportPermissions(w io.Writer, format PermissionFormat) error {
+	permissions, err := m.Permissions()
+	if err != nil {
+		return err
+	}
+	return WritePermissions(w, format, permissions)
+}
+
+// WritePermissions writes permissions to w as CSV or JSON
+func WritePermissions(w io.Writer, format PermissionFormat, permissions []api.Permission) error {
+	switch format {
+	case PermissionsCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(permissionsHeader); err != nil {
+			return err
+		}
+		for _, p := range permissions {
+			row := []string{p.SDB, p.Path, p.Principal, string(p.PrincipalType), p.Role,
+				p.LastUpdated.Format(time.RFC3339), p.LastUpdatedBy}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case PermissionsJSON:
+		return json.NewEncoder(w).Encode(permissions)
+	default:
+		return fmt.Errorf("Unknown permission format %q", format)
+	}
+}
+
+// sdbPermissions flattens the owner and permissions of an SDB
+func sdbPermissions(sdb api.SDBMetadata) []api.Permission {
+	permission := func(principal string, principalType api.PrincipalType, role string) api.Permission {
+		return api.Permission{
+			SDB:           sdb.Name,
+			Path:          sdb.Path,
+			Principal:     principal,
+			PrincipalType: principalType,
+			Role:          role,
+			LastUpdated:   sdb.LastUpdated,
+			LastUpdatedBy: sdb.LastUpdatedBy,
+		}
+	}
+	permissions := []api.Permission{}
+	if sdb.Owner != "" {
+		permissions = append(permissions, permission(sdb.Owner, api.PrincipalUserGroup, "owner"))
+	}
+	for group, role := range sdb.UserGroupPermissions {
+		permissions = append(permissions, permission(group, api.PrincipalUserGroup, role))
+	}
+	for arn, role := range sdb.IAMRolePermissions {
+		permissions = append(permissions, permission(arn, api.PrincipalIAM, role))
+	}
+	return permissions
+}
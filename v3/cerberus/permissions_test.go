@@ -0,0 +1,123 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var pagedMetadata = []string{`{
+    "has_next": true,
+    "next_offset": 1,
+    "limit": 1,
+    "offset": 0,
+    "safe_deposit_box_metadata": [{
+        "name": "shared box",
+        "path": "shared/shared-box/",
+        "owner": "Lst-Owners",
+        "last_updated_ts": "2017-01-04T23:18:40-08:00",
+        "last_updated_by": "someone",
+        "user_group_permissions": {},
+        "iam_role_permissions": {"arn:aws:iam::111111111:role/reader": "read"}
+    }]
+}`, `{
+    "has_next": false,
+    "limit": 1,
+    "offset": 1,
+    "safe_deposit_box_metadata": [{
+        "name": "app box",
+        "path": "app/app-box/",
+        "owner": "Lst-Owners",
+        "last_updated_ts": "2017-01-04T23:18:40-08:00",
+        "last_updated_by": "someone",
+        "user_group_permissions": {"Lst-Writers": "write"},
+        "iam_role_permissions": {}
+    }]
+}`}
+
+func TestPermissions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("offset") == "1" {
+			fmt.Fprint(w, pagedMetadata[1])
+			return
+		}
+		fmt.Fprint(w, pagedMetadata[0])
+	}))
+	defer ts.Close()
+
+	Convey("Metadata across several pages", t, func() {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return a sorted permission for each owner and grant", func() {
+			permissions, err := cl.Metadata().Permissions()
+			So(err, ShouldBeNil)
+			So(permissions, ShouldHaveLength, 4)
+			So(permissions[0], ShouldResemble, api.Permission{
+				SDB:           "app box",
+				Path:          "app/app-box/",
+				Principal:     "Lst-Owners",
+				PrincipalType: api.PrincipalUserGroup,
+				Role:          "owner",
+				LastUpdated:   metadataTime,
+				LastUpdatedBy: "someone",
+			})
+			So(permissions[1].Principal, ShouldEqual, "Lst-Writers")
+			So(permissions[2].Principal, ShouldEqual, "Lst-Owners")
+			So(permissions[3].Principal, ShouldEqual, "arn:aws:iam::111111111:role/reader")
+			So(permissions[3].PrincipalType, ShouldEqual, api.PrincipalIAM)
+		})
+		Convey("Should export them as CSV", func() {
+			var buf bytes.Buffer
+			So(cl.Metadata().ExportPermissions(&buf, PermissionsCSV), ShouldBeNil)
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			So(lines, ShouldHaveLength, 5)
+			So(lines[0], ShouldEqual, "sdb,path,principal,principal_type,role,last_updated_ts,last_updated_by")
+			So(lines[2], ShouldEqual, "app box,app/app-box/,Lst-Writers,user_group,write,2017-01-04T23:18:40-08:00,someone")
+		})
+		Convey("Should export them as JSON", func() {
+			var buf bytes.Buffer
+			So(cl.Metadata().ExportPermissions(&buf, PermissionsJSON), ShouldBeNil)
+			permissions := []api.Permission{}
+			So(json.Unmarshal(buf.Bytes(), &permissions), ShouldBeNil)
+			So(permissions, ShouldHaveLength, 4)
+			So(permissions[1].Role, ShouldEqual, "write")
+		})
+		Convey("Should error on an unknown format", func() {
+			So(cl.Metadata().ExportPermissions(&bytes.Buffer{}, "xml"), ShouldNotBeNil)
+		})
+	})
+
+	Convey("A failing metadata call", t, WithTestServer(http.StatusForbidden, "/v1/metadata", http.MethodGet, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should error", func() {
+			permissions, err := cl.Metadata().Permissions()
+			So(err, ShouldNotBeNil)
+			So(permissions, ShouldBeNil)
+		})
+	}))
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Operation describes a mutating request that is about to be sent, for a Precheck to decide
+// on
+type Operation struct {
+	// Method is the HTTP method for API requests, or "write" or "delete" for secrets
+	Method string
+	// Path is the API path, or the secret path including the "secret/" prefix
+	Path string
+	// Namespace is the namespace the request is made in, if any
+	Namespace string
+	// URL is the Cerberus URL, so a check can apply different rules to each environment
+	URL *url.URL
+}
+
+// Precheck is a local policy check made before every mutating request. Returning an error
+// stops the request from being sent, and the caller gets a *PrecheckError wrapping it. Read
+// requests aren't checked
+type Precheck func(Operation) error
+
+// PrecheckError is returned for a request that a Precheck stopped
+type PrecheckError struct {
+	Operation Operation
+	Err       error
+}
+
+func (e *PrecheckError) Error() string {
+	return fmt.Sprintf("Precheck blocked %s %s: %v", e.Operation.Method, e.Operation.Path, e.Err)
+}
+
+// Unwrap returns the error of the Precheck
+func (e *PrecheckError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorPathNotAllowed is returned by the Precheck from AllowPaths for paths it doesn't allow
+var ErrorPathNotAllowed = fmt.Errorf("Path is not allowed by the write policy")
+
+// WithPrecheck returns a shallow copy of the client that calls check before every mutating
+// request: API requests other than GET, HEAD and OPTIONS, and secret writes and deletes. It is
+// meant as a guard rail, such as keeping development tooling from writing to production. A
+// client can have several prechecks, which are called in the order they were added
+func (c *Client) WithPrecheck(check Precheck) *Client {
+	scoped := c.copy()
+	scoped.prechecks = append(append([]Precheck{}, c.prechecks...), check)
+	return scoped
+}
+
+// AllowPaths returns a Precheck that only allows paths matching one of the patterns. Patterns
+// use the syntax of path.Match, so "*" doesn't match a "/", and a pattern ending in "/" also
+// matches every path under it. Secret paths start with "secret/", for example
+// "secret/app/my-sdb-dev/". Paths with "." or ".." segments are never allowed, as they could
+// resolve to a path outside of the patterns
+func AllowPaths(patterns ...string) Precheck {
+	return func(op Operation) error {
+		p := strings.TrimPrefix(op.Path, "/")
+		if hasDotSegment(p) {
+			return ErrorPathNotAllowed
+		}
+		for _, pattern := range patterns {
+			pattern = strings.TrimPrefix(pattern, "/")
+			if strings.HasSuffix(pattern, "/") {
+				if matchesPrefix(pattern, p) {
+					return nil
+				}
+				continue
+			}
+			if ok, _ := path.Match(pattern, p); ok {
+				return nil
+			}
+		}
+		return ErrorPathNotAllowed
+	}
+}
+
+// hasDotSegment returns whether p has a "." or ".." segment
+func hasDotSegment(p string) bool {
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPrefix returns true if p is under the directory pattern, which ends in "/"
+func matchesPrefix(pattern, p string) bool {
+	depth := strings.Count(pattern, "/")
+	segments := strings.SplitAfterN(p, "/", depth+1)
+	if len(segments) <= depth {
+		return false
+	}
+	dir := strings.Join(segments[:depth], "")
+	ok, _ := path.Match(pattern, dir)
+	return ok
+}
+
+// precheck runs the prechecks of the client for a request with the given method and path
+func (c *Client) precheck(method, p, namespace string) error {
+	if len(c.prechecks) == 0 {
+		return nil
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	if namespace == "" {
+		namespace = c.currentNamespace()
+	}
+	op := Operation{Method: method, Path: p, Namespace: namespace, URL: c.CerberusURL}
+	for _, check := range c.prechecks {
+		if err := check(op); err != nil {
+			return &PrecheckError{Operation: op, Err: err}
+		}
+	}
+	return nil
+}
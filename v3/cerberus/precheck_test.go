@@ -0,0 +1,117 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPrecheck(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	Convey("A client with prechecks", t, func() {
+		requests = 0
+		var checked []Operation
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithPrecheck(func(op Operation) error {
+			checked = append(checked, op)
+			return nil
+		}).WithPrecheck(AllowPaths("secret/app/dev-*/", "/v2/safe-deposit-box/*"))
+
+		Convey("Should allow writes to allowed paths", func() {
+			_, err := cl.Secret().Write("app/dev-sdb/config", map[string]interface{}{"foo": "bar"})
+			So(err, ShouldBeNil)
+			_, err = cl.DoRequest(http.MethodDelete, "/v2/safe-deposit-box/1234", nil, nil)
+			So(err, ShouldBeNil)
+			So(requests, ShouldEqual, 2)
+			So(checked, ShouldHaveLength, 2)
+			So(checked[0].Method, ShouldEqual, "write")
+			So(checked[0].Path, ShouldEqual, "secret/app/dev-sdb/config")
+			So(checked[0].URL.String(), ShouldEqual, ts.URL)
+			So(checked[1].Method, ShouldEqual, http.MethodDelete)
+		})
+
+		Convey("Should block writes to other paths without sending them", func() {
+			_, err := cl.WithNamespace("team").Secret().Delete("app/prod-sdb/config")
+			So(errors.Is(err, ErrorPathNotAllowed), ShouldBeTrue)
+			var precheckErr *PrecheckError
+			So(errors.As(err, &precheckErr), ShouldBeTrue)
+			So(precheckErr.Operation.Method, ShouldEqual, "delete")
+			So(precheckErr.Operation.Namespace, ShouldEqual, "team")
+			_, err = cl.DoRequest(http.MethodPut, "/v2/safe-deposit-box/1234/nested", nil, nil)
+			So(errors.Is(err, ErrorPathNotAllowed), ShouldBeTrue)
+			So(requests, ShouldEqual, 0)
+		})
+
+		Convey("Should not check reads", func() {
+			_, err := cl.DoRequest(http.MethodGet, "/v1/metadata", nil, nil)
+			So(err, ShouldBeNil)
+			So(checked, ShouldBeEmpty)
+		})
+
+		Convey("Should not change the client it was made from", func() {
+			_, err := base.Secret().Write("app/prod-sdb/config", map[string]interface{}{"foo": "bar"})
+			So(err, ShouldBeNil)
+			So(checked, ShouldBeEmpty)
+		})
+
+		Convey("Should stop at the first failing check", func() {
+			blocked := base.WithPrecheck(func(Operation) error { return fmt.Errorf("read only") }).
+				WithPrecheck(func(op Operation) error {
+					checked = append(checked, op)
+					return nil
+				})
+			_, err := blocked.Secret().Write("app/dev-sdb/config", nil)
+			So(err.Error(), ShouldEqual, "Precheck blocked write secret/app/dev-sdb/config: read only")
+			So(checked, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestAllowPaths(t *testing.T) {
+	check := AllowPaths("secret/app/*-dev/", "/v1/secure-file/app/shared/*")
+	allowed := func(p string) bool {
+		return check(Operation{Path: p}) == nil
+	}
+	Convey("AllowPaths", t, func() {
+		So(allowed("secret/app/billing-dev/config"), ShouldBeTrue)
+		So(allowed("secret/app/billing-dev/nested/config"), ShouldBeTrue)
+		So(allowed("secret/app/billing-dev"), ShouldBeFalse)
+		So(allowed("secret/app/billing-prod/config"), ShouldBeFalse)
+		So(allowed("/v1/secure-file/app/shared/cert.pem"), ShouldBeTrue)
+		So(allowed("/v1/secure-file/app/shared/nested/cert.pem"), ShouldBeFalse)
+		So(AllowPaths()(Operation{Path: "secret/app/foo/bar"}), ShouldEqual, ErrorPathNotAllowed)
+		Convey("Should not allow leaving an allowed directory", func() {
+			So(allowed("secret/app/billing-dev/../billing-prod/config"), ShouldBeFalse)
+			So(allowed("secret/app/billing-dev/./config"), ShouldBeFalse)
+			So(allowed("/v1/secure-file/app/shared/../../private/cert.pem"), ShouldBeFalse)
+			So(allowed("secret/app/billing-dev/config..bak"), ShouldBeTrue)
+		})
+	})
+}
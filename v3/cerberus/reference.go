@@ -0,0 +1,122 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// referencePrefix starts a secret value that refers to a key of another secret, as in
+// "ref+cerberus://shared/ca#cert"
+const referencePrefix = "ref+cerberus://"
+
+// DefaultReferenceDepth is how many references in a row are followed when resolving a value,
+// unless WithSecretReferences is given another limit
+const DefaultReferenceDepth = 5
+
+// ErrorInvalidReference is returned when a secret reference doesn't have a path and a key
+var ErrorInvalidReference = fmt.Errorf("Invalid secret reference")
+
+// ErrorReferenceCycle is returned when secret references point back to themselves
+var ErrorReferenceCycle = fmt.Errorf("Secret references form a cycle")
+
+// ErrorReferenceDepth is returned when resolving a secret reference follows more references
+// than the limit
+var ErrorReferenceDepth = fmt.Errorf("Secret references are nested too deeply")
+
+// WithSecretReferences returns a shallow copy of the client where Read, and the methods built
+// on it, expand secret references. A value like "ref+cerberus://shared/ca#cert" is replaced
+// with the "cert" key of the secret at "shared/ca", so a shared secret can be referenced
+// instead of copied into every SDB. References in the referenced value are followed up to
+// maxDepth times, or DefaultReferenceDepth if maxDepth is 0 or less. A reference to a missing
+// secret or key returns an error wrapping ErrorSecretNotFound. The secret cache keeps the
+// secrets as stored, with their references
+func (c *Client) WithSecretReferences(maxDepth int) *Client {
+	scoped := c.copy()
+	if maxDepth <= 0 {
+		maxDepth = DefaultReferenceDepth
+	}
+	scoped.referenceDepth = maxDepth
+	return scoped
+}
+
+// expand returns a copy of secret with the references in its data resolved
+func (s *Secret) expand(secret *vault.Secret) (*vault.Secret, error) {
+	expanded := *secret
+	expanded.Data = make(map[string]interface{}, len(secret.Data))
+	for key, value := range secret.Data {
+		if ref, ok := value.(string); ok && strings.HasPrefix(ref, referencePrefix) {
+			resolved, err := s.resolve(ref, nil)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to resolve %s: %w", key, err)
+			}
+			value = resolved
+		}
+		expanded.Data[key] = value
+	}
+	return &expanded, nil
+}
+
+// resolve returns the value a reference points to, following further references. seen are
+// the references followed to get here
+func (s *Secret) resolve(ref string, seen []string) (interface{}, error) {
+	for _, previous := range seen {
+		if previous == ref {
+			return nil, fmt.Errorf("%s: %w", strings.Join(append(seen, ref), " -> "), ErrorReferenceCycle)
+		}
+	}
+	if len(seen) >= s.referenceDepth {
+		return nil, fmt.Errorf("%s: %w", ref, ErrorReferenceDepth)
+	}
+	path, key, ok := parseReference(ref)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", ref, ErrorInvalidReference)
+	}
+	secret, err := s.read(path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if secret != nil {
+		value, ok = secret.Data[key]
+	}
+	if secret == nil || !ok {
+		return nil, fmt.Errorf("%s: %w", ref, ErrorSecretNotFound)
+	}
+	if next, isString := value.(string); isString && strings.HasPrefix(next, referencePrefix) {
+		return s.resolve(next, append(seen, ref))
+	}
+	return value, nil
+}
+
+// parseReference splits a reference into the secret path and key
+func parseReference(ref string) (path, key string, ok bool) {
+	path, key, ok = cut(strings.TrimPrefix(ref, referencePrefix), "#")
+	path = strings.Trim(path, "/")
+	return path, key, ok && path != "" && key != ""
+}
+
+// cut is strings.Cut, which needs a newer Go version than the module requires
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSecretReferences(t *testing.T) {
+	fake := &fakeSecrets{}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	Convey("A client that expands secret references", t, func() {
+		fake.secrets = map[string]map[string]interface{}{
+			"shared/ca":     {"cert": "-----BEGIN CERTIFICATE-----", "alias": "ref+cerberus://shared/ca#cert"},
+			"app/sdb/tls":   {"ca": "ref+cerberus://shared/ca#cert", "key": "a-key"},
+			"app/sdb/alias": {"ca": "ref+cerberus://shared/ca#alias"},
+			"app/sdb/cycle": {"a": "ref+cerberus://app/sdb/cycle#b", "b": "ref+cerberus://app/sdb/cycle#a"},
+			"app/sdb/bad":   {"ca": "ref+cerberus://shared/ca"},
+			"app/sdb/gone":  {"ca": "ref+cerberus://shared/missing#cert"},
+		}
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithSecretReferences(0)
+
+		Convey("Should replace references with the values they point to", func() {
+			secret, err := cl.Secret().Read("app/sdb/tls")
+			So(err, ShouldBeNil)
+			So(secret.Data["ca"], ShouldEqual, "-----BEGIN CERTIFICATE-----")
+			So(secret.Data["key"], ShouldEqual, "a-key")
+		})
+
+		Convey("Should follow references to references", func() {
+			secret, err := cl.Secret().Read("app/sdb/alias")
+			So(err, ShouldBeNil)
+			So(secret.Data["ca"], ShouldEqual, "-----BEGIN CERTIFICATE-----")
+		})
+
+		Convey("Should stop at the depth limit", func() {
+			_, err := base.WithSecretReferences(1).Secret().Read("app/sdb/alias")
+			So(errors.Is(err, ErrorReferenceDepth), ShouldBeTrue)
+		})
+
+		Convey("Should detect cycles", func() {
+			_, err := cl.Secret().Read("app/sdb/cycle")
+			So(errors.Is(err, ErrorReferenceCycle), ShouldBeTrue)
+		})
+
+		Convey("Should reject references without a key", func() {
+			_, err := cl.Secret().Read("app/sdb/bad")
+			So(errors.Is(err, ErrorInvalidReference), ShouldBeTrue)
+		})
+
+		Convey("Should return not found for a missing secret", func() {
+			_, err := cl.Secret().Read("app/sdb/gone")
+			So(errors.Is(err, ErrorSecretNotFound), ShouldBeTrue)
+		})
+
+		Convey("Should leave secrets alone without the option", func() {
+			secret, err := base.Secret().Read("app/sdb/tls")
+			So(err, ShouldBeNil)
+			So(secret.Data["ca"], ShouldEqual, "ref+cerberus://shared/ca#cert")
+		})
+
+		Convey("Should keep references in the secret cache", func() {
+			cached := cl.WithSecretCache(time.Minute)
+			secret, err := cached.Secret().Read("app/sdb/tls")
+			So(err, ShouldBeNil)
+			So(secret.Data["ca"], ShouldEqual, "-----BEGIN CERTIFICATE-----")
+			raw, _ := cached.secretCache.get(secretCacheKey("", "app/sdb/tls"))
+			So(raw.Data["ca"], ShouldEqual, "ref+cerberus://shared/ca#cert")
+		})
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/taskcluster/httpbackoff"
+)
+
+// RetryDecision is what a RetryClassifier decides to do with the result of an attempt
+type RetryDecision int
+
+const (
+	// RetryDefault keeps the default behavior, which retries connection errors and 5xx
+	// responses and nothing else
+	RetryDefault RetryDecision = iota
+	// RetryAttempt retries the request, if there is retry budget left
+	RetryAttempt
+	// RetryStop returns the result of the attempt without retrying
+	RetryStop
+)
+
+// RetryClassifier decides whether the result of an attempt is retried. resp is nil when the
+// request failed with err before a response was received, and err is nil when there is a
+// response. The body of resp must not be read
+type RetryClassifier func(resp *http.Response, err error) RetryDecision
+
+// WithRetryClassifier returns a shallow copy of the client that asks classify whether to retry
+// each attempt, e.g. to retry 502 responses from a proxy but never retry a 500 from Cerberus.
+// Attempts classified as RetryDefault are retried as before. Retries still stop when the retry
+// budget or the deadline of the request runs out, and requests without retries, see
+// WithNoRetry, are never retried. Secret requests use the CheckRetry setting of the Vault
+// client instead. Nil goes back to the default behavior
+func (c *Client) WithRetryClassifier(classify RetryClassifier) *Client {
+	scoped := c.copy()
+	scoped.retryClassifier = classify
+	return scoped
+}
+
+// classify returns the temporary and permanent errors for an attempt, as expected by the
+// retry client. A temporary error is retried, while a permanent one stops the retries
+func (c *Client) classify(resp *http.Response, err error) (tempErr error, permErr error) {
+	decision := RetryDefault
+	if c.retryClassifier != nil {
+		decision = c.retryClassifier(resp, err)
+	}
+	switch decision {
+	case RetryAttempt:
+		if err != nil {
+			return err, nil
+		}
+		return badResponseCode(resp.StatusCode), nil
+	case RetryStop:
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode/100 != 2 {
+			return nil, badResponseCode(resp.StatusCode)
+		}
+		return nil, nil
+	default:
+		// The retry client retries errors and 5xx responses by itself
+		return err, nil
+	}
+}
+
+// badResponseCode returns the error the retry client returns for a non-2xx response
+func badResponseCode(statusCode int) error {
+	return httpbackoff.BadHttpResponseCode{
+		HttpResponseCode: statusCode,
+		Message:          "HTTP response code " + strconv.Itoa(statusCode),
+	}
+}
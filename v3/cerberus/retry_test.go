@@ -0,0 +1,110 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRetryClassifier(t *testing.T) {
+	var calls int
+	var statuses []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+	// Retry 502s from the proxy and 429s, but never a 500 from Cerberus
+	classify := func(resp *http.Response, err error) RetryDecision {
+		if resp == nil {
+			return RetryDefault
+		}
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusTooManyRequests:
+			return RetryAttempt
+		case http.StatusInternalServerError:
+			return RetryStop
+		}
+		return RetryDefault
+	}
+
+	Convey("A client with a retry classifier", t, func() {
+		calls = 0
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		classified := cl.WithRetryClassifier(classify)
+
+		Convey("Should not retry responses classified as final", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusOK}
+			resp, err := classified.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusInternalServerError)
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("Should retry responses classified as retryable", func() {
+			statuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusOK}
+			resp, err := classified.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(calls, ShouldEqual, 3)
+		})
+
+		Convey("Should stop retrying when the budget runs out", func() {
+			statuses = []int{http.StatusTooManyRequests}
+			resp, err := classified.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusTooManyRequests)
+			So(calls, ShouldBeGreaterThan, 1)
+		})
+
+		Convey("Should keep the default for other responses", func() {
+			statuses = []int{http.StatusServiceUnavailable, http.StatusOK}
+			_, err := classified.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(calls, ShouldEqual, 2)
+
+			calls = 0
+			statuses = []int{http.StatusNotFound, http.StatusOK}
+			resp, err := classified.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldNotBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("Should not change the original client", func() {
+			statuses = []int{http.StatusInternalServerError, http.StatusOK}
+			_, err := cl.DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldBeNil)
+			So(calls, ShouldEqual, 2)
+		})
+
+		Convey("Should not retry when retries are disabled", func() {
+			statuses = []int{http.StatusTooManyRequests, http.StatusOK}
+			_, err := classified.WithNoRetry().DoRequest(http.MethodGet, "/v1/blah", map[string]string{}, nil)
+			So(err, ShouldNotBeNil)
+			So(calls, ShouldEqual, 1)
+		})
+	})
+}
@@ -19,6 +19,7 @@ package cerberus
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 )
@@ -30,14 +31,27 @@ type Role struct {
 
 var roleBasePath = "/v1/role"
 
-// List returns a list of roles that can be granted
+// ErrorRoleNotFound is returned when a role with the given name doesn't exist
+var ErrorRoleNotFound = fmt.Errorf("Unable to find role")
+
+// roleRequest is the body sent when creating or updating a role. Only the fields that
+// can be set by the client are included
+type roleRequest struct {
+	Name string `json:"name"`
+}
+
+func newRoleRequest(role *api.Role) *roleRequest {
+	return &roleRequest{Name: role.Name}
+}
+
+// List returns a list of roles that can be granted, in the order of the server
 func (r *Role) List() ([]*api.Role, error) {
 	resp, err := r.c.DoRequest(http.MethodGet, roleBasePath, map[string]string{}, nil)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("Error while trying to get roles: %v", err)
+		return nil, fmt.Errorf("Error while trying to get roles: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -50,3 +64,100 @@ func (r *Role) List() ([]*api.Role, error) {
 	}
 	return roleList, nil
 }
+
+// GetByName returns the role with the given name, such as "read", "write", or "owner".
+// The name is not case sensitive. Returns ErrorRoleNotFound if there is no such role
+func (r *Role) GetByName(name string) (*api.Role, error) {
+	roles, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		if strings.EqualFold(role.Name, name) {
+			return role, nil
+		}
+	}
+	return nil, ErrorRoleNotFound
+}
+
+// Create creates a new role with the Name of the given one and returns it.
+// Only admin tokens are allowed to do this
+func (r *Role) Create(role *api.Role) (*api.Role, error) {
+	created := &api.Role{}
+	resp, err := r.c.DoRequest(http.MethodPost, roleBasePath, map[string]string{}, newRoleRequest(role))
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		// Bad requests and non-admin tokens both come back with an API error
+		if resp != nil {
+			return nil, apiError(resp, "creating role")
+		}
+		return nil, fmt.Errorf("Error while creating role: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, apiError(resp, "creating role")
+	}
+	err = parseResponse(resp.Body, created)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update updates the role with the given ID and returns it.
+// Only admin tokens are allowed to do this
+func (r *Role) Update(id string, role *api.Role) (*api.Role, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrorRoleNotFound
+	}
+	updated := &api.Role{}
+	resp, err := r.c.DoRequest(http.MethodPut, escapeID(roleBasePath, id), map[string]string{}, newRoleRequest(role))
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, ErrorRoleNotFound
+			}
+			return nil, apiError(resp, "updating role")
+		}
+		return nil, fmt.Errorf("Error while updating role: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp, "updating role")
+	}
+	err = parseResponse(resp.Body, updated)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete deletes the role with the given ID.
+// Only admin tokens are allowed to do this
+func (r *Role) Delete(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ErrorRoleNotFound
+	}
+	resp, err := r.c.DoRequest(http.MethodDelete, escapeID(roleBasePath, id), map[string]string{}, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			if resp.StatusCode == http.StatusNotFound {
+				return ErrorRoleNotFound
+			}
+			return apiError(resp, "deleting role")
+		}
+		return fmt.Errorf("Error while deleting role: %w", err)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return apiError(resp, "deleting role")
+	}
+	return nil
+}
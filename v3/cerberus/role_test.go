@@ -97,3 +97,84 @@ func TestListRole(t *testing.T) {
 		})
 	})
 }
+
+func TestGetRoleByName(t *testing.T) {
+	Convey("A valid call to GetByName", t, WithTestServer(http.StatusOK, "/v1/role", http.MethodGet, listResponse, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the role", func() {
+			role, err := cl.Role().GetByName("READ")
+			So(err, ShouldBeNil)
+			So(role, ShouldResemble, expectedList[1])
+		})
+		Convey("Should error for an unknown role", func() {
+			role, err := cl.Role().GetByName("write")
+			So(err, ShouldEqual, ErrorRoleNotFound)
+			So(role, ShouldBeNil)
+		})
+	}))
+}
+
+var createdRole = `{
+    "id": "a800558e-faaa-11e5-a8a9-7fa3b294cd46",
+    "name": "auditor",
+    "created_ts": "2016-04-05T04:19:51Z",
+    "last_updated_ts": "2016-04-05T04:19:51Z",
+    "created_by": "admin",
+    "last_updated_by": "admin"
+}`
+
+func TestRoleWrites(t *testing.T) {
+	newRole := &api.Role{Name: "auditor"}
+
+	Convey("A call to Create", t, WithServer(http.StatusCreated, false, "/v1/role", http.MethodPost, `{"name":"auditor"}`, nil, nil, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should only send the writable fields", func() {
+			_, err := cl.Role().Create(newRole)
+			So(err, ShouldBeNil)
+		})
+	}))
+
+	Convey("A valid call to Create", t, WithTestServer(http.StatusCreated, "/v1/role", http.MethodPost, createdRole, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the created role", func() {
+			role, err := cl.Role().Create(newRole)
+			So(err, ShouldBeNil)
+			So(role.ID, ShouldEqual, "a800558e-faaa-11e5-a8a9-7fa3b294cd46")
+			So(role.Name, ShouldEqual, "auditor")
+		})
+	}))
+
+	Convey("A valid call to Update", t, WithTestServer(http.StatusOK, "/v1/role/a800558e", http.MethodPut, createdRole, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the updated role", func() {
+			role, err := cl.Role().Update("a800558e", newRole)
+			So(err, ShouldBeNil)
+			So(role.Name, ShouldEqual, "auditor")
+		})
+		Convey("Should error on an empty ID", func() {
+			role, err := cl.Role().Update("", newRole)
+			So(err, ShouldEqual, ErrorRoleNotFound)
+			So(role, ShouldBeNil)
+		})
+	}))
+
+	Convey("A valid call to Delete", t, WithTestServer(http.StatusNoContent, "/v1/role/a800558e", http.MethodDelete, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should not error", func() {
+			So(cl.Role().Delete("a800558e"), ShouldBeNil)
+		})
+	}))
+
+	Convey("A Delete with a non-admin token", t, WithTestServer(http.StatusForbidden, "/v1/role/a800558e", http.MethodDelete, errorResponse, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the API error", func() {
+			So(cl.Role().Delete("a800558e"), ShouldHaveSameTypeAs, api.ErrorResponse{})
+		})
+	}))
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"io"
+	"path"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// ScopedSDB is a view of a Client bound to a single SDB. Secret and secure file paths are
+// relative to the SDB, and SDB operations target that box, so services that only use their
+// own SDB don't have to build paths by hand
+type ScopedSDB struct {
+	c    *Client
+	path string
+}
+
+// ForSDB returns a view of the client bound to the SDB with the given path (e.g.
+// "app/my-sdb"). A trailing slash is optional. If the path is empty, every operation
+// returns ErrorSafeDepositBoxNotFound
+func (c *Client) ForSDB(sdbPath string) *ScopedSDB {
+	cleaned := strings.Trim(path.Clean("/"+sdbPath), "/")
+	if cleaned != "" {
+		cleaned += "/"
+	}
+	return &ScopedSDB{
+		c:    c,
+		path: cleaned,
+	}
+}
+
+// Path returns the path of the SDB, with a trailing slash
+func (s *ScopedSDB) Path() string {
+	return s.path
+}
+
+// join returns the full path of p inside the SDB. p is cleaned first, so ".." and leading
+// or doubled slashes can't move it outside of the SDB
+func (s *ScopedSDB) join(p string) (string, error) {
+	if s.path == "" {
+		return "", ErrorSafeDepositBoxNotFound
+	}
+	return s.path + strings.TrimPrefix(path.Clean("/"+p), "/"), nil
+}
+
+// id looks up the ID of the SDB
+func (s *ScopedSDB) id() (string, error) {
+	if s.path == "" {
+		return "", ErrorSafeDepositBoxNotFound
+	}
+	sdb, err := s.c.SDB().GetByPath(s.path)
+	if err != nil {
+		return "", err
+	}
+	return sdb.ID, nil
+}
+
+// Get returns the SDB
+func (s *ScopedSDB) Get() (*api.SafeDepositBox, error) {
+	id, err := s.id()
+	if err != nil {
+		return nil, err
+	}
+	return s.c.SDB().Get(id)
+}
+
+// Update updates the SDB. Any fields that are not null in the passed object will overwrite
+// any fields on the current object
+func (s *ScopedSDB) Update(updatedSDB *api.SafeDepositBox) (*api.SafeDepositBox, error) {
+	id, err := s.id()
+	if err != nil {
+		return nil, err
+	}
+	return s.c.SDB().Update(id, updatedSDB)
+}
+
+// Delete deletes the SDB
+func (s *ScopedSDB) Delete() error {
+	id, err := s.id()
+	if err != nil {
+		return err
+	}
+	return s.c.SDB().Delete(id)
+}
+
+// Grant gives a user group or IAM principal the named role on the SDB. See SDB.Grant
+func (s *ScopedSDB) Grant(principal, roleName string) (*api.SafeDepositBox, error) {
+	id, err := s.id()
+	if err != nil {
+		return nil, err
+	}
+	return s.c.SDB().Grant(id, principal, roleName)
+}
+
+// Secret returns a Secret client whose paths are relative to the SDB
+func (s *ScopedSDB) Secret() *ScopedSecret {
+	return &ScopedSecret{s: s}
+}
+
+// SecureFile returns a SecureFile client whose paths are relative to the SDB
+func (s *ScopedSDB) SecureFile() *ScopedSecureFile {
+	return &ScopedSecureFile{s: s}
+}
+
+// ScopedSecret is a Secret client bound to a single SDB. Paths are relative to the SDB
+type ScopedSecret struct {
+	s *ScopedSDB
+}
+
+// Delete deletes the secret at the given path
+func (r *ScopedSecret) Delete(p string) (*vault.Secret, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.Secret().Delete(full)
+}
+
+// List lists secrets at the given path. An empty path lists the root of the SDB
+func (r *ScopedSecret) List(p string) (*vault.Secret, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.Secret().List(full)
+}
+
+// Read returns the secret at the given path
+func (r *ScopedSecret) Read(p string) (*vault.Secret, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.Secret().Read(full)
+}
+
+// ReadIfChanged returns the secret at the given path unless it still has the given
+// version. See Secret.ReadIfChanged
+func (r *ScopedSecret) ReadIfChanged(p, lastVersion string) (*vault.Secret, string, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return r.s.c.Secret().ReadIfChanged(full, lastVersion)
+}
+
+// ReadOptional returns the secret at the given path and whether it exists. See
+// Secret.ReadOptional
+func (r *ScopedSecret) ReadOptional(p string) (*vault.Secret, bool, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, false, err
+	}
+	return r.s.c.Secret().ReadOptional(full)
+}
+
+// Write creates a new secret at the given path and returns the response of Vault
+func (r *ScopedSecret) Write(p string, data map[string]interface{}) (*vault.Secret, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.Secret().Write(full, data)
+}
+
+// WriteWithResult is the same as Write, but returns a WriteResult whose path includes the SDB
+// path
+func (r *ScopedSecret) WriteWithResult(p string, data map[string]interface{}) (*api.WriteResult, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.Secret().WriteWithResult(full, data)
+}
+
+// ScopedSecureFile is a SecureFile client bound to a single SDB. Paths are relative to the SDB
+type ScopedSecureFile struct {
+	s *ScopedSDB
+}
+
+// List returns a list of the secure files in the SDB
+func (r *ScopedSecureFile) List() (*api.SecureFilesResponse, error) {
+	if r.s.path == "" {
+		return nil, ErrorSafeDepositBoxNotFound
+	}
+	return r.s.c.SecureFile().List(r.s.path)
+}
+
+// ListPage returns a page of the secure files in the SDB
+func (r *ScopedSecureFile) ListPage(opts api.PageOpts) (*api.SecureFilesResponse, error) {
+	if r.s.path == "" {
+		return nil, ErrorSafeDepositBoxNotFound
+	}
+	return r.s.c.SecureFile().ListPage(r.s.path, opts)
+}
+
+// Get downloads the secure file at the given path into output
+func (r *ScopedSecureFile) Get(p string, output io.Writer) error {
+	full, err := r.s.join(p)
+	if err != nil {
+		return err
+	}
+	return r.s.c.SecureFile().Get(full, output)
+}
+
+// GetWithInfo is the same as Get, but also returns the DownloadInfo of the file
+func (r *ScopedSecureFile) GetWithInfo(p string, output io.Writer) (*api.DownloadInfo, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.SecureFile().GetWithInfo(full, output)
+}
+
+// Stat returns the DownloadInfo of the secure file at the given path without downloading it
+func (r *ScopedSecureFile) Stat(p string) (*api.DownloadInfo, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return nil, err
+	}
+	return r.s.c.SecureFile().Stat(full)
+}
+
+// Put uploads a secure file to the given path
+func (r *ScopedSecureFile) Put(p string, filename string, input io.Reader) error {
+	full, err := r.s.join(p)
+	if err != nil {
+		return err
+	}
+	return r.s.c.SecureFile().Put(full, filename, input)
+}
+
+// PutWithContentType uploads a secure file to the given path with the given MIME type.
+// See SecureFile.PutWithContentType
+func (r *ScopedSecureFile) PutWithContentType(p string, filename string, contentType string, input io.Reader) error {
+	full, err := r.s.join(p)
+	if err != nil {
+		return err
+	}
+	return r.s.c.SecureFile().PutWithContentType(full, filename, contentType, input)
+}
+
+// PutIfChanged uploads a secure file to the given path unless it has the same content.
+// See SecureFile.PutIfChanged
+func (r *ScopedSecureFile) PutIfChanged(p string, filename string, input io.Reader) (bool, error) {
+	full, err := r.s.join(p)
+	if err != nil {
+		return false, err
+	}
+	return r.s.c.SecureFile().PutIfChanged(full, filename, input)
+}
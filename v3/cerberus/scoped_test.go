@@ -0,0 +1,119 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestForSDB(t *testing.T) {
+	Convey("Scoping a client", t, func() {
+		cl, _ := NewClient(GenerateMockAuth("https://test.example.com", "a-cool-token", false, false), nil)
+		So(cl.ForSDB("app/my-sdb").Path(), ShouldEqual, "app/my-sdb/")
+		So(cl.ForSDB("/app/my-sdb/").Path(), ShouldEqual, "app/my-sdb/")
+		So(cl.ForSDB("").Path(), ShouldEqual, "")
+	})
+}
+
+func TestScopedSDB(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/safe-deposit-box":
+			w.Write([]byte(`[{"id": "an-id", "name": "My SDB", "path": "app/my-sdb/", "category_id": "a-category"}]`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/safe-deposit-box/an-id":
+			w.Write([]byte(`{"id": "an-id", "name": "My SDB", "path": "app/my-sdb/"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/safe-deposit-box/an-id":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/app/my-sdb/config":
+			w.Write([]byte(`{"data": {"foo": "bar"}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/app/my-sdb/config":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/secure-file/app/my-sdb/cert.pem":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secure-files/app/my-sdb/":
+			w.Write([]byte(`{"has_next": false, "secure_file_summaries": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client scoped to an SDB", t, func() {
+		paths = nil
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		scoped := cl.ForSDB("app/my-sdb")
+		Convey("Should read secrets relative to the SDB", func() {
+			secret, err := scoped.Secret().Read("config")
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+		})
+		Convey("Should not let a path leave the SDB", func() {
+			secret, err := scoped.Secret().Read("../config")
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+			_, err = scoped.Secret().Read("../other-sdb/config")
+			So(err, ShouldBeNil)
+			So(paths[len(paths)-1], ShouldEqual, "GET /v1/secret/app/my-sdb/other-sdb/config")
+		})
+		Convey("Should write secrets relative to the SDB", func() {
+			result, err := scoped.Secret().WriteWithResult("/config", map[string]interface{}{"foo": "bar"})
+			So(err, ShouldBeNil)
+			So(result.Path, ShouldEqual, "app/my-sdb/config")
+		})
+		Convey("Should upload and list secure files in the SDB", func() {
+			So(scoped.SecureFile().Put("cert.pem", "cert.pem", bytes.NewBufferString("cert")), ShouldBeNil)
+			_, err := scoped.SecureFile().List()
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{"POST /v1/secure-file/app/my-sdb/cert.pem", "GET /v1/secure-files/app/my-sdb/"})
+		})
+		Convey("Should get and delete the SDB", func() {
+			sdb, err := scoped.Get()
+			So(err, ShouldBeNil)
+			So(sdb.ID, ShouldEqual, "an-id")
+			So(scoped.Delete(), ShouldBeNil)
+			So(paths[len(paths)-1], ShouldEqual, "DELETE /v2/safe-deposit-box/an-id")
+		})
+		Convey("Should error for an SDB the client can't see", func() {
+			sdb, err := cl.ForSDB("app/other-sdb").Get()
+			So(err, ShouldEqual, ErrorSafeDepositBoxNotFound)
+			So(sdb, ShouldBeNil)
+		})
+	})
+
+	Convey("A client scoped to an empty path", t, func() {
+		paths = nil
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		scoped := cl.ForSDB("")
+		Convey("Should error without making requests", func() {
+			_, err := scoped.Secret().Read("config")
+			So(err, ShouldEqual, ErrorSafeDepositBoxNotFound)
+			_, err = scoped.SecureFile().ListPage(api.PageOpts{})
+			So(err, ShouldEqual, ErrorSafeDepositBoxNotFound)
+			So(scoped.Delete(), ShouldEqual, ErrorSafeDepositBoxNotFound)
+			So(paths, ShouldBeEmpty)
+		})
+	})
+}
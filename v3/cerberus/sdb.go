@@ -17,12 +17,12 @@ limitations under the License.
 package cerberus
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
-	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
 )
 
 // ErrorSafeDepositBoxNotFound is returned when a specified deposit box is not found
@@ -82,7 +82,7 @@ func (s *SDB) Get(id string) (*api.SafeDepositBox, error) {
 		return nil, ErrorSafeDepositBoxNotFound
 	}
 	returnedSDB := &api.SafeDepositBox{}
-	resp, err := s.c.DoRequest(http.MethodGet, sdbBasePath+"/"+id, map[string]string{}, nil)
+	resp, err := s.c.DoRequest(http.MethodGet, escapeID(sdbBasePath, id), map[string]string{}, nil)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -92,7 +92,7 @@ func (s *SDB) Get(id string) (*api.SafeDepositBox, error) {
 				return nil, ErrorSafeDepositBoxNotFound
 			}
 		}
-		return nil, fmt.Errorf("Error while trying to get SDB: %v", err)
+		return nil, fmt.Errorf("Error while trying to get SDB: %w", err)
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("Error while trying to GET SDB. Got HTTP status code %d", resp.StatusCode)
@@ -104,7 +104,8 @@ func (s *SDB) Get(id string) (*api.SafeDepositBox, error) {
 	return returnedSDB, nil
 }
 
-// List returns a list of all SDBs the authenticated user is allowed to see
+// List returns a list of all SDBs the authenticated user is allowed to see, in the order of
+// the server, which can change between calls. Use ListSorted for a stable order
 func (s *SDB) List() ([]*api.SafeDepositBox, error) {
 	sdbList := []*api.SafeDepositBox{}
 	resp, err := s.c.DoRequest(http.MethodGet, sdbBasePath, map[string]string{}, nil)
@@ -112,7 +113,7 @@ func (s *SDB) List() ([]*api.SafeDepositBox, error) {
 		defer resp.Body.Close()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("Error while trying to list SDB: %v", err)
+		return nil, fmt.Errorf("Error while trying to list SDB: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -125,10 +126,26 @@ func (s *SDB) List() ([]*api.SafeDepositBox, error) {
 	return sdbList, nil
 }
 
+// ListSorted is the same as List, but returns the SDBs in the given order, which is the same
+// for every call as long as the SDBs don't change
+func (s *SDB) ListSorted(order SDBOrder) ([]*api.SafeDepositBox, error) {
+	sdbs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	SortSDBs(sdbs, order)
+	return sdbs, nil
+}
+
 // Create creates a new Safe Deposit Box and returns the newly created object
 func (s *SDB) Create(newSDB *api.SafeDepositBox) (*api.SafeDepositBox, error) {
 	// Create the object we are returning
 	createdSDB := &api.SafeDepositBox{}
+	if s.c.validate {
+		if err := newSDB.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	resp, err := s.c.DoRequest(http.MethodPost, sdbBasePath, map[string]string{}, newSDB)
 	if resp != nil {
 		defer resp.Body.Close()
@@ -137,17 +154,13 @@ func (s *SDB) Create(newSDB *api.SafeDepositBox) (*api.SafeDepositBox, error) {
 		// Check if it is a bad request (improperly set params)
 		if resp != nil && resp.StatusCode == http.StatusBadRequest {
 			// Return the API error to the user
-			return nil, utils.ParseAPIError(resp.Body)
+			return nil, apiError(resp, "")
 		}
-		return nil, fmt.Errorf("Error while creating SDB: %v", err)
+		return nil, fmt.Errorf("Error while creating SDB: %w", err)
 	}
 	// If it isn't a bad request, make sure it is a good request and return an error if it isn't
 	if resp.StatusCode != http.StatusCreated {
-		apiErr := utils.ParseAPIError(resp.Body)
-		if apiErr == ErrorBodyNotReturned {
-			return nil, fmt.Errorf("Error while creating SDB. Got HTTP status code %d. %v", resp.StatusCode, apiErr)
-		}
-		return nil, apiErr
+		return nil, apiError(resp, "creating SDB")
 	}
 	// Parse the created object
 	err = parseResponse(resp.Body, createdSDB)
@@ -165,8 +178,13 @@ func (s *SDB) Update(id string, updatedSDB *api.SafeDepositBox) (*api.SafeDeposi
 	if id == "" {
 		return nil, ErrorSafeDepositBoxNotFound
 	}
+	if s.c.validate {
+		if err := updatedSDB.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	returnedSDB := &api.SafeDepositBox{}
-	resp, err := s.c.DoRequest(http.MethodPut, sdbBasePath+"/"+id, map[string]string{}, updatedSDB)
+	resp, err := s.c.DoRequest(http.MethodPut, escapeID(sdbBasePath, id), map[string]string{}, updatedSDB)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -178,18 +196,14 @@ func (s *SDB) Update(id string, updatedSDB *api.SafeDepositBox) (*api.SafeDeposi
 			}
 			if resp.StatusCode == http.StatusBadRequest {
 				// Return the API error to the user
-				return nil, utils.ParseAPIError(resp.Body)
+				return nil, apiError(resp, "")
 			}
 		}
-		return nil, fmt.Errorf("Error while updating SDB: %v", err)
+		return nil, fmt.Errorf("Error while updating SDB: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		apiErr := utils.ParseAPIError(resp.Body)
-		if apiErr == ErrorBodyNotReturned {
-			return nil, fmt.Errorf("Error while updating SDB. Got HTTP status code %d. %v", resp.StatusCode, apiErr)
-		}
-		return nil, apiErr
+		return nil, apiError(resp, "updating SDB")
 	}
 	// Parse the updated object
 	err = parseResponse(resp.Body, returnedSDB)
@@ -206,7 +220,7 @@ func (s *SDB) Delete(id string) error {
 	if id == "" {
 		return ErrorSafeDepositBoxNotFound
 	}
-	resp, err := s.c.DoRequest(http.MethodDelete, sdbBasePath+"/"+id, map[string]string{}, nil)
+	resp, err := s.c.DoRequest(http.MethodDelete, escapeID(sdbBasePath, id), map[string]string{}, nil)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
@@ -216,20 +230,77 @@ func (s *SDB) Delete(id string) error {
 			if resp.StatusCode == http.StatusNotFound {
 				return ErrorSafeDepositBoxNotFound
 			}
-			apiErr := utils.ParseAPIError(resp.Body)
-			if apiErr == ErrorBodyNotReturned {
-				return fmt.Errorf("Error while deleting SDB. Got HTTP status code %d. %v", resp.StatusCode, apiErr)
+			var unavailable api.ErrorServiceUnavailable
+			if errors.As(err, &unavailable) {
+				return unavailable
 			}
-			return apiErr
+			return apiError(resp, "deleting SDB")
 		}
-		return fmt.Errorf("Error while deleting SDB: %v", err)
+		return fmt.Errorf("Error while deleting SDB: %w", err)
 	}
 	if resp.StatusCode != http.StatusNoContent {
-		apiErr := utils.ParseAPIError(resp.Body)
-		if apiErr == ErrorBodyNotReturned {
-			return fmt.Errorf("Error while deleting SDB. Got HTTP status code %d. %v", resp.StatusCode, apiErr)
-		}
-		return apiErr
+		return apiError(resp, "deleting SDB")
 	}
 	return nil
 }
+
+// GrantRead gives the user group or IAM principal ARN read access to the SDB with the given ID
+func (s *SDB) GrantRead(id, principal string) (*api.SafeDepositBox, error) {
+	return s.Grant(id, principal, "read")
+}
+
+// GrantWrite gives the user group or IAM principal ARN write access to the SDB with the given ID
+func (s *SDB) GrantWrite(id, principal string) (*api.SafeDepositBox, error) {
+	return s.Grant(id, principal, "write")
+}
+
+// GrantOwner gives the user group or IAM principal ARN the owner role on the SDB with the given ID
+func (s *SDB) GrantOwner(id, principal string) (*api.SafeDepositBox, error) {
+	return s.Grant(id, principal, "owner")
+}
+
+// Grant gives a user group or IAM principal the named role (e.g. "read") on the SDB with
+// the given ID and returns the updated SDB. Principals starting with "arn:" are treated as
+// IAM principals and everything else as a user group. An existing permission for the
+// principal is replaced. Returns ErrorRoleNotFound if the role doesn't exist
+func (s *SDB) Grant(id, principal, roleName string) (*api.SafeDepositBox, error) {
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return nil, fmt.Errorf("Principal cannot be empty")
+	}
+	role, err := s.c.Role().GetByName(roleName)
+	if err != nil {
+		return nil, err
+	}
+	sdb, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(principal, "arn:") {
+		sdb.IAMPrincipalPermissions = grantIAMPrincipal(sdb.IAMPrincipalPermissions, principal, role.ID)
+	} else {
+		sdb.UserGroupPermissions = grantUserGroup(sdb.UserGroupPermissions, principal, role.ID)
+	}
+	return s.Update(id, sdb)
+}
+
+func grantIAMPrincipal(permissions []api.IAMPrincipal, arn, roleID string) []api.IAMPrincipal {
+	for i := range permissions {
+		if permissions[i].IAMPrincipalARN == arn {
+			permissions[i].RoleID = roleID
+			return permissions
+		}
+	}
+	return append(permissions, api.IAMPrincipal{IAMPrincipalARN: arn, RoleID: roleID})
+}
+
+func grantUserGroup(permissions []api.UserGroupPermission, group, roleID string) []api.UserGroupPermission {
+	for i := range permissions {
+		if permissions[i].Name == group {
+			permissions[i].RoleID = roleID
+			return permissions
+		}
+	}
+	return append(permissions, api.UserGroupPermission{Name: group, RoleID: roleID})
+}
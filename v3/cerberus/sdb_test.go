@@ -17,10 +17,13 @@ limitations under the License.
 package cerberus
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	. "github.com/smartystreets/goconvey/convey"
@@ -207,6 +210,11 @@ func TestListSDB(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(boxes, ShouldResemble, expectedResponse)
 		})
+		Convey("Should return the SDBs sorted by name", func() {
+			boxes, err := cl.SDB().ListSorted(SDBOrderName)
+			So(err, ShouldBeNil)
+			So(boxes, ShouldResemble, []*api.SafeDepositBox{expectedResponse[1], expectedResponse[0]})
+		})
 	}))
 
 	Convey("A call to List that encounters a server error", t, WithTestServer(http.StatusInternalServerError, "/v2/safe-deposit-box", http.MethodGet, validResponse, func(ts *httptest.Server) {
@@ -742,3 +750,180 @@ func TestDeleteSDB(t *testing.T) {
 	})
 
 }
+
+func TestSDBMaintenance(t *testing.T) {
+	Convey("A maintenance page while listing SDBs", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<html><body>Down for maintenance</body></html>"))
+		}))
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should return a typed error with a retry hint", func() {
+			sdbs, err := cl.SDB().List()
+			So(sdbs, ShouldBeNil)
+			var unavailable api.ErrorServiceUnavailable
+			So(errors.As(err, &unavailable), ShouldBeTrue)
+			So(unavailable.RetryAfter, ShouldEqual, 30*time.Second)
+		})
+		Convey("Should return the typed error when deleting", func() {
+			err := cl.SDB().Delete("an-id")
+			So(err, ShouldResemble, api.ErrorServiceUnavailable{RetryAfter: 30 * time.Second})
+		})
+	})
+}
+
+func TestSDBUnexpectedResponse(t *testing.T) {
+	Convey("An HTML page from a load balancer", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<html><body>403 Forbidden</body></html>"))
+		}))
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should return a typed error with the status and content type", func() {
+			sdb, err := cl.SDB().Get("an-id")
+			So(sdb, ShouldBeNil)
+			var unexpected api.ErrorUnexpectedResponse
+			So(errors.As(err, &unexpected), ShouldBeTrue)
+			So(unexpected.StatusCode, ShouldEqual, http.StatusForbidden)
+			So(unexpected.ContentType, ShouldEqual, "text/html; charset=utf-8")
+		})
+		Convey("Should return the typed error when creating", func() {
+			_, err := cl.SDB().Create(&api.SafeDepositBox{Name: "test", CategoryID: "a-category", Owner: "owner"})
+			var unexpected api.ErrorUnexpectedResponse
+			So(errors.As(err, &unexpected), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGrant(t *testing.T) {
+	var sdbResponse = `{
+    "id": "an-id",
+    "name": "Stage",
+    "path": "app/stage",
+    "category_id": "f7ff85a0-faaa-11e5-a8a9-7fa3b294cd46",
+    "owner": "Lst-digital.platform-tools.internal",
+    "user_group_permissions": [
+        {
+            "id": "3fc6455c-faad-11e5-a8a9-7fa3b294cd46",
+            "name": "Lst-CDT.CloudPlatformEngine.FTE",
+            "role_id": "f800558e-faaa-11e5-a8a9-7fa3b294cd46"
+        }
+    ],
+    "iam_principal_permissions": []
+}`
+	var updated *api.SafeDepositBox
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/role":
+			w.Write([]byte(listResponse))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/safe-deposit-box/an-id":
+			w.Write([]byte(sdbResponse))
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/safe-deposit-box/an-id":
+			updated = &api.SafeDepositBox{}
+			json.NewDecoder(r.Body).Decode(updated)
+			json.NewEncoder(w).Encode(updated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A valid client", t, func() {
+		updated = nil
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should add an IAM principal permission", func() {
+			sdb, err := cl.SDB().GrantOwner("an-id", "arn:aws:iam::1111111111:role/role-name")
+			So(err, ShouldBeNil)
+			So(sdb.IAMPrincipalPermissions, ShouldResemble, []api.IAMPrincipal{
+				{IAMPrincipalARN: "arn:aws:iam::1111111111:role/role-name", RoleID: "f7fff4d6-faaa-11e5-a8a9-7fa3b294cd46"},
+			})
+			So(updated.UserGroupPermissions, ShouldHaveLength, 1)
+		})
+		Convey("Should add a user group permission", func() {
+			sdb, err := cl.SDB().GrantRead("an-id", "Lst-another.group")
+			So(err, ShouldBeNil)
+			So(sdb.UserGroupPermissions, ShouldHaveLength, 2)
+			So(sdb.UserGroupPermissions[1], ShouldResemble, api.UserGroupPermission{
+				Name: "Lst-another.group", RoleID: "f800558e-faaa-11e5-a8a9-7fa3b294cd46",
+			})
+		})
+		Convey("Should replace an existing permission", func() {
+			sdb, err := cl.SDB().GrantOwner("an-id", "Lst-CDT.CloudPlatformEngine.FTE")
+			So(err, ShouldBeNil)
+			So(sdb.UserGroupPermissions, ShouldHaveLength, 1)
+			So(sdb.UserGroupPermissions[0].RoleID, ShouldEqual, "f7fff4d6-faaa-11e5-a8a9-7fa3b294cd46")
+		})
+		Convey("Should error for an unknown role", func() {
+			sdb, err := cl.SDB().GrantWrite("an-id", "Lst-another.group")
+			So(err, ShouldEqual, ErrorRoleNotFound)
+			So(sdb, ShouldBeNil)
+			So(updated, ShouldBeNil)
+		})
+		Convey("Should error for an empty principal", func() {
+			sdb, err := cl.SDB().GrantRead("an-id", " ")
+			So(err, ShouldNotBeNil)
+			So(sdb, ShouldBeNil)
+		})
+	})
+}
+
+func BenchmarkSDBList(b *testing.B) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(benchmarkSDBList)
+	}))
+	defer ts.Close()
+	cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := cl.SDB().List(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestSDBValidation(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(errorResponse))
+	}))
+	defer ts.Close()
+	invalid := &api.SafeDepositBox{
+		Name:                    "Stage",
+		IAMPrincipalPermissions: []api.IAMPrincipal{{IAMPrincipalARN: "not-an-arn"}},
+	}
+
+	Convey("A client with validation", t, func() {
+		calls = 0
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		validating := cl.WithValidation()
+		Convey("Should not send an invalid SDB on create", func() {
+			sdb, err := validating.SDB().Create(invalid)
+			So(sdb, ShouldBeNil)
+			So(err, ShouldHaveSameTypeAs, api.ErrorValidation{})
+			So(calls, ShouldEqual, 0)
+		})
+		Convey("Should not send an invalid SDB on update", func() {
+			sdb, err := validating.SDB().Update("an-id", invalid)
+			So(sdb, ShouldBeNil)
+			So(err, ShouldHaveSameTypeAs, api.ErrorValidation{})
+			So(calls, ShouldEqual, 0)
+		})
+		Convey("Should send the SDB without validation", func() {
+			_, err := cl.SDB().Create(invalid)
+			So(err, ShouldHaveSameTypeAs, api.ErrorResponse{})
+			So(calls, ShouldEqual, 1)
+		})
+	})
+}
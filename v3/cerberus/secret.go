@@ -17,36 +17,358 @@ limitations under the License.
 package cerberus
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	vault "github.com/hashicorp/vault/api"
 )
 
-// Note: This is not tested because it is a simple wrapper on top of Vault, which has its own tests
+// Note: Only Write and ReadOptional are tested as everything else is a simple wrapper on top of Vault, which has its own tests
 
 // Secret wraps the vault.Logical client to make sure all paths are prefaced
 // with "secret". This does not expose Unwrap because it will not work with
 // Cerberus' path routing
 type Secret struct {
-	v *vault.Logical
+	c         *Client
+	v         *vault.Logical
+	raw       *vault.Client
+	namespace string
+	cache     *secretCache
+	negatives *negativeCache
+	queue     *WriteQueue
+	snapshot  *Snapshot
+	codecs    map[string]Codec
+	// transforms are applied to the values of matching secrets, see WithTransform
+	transforms []pathTransform
+	// referenceDepth is how deep references are followed, or 0 to not expand them
+	referenceDepth int
 }
 
 const pathPrefix = "secret/"
 
+// ErrorSecretNotFound is returned when there is no secret at a given path
+var ErrorSecretNotFound = fmt.Errorf("Unable to find secret")
+
+// ErrorSecretNotModified is returned by ReadIfChanged when the secret still has the given version
+var ErrorSecretNotModified = fmt.Errorf("Secret has not been modified")
+
 // Delete deletes the given path. Path should not be prefaced with a "/"
 func (s *Secret) Delete(path string) (*vault.Secret, error) {
-	return s.v.Delete(pathPrefix + path)
+	if s.snapshot != nil {
+		return nil, ErrorSnapshotReadOnly
+	}
+	if err := s.c.precheck("delete", pathPrefix+path, s.namespace); err != nil {
+		return nil, err
+	}
+	if s.queue != nil && s.queue.pending() {
+		return nil, s.queueWrite("delete", path, nil)
+	}
+	if err := s.c.pace(context.Background()); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	s.syncToken()
+	secret, err := s.v.Delete(pathPrefix + path)
+	s.c.emitSecret("delete", path, start, secret, err)
+	s.invalidate(path)
+	if s.queue != nil && unreachable(err) {
+		return nil, s.queueWrite("delete", path, nil)
+	}
+	if err == nil {
+		s.observe(path, nil)
+	}
+	return secret, err
 }
 
-// List lists secrets at the given path. Path should not be prefaced with a "/"
+// List lists secrets at the given path. Path should not be prefaced with a "/". Vault returns
+// the keys sorted, with folders ending in "/". If the client reads from a snapshot, the paths in the
+// snapshot are listed instead
 func (s *Secret) List(path string) (*vault.Secret, error) {
-	return s.v.List(pathPrefix + path)
+	if s.snapshot != nil {
+		return s.snapshot.list(path), nil
+	}
+	ctx, cancel := s.c.readContext()
+	defer cancel()
+	if err := s.c.pace(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	s.syncToken()
+	secret, err := s.v.ListWithContext(ctx, pathPrefix+path)
+	s.c.emitSecret("list", path, start, secret, err)
+	return secret, err
 }
 
-// Read returns the secret at the given path. Path should not be prefaced with a "/"
+// Read returns the secret at the given path. Path should not be prefaced with a "/".
+// If the client has a secret cache, a cached secret is returned without a request, and if it
+// has a negative cache, a path recently found missing returns nil without a request. If the
+// client expands secret references, they are replaced with the values they point to. If the
+// client reads from a snapshot, see WithSnapshot, the secret comes from the snapshot. Values
+// are transformed last, see WithTransform
 func (s *Secret) Read(path string) (*vault.Secret, error) {
-	return s.v.Read(pathPrefix + path)
+	secret, err := s.read(path)
+	if err != nil || secret == nil {
+		return secret, err
+	}
+	if s.referenceDepth > 0 {
+		if secret, err = s.expand(secret); err != nil {
+			return nil, err
+		}
+	}
+	return s.transformRead(path, secret)
 }
 
-// Write creates a new secret at the given path. Path should not be prefaced with a "/"
+// read returns the secret at the given path without expanding references
+func (s *Secret) read(path string) (*vault.Secret, error) {
+	if s.snapshot != nil {
+		return s.snapshot.Read(path), nil
+	}
+	if s.cache != nil {
+		if secret, ok := s.cache.get(secretCacheKey(s.namespace, path)); ok {
+			s.observe(path, secret)
+			return secret, nil
+		}
+	}
+	if s.negatives != nil && s.negatives.missing(secretCacheKey(s.namespace, path)) {
+		return nil, nil
+	}
+	ctx, cancel := s.c.readContext()
+	defer cancel()
+	if err := s.c.pace(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	s.syncToken()
+	secret, err := s.v.ReadWithContext(ctx, pathPrefix+path)
+	s.c.emitSecret("read", path, start, secret, err)
+	if s.cache != nil && err == nil && secret != nil {
+		s.cache.set(secretCacheKey(s.namespace, path), secret)
+	}
+	s.remember(path, secret, err)
+	if err == nil {
+		s.observe(path, secret)
+	}
+	return secret, err
+}
+
+// ReadIfChanged returns the secret at the given path and its version, or
+// ErrorSecretNotModified if its version is still lastVersion. If the server sends an ETag it
+// is used as the version, and the server can answer with 304 Not Modified instead of sending
+// the secret again. Otherwise the version is a hash of the secret, which still lets callers
+// skip reprocessing a secret that hasn't changed. An empty lastVersion always returns the
+// secret. Like Read, a missing secret returns nil without an error. The secret cache is
+// updated but not used. Path should not be prefaced with a "/"
+func (s *Secret) ReadIfChanged(path, lastVersion string) (*vault.Secret, string, error) {
+	if s.snapshot != nil {
+		secret := s.snapshot.Read(path)
+		version := secretVersion(secret)
+		if secret != nil && lastVersion != "" && version == lastVersion {
+			return nil, version, ErrorSecretNotModified
+		}
+		secret, err := s.transformRead(path, secret)
+		return secret, version, err
+	}
+	start := time.Now()
+	s.syncToken()
+	r := s.raw.NewRequest(http.MethodGet, "/v1/"+pathPrefix+path)
+	if r.Headers == nil {
+		r.Headers = http.Header{}
+	}
+	if lastVersion != "" {
+		r.Headers.Set("If-None-Match", lastVersion)
+	}
+	ctx, cancel := s.c.readContext()
+	defer cancel()
+	if err := s.c.pace(ctx); err != nil {
+		return nil, "", err
+	}
+	resp, err := s.raw.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil {
+		s.c.checkDeprecation(http.MethodGet, "/v1/"+pathPrefix+path, resp.Response)
+	}
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		s.c.emitSecret("read", path, start, nil, nil)
+		s.remember(path, nil, nil)
+		return nil, "", nil
+	}
+	if err != nil {
+		s.c.emitSecret("read", path, start, nil, err)
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		s.c.emit("read", pathPrefix+path, "", "", start, resp.StatusCode, nil)
+		return nil, lastVersion, ErrorSecretNotModified
+	}
+
+	secret, err := vault.ParseSecret(resp.Body)
+	s.c.emitSecret("read", path, start, secret, err)
+	if err != nil {
+		return nil, "", err
+	}
+	version := resp.Header.Get("ETag")
+	if version == "" {
+		version = secretVersion(secret)
+	}
+	if secret != nil && s.cache != nil {
+		s.cache.set(secretCacheKey(s.namespace, path), secret)
+	}
+	s.remember(path, secret, nil)
+	s.observe(path, secret)
+	if lastVersion != "" && version == lastVersion {
+		return nil, version, ErrorSecretNotModified
+	}
+	secret, err = s.transformRead(path, secret)
+	return secret, version, err
+}
+
+// secretVersion returns a version for a secret from a hash of its data, for servers that
+// don't send an ETag
+func secretVersion(secret *vault.Secret) string {
+	if secret == nil {
+		return ""
+	}
+	data, err := json.Marshal(secret.Data)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("W/\"%x\"", sum)
+}
+
+// ReadOptional returns the secret at the given path and whether it exists. A missing secret
+// returns ok=false without an error, while transport and permission failures return an error.
+// Path should not be prefaced with a "/"
+func (s *Secret) ReadOptional(path string) (secret *vault.Secret, ok bool, err error) {
+	secret, err = s.Read(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if secret == nil {
+		return nil, false, nil
+	}
+	return secret, true, nil
+}
+
+// Write creates a new secret at the given path. Path should not be prefaced with a "/".
+// Values are transformed first, see WithTransform. It returns the response of Vault, which is
+// nil if the write was queued, see WithWriteQueue. WriteWithResult describes the write instead
 func (s *Secret) Write(path string, data map[string]interface{}) (*vault.Secret, error) {
-	return s.v.Write(pathPrefix+path, data)
+	secret, _, err := s.write(path, data)
+	return secret, err
+}
+
+// WriteWithResult is the same as Write, but returns the path, request ID, version and time of
+// the write, and whether it was queued
+func (s *Secret) WriteWithResult(path string, data map[string]interface{}) (*api.WriteResult, error) {
+	_, result, err := s.write(path, data)
+	return result, err
+}
+
+// write writes the secret at path and returns the response of Vault and the WriteResult. The
+// response is nil if the write was queued
+func (s *Secret) write(path string, data map[string]interface{}) (*vault.Secret, *api.WriteResult, error) {
+	if s.snapshot != nil {
+		return nil, nil, ErrorSnapshotReadOnly
+	}
+	data, err := s.transformWrite(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.c.precheck("write", pathPrefix+path, s.namespace); err != nil {
+		return nil, nil, err
+	}
+	if s.queue != nil && s.queue.pending() {
+		return s.queuedResult(path, data)
+	}
+	if err := s.c.pace(context.Background()); err != nil {
+		return nil, nil, err
+	}
+	start := time.Now()
+	s.syncToken()
+	secret, err := s.v.Write(pathPrefix+path, data)
+	s.c.emitSecret("write", path, start, secret, err)
+	// Invalidate even if the write failed, as it may have been applied before the error
+	s.invalidate(path)
+	if s.queue != nil && unreachable(err) {
+		return s.queuedResult(path, data)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.observe(path, &vault.Secret{Data: data})
+	return secret, newWriteResult(path, secret), nil
+}
+
+// queuedResult queues a write and returns its WriteResult
+func (s *Secret) queuedResult(path string, data map[string]interface{}) (*vault.Secret, *api.WriteResult, error) {
+	if err := s.queueWrite("write", path, data); err != nil {
+		return nil, nil, err
+	}
+	return nil, &api.WriteResult{Path: path, Timestamp: time.Now(), Queued: true}, nil
+}
+
+// syncToken makes the Vault client use the current token of the auth method, which changes
+// when the token is renewed outside of the client, e.g. by an auth.TokenWatcher
+func (s *Secret) syncToken() {
+	headers, err := s.c.Authentication.GetHeaders()
+	if err != nil {
+		return
+	}
+	if token := headers.Get("X-Cerberus-Token"); token != "" && token != s.raw.Token() {
+		s.raw.SetToken(token)
+	}
+}
+
+// remember updates the negative cache, if there is one, with the result of reading path.
+// Errors leave it alone, as they don't say whether the secret exists
+func (s *Secret) remember(path string, secret *vault.Secret, err error) {
+	if s.negatives == nil || err != nil {
+		return
+	}
+	if secret == nil {
+		s.negatives.miss(secretCacheKey(s.namespace, path))
+	} else {
+		s.negatives.invalidate(secretCacheKey(s.namespace, path))
+	}
+}
+
+// invalidate removes the secret at path from the cache and the negative cache, if there are any
+func (s *Secret) invalidate(path string) {
+	if s.cache != nil {
+		s.cache.invalidate(secretCacheKey(s.namespace, path))
+	}
+	if s.negatives != nil {
+		s.negatives.invalidate(secretCacheKey(s.namespace, path))
+	}
+}
+
+// newWriteResult builds a WriteResult from the secret returned by a write, which is
+// usually nil as Cerberus returns no content
+func newWriteResult(path string, secret *vault.Secret) *api.WriteResult {
+	result := &api.WriteResult{
+		Path:      path,
+		Timestamp: time.Now(),
+	}
+	if secret == nil {
+		return result
+	}
+	result.RequestID = secret.RequestID
+	switch version := secret.Data["version"].(type) {
+	case json.Number:
+		v, _ := version.Int64()
+		result.Version = int(v)
+	case float64:
+		result.Version = int(version)
+	case int:
+		result.Version = version
+	}
+	return result
 }
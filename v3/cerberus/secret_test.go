@@ -0,0 +1,208 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSecretWrite(t *testing.T) {
+	Convey("A write that returns no content", t, WithTestServer(http.StatusNoContent, "/v1/secret/app/foo/bar", http.MethodPut, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return a result with the path and time", func() {
+			result, err := cl.Secret().WriteWithResult("app/foo/bar", map[string]interface{}{"foo": "bar"})
+			So(err, ShouldBeNil)
+			So(result.Path, ShouldEqual, "app/foo/bar")
+			So(result.Timestamp, ShouldHappenWithin, time.Second, time.Now())
+			So(result.RequestID, ShouldBeEmpty)
+			So(result.Version, ShouldEqual, 0)
+		})
+	}))
+
+	Convey("A write that returns a secret", t, WithTestServer(http.StatusOK, "/v1/secret/app/foo/bar", http.MethodPut,
+		`{"request_id": "a-request-id", "data": {"version": 3}}`, func(ts *httptest.Server) {
+			cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+			So(cl, ShouldNotBeNil)
+			Convey("Should return the request ID and version", func() {
+				result, err := cl.Secret().WriteWithResult("app/foo/bar", map[string]interface{}{"foo": "bar"})
+				So(err, ShouldBeNil)
+				So(result.RequestID, ShouldEqual, "a-request-id")
+				So(result.Version, ShouldEqual, 3)
+			})
+			Convey("Should return the response of Vault from Write", func() {
+				secret, err := cl.Secret().Write("app/foo/bar", map[string]interface{}{"foo": "bar"})
+				So(err, ShouldBeNil)
+				So(secret.RequestID, ShouldEqual, "a-request-id")
+				So(secret.Data["version"], ShouldEqual, json.Number("3"))
+			})
+		}))
+
+	Convey("A failed write", t, WithTestServer(http.StatusBadRequest, "/v1/secret/app/foo/bar", http.MethodPut,
+		`{"errors": ["bad request"]}`, func(ts *httptest.Server) {
+			cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+			So(cl, ShouldNotBeNil)
+			Convey("Should return an error", func() {
+				secret, err := cl.Secret().Write("app/foo/bar", map[string]interface{}{"foo": "bar"})
+				So(err, ShouldNotBeNil)
+				So(secret, ShouldBeNil)
+				result, err := cl.Secret().WriteWithResult("app/foo/bar", map[string]interface{}{"foo": "bar"})
+				So(err, ShouldNotBeNil)
+				So(result, ShouldBeNil)
+			})
+		}))
+}
+
+func TestSecretReadOptional(t *testing.T) {
+	Convey("A secret that exists", t, WithTestServer(http.StatusOK, "/v1/secret/app/foo/bar", http.MethodGet, `{"data": {"foo": "bar"}}`, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the secret", func() {
+			secret, ok, err := cl.Secret().ReadOptional("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+		})
+	}))
+
+	Convey("A secret that doesn't exist", t, WithTestServer(http.StatusNotFound, "/v1/secret/app/foo/bar", http.MethodGet, `{"errors": []}`, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should not error", func() {
+			secret, ok, err := cl.Secret().ReadOptional("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+			So(secret, ShouldBeNil)
+		})
+	}))
+
+	Convey("A secret without permission", t, WithTestServer(http.StatusForbidden, "/v1/secret/app/foo/bar", http.MethodGet, `{"errors": ["permission denied"]}`, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should error", func() {
+			secret, ok, err := cl.Secret().ReadOptional("app/foo/bar")
+			So(err, ShouldNotBeNil)
+			So(ok, ShouldBeFalse)
+			So(secret, ShouldBeNil)
+		})
+	}))
+}
+
+func TestSecretReadIfChanged(t *testing.T) {
+	var reads int
+	var etag string
+	data := `{"data": {"foo": "bar"}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/app/foo/bar" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		reads++
+		if etag != "" {
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(data))
+	}))
+	defer ts.Close()
+
+	Convey("A server that sends an ETag", t, func() {
+		reads = 0
+		etag = `"v1"`
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		secret, version, err := cl.Secret().ReadIfChanged("app/foo/bar", "")
+		So(err, ShouldBeNil)
+		So(secret.Data["foo"], ShouldEqual, "bar")
+		So(version, ShouldEqual, `"v1"`)
+		Convey("Should return not modified for the same version", func() {
+			secret, version, err := cl.Secret().ReadIfChanged("app/foo/bar", `"v1"`)
+			So(err, ShouldEqual, ErrorSecretNotModified)
+			So(secret, ShouldBeNil)
+			So(version, ShouldEqual, `"v1"`)
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should return the secret once it changes", func() {
+			etag = `"v2"`
+			secret, version, err := cl.Secret().ReadIfChanged("app/foo/bar", `"v1"`)
+			So(err, ShouldBeNil)
+			So(secret, ShouldNotBeNil)
+			So(version, ShouldEqual, `"v2"`)
+		})
+	})
+
+	Convey("A server without ETags", t, func() {
+		etag = ""
+		data = `{"data": {"foo": "bar"}}`
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		_, version, err := cl.Secret().ReadIfChanged("app/foo/bar", "")
+		So(err, ShouldBeNil)
+		So(version, ShouldNotBeEmpty)
+		Convey("Should compare a hash of the secret", func() {
+			_, _, err := cl.Secret().ReadIfChanged("app/foo/bar", version)
+			So(err, ShouldEqual, ErrorSecretNotModified)
+			data = `{"data": {"foo": "baz"}}`
+			secret, changed, err := cl.Secret().ReadIfChanged("app/foo/bar", version)
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "baz")
+			So(changed, ShouldNotEqual, version)
+		})
+	})
+
+	Convey("A missing secret", t, func() {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		secret, version, err := cl.Secret().ReadIfChanged("app/foo/missing", "")
+		So(err, ShouldBeNil)
+		So(secret, ShouldBeNil)
+		So(version, ShouldBeEmpty)
+	})
+}
+
+func TestSecretTokenRenewal(t *testing.T) {
+	var tokens []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("X-Vault-Token"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"key": "value"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A client whose auth method got a new token outside of it", t, func() {
+		tokens = nil
+		m := GenerateMockAuth(ts.URL, "a-cool-token", false, false)
+		cl, _ := NewClient(m, nil)
+		_, err := cl.Secret().Read("app/sdb/config")
+		So(err, ShouldBeNil)
+		m.headers.Set("X-Cerberus-Token", "a-renewed-token")
+		Convey("Should use the new token for secrets", func() {
+			_, err := cl.Secret().Read("app/sdb/config")
+			So(err, ShouldBeNil)
+			_, err = cl.WithNamespace("team-a").Secret().List("app/sdb")
+			So(err, ShouldBeNil)
+			So(tokens, ShouldResemble, []string{"a-cool-token", "a-renewed-token", "a-renewed-token"})
+		})
+	})
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// secretCache keeps secrets that were read recently. It is shared by a Client and all of its
+// copies, so writes and deletes through any of them invalidate the cached secret
+type secretCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	secret  *vault.Secret
+	expires time.Time
+}
+
+func newSecretCache(ttl time.Duration) *secretCache {
+	return &secretCache{
+		ttl:     ttl,
+		entries: map[string]cachedSecret{},
+	}
+}
+
+// secretCacheKey returns the cache key of a path, as the same path can hold a different
+// secret in another namespace
+func secretCacheKey(namespace, path string) string {
+	return namespace + "\x00" + path
+}
+
+// get returns the cached secret for the key if it hasn't expired
+func (s *secretCache) get(key string) (*vault.Secret, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.secret, true
+}
+
+func (s *secretCache) set(key string, secret *vault.Secret) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = cachedSecret{secret: secret, expires: time.Now().Add(s.ttl)}
+}
+
+func (s *secretCache) invalidate(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// WithSecretCache returns a shallow copy of the client that caches secrets it reads for the
+// given duration. Writing or deleting a secret through the client, or any copy of it, removes
+// it from the cache, so the new value is read on the next call. Changes made by anyone else
+// are only seen once the cached secret expires. Cached secrets are shared between callers and
+// must not be modified
+func (c *Client) WithSecretCache(ttl time.Duration) *Client {
+	scoped := c.copy()
+	scoped.secretCache = newSecretCache(ttl)
+	return scoped
+}
+
+// maxNegativeEntries is how many missing paths a negativeCache remembers at most
+const maxNegativeEntries = 10000
+
+// negativeCache remembers paths that had no secret, so repeated reads of a missing path don't
+// reach Cerberus every time. Each consecutive miss doubles how long the path is remembered, up
+// to a maximum. Like secretCache, it is shared by a Client and all of its copies
+type negativeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxTTL  time.Duration
+	entries map[string]negativeEntry
+	// limit is the most entries kept, and nextSweep is when stale entries are next removed
+	limit     int
+	nextSweep time.Time
+}
+
+type negativeEntry struct {
+	// misses is how many reads in a row found nothing
+	misses  uint
+	expires time.Time
+}
+
+func newNegativeCache(ttl, maxTTL time.Duration) *negativeCache {
+	if maxTTL < ttl {
+		maxTTL = ttl
+	}
+	return &negativeCache{
+		ttl:     ttl,
+		maxTTL:  maxTTL,
+		entries: map[string]negativeEntry{},
+		limit:   maxNegativeEntries,
+	}
+}
+
+// missing returns true if the path for the key was missing recently enough to skip reading it
+func (n *negativeCache) missing(key string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	entry, ok := n.entries[key]
+	return ok && time.Now().Before(entry.expires)
+}
+
+// miss records that there was no secret for the key. The entry is kept after it expires, so
+// the next miss remembers the path for twice as long, unless it has been expired for longer
+// than maxTTL. When the cache is full of unexpired entries, new paths aren't remembered
+func (n *negativeCache) miss(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	if now.After(n.nextSweep) {
+		n.sweep(now.Add(-n.maxTTL))
+		n.nextSweep = now.Add(n.maxTTL)
+	}
+	entry, ok := n.entries[key]
+	if !ok && len(n.entries) >= n.limit {
+		n.sweep(now)
+		if len(n.entries) >= n.limit {
+			return
+		}
+	}
+	ttl := n.ttl
+	for i := uint(0); i < entry.misses && ttl < n.maxTTL; i++ {
+		ttl *= 2
+	}
+	if ttl > n.maxTTL {
+		ttl = n.maxTTL
+	}
+	n.entries[key] = negativeEntry{misses: entry.misses + 1, expires: now.Add(ttl)}
+}
+
+// sweep removes the entries that expired before cutoff
+func (n *negativeCache) sweep(cutoff time.Time) {
+	for key, entry := range n.entries {
+		if entry.expires.Before(cutoff) {
+			delete(n.entries, key)
+		}
+	}
+}
+
+// invalidate forgets the key, after it was written or a secret was found for it
+func (n *negativeCache) invalidate(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.entries, key)
+}
+
+// WithNegativeCache returns a shallow copy of the client that remembers paths with no secret,
+// so reads of them return nil without a request, like probes for optional features. A path is
+// remembered for ttl after the first miss, and each consecutive miss doubles that up to maxTTL.
+// Writing or deleting the path through the client, or any copy of it, forgets it at once.
+// Secrets created by anyone else are only seen once the entry expires, so keep ttl short. At
+// most 10000 paths are remembered, and paths expired for longer than maxTTL are
+// forgotten
+func (c *Client) WithNegativeCache(ttl, maxTTL time.Duration) *Client {
+	scoped := c.copy()
+	scoped.negativeCache = newNegativeCache(ttl, maxTTL)
+	return scoped
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSecretCache(t *testing.T) {
+	var reads, version int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			reads++
+			fmt.Fprintf(w, `{"data": {"version": "%d"}}`, version)
+		default:
+			version++
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client with a secret cache", t, func() {
+		reads, version = 0, 1
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithSecretCache(time.Minute)
+		So(cl, ShouldNotBeNil)
+		Convey("Should read a secret once", func() {
+			first, err := cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			second, err := cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(second, ShouldEqual, first)
+			So(reads, ShouldEqual, 1)
+		})
+		Convey("Should read the new value after a write", func() {
+			cl.Secret().Read("app/foo/bar")
+			_, err := cl.Secret().Write("app/foo/bar", map[string]interface{}{"foo": "rotated"})
+			So(err, ShouldBeNil)
+			secret, err := cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(secret.Data["version"], ShouldEqual, "2")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should read again after a delete", func() {
+			cl.Secret().Read("app/foo/bar")
+			_, err := cl.Secret().Delete("app/foo/bar")
+			So(err, ShouldBeNil)
+			cl.Secret().Read("app/foo/bar")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should invalidate through copies of the client", func() {
+			cl.Secret().Read("app/foo/bar")
+			cl.WithNoRetry().Secret().Write("app/foo/bar", map[string]interface{}{"foo": "rotated"})
+			cl.Secret().Read("app/foo/bar")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should cache each namespace separately", func() {
+			cl.Secret().Read("app/foo/bar")
+			cl.WithNamespace("team-b").Secret().Read("app/foo/bar")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should read again once the cached secret expires", func() {
+			short := base.WithSecretCache(time.Millisecond)
+			short.Secret().Read("app/foo/bar")
+			time.Sleep(5 * time.Millisecond)
+			short.Secret().Read("app/foo/bar")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should not cache for the original client", func() {
+			base.Secret().Read("app/foo/bar")
+			base.Secret().Read("app/foo/bar")
+			So(reads, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestNegativeCache(t *testing.T) {
+	var reads int
+	var exists bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method != http.MethodGet:
+			exists = true
+			w.WriteHeader(http.StatusNoContent)
+		case exists:
+			reads++
+			w.Write([]byte(`{"data": {"feature": "on"}}`))
+		default:
+			reads++
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A client with a negative cache", t, func() {
+		reads, exists = 0, false
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithNegativeCache(time.Minute, time.Hour)
+		Convey("Should read a missing secret once", func() {
+			for i := 0; i < 3; i++ {
+				secret, err := cl.Secret().Read("app/foo/feature")
+				So(err, ShouldBeNil)
+				So(secret, ShouldBeNil)
+			}
+			So(reads, ShouldEqual, 1)
+		})
+		Convey("Should read the secret after it is written", func() {
+			cl.Secret().Read("app/foo/feature")
+			_, err := cl.WithNoRetry().Secret().Write("app/foo/feature", map[string]interface{}{"feature": "on"})
+			So(err, ShouldBeNil)
+			secret, err := cl.Secret().Read("app/foo/feature")
+			So(err, ShouldBeNil)
+			So(secret.Data["feature"], ShouldEqual, "on")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should not cache secrets that exist", func() {
+			exists = true
+			cl.Secret().Read("app/foo/feature")
+			cl.Secret().Read("app/foo/feature")
+			So(reads, ShouldEqual, 2)
+		})
+		Convey("Should cache each namespace separately", func() {
+			cl.Secret().Read("app/foo/feature")
+			cl.WithNamespace("team").Secret().Read("app/foo/feature")
+			So(reads, ShouldEqual, 2)
+		})
+	})
+
+	Convey("A negative cache", t, func() {
+		n := newNegativeCache(time.Second, 5*time.Second)
+		ttl := func() time.Duration {
+			return time.Until(n.entries["key"].expires).Round(time.Second)
+		}
+		Convey("Should double the TTL for each miss up to the maximum", func() {
+			n.miss("key")
+			So(ttl(), ShouldEqual, time.Second)
+			n.miss("key")
+			So(ttl(), ShouldEqual, 2*time.Second)
+			n.miss("key")
+			So(ttl(), ShouldEqual, 4*time.Second)
+			n.miss("key")
+			So(ttl(), ShouldEqual, 5*time.Second)
+			So(n.missing("key"), ShouldBeTrue)
+		})
+		Convey("Should start over after being invalidated", func() {
+			n.miss("key")
+			n.miss("key")
+			n.invalidate("key")
+			So(n.missing("key"), ShouldBeFalse)
+			n.miss("key")
+			So(ttl(), ShouldEqual, time.Second)
+		})
+		Convey("Should not have a maximum below the TTL", func() {
+			So(newNegativeCache(time.Minute, 0).maxTTL, ShouldEqual, time.Minute)
+		})
+		Convey("Should remove entries that expired longer than the maximum ago", func() {
+			n.miss("stale")
+			n.miss("key")
+			n.entries["stale"] = negativeEntry{misses: 3, expires: time.Now().Add(-6 * time.Second)}
+			n.nextSweep = time.Now().Add(-time.Second)
+			n.miss("other")
+			So(n.entries, ShouldNotContainKey, "stale")
+			So(n.entries, ShouldContainKey, "key")
+		})
+		Convey("Should not grow past its limit", func() {
+			n.limit = 2
+			n.miss("a")
+			n.miss("b")
+			n.miss("c")
+			So(n.entries, ShouldHaveLength, 2)
+			So(n.missing("c"), ShouldBeFalse)
+			Convey("But make room by removing expired entries", func() {
+				n.entries["a"] = negativeEntry{misses: 1, expires: time.Now().Add(-time.Millisecond)}
+				n.miss("c")
+				So(n.missing("c"), ShouldBeTrue)
+				So(n.entries, ShouldNotContainKey, "a")
+			})
+		})
+	})
+}
@@ -18,11 +18,20 @@ package cerberus
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
-	"path"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 )
@@ -32,23 +41,65 @@ type SecureFile struct {
 	c *Client
 }
 
+// ErrorSecureFileNotFound is returned when a secure file doesn't exist
+var ErrorSecureFileNotFound = fmt.Errorf("Unable to find secure file")
+
 var secureFileBasePath = "/v1/secure-file"
 var secureFileListBasePath = "/v1/secure-files"
 
-// List returns a list of secure files
+// List returns all secure files under rootpath, sorted by path. It follows the pages of the
+// server, so the response has every file and no next page
 func (r *SecureFile) List(rootpath string) (*api.SecureFilesResponse, error) {
+	params := map[string]string{
+		"list": "true",
+	}
+	files, err := r.list(rootpath, params)
+	if err != nil {
+		return nil, err
+	}
+	for offset := files.Offset; files.HasNext && files.NextOffset > offset; {
+		offset = files.NextOffset
+		params["offset"] = fmt.Sprintf("%d", offset)
+		page, err := r.list(rootpath, params)
+		if err != nil {
+			return nil, err
+		}
+		files.Summaries = append(files.Summaries, page.Summaries...)
+		files.HasNext, files.NextOffset = page.HasNext, page.NextOffset
+	}
+	files.HasNext, files.NextOffset = false, 0
+	files.ResultCount = len(files.Summaries)
+	sort.SliceStable(files.Summaries, func(i, j int) bool {
+		return files.Summaries[i].Path < files.Summaries[j].Path
+	})
+	return files, nil
+}
+
+// ListPage returns a page of secure files. If the limit isn't set, the server default is used.
+// Pages are in the order of the server, so files added or deleted between calls can shift the
+// offsets. List or Browse return all files in a stable order
+func (r *SecureFile) ListPage(rootpath string, opts api.PageOpts) (*api.SecureFilesResponse, error) {
+	params := map[string]string{
+		"list":   "true",
+		"offset": fmt.Sprintf("%d", opts.Offset),
+	}
+	if opts.Limit != 0 {
+		params["limit"] = fmt.Sprintf("%d", opts.Limit)
+	}
+	return r.list(rootpath, params)
+}
+
+func (r *SecureFile) list(rootpath string, params map[string]string) (*api.SecureFilesResponse, error) {
 	resp, err := r.c.DoRequest(http.MethodGet,
-		// path.Join will remove last '/' but cerberus expect a / suffix => Let's add it
-		path.Join(secureFileListBasePath, rootpath)+"/",
-		map[string]string{
-			"list": "true",
-		},
+		// escapePath will remove last '/' but cerberus expect a / suffix => Let's add it
+		escapePath(secureFileListBasePath, rootpath)+"/",
+		params,
 		nil)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("error while trying to get secure files: %v", err)
+		return nil, fmt.Errorf("error while trying to get secure files: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -66,39 +117,148 @@ func (r *SecureFile) List(rootpath string) (*api.SecureFilesResponse, error) {
 
 // Get downloads a secure file under localfile. File will be saved in output
 func (r *SecureFile) Get(secureFilePath string, output io.Writer) error {
-	resp, err := r.c.DoRequest(http.MethodGet,
-		path.Join(secureFileBasePath, secureFilePath),
-		map[string]string{},
-		nil)
+	_, err := r.get(secureFilePath, nil, output)
+	return err
+}
+
+// GetWithInfo is the same as Get, but also returns the original filename, size, content type
+// and SHA-256 of the file
+func (r *SecureFile) GetWithInfo(secureFilePath string, output io.Writer) (*api.DownloadInfo, error) {
+	return r.get(secureFilePath, nil, output)
+}
+
+func (r *SecureFile) get(secureFilePath string, params url.Values, output io.Writer) (*api.DownloadInfo, error) {
+	// Downloads are streamed to output, so the response limit doesn't apply
+	resp, err := r.c.Do(&Request{
+		Method:          http.MethodGet,
+		Path:            escapePath(secureFileBasePath, secureFilePath),
+		Params:          params,
+		MaxResponseSize: -1,
+		kind:            kindTransfer,
+	})
 	if resp != nil {
 		defer resp.Body.Close()
 	}
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrorSecureFileNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("error while downloading secure file: %v", err)
+		return nil, fmt.Errorf("error while downloading secure file: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("error while trying to download secure file %s. Got HTTP status code %d",
+		return nil, fmt.Errorf("error while trying to download secure file %s. Got HTTP status code %d",
 			secureFilePath,
 			resp.StatusCode)
 	}
 
 	// Copy
-	_, err = io.Copy(output, resp.Body)
+	info := downloadInfo(resp)
+	info.Size, err = io.Copy(output, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// Stat returns the DownloadInfo of a secure file without downloading it
+func (r *SecureFile) Stat(secureFilePath string) (*api.DownloadInfo, error) {
+	resp, err := r.c.Do(&Request{
+		Method: http.MethodHead,
+		Path:   escapePath(secureFileBasePath, secureFilePath),
+	})
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrorSecureFileNotFound
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error while checking secure file: %w", err)
 	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error while trying to check secure file %s. Got HTTP status code %d",
+			secureFilePath,
+			resp.StatusCode)
+	}
+	return downloadInfo(resp), nil
+}
 
-	return nil
+// downloadInfo reads the DownloadInfo from the headers of a secure file response
+func downloadInfo(resp *http.Response) *api.DownloadInfo {
+	info := &api.DownloadInfo{
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		SHA256:      contentDigest(resp.Header),
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		info.Filename = params["filename"]
+	}
+	return info
+}
+
+// contentDigest returns the hex SHA-256 digest of a secure file from the Repr-Digest header,
+// or the older Digest header, of a response. It returns an empty string if neither has one
+func contentDigest(header http.Header) string {
+	if sum := sha256Field(header.Get("Repr-Digest")); sum != "" {
+		return sum
+	}
+	return sha256Field(header.Get("Digest"))
+}
+
+// sha256Field returns the sha-256 digest from a digest header as hex. Repr-Digest wraps the
+// base64 value in colons and Digest doesn't
+func sha256Field(value string) string {
+	for _, field := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "sha-256") {
+			continue
+		}
+		if sum, err := base64.StdEncoding.DecodeString(strings.Trim(parts[1], ":")); err == nil && len(sum) == sha256.Size {
+			return hex.EncodeToString(sum)
+		}
+	}
+	return ""
+}
+
+// quoteEscaper escapes a filename for the Content-Disposition header of a multipart part
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// detectContentType returns the MIME type of a file from the extension of its name, or from
+// the first bytes of its content if the extension isn't known
+func detectContentType(filename string, head []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(head)
 }
 
-// getUploadFileBodyWriter create a reader containing an encoded multipart file. It returns a reader, a content-type and/or possible error
-func getUploadFileBodyWriter(filename string, input io.Reader) (io.Reader, string, error) {
+// getUploadFileBodyWriter create a reader containing an encoded multipart file. The file part
+// has the given content type, or one detected from the filename and content if it's empty. It
+// returns a reader, a content-type and/or possible error
+func getUploadFileBodyWriter(filename string, fileContentType string, input io.Reader) (io.Reader, string, error) {
+	if fileContentType == "" {
+		// DetectContentType looks at most at the first 512 bytes
+		head := make([]byte, 512)
+		n, err := io.ReadFull(input, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, "", err
+		}
+		head = head[:n]
+		fileContentType = detectContentType(filename, head)
+		input = io.MultiReader(bytes.NewReader(head), input)
+	}
+
 	// Create mpart
 	var b bytes.Buffer
 	w := multipart.NewWriter(&b)
 
-	part, err := w.CreateFormFile("file-content", filename)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition",
+		fmt.Sprintf(`form-data; name="file-content"; filename="%s"`, quoteEscaper.Replace(filename)))
+	header.Set("Content-Type", fileContentType)
+	part, err := w.CreatePart(header)
 	if err != nil {
 		return nil, "", err
 	}
@@ -118,25 +278,40 @@ func getUploadFileBodyWriter(filename string, input io.Reader) (io.Reader, strin
 	return &b, contentType, nil
 }
 
-// Put uploads a secure file to a given location localfile
+// Put uploads a secure file to a given location localfile. The MIME type of the file is
+// detected from the extension of filename, or from its content if the extension isn't known
 func (r *SecureFile) Put(secureFilePath string, filename string, input io.Reader) error {
+	return r.PutWithContentType(secureFilePath, filename, "", input)
+}
+
+// PutWithContentType uploads a secure file like Put, but with the given MIME type instead of
+// a detected one. An empty contentType detects it like Put
+func (r *SecureFile) PutWithContentType(secureFilePath string, filename string, contentType string, input io.Reader) error {
+	return r.put(secureFilePath, filename, contentType, input, nil)
+}
+
+// put uploads a secure file, sending header with the request
+func (r *SecureFile) put(secureFilePath string, filename string, contentType string, input io.Reader, header http.Header) error {
 	// Create multipart body and content type
-	body, contentType, err := getUploadFileBodyWriter(filename, input)
+	body, bodyContentType, err := getUploadFileBodyWriter(filename, contentType, input)
 	if err != nil {
-		return fmt.Errorf("error creating upload body: %v", err)
+		return fmt.Errorf("error creating upload body: %w", err)
 	}
 
 	// Send request
-	resp, err := r.c.DoRequestWithBody(http.MethodPost,
-		path.Join(secureFileBasePath, secureFilePath),
-		map[string]string{},
-		contentType,
-		body)
+	resp, err := r.c.Do(&Request{
+		Method:      http.MethodPost,
+		Path:        escapePath(secureFileBasePath, secureFilePath),
+		ContentType: bodyContentType,
+		Body:        body,
+		Header:      header,
+		kind:        kindTransfer,
+	})
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err != nil {
-		return fmt.Errorf("error while downloading secure file: %v", err)
+		return fmt.Errorf("error while downloading secure file: %w", err)
 	}
 
 	// expected sucess reply is "no content"
@@ -148,3 +323,101 @@ func (r *SecureFile) Put(secureFilePath string, filename string, input io.Reader
 
 	return nil
 }
+
+// PutIfChanged uploads a secure file like Put, but skips the upload if the remote file
+// has the same content. It returns whether the file was uploaded. The local content is
+// hashed while it is read, seeking back afterwards if input is an io.ReadSeeker and through
+// a temporary file otherwise, so it is never held in memory. The remote file is compared by
+// its size and, if Stat reports one, its SHA-256 digest, so unchanged files are neither
+// uploaded nor downloaded. Only when the server reports no digest and the sizes match is the
+// remote file streamed and hashed. The digest is sent with the upload in the Repr-Digest
+// header for servers that keep it. The filename is not compared
+func (r *SecureFile) PutIfChanged(secureFilePath string, filename string, input io.Reader) (bool, error) {
+	local, err := hashInput(input)
+	if err != nil {
+		return false, fmt.Errorf("error reading file content: %w", err)
+	}
+	defer local.close()
+	unchanged, err := r.matches(secureFilePath, local)
+	if err != nil {
+		return false, err
+	}
+	if unchanged {
+		return false, nil
+	}
+	header := http.Header{}
+	header.Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(local.sum)+":")
+	if err := r.put(secureFilePath, filename, "", local.body, header); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hashedInput is the content of a file to upload, with its size and SHA-256 digest
+type hashedInput struct {
+	body  io.Reader
+	size  int64
+	sum   []byte
+	close func()
+}
+
+// hashInput hashes input and returns a reader for the same content. An io.ReadSeeker is
+// read twice, and anything else is copied to a temporary file while it is hashed
+func hashInput(input io.Reader) (*hashedInput, error) {
+	hash := sha256.New()
+	if seeker, ok := input.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		size, err := io.Copy(hash, seeker)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return &hashedInput{body: seeker, size: size, sum: hash.Sum(nil), close: func() {}}, nil
+	}
+	tmp, err := os.CreateTemp("", "cerberus-secure-file-")
+	if err != nil {
+		return nil, err
+	}
+	remove := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, hash), input)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		remove()
+		return nil, err
+	}
+	return &hashedInput{body: tmp, size: size, sum: hash.Sum(nil), close: remove}, nil
+}
+
+// matches returns whether the secure file at the given path has the content of local.
+// It returns false without an error if the file doesn't exist
+func (r *SecureFile) matches(secureFilePath string, local *hashedInput) (bool, error) {
+	info, err := r.Stat(secureFilePath)
+	if err == ErrorSecureFileNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if info.Size >= 0 && info.Size != local.size {
+		return false, nil
+	}
+	if info.SHA256 != "" {
+		return info.SHA256 == hex.EncodeToString(local.sum), nil
+	}
+
+	remote := sha256.New()
+	if err := r.Get(secureFilePath, remote); err != nil {
+		return false, err
+	}
+	return bytes.Equal(remote.Sum(nil), local.sum), nil
+}
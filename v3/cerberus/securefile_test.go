@@ -18,10 +18,13 @@ package cerberus
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -125,6 +128,53 @@ func TestSecureFileList(t *testing.T) {
 	})
 }
 
+func TestSecureFileListPages(t *testing.T) {
+	Convey("A List of secure files over several pages", t, func() {
+		var offsets []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			offset := r.URL.Query().Get("offset")
+			offsets = append(offsets, offset)
+			w.Header().Set("Content-Type", "application/json")
+			switch offset {
+			case "":
+				w.Write([]byte(`{"has_next": true, "next_offset": 2, "limit": 2, "file_count_in_result": 2, "total_file_count": 3,
+					"secure_file_summaries": [{"path": "my/sdb/c.txt"}, {"path": "my/sdb/a.txt"}]}`))
+			default:
+				w.Write([]byte(`{"has_next": false, "limit": 2, "offset": 2, "file_count_in_result": 1, "total_file_count": 3,
+					"secure_file_summaries": [{"path": "my/sdb/b.txt"}]}`))
+			}
+		}))
+		Reset(ts.Close)
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the files of every page, sorted by path", func() {
+			files, err := cl.SecureFile().List("my/sdb")
+			So(err, ShouldBeNil)
+			So(offsets, ShouldResemble, []string{"", "2"})
+			So(files.HasNext, ShouldBeFalse)
+			So(files.ResultCount, ShouldEqual, 3)
+			So(files.TotalCount, ShouldEqual, 3)
+			paths := []string{}
+			for _, summary := range files.Summaries {
+				paths = append(paths, summary.Path)
+			}
+			So(paths, ShouldResemble, []string{"my/sdb/a.txt", "my/sdb/b.txt", "my/sdb/c.txt"})
+		})
+	})
+}
+
+func TestSecureFileListPage(t *testing.T) {
+	Convey("A valid call to ListPage", t, WithServer(http.StatusOK, false, "/v1/secure-files/my/sdb", http.MethodGet, "",
+		map[string]string{"list": "true", "limit": "10", "offset": "20"}, nil, func(ts *httptest.Server) {
+			cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+			So(cl, ShouldNotBeNil)
+			Convey("Should send the pagination params", func() {
+				_, err := cl.SecureFile().ListPage("my/sdb", api.PageOpts{Limit: 10, Offset: 20})
+				So(err, ShouldBeNil)
+			})
+		}))
+}
+
 func TestSecureFileGet(t *testing.T) {
 	var fileBuffer bytes.Buffer
 
@@ -138,9 +188,19 @@ func TestSecureFileGet(t *testing.T) {
 			So(cl, ShouldNotBeNil)
 			Convey("Should return a valid file", func() {
 				fileBuffer.Reset()
-				err := cl.SecureFile().Get("/test/file/hello.txt", &fileBuffer)
+				info, err := cl.SecureFile().GetWithInfo("/test/file/hello.txt", &fileBuffer)
 				So(err, ShouldBeNil)
 				So(fileBuffer.Bytes(), ShouldResemble, []byte("hello world"))
+				So(info, ShouldResemble, &api.DownloadInfo{
+					Filename:    "hello.txt",
+					Size:        11,
+					ContentType: "application/octet-stream",
+				})
+			})
+			Convey("Should download the file with Get", func() {
+				fileBuffer.Reset()
+				So(cl.SecureFile().Get("/test/file/hello.txt", &fileBuffer), ShouldBeNil)
+				So(fileBuffer.Bytes(), ShouldResemble, []byte("hello world"))
 			})
 		}))
 
@@ -154,8 +214,9 @@ func TestSecureFileGet(t *testing.T) {
 			So(cl, ShouldNotBeNil)
 			Convey("Should return a valid file", func() {
 				fileBuffer.Reset()
-				err := cl.SecureFile().Get("/test/file/hello.txt", &fileBuffer)
+				info, err := cl.SecureFile().GetWithInfo("/test/file/hello.txt", &fileBuffer)
 				So(err, ShouldNotBeNil)
+				So(info, ShouldBeNil)
 			})
 		}))
 
@@ -170,6 +231,42 @@ func TestSecureFileGet(t *testing.T) {
 	})
 }
 
+func TestSecureFileStat(t *testing.T) {
+	Convey("A valid call to Stat", t, withBinaryTestServer(http.StatusOK,
+		"/v1/secure-file/test/file/hello.txt",
+		http.MethodHead,
+		"hello.txt",
+		[]byte("hello world"),
+		func(ts *httptest.Server) {
+			cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+			So(cl, ShouldNotBeNil)
+			Convey("Should return the file info", func() {
+				info, err := cl.SecureFile().Stat("/test/file/hello.txt")
+				So(err, ShouldBeNil)
+				So(info, ShouldResemble, &api.DownloadInfo{
+					Filename:    "hello.txt",
+					Size:        11,
+					ContentType: "application/octet-stream",
+				})
+			})
+		}))
+
+	Convey("A Stat of a missing file", t, withBinaryTestServer(http.StatusNotFound,
+		"/v1/secure-file/test/file/hello.txt",
+		http.MethodHead,
+		"hello.txt",
+		nil,
+		func(ts *httptest.Server) {
+			cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+			So(cl, ShouldNotBeNil)
+			Convey("Should return ErrorSecureFileNotFound", func() {
+				info, err := cl.SecureFile().Stat("/test/file/hello.txt")
+				So(err, ShouldEqual, ErrorSecureFileNotFound)
+				So(info, ShouldBeNil)
+			})
+		}))
+}
+
 func getTestInputReader(t *testing.T, content string) io.Reader {
 	var buf bytes.Buffer
 	if _, err := buf.WriteString(content); err != nil {
@@ -227,3 +324,181 @@ func TestSecureFilePut(t *testing.T) {
 		})
 	})
 }
+
+func TestSecureFilePutContentType(t *testing.T) {
+	var partType, partName, partContent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file-content")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		partType = header.Header.Get("Content-Type")
+		partName = header.Filename
+		partContent = string(content)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 600)...)
+
+	Convey("A secure file upload", t, func() {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should detect the type from the extension", func() {
+			err := cl.SecureFile().Put("app/sdb/config.json", "config.json", bytes.NewBufferString("{}"))
+			So(err, ShouldBeNil)
+			So(partType, ShouldEqual, "application/json")
+			So(partName, ShouldEqual, "config.json")
+			So(partContent, ShouldEqual, "{}")
+		})
+		Convey("Should sniff the type without a known extension", func() {
+			err := cl.SecureFile().Put("app/sdb/logo", "logo", bytes.NewReader(png))
+			So(err, ShouldBeNil)
+			So(partType, ShouldEqual, "image/png")
+			So(partContent, ShouldEqual, string(png))
+		})
+		Convey("Should fall back to a generic type", func() {
+			err := cl.SecureFile().Put("app/sdb/blob", "blob", bytes.NewReader([]byte{0, 1, 2}))
+			So(err, ShouldBeNil)
+			So(partType, ShouldEqual, "application/octet-stream")
+		})
+		Convey("Should use the given type", func() {
+			err := cl.SecureFile().PutWithContentType("app/sdb/cert", `my "cert".pem`, "application/x-pem-file", bytes.NewBufferString("cert"))
+			So(err, ShouldBeNil)
+			So(partType, ShouldEqual, "application/x-pem-file")
+			So(partName, ShouldEqual, `my "cert".pem`)
+		})
+	})
+}
+
+// fileStore is a single secure file kept in memory by withSecureFileStore
+type fileStore struct {
+	content []byte
+	// digest makes the server report the digest of the file
+	digest       bool
+	uploads      int
+	downloads    int
+	uploadDigest string
+}
+
+// withSecureFileStore starts a server that stores a single secure file in memory
+func withSecureFileStore(store *fileStore, f func(ts *httptest.Server, store *fileStore)) func() {
+	return func() {
+		Convey("http requests should be correct", func(c C) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.So(r.URL.Path, ShouldEqual, "/v1/secure-file/test/file/hello.txt")
+				switch r.Method {
+				case http.MethodHead, http.MethodGet:
+					if store.content == nil {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					if r.Method == http.MethodGet {
+						store.downloads++
+					}
+					if store.digest {
+						sum := sha256.Sum256(store.content)
+						w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+					}
+					w.Header().Set("Content-Type", "application/octet-stream")
+					w.Header().Set("Content-Length", fmt.Sprint(len(store.content)))
+					w.WriteHeader(http.StatusOK)
+					w.Write(store.content)
+				case http.MethodPost:
+					file, _, err := r.FormFile("file-content")
+					c.So(err, ShouldBeNil)
+					store.content, err = io.ReadAll(file)
+					c.So(err, ShouldBeNil)
+					store.uploads++
+					store.uploadDigest = r.Header.Get("Repr-Digest")
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			f(ts, store)
+			Reset(func() {
+				ts.Close()
+			})
+		})
+	}
+}
+
+func TestSecureFilePutIfChanged(t *testing.T) {
+	Convey("A secure file with the same content", t, withSecureFileStore(&fileStore{content: []byte("hello world")}, func(ts *httptest.Server, store *fileStore) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should skip the upload", func() {
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", getTestInputReader(t, "hello world"))
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeFalse)
+			So(store.uploads, ShouldEqual, 0)
+		})
+	}))
+
+	Convey("A secure file whose digest the server reports", t, withSecureFileStore(&fileStore{content: []byte("hello world"), digest: true}, func(ts *httptest.Server, store *fileStore) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should skip the upload without downloading the file", func() {
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", getTestInputReader(t, "hello world"))
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeFalse)
+			So(store.downloads, ShouldEqual, 0)
+		})
+		Convey("Should upload changed content of the same size without downloading the file", func() {
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", getTestInputReader(t, "hello there"))
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeTrue)
+			So(store.downloads, ShouldEqual, 0)
+			So(string(store.content), ShouldEqual, "hello there")
+		})
+	}))
+
+	Convey("A secure file with different content of the same size", t, withSecureFileStore(&fileStore{content: []byte("hello there")}, func(ts *httptest.Server, store *fileStore) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should upload the file with its digest", func() {
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", getTestInputReader(t, "hello world"))
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeTrue)
+			So(store.uploads, ShouldEqual, 1)
+			So(string(store.content), ShouldEqual, "hello world")
+			So(store.uploadDigest, ShouldEqual, "sha-256=:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=:")
+		})
+	}))
+
+	Convey("A secure file with a different size", t, withSecureFileStore(&fileStore{content: []byte("hello")}, func(ts *httptest.Server, store *fileStore) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should upload the file without downloading it", func() {
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", getTestInputReader(t, "hello world"))
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeTrue)
+			So(store.uploads, ShouldEqual, 1)
+			So(store.downloads, ShouldEqual, 0)
+		})
+		Convey("Should upload a seekable input from where it was", func() {
+			input := strings.NewReader("skip:seekable input")
+			input.Seek(5, io.SeekStart)
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", input)
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeTrue)
+			So(string(store.content), ShouldEqual, "seekable input")
+		})
+	}))
+
+	Convey("A secure file that doesn't exist", t, withSecureFileStore(&fileStore{}, func(ts *httptest.Server, store *fileStore) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should upload the file", func() {
+			uploaded, err := cl.SecureFile().PutIfChanged("/test/file/hello.txt", "hello.txt", getTestInputReader(t, "hello world"))
+			So(err, ShouldBeNil)
+			So(uploaded, ShouldBeTrue)
+			So(store.uploads, ShouldEqual, 1)
+		})
+	}))
+}
+
+func TestContentDigest(t *testing.T) {
+	Convey("The digest of a secure file", t, func() {
+		hex := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+		So(contentDigest(http.Header{"Repr-Digest": {"sha-512=:abc=:, sha-256=:uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=:"}}), ShouldEqual, hex)
+		So(contentDigest(http.Header{"Digest": {"SHA-256=uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek="}}), ShouldEqual, hex)
+		So(contentDigest(http.Header{"Digest": {"md5=XrY7u+Ae7tCTyyK7j1rNww=="}}), ShouldBeEmpty)
+		So(contentDigest(http.Header{}), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// snapshotFormat is the version of the snapshot file format
+const snapshotFormat = 1
+
+// snapshotWarning prefixes the warning added to every secret read from a snapshot
+const snapshotWarning = "Read from a Cerberus snapshot captured at "
+
+// ErrorSnapshotReadOnly is returned when writing or deleting a secret with a client that reads
+// from a snapshot
+var ErrorSnapshotReadOnly = fmt.Errorf("Secrets cannot be changed in a snapshot")
+
+// SnapshotManifest describes the contents of a snapshot. It is stored unencrypted, so it can be
+// inspected without the key, but it is covered by both the signature and the encryption
+type SnapshotManifest struct {
+	// Format is the version of the snapshot format
+	Format int `json:"format"`
+	// Captured is when the secrets were read from Cerberus
+	Captured time.Time `json:"captured"`
+	// URL is the Cerberus URL the secrets were read from
+	URL string `json:"url"`
+	// Namespace is the namespace the secrets were read in, if any
+	Namespace string `json:"namespace,omitempty"`
+	// Paths are the paths of the secrets in the snapshot, sorted
+	Paths []string `json:"paths"`
+}
+
+// snapshotFile is the layout of an exported snapshot
+type snapshotFile struct {
+	// Manifest is kept as raw JSON so the signature is checked against the exact bytes
+	Manifest json.RawMessage `json:"manifest"`
+	// Secrets is the encrypted secret data, prefixed with the nonce
+	Secrets []byte `json:"secrets"`
+	// Signature is an Ed25519 signature of the manifest followed by the encrypted secrets
+	Signature []byte `json:"signature"`
+}
+
+// Snapshot is a read-only copy of secrets exported with ExportSnapshot, for environments that
+// can't reach Cerberus. Use WithSnapshot to serve Secret().Read calls from it
+type Snapshot struct {
+	manifest SnapshotManifest
+	secrets  map[string]map[string]interface{}
+}
+
+// ExportSnapshot reads the secrets at paths and writes them to w as a snapshot that can be
+// loaded with LoadSnapshot where Cerberus is unreachable. A path ending in "/" exports every
+// secret under it, e.g. "app/my-sdb/". Missing secrets are left out. The secrets are
+// encrypted with AES-GCM using key, which must be 16, 24 or 32 bytes long, and the snapshot
+// is signed with signer so it can't be changed without the loader noticing. Secrets are
+// exported as they are stored, without expanding references. The manifest of the snapshot
+// is returned
+func (c *Client) ExportSnapshot(w io.Writer, paths []string, key []byte, signer ed25519.PrivateKey) (*SnapshotManifest, error) {
+	aead, err := snapshotCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(signer) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("Invalid snapshot signing key")
+	}
+	s := c.Secret()
+	secrets := map[string]map[string]interface{}{}
+	for _, path := range paths {
+		if err := s.exportPath(path, secrets); err != nil {
+			return nil, err
+		}
+	}
+	manifest := SnapshotManifest{
+		Format:    snapshotFormat,
+		Captured:  time.Now().UTC(),
+		URL:       c.CerberusURL.String(),
+		Namespace: c.currentNamespace(),
+		Paths:     []string{},
+	}
+	for path := range secrets {
+		manifest.Paths = append(manifest.Paths, path)
+	}
+	sort.Strings(manifest.Paths)
+
+	rawManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	// The manifest is authenticated with the secrets, so it can't be swapped between snapshots
+	sealed := aead.Seal(nonce, nonce, plain, rawManifest)
+	file := snapshotFile{
+		Manifest:  rawManifest,
+		Secrets:   sealed,
+		Signature: ed25519.Sign(signer, signedSnapshot(rawManifest, sealed)),
+	}
+	if err := json.NewEncoder(w).Encode(file); err != nil {
+		return nil, fmt.Errorf("Unable to write snapshot: %v", err)
+	}
+	return &manifest, nil
+}
+
+// exportPath reads the secret at path, or every secret under it if it ends in "/", into secrets
+func (s *Secret) exportPath(path string, secrets map[string]map[string]interface{}) error {
+	if !strings.HasSuffix(path, "/") {
+		secret, err := s.read(path)
+		if err != nil {
+			return fmt.Errorf("Unable to export %s: %v", path, err)
+		}
+		if secret != nil {
+			secrets[path] = secret.Data
+		}
+		return nil
+	}
+	list, err := s.List(strings.TrimSuffix(path, "/"))
+	if err != nil {
+		return fmt.Errorf("Unable to export %s: %v", path, err)
+	}
+	if list == nil {
+		return nil
+	}
+	keys, _ := list.Data["keys"].([]interface{})
+	for _, k := range keys {
+		if name, ok := k.(string); ok {
+			if err := s.exportPath(path+name, secrets); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by ExportSnapshot. The signature is checked with
+// verifier, the public key of the signing key, before the secrets are decrypted with key
+func LoadSnapshot(r io.Reader, key []byte, verifier ed25519.PublicKey) (*Snapshot, error) {
+	aead, err := snapshotCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(verifier) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Invalid snapshot verification key")
+	}
+	file := snapshotFile{}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("Unable to parse snapshot: %v", err)
+	}
+	if !ed25519.Verify(verifier, signedSnapshot(file.Manifest, file.Secrets), file.Signature) {
+		return nil, fmt.Errorf("Invalid snapshot signature, the snapshot may have been modified")
+	}
+	snap := &Snapshot{}
+	if err := json.Unmarshal(file.Manifest, &snap.manifest); err != nil {
+		return nil, fmt.Errorf("Unable to parse snapshot manifest: %v", err)
+	}
+	if snap.manifest.Format != snapshotFormat {
+		return nil, fmt.Errorf("Unsupported snapshot format %d", snap.manifest.Format)
+	}
+	size := aead.NonceSize()
+	if len(file.Secrets) < size {
+		return nil, fmt.Errorf("Unable to read snapshot: secrets are truncated")
+	}
+	plain, err := aead.Open(nil, file.Secrets[:size], file.Secrets[size:], file.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt snapshot, the key may be wrong: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(plain))
+	decoder.UseNumber()
+	if err := decoder.Decode(&snap.secrets); err != nil {
+		return nil, fmt.Errorf("Unable to parse snapshot: %v", err)
+	}
+	return snap, nil
+}
+
+// snapshotCipher returns the AES-GCM cipher for a snapshot key
+func snapshotCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid snapshot key: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid snapshot key: %v", err)
+	}
+	return aead, nil
+}
+
+// signedSnapshot returns the bytes covered by the signature of a snapshot
+func signedSnapshot(manifest, secrets []byte) []byte {
+	signed := make([]byte, 0, len(manifest)+len(secrets))
+	signed = append(signed, manifest...)
+	return append(signed, secrets...)
+}
+
+// Manifest returns the manifest of the snapshot
+func (s *Snapshot) Manifest() SnapshotManifest {
+	manifest := s.manifest
+	manifest.Paths = append([]string(nil), s.manifest.Paths...)
+	return manifest
+}
+
+// Read returns the secret at path from the snapshot, or nil if it isn't in the snapshot. The
+// secret has a warning saying when the snapshot was captured, see SnapshotCaptured
+func (s *Snapshot) Read(path string) *vault.Secret {
+	data, ok := s.secrets[path]
+	if !ok {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+	return &vault.Secret{
+		Data:     copied,
+		Warnings: []string{snapshotWarning + s.manifest.Captured.Format(time.RFC3339)},
+	}
+}
+
+// list returns the keys directly under path in the Vault list format, or nil if there are none
+func (s *Snapshot) list(path string) *vault.Secret {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	seen := map[string]bool{}
+	keys := []interface{}{}
+	for _, p := range s.manifest.Paths {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		name := p[len(prefix):]
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i+1]
+		}
+		if !seen[name] {
+			seen[name] = true
+			keys = append(keys, name)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return &vault.Secret{
+		Data:     map[string]interface{}{"keys": keys},
+		Warnings: []string{snapshotWarning + s.manifest.Captured.Format(time.RFC3339)},
+	}
+}
+
+// SnapshotCaptured returns when the snapshot a secret was read from was captured, and false
+// if the secret wasn't read from a snapshot
+func SnapshotCaptured(secret *vault.Secret) (time.Time, bool) {
+	if secret == nil {
+		return time.Time{}, false
+	}
+	for _, warning := range secret.Warnings {
+		if strings.HasPrefix(warning, snapshotWarning) {
+			captured, err := time.Parse(time.RFC3339, warning[len(snapshotWarning):])
+			if err == nil {
+				return captured, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// WithSnapshot returns a shallow copy of the client that serves Secret().Read and List from
+// snap instead of Cerberus, for environments with no connectivity. Secrets missing from the
+// snapshot are returned as nil, like a missing secret in Cerberus, and writes and deletes
+// return ErrorSnapshotReadOnly. Every secret read this way carries a warning with the capture
+// time of the snapshot, see SnapshotCaptured. Since NewClient logs in, create the client with
+// an auth.TokenAuth, which doesn't need Cerberus to be reachable
+func (c *Client) WithSnapshot(snap *Snapshot) *Client {
+	scoped := c.copy()
+	scoped.snapshot = snap
+	return scoped
+}
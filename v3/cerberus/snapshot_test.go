@@ -0,0 +1,120 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSnapshot(t *testing.T) {
+	fake := &fakeSecrets{secrets: map[string]map[string]interface{}{
+		"app/sdb/config":     {"key": "value"},
+		"app/sdb/nested/db":  {"password": "hunter2"},
+		"app/other/settings": {"color": "blue"},
+	}}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	key := bytes.Repeat([]byte{3}, 32)
+	public, private, _ := ed25519.GenerateKey(nil)
+
+	Convey("A snapshot", t, func() {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		buf := &bytes.Buffer{}
+		manifest, err := cl.ExportSnapshot(buf, []string{"app/sdb/", "app/other/settings", "app/missing"}, key, private)
+		So(err, ShouldBeNil)
+		So(manifest.Paths, ShouldResemble, []string{"app/other/settings", "app/sdb/config", "app/sdb/nested/db"})
+		So(manifest.URL, ShouldEqual, ts.URL)
+		So(manifest.Captured, ShouldHappenWithin, time.Minute, time.Now())
+		So(buf.String(), ShouldNotContainSubstring, "hunter2")
+
+		Convey("Should load with the right keys", func() {
+			snap, err := LoadSnapshot(bytes.NewReader(buf.Bytes()), key, public)
+			So(err, ShouldBeNil)
+			So(snap.Manifest().Paths, ShouldResemble, manifest.Paths)
+
+			Convey("And serve reads without Cerberus", func() {
+				offline, err := auth.NewTokenAuth("https://unreachable.example.com", "a-cool-token")
+				So(err, ShouldBeNil)
+				client, err := NewClient(offline, nil)
+				So(err, ShouldBeNil)
+				client = client.WithSnapshot(snap)
+
+				secret, err := client.Secret().Read("app/sdb/nested/db")
+				So(err, ShouldBeNil)
+				So(secret.Data["password"], ShouldEqual, "hunter2")
+				captured, ok := SnapshotCaptured(secret)
+				So(ok, ShouldBeTrue)
+				So(captured, ShouldHappenWithin, time.Second, manifest.Captured)
+
+				secret, err = client.Secret().Read("app/missing")
+				So(err, ShouldBeNil)
+				So(secret, ShouldBeNil)
+
+				list, err := client.Secret().List("app/sdb")
+				So(err, ShouldBeNil)
+				So(list.Data["keys"], ShouldResemble, []interface{}{"config", "nested/"})
+
+				_, err = client.Secret().Write("app/sdb/config", map[string]interface{}{"key": "new"})
+				So(err, ShouldEqual, ErrorSnapshotReadOnly)
+				_, err = client.Secret().Delete("app/sdb/config")
+				So(err, ShouldEqual, ErrorSnapshotReadOnly)
+			})
+		})
+
+		Convey("Should not load with the wrong key", func() {
+			_, err := LoadSnapshot(bytes.NewReader(buf.Bytes()), bytes.Repeat([]byte{4}, 32), public)
+			So(err, ShouldNotBeNil)
+			otherPublic, _, _ := ed25519.GenerateKey(nil)
+			_, err = LoadSnapshot(bytes.NewReader(buf.Bytes()), key, otherPublic)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should not load when the manifest was changed", func() {
+			file := snapshotFile{}
+			json.Unmarshal(buf.Bytes(), &file)
+			file.Manifest = bytes.Replace(file.Manifest, []byte("2"), []byte("1"), 1)
+			changed, _ := json.Marshal(file)
+			_, err := LoadSnapshot(bytes.NewReader(changed), key, public)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should not mark secrets read from Cerberus", func() {
+			secret, err := cl.Secret().Read("app/sdb/config")
+			So(err, ShouldBeNil)
+			_, ok := SnapshotCaptured(secret)
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("Invalid keys", t, func() {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		_, err := cl.ExportSnapshot(&bytes.Buffer{}, nil, []byte("short"), nil)
+		So(err, ShouldNotBeNil)
+		_, err = cl.ExportSnapshot(&bytes.Buffer{}, nil, key, nil)
+		So(err, ShouldNotBeNil)
+		_, err = LoadSnapshot(&bytes.Buffer{}, key, nil)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts are default timeouts for kinds of operations that take very different amounts of
+// time, so quick reads can fail fast without cutting off large uploads. A zero value falls
+// back to the timeout of the client, if any
+type Timeouts struct {
+	// Read is for secret reads and lists
+	Read time.Duration
+	// Transfer is for secure file uploads and downloads
+	Transfer time.Duration
+	// Admin is for metadata scans and SDB restores
+	Admin time.Duration
+}
+
+// operationKind is the kind of operation a request is, which picks its default timeout
+type operationKind int
+
+const (
+	kindDefault operationKind = iota
+	kindRead
+	kindTransfer
+	kindAdmin
+)
+
+// WithTimeouts returns a shallow copy of the client with default timeouts for each kind of
+// operation. Like WithTimeout, they cover retries and token refreshes. The Timeout of a
+// Request still takes precedence. Secret reads and lists use the Read timeout on top of the
+// settings of the Vault client
+func (c *Client) WithTimeouts(timeouts Timeouts) *Client {
+	scoped := c.copy()
+	scoped.timeouts = timeouts
+	return scoped
+}
+
+// timeoutFor returns the default timeout for a kind of operation
+func (c *Client) timeoutFor(kind operationKind) time.Duration {
+	var timeout time.Duration
+	switch kind {
+	case kindRead:
+		timeout = c.timeouts.Read
+	case kindTransfer:
+		timeout = c.timeouts.Transfer
+	case kindAdmin:
+		timeout = c.timeouts.Admin
+	}
+	if timeout == 0 {
+		return c.timeout
+	}
+	return timeout
+}
+
+// readContext returns the context for a secret read or list, with the Read timeout if one
+// is set. Secret requests don't otherwise use the client timeout
+func (c *Client) readContext() (context.Context, context.CancelFunc) {
+	if c.timeouts.Read > 0 {
+		return context.WithTimeout(context.Background(), c.timeouts.Read)
+	}
+	return context.Background(), func() {}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTimeouts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"key": "value"}}`))
+	}))
+	defer ts.Close()
+
+	Convey("A client with timeouts by kind of operation", t, func() {
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithTimeouts(Timeouts{Read: 50 * time.Millisecond, Transfer: time.Second, Admin: 50 * time.Millisecond})
+
+		Convey("Should fail secret reads quickly", func() {
+			start := time.Now()
+			_, err := cl.Secret().Read("app/sdb/config")
+			So(err, ShouldNotBeNil)
+			_, err = cl.Secret().List("app/sdb")
+			So(err, ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, 350*time.Millisecond)
+		})
+
+		Convey("Should give secure file transfers more time", func() {
+			var buf bytes.Buffer
+			err := cl.SecureFile().Get("app/sdb/file", &buf)
+			So(err, ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "value")
+		})
+
+		Convey("Should fail metadata scans at the admin timeout", func() {
+			start := time.Now()
+			_, err := cl.Metadata().List(MetadataOpts{})
+			So(err, ShouldNotBeNil)
+			So(time.Since(start), ShouldBeLessThan, 200*time.Millisecond)
+		})
+
+		Convey("Should use the timeout of the request over the default", func() {
+			_, err := cl.Do(&Request{Method: http.MethodGet, Path: "/v1/metadata", Timeout: time.Second, kind: kindAdmin})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Should fall back to the client timeout", func() {
+			scoped := base.WithTimeout(time.Minute).WithTimeouts(Timeouts{Read: time.Second})
+			So(scoped.timeoutFor(kindRead), ShouldEqual, time.Second)
+			So(scoped.timeoutFor(kindTransfer), ShouldEqual, time.Minute)
+			So(scoped.timeoutFor(kindDefault), ShouldEqual, time.Minute)
+		})
+	})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+)
+
+// Tokens is a subclient for finding and revoking the active tokens issued to a principal.
+// Not every Cerberus deployment exposes the token management endpoints
+type Tokens struct {
+	c *Client
+}
+
+var tokenBasePath = "/v1/token"
+
+// ErrorTokensNotSupported is returned when the Cerberus deployment doesn't have the token
+// management endpoints
+var ErrorTokensNotSupported = fmt.Errorf("Token management is not supported by this Cerberus deployment")
+
+// ErrorTokenNotFound is returned when a token with the given ID doesn't exist or has already
+// been revoked
+var ErrorTokenNotFound = fmt.Errorf("Unable to find token")
+
+// List returns the active tokens issued to the given IAM principal ARN or username. An empty
+// principal lists every token the current token is allowed to see, which is only its own
+// unless it is an admin token
+func (t *Tokens) List(principal string) ([]*api.IssuedToken, error) {
+	params := map[string]string{}
+	if principal = strings.TrimSpace(principal); principal != "" {
+		params["principal"] = principal
+	}
+	resp, err := t.c.DoRequest(http.MethodGet, tokenBasePath, params, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			if endpointMissing(resp.StatusCode) {
+				return nil, ErrorTokensNotSupported
+			}
+			return nil, apiError(resp, "listing tokens")
+		}
+		return nil, fmt.Errorf("Error while listing tokens: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error while listing tokens. Got HTTP status code %d", resp.StatusCode)
+	}
+	var tokens = []*api.IssuedToken{}
+	err = parseResponse(resp.Body, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke revokes the token with the given ID, as returned by List. Revoking another
+// principal's token requires an admin token
+func (t *Tokens) Revoke(id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ErrorTokenNotFound
+	}
+	resp, err := t.c.DoRequest(http.MethodDelete, escapeID(tokenBasePath, id), map[string]string{}, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		if resp != nil {
+			switch {
+			case resp.StatusCode == http.StatusNotFound:
+				return ErrorTokenNotFound
+			case endpointMissing(resp.StatusCode):
+				return ErrorTokensNotSupported
+			}
+			return apiError(resp, "revoking token")
+		}
+		return fmt.Errorf("Error while revoking token: %w", err)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error while revoking token. Got HTTP status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RevokeAll revokes every active token issued to the given principal and returns how many
+// were revoked. It stops at the first error. Tokens that disappear between listing and
+// revoking them, e.g. because they expired, are skipped
+func (t *Tokens) RevokeAll(principal string) (int, error) {
+	if strings.TrimSpace(principal) == "" {
+		return 0, fmt.Errorf("A principal is required to revoke all of its tokens")
+	}
+	tokens, err := t.List(principal)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, token := range tokens {
+		err := t.Revoke(token.ID)
+		if err == ErrorTokenNotFound {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var tokenListResponse = `[
+    {
+        "id": "3e1b1e0a",
+        "principal": "arn:aws:iam::111111111:role/example-role",
+        "principal_type": "iam_principal",
+        "policies": ["app-read"],
+        "created_ts": "2016-04-05T04:19:51Z",
+        "expires_ts": "2016-04-05T05:19:51Z"
+    }
+]`
+
+func TestListTokens(t *testing.T) {
+	principal := "arn:aws:iam::111111111:role/example-role"
+
+	Convey("A valid call to List", t, WithTestServer(http.StatusOK, "/v1/token", http.MethodGet, tokenListResponse, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the tokens", func() {
+			tokens, err := cl.Tokens().List(principal)
+			So(err, ShouldBeNil)
+			So(tokens, ShouldHaveLength, 1)
+			So(tokens[0].ID, ShouldEqual, "3e1b1e0a")
+			So(tokens[0].PrincipalType, ShouldEqual, api.PrincipalIAM)
+			So(tokens[0].Policies, ShouldResemble, []string{"app-read"})
+			So(tokens[0].Expires, ShouldEqual, parsedTime.Add(time.Hour))
+			So(tokens[0].LastUsed.IsZero(), ShouldBeTrue)
+		})
+	}))
+
+	Convey("A List against a server without token management", t, WithTestServer(http.StatusNotFound, "/v1/token", http.MethodGet, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should say it isn't supported", func() {
+			tokens, err := cl.Tokens().List(principal)
+			So(err, ShouldEqual, ErrorTokensNotSupported)
+			So(tokens, ShouldBeNil)
+		})
+	}))
+
+	Convey("A List with a non-admin token", t, WithTestServer(http.StatusForbidden, "/v1/token", http.MethodGet, errorResponse, func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the API error", func() {
+			_, err := cl.Tokens().List(principal)
+			So(err, ShouldHaveSameTypeAs, api.ErrorResponse{})
+		})
+	}))
+}
+
+func TestRevokeTokens(t *testing.T) {
+	Convey("A valid call to Revoke", t, WithTestServer(http.StatusNoContent, "/v1/token/3e1b1e0a", http.MethodDelete, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should not error", func() {
+			So(cl.Tokens().Revoke("3e1b1e0a"), ShouldBeNil)
+		})
+		Convey("Should error on an empty ID", func() {
+			So(cl.Tokens().Revoke(" "), ShouldEqual, ErrorTokenNotFound)
+		})
+	}))
+
+	Convey("A Revoke of an unknown token", t, WithTestServer(http.StatusNotFound, "/v1/token/3e1b1e0a", http.MethodDelete, "", func(ts *httptest.Server) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return ErrorTokenNotFound", func() {
+			So(cl.Tokens().Revoke("3e1b1e0a"), ShouldEqual, ErrorTokenNotFound)
+		})
+	}))
+
+	Convey("A call to RevokeAll", t, func() {
+		var revoked []string
+		var principal string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == http.MethodGet {
+				principal = r.URL.Query().Get("principal")
+				w.Write([]byte(`[{"id": "one"}, {"id": "gone"}, {"id": "two"}]`))
+				return
+			}
+			if r.URL.Path == "/v1/token/gone" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			revoked = append(revoked, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		Convey("Should revoke every token that still exists", func() {
+			count, err := cl.Tokens().RevokeAll("arn:aws:iam::111111111:role/example-role")
+			So(err, ShouldBeNil)
+			So(principal, ShouldEqual, "arn:aws:iam::111111111:role/example-role")
+			So(count, ShouldEqual, 2)
+			So(revoked, ShouldResemble, []string{"/v1/token/one", "/v1/token/two"})
+		})
+		Convey("Should need a principal", func() {
+			_, err := cl.Tokens().RevokeAll("")
+			So(err, ShouldNotBeNil)
+			So(revoked, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// ValueTransform changes the string values of secrets as they are read and written, e.g. to
+// normalize certificates that were stored with or without a trailing newline
+type ValueTransform interface {
+	// Read transforms a value read from Cerberus
+	Read(key, value string) (string, error)
+	// Write transforms a value before it is written to Cerberus
+	Write(key, value string) (string, error)
+}
+
+// TrimTrailingNewlines removes trailing newlines from values on read and on write
+type TrimTrailingNewlines struct{}
+
+// Read removes trailing newlines from value
+func (TrimTrailingNewlines) Read(key, value string) (string, error) {
+	return strings.TrimRight(value, "\r\n"), nil
+}
+
+// Write removes trailing newlines from value
+func (TrimTrailingNewlines) Write(key, value string) (string, error) {
+	return strings.TrimRight(value, "\r\n"), nil
+}
+
+// NormalizePEM normalizes PEM encoded values on read and on write. Escaped "\n" sequences,
+// which are common when a certificate was pasted into a single line field, and Windows line
+// endings become newlines, surrounding whitespace is removed and the value ends with exactly
+// one newline. Values that aren't PEM encoded are left as they are
+type NormalizePEM struct{}
+
+// Read normalizes value if it is PEM encoded
+func (NormalizePEM) Read(key, value string) (string, error) {
+	return normalizePEM(value), nil
+}
+
+// Write normalizes value if it is PEM encoded
+func (NormalizePEM) Write(key, value string) (string, error) {
+	return normalizePEM(value), nil
+}
+
+func normalizePEM(value string) string {
+	if !strings.Contains(value, "-----BEGIN ") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\n`, "\n")
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	return strings.TrimSpace(value) + "\n"
+}
+
+// Base64Values stores values base64 encoded, e.g. for binary keystores. Values are decoded on
+// read and encoded on write
+type Base64Values struct{}
+
+// Read decodes value, and returns an error if it isn't valid base64
+func (Base64Values) Read(key, value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("Value of %s is not base64 encoded: %v", key, err)
+	}
+	return string(decoded), nil
+}
+
+// Write encodes value
+func (Base64Values) Write(key, value string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(value)), nil
+}
+
+// TransformKeys returns a ValueTransform that applies transform only to the given keys of a
+// secret and leaves the other keys as they are
+func TransformKeys(transform ValueTransform, keys ...string) ValueTransform {
+	only := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		only[key] = true
+	}
+	return keysTransform{transform: transform, keys: only}
+}
+
+type keysTransform struct {
+	transform ValueTransform
+	keys      map[string]bool
+}
+
+func (k keysTransform) Read(key, value string) (string, error) {
+	if !k.keys[key] {
+		return value, nil
+	}
+	return k.transform.Read(key, value)
+}
+
+func (k keysTransform) Write(key, value string) (string, error) {
+	if !k.keys[key] {
+		return value, nil
+	}
+	return k.transform.Write(key, value)
+}
+
+// pathTransform is a ValueTransform registered for secrets matching pattern
+type pathTransform struct {
+	pattern   string
+	transform ValueTransform
+}
+
+// matches reports whether the secret at p matches the pattern. A pattern ending in "/" matches
+// every secret under it, any other pattern is matched with path.Match
+func (t pathTransform) matches(p string) bool {
+	if strings.HasSuffix(t.pattern, "/") {
+		return strings.HasPrefix(p, t.pattern)
+	}
+	matched, err := path.Match(t.pattern, p)
+	return err == nil && matched
+}
+
+// WithTransform returns a shallow copy of the client that applies transform to the string
+// values of secrets matching pattern when they are read and written. A pattern ending in "/",
+// e.g. "app/my-sdb/", matches every secret under it, and any other pattern is matched with
+// path.Match, e.g. "app/*/certificates". When several transforms match a secret, they are
+// applied in the order they were added on write, and in reverse order on read. Values that
+// aren't strings are left as they are
+func (c *Client) WithTransform(pattern string, transform ValueTransform) *Client {
+	scoped := c.copy()
+	scoped.transforms = append(append([]pathTransform{}, c.transforms...), pathTransform{pattern: pattern, transform: transform})
+	return scoped
+}
+
+// transformsFor returns the transforms that match the secret at p
+func (s *Secret) transformsFor(p string) []ValueTransform {
+	var matched []ValueTransform
+	for _, t := range s.transforms {
+		if t.matches(p) {
+			matched = append(matched, t.transform)
+		}
+	}
+	return matched
+}
+
+// transformRead applies the read transforms for p to a copy of secret, so a cached secret is
+// never changed
+func (s *Secret) transformRead(p string, secret *vault.Secret) (*vault.Secret, error) {
+	transforms := s.transformsFor(p)
+	if secret == nil || len(transforms) == 0 {
+		return secret, nil
+	}
+	data := make(map[string]interface{}, len(secret.Data))
+	for key, value := range secret.Data {
+		str, ok := value.(string)
+		if !ok {
+			data[key] = value
+			continue
+		}
+		for i := len(transforms) - 1; i >= 0; i-- {
+			var err error
+			if str, err = transforms[i].Read(key, str); err != nil {
+				return nil, fmt.Errorf("Unable to transform secret %s: %w", p, err)
+			}
+		}
+		data[key] = str
+	}
+	transformed := *secret
+	transformed.Data = data
+	return &transformed, nil
+}
+
+// transformWrite applies the write transforms for p to a copy of data
+func (s *Secret) transformWrite(p string, data map[string]interface{}) (map[string]interface{}, error) {
+	transforms := s.transformsFor(p)
+	if len(transforms) == 0 {
+		return data, nil
+	}
+	transformed := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		str, ok := value.(string)
+		if !ok {
+			transformed[key] = value
+			continue
+		}
+		for _, transform := range transforms {
+			var err error
+			if str, err = transform.Write(key, str); err != nil {
+				return nil, fmt.Errorf("Unable to transform secret %s: %w", p, err)
+			}
+		}
+		transformed[key] = str
+	}
+	return transformed, nil
+}
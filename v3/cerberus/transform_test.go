@@ -0,0 +1,132 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testPEM = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+func TestValueTransforms(t *testing.T) {
+	Convey("The built in transforms", t, func() {
+		Convey("Should trim trailing newlines", func() {
+			value, err := TrimTrailingNewlines{}.Read("key", "value\r\n\n")
+			So(err, ShouldBeNil)
+			So(value, ShouldEqual, "value")
+		})
+
+		Convey("Should normalize PEM values", func() {
+			for _, stored := range []string{
+				testPEM,
+				"  -----BEGIN CERTIFICATE-----\r\nMIIB\r\n-----END CERTIFICATE-----",
+				`-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n\n`,
+			} {
+				value, err := NormalizePEM{}.Read("cert", stored)
+				So(err, ShouldBeNil)
+				So(value, ShouldEqual, testPEM)
+			}
+			value, _ := NormalizePEM{}.Write("password", " hunter2\n")
+			So(value, ShouldEqual, " hunter2\n")
+		})
+
+		Convey("Should encode and decode base64 values", func() {
+			encoded, _ := Base64Values{}.Write("keystore", "\x00binary")
+			So(encoded, ShouldEqual, "AGJpbmFyeQ==")
+			decoded, err := Base64Values{}.Read("keystore", encoded)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldEqual, "\x00binary")
+			_, err = Base64Values{}.Read("keystore", "not base64!")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should only transform the given keys", func() {
+			only := TransformKeys(TrimTrailingNewlines{}, "cert")
+			value, _ := only.Read("cert", "value\n")
+			So(value, ShouldEqual, "value")
+			value, _ = only.Read("other", "value\n")
+			So(value, ShouldEqual, "value\n")
+		})
+	})
+
+	Convey("Transform patterns", t, func() {
+		Convey("Should match everything under a prefix", func() {
+			So(pathTransform{pattern: "app/sdb/"}.matches("app/sdb/certs/web"), ShouldBeTrue)
+			So(pathTransform{pattern: "app/sdb/"}.matches("app/other/web"), ShouldBeFalse)
+		})
+
+		Convey("Should match globs one segment at a time", func() {
+			So(pathTransform{pattern: "app/*/certs"}.matches("app/sdb/certs"), ShouldBeTrue)
+			So(pathTransform{pattern: "app/*/certs"}.matches("app/sdb/more/certs"), ShouldBeFalse)
+			So(pathTransform{pattern: "app/[/certs"}.matches("app/[/certs"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestWithTransform(t *testing.T) {
+	fake := &fakeSecrets{}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	Convey("A client with transforms", t, func() {
+		fake.secrets = map[string]map[string]interface{}{
+			"app/sdb/certs": {"cert": "-----BEGIN CERTIFICATE-----\r\nMIIB\r\n-----END CERTIFICATE-----", "port": 443},
+			"app/sdb/other": {"cert": "value\n"},
+		}
+		base, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		cl := base.WithTransform("app/*/certs", NormalizePEM{}).WithTransform("app/sdb/", TransformKeys(Base64Values{}, "keystore"))
+
+		Convey("Should transform values when reading matching secrets", func() {
+			secret, err := cl.Secret().Read("app/sdb/certs")
+			So(err, ShouldBeNil)
+			So(secret.Data["cert"], ShouldEqual, testPEM)
+			So(fmt.Sprint(secret.Data["port"]), ShouldEqual, "443")
+		})
+
+		Convey("Should leave other secrets as they are", func() {
+			secret, err := cl.Secret().Read("app/sdb/other")
+			So(err, ShouldBeNil)
+			So(secret.Data["cert"], ShouldEqual, "value\n")
+			secret, err = base.Secret().Read("app/sdb/certs")
+			So(err, ShouldBeNil)
+			So(secret.Data["cert"], ShouldNotEqual, testPEM)
+		})
+
+		Convey("Should transform values before writing", func() {
+			data := map[string]interface{}{"cert": testPEM + "\n\n", "keystore": "\x00binary"}
+			_, err := cl.Secret().Write("app/sdb/certs", data)
+			So(err, ShouldBeNil)
+			So(fake.secrets["app/sdb/certs"]["cert"], ShouldEqual, testPEM)
+			So(fake.secrets["app/sdb/certs"]["keystore"], ShouldEqual, "AGJpbmFyeQ==")
+			So(data["keystore"], ShouldEqual, "\x00binary")
+
+			secret, err := cl.Secret().Read("app/sdb/certs")
+			So(err, ShouldBeNil)
+			So(secret.Data["keystore"], ShouldEqual, "\x00binary")
+		})
+
+		Convey("Should return an error if a value can't be transformed", func() {
+			fake.secrets["app/sdb/other"]["keystore"] = "not base64!"
+			_, err := cl.Secret().Read("app/sdb/other")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// SecretVersions is a subclient for the version history of secrets and secure files. It needs a
+// Cerberus deployment with FeatureSecretVersions, and returns a NotSupportedError otherwise
+type SecretVersions struct {
+	c *Client
+}
+
+var secretVersionsBasePath = "/v1/secret-versions"
+
+// versionIDParam is the query parameter that selects a version of a secret or secure file
+const versionIDParam = "versionId"
+
+// List returns the versions of the secret or secure file at path, newest first
+func (v *SecretVersions) List(path string) (*api.SecretVersionsResponse, error) {
+	return v.ListPage(path, api.PageOpts{})
+}
+
+// ListPage returns a page of the versions of the secret or secure file at path. If the limit
+// isn't set, the server default is used
+func (v *SecretVersions) ListPage(path string, opts api.PageOpts) (*api.SecretVersionsResponse, error) {
+	if err := v.c.unsupported(FeatureSecretVersions); err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"offset": fmt.Sprintf("%d", opts.Offset),
+	}
+	if opts.Limit != 0 {
+		params["limit"] = fmt.Sprintf("%d", opts.Limit)
+	}
+	resp, err := v.c.DoRequest(http.MethodGet, escapePath(secretVersionsBasePath, path), params, nil)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if unsupported := v.c.detectVersions(FeatureSecretVersions, resp); unsupported != nil {
+		return nil, unsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error while listing secret versions: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error while listing secret versions. Got HTTP status code %d", resp.StatusCode)
+	}
+	versions := &api.SecretVersionsResponse{}
+	if err := parseResponse(resp.Body, versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// ReadVersion returns the version of the secret at path with the given ID, as listed by
+// SecretVersions. Older Cerberus deployments ignore the version and return the current secret,
+// so a NotSupportedError is returned instead if the server doesn't support
+// FeatureSecretVersions. The secret cache isn't used
+func (s *Secret) ReadVersion(path, versionID string) (*vault.Secret, error) {
+	if err := s.c.requireVersions(FeatureSecretVersions, path); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.c.readContext()
+	defer cancel()
+	if err := s.c.pace(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	s.syncToken()
+	secret, err := s.v.ReadWithDataWithContext(ctx, pathPrefix+path, map[string][]string{
+		versionIDParam: {versionID},
+	})
+	s.c.emitSecret("read", path, start, secret, err)
+	return secret, err
+}
+
+// GetVersion downloads the version of a secure file with the given ID, as listed by
+// SecretVersions, to output. Like ReadVersion, a NotSupportedError is returned if the server
+// doesn't support FeatureFileVersions
+func (r *SecureFile) GetVersion(secureFilePath, versionID string, output io.Writer) (*api.DownloadInfo, error) {
+	if err := r.c.requireVersions(FeatureFileVersions, secureFilePath); err != nil {
+		return nil, err
+	}
+	return r.get(secureFilePath, url.Values{versionIDParam: []string{versionID}}, output)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var versionsResponse = `{
+    "has_next": false,
+    "next_offset": 0,
+    "limit": 100,
+    "offset": 0,
+    "version_count_in_result": 1,
+    "total_version_count": 1,
+    "secure_data_version_summaries": [
+        {
+            "id": "b4b3c3c1",
+            "sdbox_id": "a7d703da-faac-11e5-a8a9-7fa3b294cd46",
+            "path": "app/my-sdb/config",
+            "type": "OBJECT",
+            "action": "UPDATE",
+            "size_in_bytes": 42,
+            "version_created_by": "arn:aws:iam::111111111:role/writer",
+            "version_created_ts": "2016-04-05T04:19:51Z",
+            "action_principal": "arn:aws:iam::111111111:role/writer",
+            "action_ts": "2016-04-05T04:19:51Z"
+        }
+    ]
+}`
+
+// versionsServer is a Cerberus server with or without the secret versions endpoint. It
+// counts the requests to that endpoint and records the version IDs it was asked for
+type versionsServer struct {
+	*httptest.Server
+	supported bool
+	// missing is the status of the versions endpoint when it isn't supported
+	missing    int
+	listCalls  int
+	versionIDs []string
+}
+
+func newVersionsServer(supported bool) *versionsServer {
+	s := &versionsServer{supported: supported, missing: http.StatusMethodNotAllowed}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, secretVersionsBasePath+"/"):
+			s.listCalls++
+			if !s.supported {
+				w.WriteHeader(s.missing)
+				return
+			}
+			w.Write([]byte(versionsResponse))
+		case r.URL.Path == "/v1/secret/app/my-sdb/config":
+			s.versionIDs = append(s.versionIDs, r.URL.Query().Get("versionId"))
+			w.Write([]byte(`{"data": {"password": "old"}}`))
+		case r.URL.Path == "/v1/secure-file/app/my-sdb/cert.pem":
+			s.versionIDs = append(s.versionIDs, r.URL.Query().Get("versionId"))
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("old certificate"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func TestSecretVersions(t *testing.T) {
+	Convey("A server with secret versions", t, func() {
+		ts := newVersionsServer(true)
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+
+		Convey("Should list the versions of a secret", func() {
+			versions, err := cl.SecretVersions().List("app/my-sdb/config")
+			So(err, ShouldBeNil)
+			So(versions.Summaries, ShouldHaveLength, 1)
+			So(versions.Summaries[0].ID, ShouldEqual, "b4b3c3c1")
+			So(versions.Summaries[0].Action, ShouldEqual, "UPDATE")
+			So(versions.Summaries[0].VersionCreated, ShouldEqual, parsedTime)
+			So(versions.Page().HasNext, ShouldBeFalse)
+			supported, known := cl.Supports(FeatureSecretVersions)
+			So(known, ShouldBeTrue)
+			So(supported, ShouldBeTrue)
+		})
+
+		Convey("Should read a version of a secret", func() {
+			secret, err := cl.Secret().ReadVersion("app/my-sdb/config", "b4b3c3c1")
+			So(err, ShouldBeNil)
+			So(secret.Data["password"], ShouldEqual, "old")
+			So(ts.versionIDs, ShouldResemble, []string{"b4b3c3c1"})
+			Convey("And only check for support once", func() {
+				_, err := cl.Secret().ReadVersion("app/my-sdb/config", "a1")
+				So(err, ShouldBeNil)
+				So(ts.listCalls, ShouldEqual, 1)
+			})
+		})
+
+		Convey("Should download a version of a secure file", func() {
+			var out bytes.Buffer
+			_, err := cl.SecureFile().GetVersion("app/my-sdb/cert.pem", "c9", &out)
+			So(err, ShouldBeNil)
+			So(out.String(), ShouldEqual, "old certificate")
+			So(ts.versionIDs, ShouldResemble, []string{"c9"})
+		})
+	})
+
+	Convey("An older server without secret versions", t, func() {
+		ts := newVersionsServer(false)
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+
+		Convey("Should return a NotSupportedError when listing versions", func() {
+			_, err := cl.SecretVersions().List("app/my-sdb/config")
+			So(errors.Is(err, ErrorNotSupportedByServer), ShouldBeTrue)
+			var notSupported *NotSupportedError
+			So(errors.As(err, &notSupported), ShouldBeTrue)
+			So(notSupported.Feature, ShouldEqual, FeatureSecretVersions)
+		})
+
+		Convey("Should not read the current secret instead of a version", func() {
+			_, err := cl.Secret().ReadVersion("app/my-sdb/config", "b4b3c3c1")
+			So(errors.Is(err, ErrorNotSupportedByServer), ShouldBeTrue)
+			So(ts.versionIDs, ShouldBeEmpty)
+			Convey("And remember it for copies of the client", func() {
+				var out bytes.Buffer
+				_, err := cl.WithNamespace("team-b").SecureFile().GetVersion("app/my-sdb/cert.pem", "c9", &out)
+				So(err, ShouldResemble, &NotSupportedError{Feature: FeatureFileVersions})
+				So(ts.listCalls, ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestSecretVersionsNotFound(t *testing.T) {
+	Convey("A server that returns 404 for the versions of a path", t, func() {
+		ts := newVersionsServer(false)
+		ts.missing = http.StatusNotFound
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+
+		Convey("Should not treat the versions as unsupported", func() {
+			_, err := cl.SecretVersions().List("app/my-sdb/config")
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, ErrorNotSupportedByServer), ShouldBeFalse)
+			_, known := cl.Supports(FeatureSecretVersions)
+			So(known, ShouldBeFalse)
+		})
+
+		Convey("Should not read the current secret instead of a version", func() {
+			_, err := cl.Secret().ReadVersion("app/my-sdb/config", "b4b3c3c1")
+			So(err, ShouldNotBeNil)
+			So(errors.Is(err, ErrorNotSupportedByServer), ShouldBeFalse)
+			So(ts.versionIDs, ShouldBeEmpty)
+			Convey("And check again next time", func() {
+				ts.supported = true
+				secret, err := cl.Secret().ReadVersion("app/my-sdb/config", "b4b3c3c1")
+				So(err, ShouldBeNil)
+				So(secret.Data["password"], ShouldEqual, "old")
+				So(ts.listCalls, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestCapabilitiesHeader(t *testing.T) {
+	Convey("A server that announces its capabilities", t, func() {
+		var announced string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(CapabilitiesHeader, announced)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[]`)
+		}))
+		defer ts.Close()
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		_, known := cl.Supports(FeatureSecretVersions)
+		So(known, ShouldBeFalse)
+
+		Convey("Should record the features it lists", func() {
+			announced = "Secret-Versions, something-else"
+			_, err := cl.Category().List()
+			So(err, ShouldBeNil)
+			supported, known := cl.Supports(FeatureSecretVersions)
+			So(known, ShouldBeTrue)
+			So(supported, ShouldBeTrue)
+			Convey("And treat the others as unsupported", func() {
+				supported, known := cl.Supports(FeatureFileVersions)
+				So(known, ShouldBeTrue)
+				So(supported, ShouldBeFalse)
+				var out bytes.Buffer
+				_, err := cl.SecureFile().GetVersion("app/my-sdb/cert.pem", "c9", &out)
+				So(errors.Is(err, ErrorNotSupportedByServer), ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/cenkalti/backoff"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// waitBackOff returns the backoff used by the WaitFor helpers, which gives up after timeout
+func waitBackOff(timeout time.Duration) backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 250 * time.Millisecond
+	b.MaxInterval = 5 * time.Second
+	b.MaxElapsedTime = timeout
+	return b
+}
+
+// WaitForSecret reads the secret at the given path, retrying with backoff until it is
+// readable or the timeout passes. Newly created SDBs and permissions take a moment to
+// apply, so this can be used right after creating them. Every attempt asks the server, even
+// if the client has a secret or negative cache. If the secret was still missing when the
+// timeout passed, the error wraps ErrorSecretNotFound, otherwise the last error is returned
+// as is. Path should not be prefaced with a "/"
+func (c *Client) WaitForSecret(path string, timeout time.Duration) (*vault.Secret, error) {
+	secrets := c.Secret()
+	var secret *vault.Secret
+	err := backoff.Retry(func() error {
+		// A cached miss would keep the wait going, and the read caches the secret once found
+		secrets.invalidate(path)
+		var readErr error
+		secret, readErr = secrets.Read(path)
+		if readErr != nil {
+			return readErr
+		}
+		if secret == nil {
+			return ErrorSecretNotFound
+		}
+		return nil
+	}, waitBackOff(timeout))
+	if err == ErrorSecretNotFound {
+		return nil, fmt.Errorf("Timed out after %v waiting for secret %s: %w", timeout, path, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// WaitForSDB looks up the SDB with the given name, retrying with backoff until it is
+// visible or the timeout passes. If the SDB was still missing when the timeout passed, the
+// error wraps ErrorSafeDepositBoxNotFound, otherwise the last error is returned as is
+func (c *Client) WaitForSDB(name string, timeout time.Duration) (*api.SafeDepositBox, error) {
+	var sdb *api.SafeDepositBox
+	err := backoff.Retry(func() error {
+		var getErr error
+		sdb, getErr = c.SDB().GetByName(name)
+		return getErr
+	}, waitBackOff(timeout))
+	if err == ErrorSafeDepositBoxNotFound {
+		return nil, fmt.Errorf("Timed out after %v waiting for SDB %s: %w", timeout, name, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sdb, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// withEventualServer starts a server that returns 404 for the given number of calls
+// before returning the body
+func withEventualServer(failures int, body string, f func(ts *httptest.Server, calls *int)) func() {
+	return func() {
+		calls := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			if calls <= failures {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors": []}`))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(body))
+		}))
+		f(ts, &calls)
+		Reset(func() {
+			ts.Close()
+		})
+	}
+}
+
+func TestWaitForSecret(t *testing.T) {
+	Convey("A secret that becomes readable", t, withEventualServer(2, `{"data": {"foo": "bar"}}`, func(ts *httptest.Server, calls *int) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the secret", func() {
+			secret, err := cl.WaitForSecret("app/foo/bar", 10*time.Second)
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+			So(*calls, ShouldEqual, 3)
+		})
+	}))
+
+	Convey("A secret that never becomes readable", t, withEventualServer(1000, "", func(ts *httptest.Server, calls *int) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should time out", func() {
+			secret, err := cl.WaitForSecret("app/foo/bar", 500*time.Millisecond)
+			So(secret, ShouldBeNil)
+			So(errors.Is(err, ErrorSecretNotFound), ShouldBeTrue)
+			So(err.Error(), ShouldStartWith, "Timed out after")
+		})
+	}))
+
+	Convey("A secret cached as missing that becomes readable", t, withEventualServer(1, `{"data": {"foo": "bar"}}`, func(ts *httptest.Server, calls *int) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		cl = cl.WithNegativeCache(time.Minute, time.Minute)
+		missing, err := cl.Secret().Read("app/foo/bar")
+		So(err, ShouldBeNil)
+		So(missing, ShouldBeNil)
+		Convey("Should read it from the server and forget the miss", func() {
+			secret, err := cl.WaitForSecret("app/foo/bar", 10*time.Second)
+			So(err, ShouldBeNil)
+			So(secret.Data["foo"], ShouldEqual, "bar")
+			So(*calls, ShouldEqual, 2)
+			secret, err = cl.Secret().Read("app/foo/bar")
+			So(err, ShouldBeNil)
+			So(secret, ShouldNotBeNil)
+		})
+	}))
+
+	Convey("A secret that can't be read", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		Reset(ts.Close)
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the last error as is", func() {
+			secret, err := cl.WaitForSecret("app/foo/bar", 500*time.Millisecond)
+			So(secret, ShouldBeNil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldNotContainSubstring, "Timed out")
+			So(errors.Is(err, ErrorSecretNotFound), ShouldBeFalse)
+		})
+	})
+}
+
+func TestWaitForSDB(t *testing.T) {
+	Convey("An SDB that becomes visible", t, withEventualServer(1, `[{"id": "an-id", "name": "Stage"}]`, func(ts *httptest.Server, calls *int) {
+		cl, _ := NewClient(GenerateMockAuth(ts.URL, "a-cool-token", false, false), nil)
+		So(cl, ShouldNotBeNil)
+		Convey("Should return the SDB", func() {
+			sdb, err := cl.WaitForSDB("Stage", 10*time.Second)
+			So(err, ShouldBeNil)
+			So(sdb.ID, ShouldEqual, "an-id")
+		})
+		Convey("Should time out for a different SDB", func() {
+			sdb, err := cl.WaitForSDB("Prod", 500*time.Millisecond)
+			So(sdb, ShouldBeNil)
+			So(errors.Is(err, ErrorSafeDepositBoxNotFound), ShouldBeTrue)
+		})
+	}))
+}
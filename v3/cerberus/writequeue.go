@@ -0,0 +1,319 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/Nike-Inc/cerberus-go-client/v3/utils"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// ErrorWriteConflict is reported by Replay for a queued write when the secret was changed
+// by someone else after the client last read it
+var ErrorWriteConflict = fmt.Errorf("Secret was changed since the write was queued")
+
+// QueuedWrite is a secret write or delete that was made while Cerberus was unreachable
+type QueuedWrite struct {
+	// Operation is either "write" or "delete"
+	Operation string `json:"operation"`
+	// Namespace is the namespace the write was made in, if any
+	Namespace string `json:"namespace,omitempty"`
+	// Path is the secret path, without the "secret/" prefix
+	Path string `json:"path"`
+	// Data is the secret that was written. It is nil for deletes
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Base is the version of the secret the client last saw when the write was queued, or
+	// empty if it hadn't read it. Replay compares it with the current secret
+	Base string `json:"base,omitempty"`
+	// Queued is when the write was queued
+	Queued time.Time `json:"queued"`
+}
+
+// RejectedWrite is a queued write that Replay dropped from the queue without applying it
+type RejectedWrite struct {
+	Write QueuedWrite
+	// Err is ErrorWriteConflict if the secret changed, or the error returned by Cerberus
+	Err error
+}
+
+// ReplayReport describes what Replay did with the queued writes
+type ReplayReport struct {
+	// Replayed are the writes that were applied, in order
+	Replayed []QueuedWrite
+	// Rejected are the writes that were dropped because of a conflict or an error
+	Rejected []RejectedWrite
+}
+
+// WriteQueue is a durable queue for secret writes made while Cerberus is unreachable, for
+// deployments with intermittent connectivity. Writes are kept in a file encrypted with
+// AES-GCM, so they survive restarts, and are replayed in order by Replay. A queue can be
+// shared by several clients, but should only be used by one process at a time
+type WriteQueue struct {
+	mu       sync.Mutex
+	path     string
+	aead     cipher.AEAD
+	entries  []QueuedWrite
+	versions map[string]string
+}
+
+// queueState is what is persisted in the queue file
+type queueState struct {
+	Entries  []QueuedWrite     `json:"entries"`
+	Versions map[string]string `json:"versions,omitempty"`
+}
+
+// NewWriteQueue opens the write queue in the file at path, creating it when needed. The key
+// encrypts the file and must be 16, 24 or 32 bytes long, e.g. a data key from KMS. The file
+// and its directory are created readable only by the current user
+func NewWriteQueue(path string, key []byte) (*WriteQueue, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("Queue path cannot be empty")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid queue key: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid queue key: %v", err)
+	}
+	q := &WriteQueue{
+		path:     path,
+		aead:     aead,
+		versions: map[string]string{},
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// WithWriteQueue returns a shallow copy of the client that queues secret writes and deletes
+// in q when Cerberus can't be reached, instead of returning the connection error. A queued
+// write returns no Vault response, WriteWithResult returns a WriteResult with Queued set, and
+// a queued delete returns no error. While
+// writes are queued, later writes and deletes are queued behind them to keep them in order,
+// so call Replay once Cerberus is reachable again. Reads are not affected and return the
+// secret as it is in Cerberus, without the queued writes
+func (c *Client) WithWriteQueue(q *WriteQueue) *Client {
+	scoped := c.copy()
+	scoped.writeQueue = q
+	return scoped
+}
+
+// Len returns the number of queued writes
+func (q *WriteQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Pending returns a copy of the queued writes, oldest first
+func (q *WriteQueue) Pending() []QueuedWrite {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueuedWrite(nil), q.entries...)
+}
+
+// Replay applies the queued writes in order using c. Before each one, the secret is read and
+// compared with the version the client last saw when the write was queued. If someone else
+// changed it since, the write is rejected with ErrorWriteConflict instead of overwriting their
+// change. Writes that Cerberus rejects are dropped as well, and both are returned in the
+// report for the caller to handle. If Cerberus can't be reached, Replay stops and returns the
+// error, keeping the remaining writes queued
+func (q *WriteQueue) Replay(c *Client) (*ReplayReport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	report := &ReplayReport{}
+	// Replay without the queue so connection errors aren't queued again
+	direct := c.copy()
+	direct.writeQueue = nil
+	for len(q.entries) > 0 {
+		entry := q.entries[0]
+		err := q.apply(direct.WithNamespace(entry.Namespace).Secret(), entry)
+		if unreachable(err) {
+			return report, err
+		}
+		q.entries = q.entries[1:]
+		if err != nil {
+			report.Rejected = append(report.Rejected, RejectedWrite{Write: entry, Err: err})
+		} else {
+			report.Replayed = append(report.Replayed, entry)
+		}
+		if err := q.save(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// apply checks a queued write for conflicts and applies it
+func (q *WriteQueue) apply(s *Secret, entry QueuedWrite) error {
+	key := secretCacheKey(entry.Namespace, entry.Path)
+	s.syncToken()
+	current, err := s.v.Read(pathPrefix + entry.Path)
+	if err != nil {
+		return err
+	}
+	if entry.Base != "" && secretVersion(current) != entry.Base {
+		// Forget the version so later writes to the path aren't rejected as well
+		delete(q.versions, key)
+		return ErrorWriteConflict
+	}
+	if entry.Operation == "delete" {
+		_, err = s.v.Delete(pathPrefix + entry.Path)
+	} else {
+		_, err = s.v.Write(pathPrefix+entry.Path, entry.Data)
+	}
+	s.invalidate(entry.Path)
+	return err
+}
+
+// observe records the version of a secret the client saw, used to detect conflicts when
+// writes to it are queued. It is only kept in memory until a write is queued
+func (q *WriteQueue) observe(namespace, path, version string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if version == "" {
+		delete(q.versions, secretCacheKey(namespace, path))
+		return
+	}
+	q.versions[secretCacheKey(namespace, path)] = version
+}
+
+// pending returns whether there are queued writes
+func (q *WriteQueue) pending() bool {
+	return q.Len() > 0
+}
+
+// enqueue adds a write to the queue and saves it before returning
+func (q *WriteQueue) enqueue(operation, namespace, path string, data map[string]interface{}) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := secretCacheKey(namespace, path)
+	q.entries = append(q.entries, QueuedWrite{
+		Operation: operation,
+		Namespace: namespace,
+		Path:      path,
+		Data:      data,
+		Base:      q.versions[key],
+		Queued:    time.Now(),
+	})
+	// Later writes to the path are based on this one
+	if operation == "delete" {
+		delete(q.versions, key)
+	} else {
+		q.versions[key] = secretVersion(&vault.Secret{Data: data})
+	}
+	if err := q.save(); err != nil {
+		q.entries = q.entries[:len(q.entries)-1]
+		return err
+	}
+	return nil
+}
+
+// load reads and decrypts the queue file. A missing file is an empty queue
+func (q *WriteQueue) load() error {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to read write queue: %v", err)
+	}
+	size := q.aead.NonceSize()
+	if len(data) < size {
+		return fmt.Errorf("Unable to read write queue: file is truncated")
+	}
+	plain, err := q.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return fmt.Errorf("Unable to decrypt write queue, the key may be wrong: %v", err)
+	}
+	state := queueState{}
+	decoder := json.NewDecoder(bytes.NewReader(plain))
+	decoder.UseNumber()
+	if err := decoder.Decode(&state); err != nil {
+		return fmt.Errorf("Unable to parse write queue: %v", err)
+	}
+	q.entries = state.Entries
+	if state.Versions != nil {
+		q.versions = state.Versions
+	}
+	return nil
+}
+
+// save encrypts the queue and replaces the queue file with it, so a crash leaves either the
+// old or the new queue
+func (q *WriteQueue) save() error {
+	plain, err := json.Marshal(queueState{Entries: q.entries, Versions: q.versions})
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, q.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := q.aead.Seal(nonce, nonce, plain, nil)
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return fmt.Errorf("Unable to create write queue directory: %v", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return fmt.Errorf("Unable to write write queue: %v", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("Unable to write write queue: %v", err)
+	}
+	return nil
+}
+
+// unreachable returns whether err means Cerberus couldn't be reached
+func unreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var connErr api.ErrorConnection
+	return errors.As(utils.ConnectionError("", err), &connErr)
+}
+
+// queueWrite queues a write or delete made through s
+func (s *Secret) queueWrite(operation, path string, data map[string]interface{}) error {
+	if err := s.queue.enqueue(operation, s.namespace, path, data); err != nil {
+		return fmt.Errorf("Unable to queue %s of %s: %w", operation, path, err)
+	}
+	return nil
+}
+
+// observe records the version of a secret read or written through s, if it has a queue
+func (s *Secret) observe(path string, secret *vault.Secret) {
+	if s.queue != nil {
+		s.queue.observe(s.namespace, path, secretVersion(secret))
+	}
+}
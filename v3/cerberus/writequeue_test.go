@@ -0,0 +1,244 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cerberus
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeSecrets is a Cerberus server that keeps secrets in memory
+type fakeSecrets struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+	writes  []string
+}
+
+func (f *fakeSecrets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+	w.Header().Set("Content-Type", "application/json")
+	method := r.Method
+	if r.URL.Query().Get("list") == "true" {
+		method = "LIST"
+	}
+	switch method {
+	case http.MethodGet:
+		data, ok := f.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	case "LIST":
+		keys := []string{}
+		seen := map[string]bool{}
+		for p := range f.secrets {
+			if strings.HasPrefix(p, path+"/") {
+				name := strings.SplitAfterN(p[len(path)+1:], "/", 2)[0]
+				if !seen[name] {
+					seen[name] = true
+					keys = append(keys, name)
+				}
+			}
+		}
+		sort.Strings(keys)
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case http.MethodDelete:
+		delete(f.secrets, path)
+		f.writes = append(f.writes, "delete "+path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&data)
+		f.secrets[path] = data
+		f.writes = append(f.writes, "write "+path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeSecrets) set(path string, data map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.secrets[path] = data
+}
+
+func TestNewWriteQueue(t *testing.T) {
+	Convey("An empty path", t, func() {
+		q, err := NewWriteQueue("", make([]byte, 32))
+		So(err, ShouldNotBeNil)
+		So(q, ShouldBeNil)
+	})
+	Convey("An invalid key", t, func() {
+		q, err := NewWriteQueue(filepath.Join(t.TempDir(), "queue"), []byte("short"))
+		So(err, ShouldNotBeNil)
+		So(q, ShouldBeNil)
+	})
+	Convey("A missing file", t, func() {
+		q, err := NewWriteQueue(filepath.Join(t.TempDir(), "queue"), make([]byte, 32))
+		So(err, ShouldBeNil)
+		So(q.Len(), ShouldEqual, 0)
+	})
+}
+
+func TestWriteQueue(t *testing.T) {
+	fake := &fakeSecrets{}
+	online := httptest.NewServer(fake)
+	defer online.Close()
+	// A server that was closed refuses connections, like an unreachable Cerberus
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	key := bytes.Repeat([]byte{7}, 32)
+
+	Convey("A client with a write queue", t, func() {
+		fake.secrets = map[string]map[string]interface{}{}
+		fake.writes = nil
+		path := filepath.Join(t.TempDir(), "cerberus", "queue")
+		q, err := NewWriteQueue(path, key)
+		So(err, ShouldBeNil)
+		onlineClient, _ := NewClient(GenerateMockAuth(online.URL, "a-cool-token", false, false), nil)
+		offlineClient, _ := NewClient(GenerateMockAuth(down.URL, "a-cool-token", false, false), nil)
+		offlineClient.vaultClient.SetMaxRetries(0)
+		onlineClient = onlineClient.WithWriteQueue(q)
+		offlineClient = offlineClient.WithWriteQueue(q)
+
+		Convey("Should write directly when Cerberus is reachable", func() {
+			result, err := onlineClient.Secret().WriteWithResult("app/sdb/config", map[string]interface{}{"key": "value"})
+			So(err, ShouldBeNil)
+			So(result.Queued, ShouldBeFalse)
+			So(q.Len(), ShouldEqual, 0)
+		})
+
+		Convey("When Cerberus is unreachable", func() {
+			result, err := offlineClient.Secret().WriteWithResult("app/sdb/config", map[string]interface{}{"key": "super-secret"})
+			So(err, ShouldBeNil)
+			So(result.Queued, ShouldBeTrue)
+			_, err = offlineClient.Secret().Delete("app/sdb/old")
+			So(err, ShouldBeNil)
+
+			Convey("Should queue the writes in order", func() {
+				pending := q.Pending()
+				So(pending, ShouldHaveLength, 2)
+				So(pending[0].Operation, ShouldEqual, "write")
+				So(pending[0].Data, ShouldResemble, map[string]interface{}{"key": "super-secret"})
+				So(pending[1].Operation, ShouldEqual, "delete")
+			})
+
+			Convey("Should encrypt the queue file", func() {
+				data, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(string(data), ShouldNotContainSubstring, "super-secret")
+				info, _ := os.Stat(path)
+				So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+			})
+
+			Convey("Should keep the writes across restarts", func() {
+				reopened, err := NewWriteQueue(path, key)
+				So(err, ShouldBeNil)
+				pending := reopened.Pending()
+				So(pending, ShouldHaveLength, 2)
+				So(pending[0].Path, ShouldEqual, "app/sdb/config")
+				So(pending[0].Data, ShouldResemble, map[string]interface{}{"key": "super-secret"})
+				So(pending[1].Path, ShouldEqual, "app/sdb/old")
+				_, err = NewWriteQueue(path, bytes.Repeat([]byte{8}, 32))
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("Should queue later writes behind them even when Cerberus is reachable", func() {
+				result, err := onlineClient.Secret().WriteWithResult("app/sdb/other", map[string]interface{}{"key": "value"})
+				So(err, ShouldBeNil)
+				So(result.Queued, ShouldBeTrue)
+				So(q.Len(), ShouldEqual, 3)
+				So(fake.writes, ShouldBeEmpty)
+			})
+
+			Convey("Should keep them queued when replaying fails to connect", func() {
+				report, err := q.Replay(offlineClient)
+				So(err, ShouldNotBeNil)
+				So(report.Replayed, ShouldBeEmpty)
+				So(q.Len(), ShouldEqual, 2)
+			})
+
+			Convey("Should replay them in order once Cerberus is reachable", func() {
+				report, err := q.Replay(onlineClient)
+				So(err, ShouldBeNil)
+				So(report.Replayed, ShouldHaveLength, 2)
+				So(report.Rejected, ShouldBeEmpty)
+				So(fake.writes, ShouldResemble, []string{"write app/sdb/config", "delete app/sdb/old"})
+				So(fake.secrets["app/sdb/config"], ShouldResemble, map[string]interface{}{"key": "super-secret"})
+				So(q.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("With a secret that was changed by someone else while offline", func() {
+			fake.set("app/sdb/config", map[string]interface{}{"key": "original"})
+			_, err := onlineClient.Secret().Read("app/sdb/config")
+			So(err, ShouldBeNil)
+			_, err = offlineClient.Secret().Write("app/sdb/config", map[string]interface{}{"key": "mine"})
+			So(err, ShouldBeNil)
+			_, err = offlineClient.Secret().Write("app/sdb/unchanged", map[string]interface{}{"key": "mine"})
+			So(err, ShouldBeNil)
+			fake.set("app/sdb/config", map[string]interface{}{"key": "theirs"})
+
+			Convey("Should reject the conflicting write and replay the rest", func() {
+				report, err := q.Replay(onlineClient)
+				So(err, ShouldBeNil)
+				So(report.Rejected, ShouldHaveLength, 1)
+				So(report.Rejected[0].Err, ShouldEqual, ErrorWriteConflict)
+				So(report.Rejected[0].Write.Path, ShouldEqual, "app/sdb/config")
+				So(report.Replayed, ShouldHaveLength, 1)
+				So(fake.secrets["app/sdb/config"], ShouldResemble, map[string]interface{}{"key": "theirs"})
+				So(q.Len(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("With a secret that wasn't changed while offline", func() {
+			fake.set("app/sdb/config", map[string]interface{}{"key": "original"})
+			_, err := onlineClient.Secret().Read("app/sdb/config")
+			So(err, ShouldBeNil)
+			_, err = offlineClient.Secret().Write("app/sdb/config", map[string]interface{}{"key": "first"})
+			So(err, ShouldBeNil)
+			_, err = offlineClient.Secret().Write("app/sdb/config", map[string]interface{}{"key": "second"})
+			So(err, ShouldBeNil)
+
+			Convey("Should replay every write to it", func() {
+				report, err := q.Replay(onlineClient)
+				So(err, ShouldBeNil)
+				So(report.Rejected, ShouldBeEmpty)
+				So(report.Replayed, ShouldHaveLength, 2)
+				So(fake.secrets["app/sdb/config"], ShouldResemble, map[string]interface{}{"key": "second"})
+			})
+		})
+	})
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command cerberus-bench runs a load test against a Cerberus environment using the STS auth
+// method and prints latency percentiles and error rates. For example:
+//
+//	cerberus-bench -url https://cerberus.example.com -region us-west-2 -concurrency 20 \
+//		-duration 1m -read app/my-sdb/config,app/my-sdb/db -write app/load-test/bench -mix 8,1,0
+//
+// Writes replace the secret at the write path, so only use a path set aside for testing.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/bench"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	cerberusURL := flag.String("url", os.Getenv("CERBERUS_URL"), "the Cerberus URL")
+	region := flag.String("region", os.Getenv("AWS_REGION"), "the AWS region to authenticate in")
+	concurrency := flag.Int("concurrency", 10, "the number of concurrent workers")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run for")
+	requests := flag.Int("requests", 0, "stop after this many requests")
+	reads := flag.String("read", "", "comma separated secret paths to read")
+	write := flag.String("write", "", "a secret path to write to; its secret is replaced")
+	files := flag.String("file", "", "comma separated secure file paths to download")
+	mix := flag.String("mix", "1,1,1", "relative weights of reads, writes and file downloads")
+	timeout := flag.Duration("timeout", 0, "timeout of each request, or 0 for the client default")
+	retries := flag.Int("retries", -1, "maximum retries of secret requests, or -1 for the client default")
+	flag.Parse()
+
+	weights, err := parseMix(*mix)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authMethod, err := auth.NewSTSAuth(*cerberusURL, *region)
+	if err != nil {
+		log.Fatalf("Unable to set up authentication: %v", err)
+	}
+	client, err := cerberus.NewClient(authMethod, nil)
+	if err != nil {
+		log.Fatalf("Unable to authenticate with Cerberus: %v", err)
+	}
+	if *timeout > 0 {
+		client = client.WithTimeout(*timeout)
+	}
+	if *retries >= 0 {
+		client.VaultClient().SetMaxRetries(*retries)
+	}
+
+	// Keep the log quiet so it doesn't slow down the run
+	log.SetLevel(log.WarnLevel)
+	report, err := bench.Run(context.Background(), client, bench.Options{
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Requests:    *requests,
+		Workload: bench.Workload{
+			ReadWeight:  weights[0],
+			WriteWeight: weights[1],
+			FileWeight:  weights[2],
+			ReadPaths:   split(*reads),
+			WritePath:   *write,
+			FilePaths:   split(*files),
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(report)
+}
+
+// parseMix parses the read, write and file weights
+func parseMix(mix string) ([3]int, error) {
+	var weights [3]int
+	parts := strings.Split(mix, ",")
+	if len(parts) != 3 {
+		return weights, fmt.Errorf("-mix needs three weights, e.g. 8,1,1")
+	}
+	for i, part := range parts {
+		w, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || w < 0 {
+			return weights, fmt.Errorf("Invalid weight %q in -mix", part)
+		}
+		weights[i] = w
+	}
+	return weights, nil
+}
+
+func split(paths string) []string {
+	var out []string
+	for _, p := range strings.Split(paths, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command cerberus-sidecar authenticates with Cerberus using the STS auth method and shares
+// the token and secret reads with other processes on the same host. See the sidecar package
+// for the endpoints. For example:
+//
+//	cerberus-sidecar -url https://cerberus.example.com -region us-west-2 -listen 127.0.0.1:8999
+//	curl -H "X-Cerberus-Sidecar: true" http://127.0.0.1:8999/v1/secret/app/my-sdb/config
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	"github.com/Nike-Inc/cerberus-go-client/v3/sidecar"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	cerberusURL := flag.String("url", os.Getenv("CERBERUS_URL"), "the Cerberus URL")
+	region := flag.String("region", os.Getenv("AWS_REGION"), "the AWS region to authenticate in")
+	listen := flag.String("listen", "127.0.0.1:8999", "a loopback address, or unix:<path> for a Unix socket")
+	flag.Parse()
+
+	authMethod, err := auth.NewSTSAuth(*cerberusURL, *region)
+	if err != nil {
+		log.Fatalf("Unable to set up authentication: %v", err)
+	}
+	client, err := cerberus.NewClient(authMethod, nil)
+	if err != nil {
+		log.Fatalf("Unable to authenticate with Cerberus: %v", err)
+	}
+	watcher, err := auth.NewTokenWatcher(authMethod)
+	if err != nil {
+		log.Fatalf("Unable to watch the Cerberus token: %v", err)
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	server, err := sidecar.NewServer(client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	network, address := "tcp", *listen
+	if strings.HasPrefix(address, "unix:") {
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+		os.Remove(address)
+	}
+	listener, err := sidecar.Listen(network, address)
+	if err != nil {
+		log.Fatalf("Unable to listen on %s: %v", *listen, err)
+	}
+	if network == "unix" {
+		// Only processes running as the same user can read the token
+		os.Chmod(address, 0600)
+	}
+
+	httpServer := &http.Server{Handler: server}
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-signals:
+		case err := <-watcher.DoneCh():
+			log.Errorf("Stopped renewing the Cerberus token: %v", err)
+		}
+		httpServer.Close()
+	}()
+	log.Infof("Serving Cerberus token and secrets on %s", *listen)
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command cerberus-verify checks that every secret and secure file in a manifest exists and
+// can be read, and exits with status 1 if any can't. It is meant to run in CI before a
+// deployment. Authentication is configured by the environment, as described for
+// auth.NewAuthFromEnvironment. For example:
+//
+//	CERBERUS_URL=https://cerberus.example.com AWS_REGION=us-west-2 cerberus-verify -manifest secrets.yaml
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	"github.com/Nike-Inc/cerberus-go-client/v3/manifest"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	path := flag.String("manifest", "cerberus-manifest.yaml", "the manifest to verify")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	m, err := manifest.Load(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authMethod, err := auth.NewAuthFromEnvironment()
+	if err != nil {
+		log.Fatalf("Unable to set up authentication: %v", err)
+	}
+	client, err := cerberus.NewClient(authMethod, nil)
+	if err != nil {
+		log.Fatalf("Unable to authenticate with Cerberus: %v", err)
+	}
+	report, err := manifest.Verify(client, m)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		fmt.Print(report)
+	}
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
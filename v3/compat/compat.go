@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compat has the API of the root module, github.com/Nike-Inc/cerberus-go-client,
+// implemented on top of v3, so a large codebase can move to the v3 module one package at a
+// time instead of in one rewrite.
+//
+// Replace imports of the root cerberus package with this package and the root auth and api
+// packages with their v3 versions, whose APIs are the same. The client is the v3 client, which
+// keeps the signatures of the root module, so new code can use v3 features through the same
+// client.
+package compat
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+)
+
+// Client is a v3 cerberus.Client
+type Client struct {
+	*cerberus.Client
+}
+
+// NewClient is the same as cerberus.NewClient
+func NewClient(authMethod auth.Auth, otpFile *os.File) (*Client, error) {
+	c, err := cerberus.NewClient(authMethod, otpFile)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(c), nil
+}
+
+// NewClientWithHeaders is the same as cerberus.NewClientWithHeaders
+func NewClientWithHeaders(authMethod auth.Auth, otpFile *os.File, defaultHeaders http.Header) (*Client, error) {
+	c, err := cerberus.NewClientWithHeaders(authMethod, otpFile, defaultHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(c), nil
+}
+
+// Wrap returns a Client for a v3 client, e.g. one configured with the v3 With methods
+func Wrap(c *cerberus.Client) *Client {
+	return &Client{Client: c}
+}
+
+// V3 returns the v3 client, for code that has been migrated
+func (c *Client) V3() *cerberus.Client {
+	return c.Client
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compat
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClient(t *testing.T) {
+	var written map[string]interface{}
+	var posted struct {
+		contentType string
+		body        string
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/app/sdb/db":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"request_id": "req-1", "data": {"version": 3}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/raw":
+			body, _ := ioutil.ReadAll(r.Body)
+			posted.contentType, posted.body = r.Header.Get("Content-Type"), string(body)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secure-file/"):
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("file contents"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	Convey("A compat client", t, func() {
+		written = nil
+		authMethod, err := auth.NewTokenAuth(ts.URL, "a-cool-token")
+		So(err, ShouldBeNil)
+		cl, err := NewClient(authMethod, nil)
+		So(err, ShouldBeNil)
+
+		Convey("Should return the Vault response of a write", func() {
+			response, err := cl.Secret().Write("app/sdb/db", map[string]interface{}{"password": "hunter2"})
+			So(err, ShouldBeNil)
+			So(written["password"], ShouldEqual, "hunter2")
+			So(response.RequestID, ShouldEqual, "req-1")
+			So(response.Data["version"], ShouldEqual, json.Number("3"))
+		})
+
+		Convey("Should download secure files", func() {
+			var buf bytes.Buffer
+			So(cl.SecureFile().Get("app/sdb/file.txt", &buf), ShouldBeNil)
+			So(buf.String(), ShouldEqual, "file contents")
+		})
+
+		Convey("Should encode a []byte request body as JSON", func() {
+			resp, err := cl.DoRequest(http.MethodPost, "/v1/raw", nil, []byte("raw"))
+			So(err, ShouldBeNil)
+			resp.Body.Close()
+			So(posted.contentType, ShouldEqual, "application/json")
+			So(posted.body, ShouldEqual, "\"cmF3\"\n")
+		})
+
+		Convey("Should share the v3 client", func() {
+			So(cl.V3(), ShouldEqual, cl.Client)
+			So(Wrap(cl.V3()).Client, ShouldEqual, cl.Client)
+		})
+	})
+}
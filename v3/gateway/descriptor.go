@@ -0,0 +1,144 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// The messages are described in code rather than generated from gateway.proto, so the package
+// doesn't need generated code. Keep the two in sync
+
+const protoPackage = "cerberus.gateway.v1"
+
+// ServiceName is the full name of the gRPC service
+const ServiceName = protoPackage + ".Secrets"
+
+// descriptor is the file descriptor of gateway.proto
+var descriptor = mustBuildDescriptor()
+
+func mustBuildDescriptor() protoreflect.FileDescriptor {
+	fd, err := protodesc.NewFile(fileDescriptorProto(), nil)
+	if err != nil {
+		panic(err)
+	}
+	return fd
+}
+
+// message returns the descriptor of the message with the given name
+func message(name string) protoreflect.MessageDescriptor {
+	return descriptor.Messages().ByName(protoreflect.Name(name))
+}
+
+func fileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("cerberus/gateway/v1/gateway.proto"),
+		Package: proto.String(protoPackage),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			messageProto("SecretRequest", stringField("path", 1)),
+			withMapEntry(messageProto("Secret", mapField("Secret", "data", 1)), "data"),
+			messageProto("SecretList", repeatedStringField("keys", 1)),
+			withMapEntry(messageProto("WriteSecretRequest", stringField("path", 1), mapField("WriteSecretRequest", "data", 2)), "data"),
+			messageProto("FileRequest", stringField("path", 1)),
+			messageProto("File", stringField("path", 1), stringField("filename", 2), stringField("content_type", 3), bytesField("content", 4)),
+			messageProto("Empty"),
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{serviceProto()},
+	}
+}
+
+func serviceProto() *descriptorpb.ServiceDescriptorProto {
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String("Secrets")}
+	for _, m := range methods {
+		service.Method = append(service.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(m.name),
+			InputType:  proto.String("." + protoPackage + "." + m.input),
+			OutputType: proto.String("." + protoPackage + "." + m.output),
+		})
+	}
+	return service
+}
+
+func messageProto(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	return &descriptorpb.DescriptorProto{Name: proto.String(name), Field: fields}
+}
+
+// withMapEntry adds the nested entry message of a map<string, string> field to m
+func withMapEntry(m *descriptorpb.DescriptorProto, field string) *descriptorpb.DescriptorProto {
+	m.NestedType = append(m.NestedType, &descriptorpb.DescriptorProto{
+		Name:    proto.String(mapEntryName(field)),
+		Field:   []*descriptorpb.FieldDescriptorProto{stringField("key", 1), stringField("value", 2)},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	})
+	return m
+}
+
+// mapEntryName returns the name protoc gives the entry message of a map field
+func mapEntryName(field string) string {
+	return string(field[0]-'a'+'A') + field[1:] + "Entry"
+}
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(jsonName(name)),
+		Number:   proto.Int32(number),
+		Type:     typ.Enum(),
+		Label:    label.Enum(),
+	}
+}
+
+func stringField(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return field(name, number, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL)
+}
+
+func bytesField(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return field(name, number, descriptorpb.FieldDescriptorProto_TYPE_BYTES, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL)
+}
+
+func repeatedStringField(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return field(name, number, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED)
+}
+
+func mapField(message, name string, number int32) *descriptorpb.FieldDescriptorProto {
+	f := field(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_LABEL_REPEATED)
+	f.TypeName = proto.String("." + protoPackage + "." + message + "." + mapEntryName(name))
+	return f
+}
+
+// jsonName converts a snake_case field name to lowerCamelCase, like protoc does
+func jsonName(name string) string {
+	out := make([]byte, 0, len(name))
+	upper := false
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '_':
+			upper = true
+		case upper && c >= 'a' && c <= 'z':
+			out = append(out, c-'a'+'A')
+			upper = false
+		default:
+			out = append(out, c)
+			upper = false
+		}
+	}
+	return string(out)
+}
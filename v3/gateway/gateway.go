@@ -0,0 +1,321 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gateway exposes the secret and secure file operations of a Cerberus client as a gRPC
+// service, so platforms can front Cerberus with a uniform RPC interface while this client
+// handles authentication, retries and caching underneath. The service is described by
+// gateway.proto, which can be used to generate clients in any language.
+//
+// Calls are made with the client's own token, so the gateway denies every call until it has an
+// Authorizer that decides what each caller may access. With token delegation, callers send their
+// own Cerberus token in the x-cerberus-token metadata instead, and Cerberus decides.
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	vault "github.com/hashicorp/vault/api"
+	"github.com/taskcluster/httpbackoff"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// TokenMetadata is the metadata key callers send their Cerberus token in when token
+// delegation is enabled
+const TokenMetadata = "x-cerberus-token"
+
+// Authorizer decides whether the caller of a gRPC method may access a path. The method is the
+// short method name, e.g. "ReadSecret", and the path is the secret or secure file path from
+// the request. Returning an error denies the call; errors that aren't a gRPC status are
+// returned to the caller as PermissionDenied
+type Authorizer func(ctx context.Context, method, path string) error
+
+// AllowAll is an Authorizer that allows every call. It gives every caller that can reach the
+// gateway the access of the client's token, so only use it where the network already limits
+// who can connect
+func AllowAll(ctx context.Context, method, path string) error {
+	return nil
+}
+
+// Server implements the gRPC service on top of a Cerberus client
+type Server struct {
+	client    *cerberus.Client
+	authorize Authorizer
+	delegate  bool
+}
+
+// NewServer returns a Server for the given client. It denies every call with PermissionDenied
+// until an Authorizer or token delegation is set
+func NewServer(client *cerberus.Client) (*Server, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Client cannot be nil")
+	}
+	return &Server{client: client}, nil
+}
+
+// WithAuthorizer sets the Authorizer called before every call
+func (s *Server) WithAuthorizer(authorize Authorizer) *Server {
+	s.authorize = authorize
+	return s
+}
+
+// WithTokenDelegation makes every call with the token the caller sends in the TokenMetadata
+// metadata, using Client.WithToken, so Cerberus enforces the caller's own permissions. Calls
+// without a token fail with Unauthenticated
+func (s *Server) WithTokenDelegation() *Server {
+	s.delegate = true
+	return s
+}
+
+// Register registers the service with a gRPC server
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	registrar.RegisterService(serviceDesc(), s)
+}
+
+// method is one RPC of the service. call fills out from in using the given client
+type method struct {
+	name   string
+	input  string
+	output string
+	call   func(c *cerberus.Client, path string, in, out *dynamicpb.Message) error
+}
+
+var methods = []method{
+	{name: "ReadSecret", input: "SecretRequest", output: "Secret", call: readSecret},
+	{name: "ListSecrets", input: "SecretRequest", output: "SecretList", call: listSecrets},
+	{name: "WriteSecret", input: "WriteSecretRequest", output: "Empty", call: writeSecret},
+	{name: "DeleteSecret", input: "SecretRequest", output: "Empty", call: deleteSecret},
+	{name: "GetFile", input: "FileRequest", output: "File", call: getFile},
+	{name: "PutFile", input: "File", output: "Empty", call: putFile},
+}
+
+// serviceDesc describes the service the same way generated code does
+func serviceDesc() *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: ServiceName,
+		HandlerType: (*interface{})(nil),
+		Metadata:    "cerberus/gateway/v1/gateway.proto",
+	}
+	for _, m := range methods {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{MethodName: m.name, Handler: m.handler})
+	}
+	return desc
+}
+
+// handler decodes the request and runs the call through the interceptor, if any
+func (m method) handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := dynamicpb.NewMessage(message(m.input))
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	serve := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).serve(ctx, m, req.(*dynamicpb.Message))
+	}
+	if interceptor == nil {
+		return serve(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + m.name}, serve)
+}
+
+func (s *Server) serve(ctx context.Context, m method, in *dynamicpb.Message) (*dynamicpb.Message, error) {
+	path := getString(in, "path")
+	if path == "" {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+	if s.authorize == nil && !s.delegate {
+		return nil, status.Error(codes.PermissionDenied, "the gateway has no authorizer and no token delegation")
+	}
+	if s.authorize != nil {
+		if err := s.authorize(ctx, m.name, path); err != nil {
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	client, err := s.clientFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := dynamicpb.NewMessage(message(m.output))
+	if err := m.call(client, path, in, out); err != nil {
+		return nil, toStatus(err)
+	}
+	return out, nil
+}
+
+// clientFor returns the client to make a call with, which uses the caller's token with
+// token delegation
+func (s *Server) clientFor(ctx context.Context) (*cerberus.Client, error) {
+	if !s.delegate {
+		return s.client, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(TokenMetadata)
+	if len(tokens) == 0 || tokens[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, TokenMetadata+" metadata is required")
+	}
+	client, err := s.client.WithToken(tokens[0])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return client, nil
+}
+
+// toStatus converts an error from the client to a gRPC status
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Internal
+	var vaultErr *vault.ResponseError
+	var httpErr httpbackoff.BadHttpResponseCode
+	var unavailable api.ErrorServiceUnavailable
+	var connErr api.ErrorConnection
+	switch {
+	case errors.Is(err, cerberus.ErrorSecureFileNotFound):
+		code = codes.NotFound
+	case errors.Is(err, api.ErrorUnauthenticated), errors.Is(err, api.ErrorUnauthorized):
+		code = codes.Unauthenticated
+	case errors.As(err, &vaultErr):
+		code = codeFor(vaultErr.StatusCode)
+	case errors.As(err, &httpErr):
+		code = codeFor(httpErr.HttpResponseCode)
+	case errors.As(err, &unavailable), errors.As(err, &connErr):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// codeFor returns the gRPC code for an HTTP status code from Cerberus
+func codeFor(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+func readSecret(c *cerberus.Client, path string, in, out *dynamicpb.Message) error {
+	secret, err := c.Secret().Read(path)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return status.Errorf(codes.NotFound, "Secret %s not found", path)
+	}
+	data := out.Mutable(out.Descriptor().Fields().ByName("data")).Map()
+	for k, v := range secret.Data {
+		value, err := stringValue(v)
+		if err != nil {
+			return err
+		}
+		data.Set(protoreflect.ValueOfString(k).MapKey(), protoreflect.ValueOfString(value))
+	}
+	return nil
+}
+
+func listSecrets(c *cerberus.Client, path string, in, out *dynamicpb.Message) error {
+	secret, err := c.Secret().List(path)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return status.Errorf(codes.NotFound, "No secrets found under %s", path)
+	}
+	keys, _ := secret.Data["keys"].([]interface{})
+	list := out.Mutable(out.Descriptor().Fields().ByName("keys")).List()
+	for _, k := range keys {
+		list.Append(protoreflect.ValueOfString(fmt.Sprint(k)))
+	}
+	return nil
+}
+
+func writeSecret(c *cerberus.Client, path string, in, out *dynamicpb.Message) error {
+	data := map[string]interface{}{}
+	in.Get(in.Descriptor().Fields().ByName("data")).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		data[k.String()] = v.String()
+		return true
+	})
+	_, err := c.Secret().Write(path, data)
+	return err
+}
+
+func deleteSecret(c *cerberus.Client, path string, in, out *dynamicpb.Message) error {
+	_, err := c.Secret().Delete(path)
+	return err
+}
+
+func getFile(c *cerberus.Client, path string, in, out *dynamicpb.Message) error {
+	var content bytes.Buffer
+	info, err := c.SecureFile().GetWithInfo(path, &content)
+	if err != nil {
+		return err
+	}
+	setString(out, "path", path)
+	setString(out, "filename", info.Filename)
+	setString(out, "content_type", info.ContentType)
+	out.Set(out.Descriptor().Fields().ByName("content"), protoreflect.ValueOfBytes(content.Bytes()))
+	return nil
+}
+
+func putFile(c *cerberus.Client, path string, in, out *dynamicpb.Message) error {
+	filename := getString(in, "filename")
+	if filename == "" {
+		return status.Error(codes.InvalidArgument, "filename is required")
+	}
+	content := in.Get(in.Descriptor().Fields().ByName("content")).Bytes()
+	return c.SecureFile().Put(path, filename, bytes.NewReader(content))
+}
+
+func getString(m *dynamicpb.Message, field protoreflect.Name) string {
+	return m.Get(m.Descriptor().Fields().ByName(field)).String()
+}
+
+func setString(m *dynamicpb.Message, field protoreflect.Name, value string) {
+	m.Set(m.Descriptor().Fields().ByName(field), protoreflect.ValueOfString(value))
+}
+
+// stringValue returns a secret value as a string. Values that aren't strings are sent as JSON
+func stringValue(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("Unable to encode secret value: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// staticAuth is an Auth with a fixed token
+type staticAuth struct {
+	baseURL *url.URL
+}
+
+func (a *staticAuth) GetToken(*os.File) (string, error) { return "service-token", nil }
+func (a *staticAuth) IsAuthenticated() bool             { return true }
+func (a *staticAuth) Refresh() error                    { return nil }
+func (a *staticAuth) Logout() error                     { return nil }
+func (a *staticAuth) GetURL() *url.URL                  { return a.baseURL }
+func (a *staticAuth) GetExpiry() (time.Time, error) {
+	return time.Time{}, fmt.Errorf("Expiry time not set")
+}
+func (a *staticAuth) GetHeaders() (http.Header, error) {
+	return http.Header{"X-Cerberus-Token": []string{"service-token"}}, nil
+}
+
+// fakeCerberus keeps secrets and secure files in memory. Only the "user-token" and
+// "service-token" tokens may access "app/private"
+type fakeCerberus struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+	files   map[string]string
+	tokens  []string
+}
+
+func (f *fakeCerberus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	token := r.Header.Get("X-Vault-Token") + r.Header.Get("X-Cerberus-Token")
+	f.tokens = append(f.tokens, token)
+	w.Header().Set("Content-Type", "application/json")
+	if strings.Contains(r.URL.Path, "app/private") && token != "user-token" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors": ["permission denied"]}`))
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/v1/secure-file/") {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secure-file/")
+		if r.Method == http.MethodPost {
+			file, header, _ := r.FormFile("file-content")
+			content, _ := io.ReadAll(file)
+			f.files[path] = header.Filename + ":" + string(content)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		file, ok := f.files[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.SplitN(file, ":", 2)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+parts[0]+`"`)
+		w.Write([]byte(parts[1]))
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+		var keys []string
+		for k := range f.secrets {
+			if strings.HasPrefix(k, path+"/") {
+				keys = append(keys, strings.TrimPrefix(k, path+"/"))
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case r.Method == http.MethodGet:
+		data, ok := f.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	case r.Method == http.MethodDelete:
+		delete(f.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		data := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&data)
+		f.secrets[path] = data
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// call invokes a method of the service with a request built from fields
+func call(ctx context.Context, conn *grpc.ClientConn, name string, fields map[string]interface{}) (*dynamicpb.Message, error) {
+	var m method
+	for _, candidate := range methods {
+		if candidate.name == name {
+			m = candidate
+		}
+	}
+	in := dynamicpb.NewMessage(message(m.input))
+	for k, v := range fields {
+		fd := in.Descriptor().Fields().ByName(protoreflect.Name(k))
+		switch v := v.(type) {
+		case string:
+			in.Set(fd, protoreflect.ValueOfString(v))
+		case []byte:
+			in.Set(fd, protoreflect.ValueOfBytes(v))
+		case map[string]string:
+			entries := in.Mutable(fd).Map()
+			for key, value := range v {
+				entries.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfString(value))
+			}
+		}
+	}
+	out := dynamicpb.NewMessage(message(m.output))
+	err := conn.Invoke(ctx, "/"+ServiceName+"/"+name, in, out)
+	return out, err
+}
+
+func TestNewServer(t *testing.T) {
+	Convey("A nil client should error", t, func() {
+		s, err := NewServer(nil)
+		So(err, ShouldNotBeNil)
+		So(s, ShouldBeNil)
+	})
+}
+
+func TestDescriptor(t *testing.T) {
+	Convey("The descriptor", t, func() {
+		Convey("Should describe every method", func() {
+			service := descriptor.Services().ByName("Secrets")
+			So(service, ShouldNotBeNil)
+			So(service.Methods().Len(), ShouldEqual, len(methods))
+		})
+		Convey("Should use protoc JSON names", func() {
+			So(message("File").Fields().ByName("content_type").JSONName(), ShouldEqual, "contentType")
+		})
+		Convey("Should describe map fields", func() {
+			So(message("Secret").Fields().ByName("data").IsMap(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestServer(t *testing.T) {
+	fake := &fakeCerberus{}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	client, _ := cerberus.NewClient(&staticAuth{baseURL: u}, nil)
+	client.VaultClient().SetMaxRetries(0)
+
+	serve := func(s *Server) (*grpc.ClientConn, func()) {
+		listener := bufconn.Listen(1024 * 1024)
+		server := grpc.NewServer()
+		s.Register(server)
+		go server.Serve(listener)
+		conn, _ := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+		return conn, func() {
+			conn.Close()
+			server.Stop()
+		}
+	}
+	ctx := context.Background()
+
+	Convey("A gateway", t, func() {
+		fake.secrets = map[string]map[string]interface{}{
+			"app/sdb/config":  {"password": "hunter2", "port": float64(8080)},
+			"app/private/key": {"key": "secret"},
+		}
+		fake.files = map[string]string{"app/sdb/cert.pem": "cert.pem:CERT"}
+		fake.tokens = nil
+		s, _ := NewServer(client)
+		s.WithAuthorizer(AllowAll)
+		conn, stop := serve(s)
+		defer stop()
+
+		Convey("Should read a secret", func() {
+			out, err := call(ctx, conn, "ReadSecret", map[string]interface{}{"path": "app/sdb/config"})
+			So(err, ShouldBeNil)
+			data := out.Get(out.Descriptor().Fields().ByName("data")).Map()
+			So(data.Get(protoreflect.ValueOfString("password").MapKey()).String(), ShouldEqual, "hunter2")
+			So(data.Get(protoreflect.ValueOfString("port").MapKey()).String(), ShouldEqual, "8080")
+		})
+		Convey("Should return NotFound for a missing secret", func() {
+			_, err := call(ctx, conn, "ReadSecret", map[string]interface{}{"path": "app/sdb/missing"})
+			So(status.Code(err), ShouldEqual, codes.NotFound)
+		})
+		Convey("Should require a path", func() {
+			_, err := call(ctx, conn, "ReadSecret", nil)
+			So(status.Code(err), ShouldEqual, codes.InvalidArgument)
+		})
+		Convey("Should return PermissionDenied when Cerberus forbids it", func() {
+			_, err := call(ctx, conn, "ReadSecret", map[string]interface{}{"path": "app/private/key"})
+			So(status.Code(err), ShouldEqual, codes.PermissionDenied)
+		})
+		Convey("Should list secrets", func() {
+			out, err := call(ctx, conn, "ListSecrets", map[string]interface{}{"path": "app/sdb"})
+			So(err, ShouldBeNil)
+			keys := out.Get(out.Descriptor().Fields().ByName("keys")).List()
+			So(keys.Len(), ShouldEqual, 1)
+			So(keys.Get(0).String(), ShouldEqual, "config")
+		})
+		Convey("Should write and delete a secret", func() {
+			_, err := call(ctx, conn, "WriteSecret", map[string]interface{}{"path": "app/sdb/new", "data": map[string]string{"a": "b"}})
+			So(err, ShouldBeNil)
+			So(fake.secrets["app/sdb/new"], ShouldResemble, map[string]interface{}{"a": "b"})
+			_, err = call(ctx, conn, "DeleteSecret", map[string]interface{}{"path": "app/sdb/new"})
+			So(err, ShouldBeNil)
+			So(fake.secrets, ShouldNotContainKey, "app/sdb/new")
+		})
+		Convey("Should get a secure file", func() {
+			out, err := call(ctx, conn, "GetFile", map[string]interface{}{"path": "app/sdb/cert.pem"})
+			So(err, ShouldBeNil)
+			So(getString(out, "filename"), ShouldEqual, "cert.pem")
+			So(string(out.Get(out.Descriptor().Fields().ByName("content")).Bytes()), ShouldEqual, "CERT")
+			_, err = call(ctx, conn, "GetFile", map[string]interface{}{"path": "app/sdb/missing.pem"})
+			So(status.Code(err), ShouldEqual, codes.NotFound)
+		})
+		Convey("Should put a secure file", func() {
+			_, err := call(ctx, conn, "PutFile", map[string]interface{}{"path": "app/sdb/key.pem", "filename": "key.pem", "content": []byte("KEY")})
+			So(err, ShouldBeNil)
+			So(fake.files["app/sdb/key.pem"], ShouldEqual, "key.pem:KEY")
+		})
+	})
+
+	Convey("A gateway without an authorizer or token delegation", t, func() {
+		fake.tokens = nil
+		s, _ := NewServer(client)
+		conn, stop := serve(s)
+		defer stop()
+		Convey("Should deny every call without calling Cerberus", func() {
+			for _, m := range methods {
+				_, err := call(ctx, conn, m.name, map[string]interface{}{"path": "app/sdb/config"})
+				So(status.Code(err), ShouldEqual, codes.PermissionDenied)
+			}
+			So(fake.tokens, ShouldBeEmpty)
+		})
+	})
+
+	Convey("A gateway with an authorizer", t, func() {
+		var calls []string
+		s, _ := NewServer(client)
+		s.WithAuthorizer(func(ctx context.Context, method, path string) error {
+			calls = append(calls, method+" "+path)
+			if strings.HasPrefix(path, "app/sdb/") {
+				return nil
+			}
+			return fmt.Errorf("not allowed")
+		})
+		conn, stop := serve(s)
+		defer stop()
+		Convey("Should allow what the authorizer allows", func() {
+			_, err := call(ctx, conn, "ReadSecret", map[string]interface{}{"path": "app/sdb/config"})
+			So(err, ShouldBeNil)
+			So(calls, ShouldResemble, []string{"ReadSecret app/sdb/config"})
+		})
+		Convey("Should deny the rest", func() {
+			_, err := call(ctx, conn, "ReadSecret", map[string]interface{}{"path": "app/other/config"})
+			So(status.Code(err), ShouldEqual, codes.PermissionDenied)
+		})
+	})
+
+	Convey("A gateway with token delegation", t, func() {
+		fake.tokens = nil
+		s, _ := NewServer(client)
+		s.WithTokenDelegation()
+		conn, stop := serve(s)
+		defer stop()
+		Convey("Should require a token", func() {
+			_, err := call(ctx, conn, "ReadSecret", map[string]interface{}{"path": "app/private/key"})
+			So(status.Code(err), ShouldEqual, codes.Unauthenticated)
+		})
+		Convey("Should use the caller's token", func() {
+			userCtx := metadata.AppendToOutgoingContext(ctx, TokenMetadata, "user-token")
+			_, err := call(userCtx, conn, "ReadSecret", map[string]interface{}{"path": "app/private/key"})
+			So(err, ShouldBeNil)
+			So(fake.tokens, ShouldResemble, []string{"user-token"})
+		})
+	})
+}
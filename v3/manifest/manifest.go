@@ -0,0 +1,273 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifest checks that the secrets and secure files services depend on exist and can
+// be read, so a deployment can fail in CI instead of at startup. A manifest is YAML that
+// lists what each service needs:
+//
+//	services:
+//	  billing:
+//	    secrets:
+//	      - path: app/billing/db
+//	        keys: [username, password]
+//	    files:
+//	      - app/billing/tls.pem
+//
+// Secret paths are the same as for Secret.Read and file paths the same as for SecureFile.Get.
+// Keys are optional, and without them the secret only has to exist.
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	vault "github.com/hashicorp/vault/api"
+	"github.com/taskcluster/httpbackoff"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest lists the secrets and secure files each service needs
+type Manifest struct {
+	Services map[string]Service `yaml:"services"`
+}
+
+// Service lists the secrets and secure files one service needs
+type Service struct {
+	Secrets []Secret `yaml:"secrets"`
+	Files   []string `yaml:"files"`
+}
+
+// Secret is a secret path and the keys that must be in it
+type Secret struct {
+	Path string   `yaml:"path"`
+	Keys []string `yaml:"keys"`
+}
+
+// Parse reads a manifest. Unknown fields are an error, so a typo doesn't silently skip a check
+func Parse(r io.Reader) (*Manifest, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	m := &Manifest{}
+	if err := decoder.Decode(m); err != nil {
+		return nil, fmt.Errorf("Invalid manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load reads the manifest in the given file
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bytes.NewReader(data))
+}
+
+// Validate returns an error if the manifest has no services or an entry has no path
+func (m *Manifest) Validate() error {
+	if len(m.Services) == 0 {
+		return fmt.Errorf("Invalid manifest: no services")
+	}
+	for name, service := range m.Services {
+		for i, secret := range service.Secrets {
+			if strings.TrimSpace(secret.Path) == "" {
+				return fmt.Errorf("Invalid manifest: secret %d of service %s has no path", i, name)
+			}
+		}
+		for i, file := range service.Files {
+			if strings.TrimSpace(file) == "" {
+				return fmt.Errorf("Invalid manifest: file %d of service %s has no path", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Status is the outcome of checking one entry of a manifest
+type Status string
+
+const (
+	// StatusOK means the secret, key or file exists and can be read
+	StatusOK Status = "ok"
+	// StatusMissing means the secret, key or file doesn't exist
+	StatusMissing Status = "missing"
+	// StatusDenied means the client isn't allowed to read it
+	StatusDenied Status = "denied"
+	// StatusError means it couldn't be checked, e.g. because Cerberus was unreachable
+	StatusError Status = "error"
+)
+
+// Kinds of entries in a Result
+const (
+	KindSecret = "secret"
+	KindFile   = "file"
+)
+
+// Result is the outcome of checking one secret, secret key or secure file
+type Result struct {
+	Service string `json:"service"`
+	// Kind is KindSecret or KindFile
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	// Key is the key in the secret that was checked, if any
+	Key    string `json:"key,omitempty"`
+	Status Status `json:"status"`
+	// Error is the error that caused StatusDenied or StatusError
+	Error string `json:"error,omitempty"`
+}
+
+func (r Result) String() string {
+	entry := r.Path
+	if r.Key != "" {
+		entry += "#" + r.Key
+	}
+	s := fmt.Sprintf("%s %s %s: %s", r.Service, r.Kind, entry, r.Status)
+	if r.Error != "" {
+		s += " (" + r.Error + ")"
+	}
+	return s
+}
+
+// Report is the outcome of checking every entry of a manifest
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// OK returns true if every entry of the manifest exists and can be read
+func (r *Report) OK() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the results that aren't StatusOK
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, result := range r.Results {
+		if result.Status != StatusOK {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// String lists the failures, or says that every entry was found
+func (r *Report) String() string {
+	failures := r.Failures()
+	if len(failures) == 0 {
+		return fmt.Sprintf("All %d manifest entries are present and readable\n", len(r.Results))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d manifest entries failed:\n", len(failures), len(r.Results))
+	for _, f := range failures {
+		fmt.Fprintf(&b, "  %s\n", f)
+	}
+	return b.String()
+}
+
+// Verify checks every entry of the manifest with the given client and reports the outcome of
+// each one. Services are checked in name order. Failed checks are in the report rather than
+// returned, so an error means the manifest itself is invalid
+func Verify(client *cerberus.Client, m *Manifest) (*Report, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(m.Services))
+	for name := range m.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &Report{}
+	for _, name := range names {
+		service := m.Services[name]
+		for _, secret := range service.Secrets {
+			report.Results = append(report.Results, verifySecret(client, name, secret)...)
+		}
+		for _, file := range service.Files {
+			report.Results = append(report.Results, verifyFile(client, name, file))
+		}
+	}
+	return report, nil
+}
+
+// verifySecret reads a secret once and checks each of its keys
+func verifySecret(client *cerberus.Client, service string, secret Secret) []Result {
+	result := Result{Service: service, Kind: KindSecret, Path: secret.Path}
+	read, ok, err := client.Secret().ReadOptional(secret.Path)
+	switch {
+	case err != nil:
+		result.Status, result.Error = statusFor(err), err.Error()
+	case !ok:
+		result.Status = StatusMissing
+	default:
+		result.Status = StatusOK
+	}
+	if result.Status != StatusOK || len(secret.Keys) == 0 {
+		return []Result{result}
+	}
+	results := make([]Result, 0, len(secret.Keys))
+	for _, key := range secret.Keys {
+		keyResult := result
+		keyResult.Key = key
+		if _, ok := read.Data[key]; !ok {
+			keyResult.Status = StatusMissing
+		}
+		results = append(results, keyResult)
+	}
+	return results
+}
+
+// verifyFile checks that a secure file exists without downloading it
+func verifyFile(client *cerberus.Client, service, path string) Result {
+	result := Result{Service: service, Kind: KindFile, Path: path, Status: StatusOK}
+	_, err := client.SecureFile().Stat(path)
+	switch {
+	case errors.Is(err, cerberus.ErrorSecureFileNotFound):
+		result.Status = StatusMissing
+	case err != nil:
+		result.Status, result.Error = statusFor(err), err.Error()
+	}
+	return result
+}
+
+// statusFor returns StatusDenied for authorization failures and StatusError otherwise
+func statusFor(err error) Status {
+	var vaultErr *vault.ResponseError
+	var httpErr httpbackoff.BadHttpResponseCode
+	code := 0
+	switch {
+	case errors.Is(err, api.ErrorUnauthorized):
+		return StatusDenied
+	case errors.As(err, &vaultErr):
+		code = vaultErr.StatusCode
+	case errors.As(err, &httpErr):
+		code = httpErr.HttpResponseCode
+	}
+	if code == http.StatusUnauthorized || code == http.StatusForbidden {
+		return StatusDenied
+	}
+	return StatusError
+}
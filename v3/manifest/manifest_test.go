@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var testManifest = `
+services:
+  web:
+    secrets:
+      - path: app/web/config
+  billing:
+    secrets:
+      - path: app/billing/db
+        keys: [username, password]
+      - path: app/billing/missing
+      - path: app/other/denied
+    files:
+      - app/billing/tls.pem
+      - app/billing/missing.pem
+`
+
+// fakeCerberus serves the secrets and secure files of the test manifest
+func fakeCerberus() *httptest.Server {
+	secrets := map[string]map[string]interface{}{
+		"app/web/config": {"port": 8080},
+		"app/billing/db": {"username": "billing"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "app/other/"):
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+		case r.URL.Path == "/v1/secure-file/app/billing/tls.pem":
+			w.WriteHeader(http.StatusOK)
+		case strings.HasPrefix(r.URL.Path, "/v1/secret/"):
+			data, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/secret/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors": []}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestParse(t *testing.T) {
+	Convey("A valid manifest", t, func() {
+		m, err := Parse(strings.NewReader(testManifest))
+		So(err, ShouldBeNil)
+		So(m.Services, ShouldHaveLength, 2)
+		So(m.Services["billing"].Secrets[0], ShouldResemble, Secret{Path: "app/billing/db", Keys: []string{"username", "password"}})
+		So(m.Services["billing"].Files, ShouldResemble, []string{"app/billing/tls.pem", "app/billing/missing.pem"})
+	})
+	Convey("A manifest with an unknown field", t, func() {
+		_, err := Parse(strings.NewReader("services:\n  web:\n    secret:\n      - path: app/web/config\n"))
+		So(err, ShouldNotBeNil)
+	})
+	Convey("A manifest without services", t, func() {
+		_, err := Parse(strings.NewReader("services: {}\n"))
+		So(err, ShouldNotBeNil)
+	})
+	Convey("A manifest with an empty path", t, func() {
+		_, err := Parse(strings.NewReader("services:\n  web:\n    files: ['']\n"))
+		So(err, ShouldNotBeNil)
+	})
+	Convey("A manifest file", t, func() {
+		path := filepath.Join(t.TempDir(), "secrets.yaml")
+		os.WriteFile(path, []byte(testManifest), 0600)
+		m, err := Load(path)
+		So(err, ShouldBeNil)
+		So(m.Services, ShouldContainKey, "web")
+		_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	Convey("Verifying a manifest", t, func() {
+		ts := fakeCerberus()
+		defer ts.Close()
+		a, _ := auth.NewTokenAuth(ts.URL, "a-cool-token")
+		cl, _ := cerberus.NewClient(a, nil)
+		m, _ := Parse(strings.NewReader(testManifest))
+		report, err := Verify(cl.WithNoRetry(), m)
+		So(err, ShouldBeNil)
+
+		Convey("Should report every entry in service order", func() {
+			So(report.Results, ShouldHaveLength, 7)
+			So(report.Results[0], ShouldResemble, Result{Service: "billing", Kind: KindSecret, Path: "app/billing/db", Key: "username", Status: StatusOK})
+			So(report.Results[1].Key, ShouldEqual, "password")
+			So(report.Results[1].Status, ShouldEqual, StatusMissing)
+			So(report.Results[2].Status, ShouldEqual, StatusMissing)
+			So(report.Results[3].Status, ShouldEqual, StatusDenied)
+			So(report.Results[3].Error, ShouldNotBeEmpty)
+			So(report.Results[4].Status, ShouldEqual, StatusOK)
+			So(report.Results[5].Status, ShouldEqual, StatusMissing)
+			So(report.Results[6], ShouldResemble, Result{Service: "web", Kind: KindSecret, Path: "app/web/config", Status: StatusOK})
+		})
+
+		Convey("Should list the failures", func() {
+			So(report.OK(), ShouldBeFalse)
+			So(report.Failures(), ShouldHaveLength, 4)
+			So(report.String(), ShouldStartWith, "4 of 7 manifest entries failed:\n")
+			So(report.String(), ShouldContainSubstring, "billing secret app/billing/db#password: missing\n")
+		})
+	})
+
+	Convey("A report without failures", t, func() {
+		report := &Report{Results: []Result{{Service: "web", Kind: KindFile, Path: "app/web/tls.pem", Status: StatusOK}}}
+		So(report.OK(), ShouldBeTrue)
+		So(report.String(), ShouldEqual, "All 1 manifest entries are present and readable\n")
+	})
+
+	Convey("An invalid manifest", t, func() {
+		_, err := Verify(nil, &Manifest{})
+		So(err, ShouldNotBeNil)
+	})
+}
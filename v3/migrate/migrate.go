@@ -0,0 +1,161 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate copies secrets between Cerberus and AWS Secrets Manager or SSM Parameter
+// Store, for teams moving onto or off of Cerberus.
+//
+// Each Cerberus secret path keeps its structure. In Secrets Manager it becomes one secret
+// whose value is the secret's keys as a JSON object. In Parameter Store each key becomes
+// its own SecureString parameter under the secret path. Parameter values are strings, so
+// other values are stored as JSON.
+//
+// A destination that already has different content is reported as a Conflict and left
+// alone, unless Options.Overwrite is set.
+package migrate
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+)
+
+// Options changes how a migration copies secrets
+type Options struct {
+	// Overwrite replaces destinations that have different content instead of reporting a conflict
+	Overwrite bool
+	// DryRun reports what would be copied without writing anything
+	DryRun bool
+	// KMSKeyID is the KMS key used to encrypt new Secrets Manager secrets and SSM
+	// parameters. The AWS managed key is used if it is empty
+	KMSKeyID string
+}
+
+// Report describes the outcome of a migration
+type Report struct {
+	// Copied has the destinations that were written, or would be written in a dry run
+	Copied []string
+	// Unchanged has the destinations that already had the same content
+	Unchanged []string
+	// Conflicts has the secrets that weren't copied
+	Conflicts []Conflict
+}
+
+// Conflict is a secret that wasn't copied, and why
+type Conflict struct {
+	Source      string
+	Destination string
+	Reason      string
+}
+
+func (r *Report) conflict(source, destination, reason string) {
+	r.Conflicts = append(r.Conflicts, Conflict{Source: source, Destination: destination, Reason: reason})
+}
+
+// Conflict reasons
+const (
+	reasonDifferent = "destination has different content"
+	reasonNotObject = "source is not a JSON object"
+	reasonNoPath    = "source is not under a secret path"
+)
+
+// readTree reads every secret in the SDB and returns their paths relative to the SDB, in
+// order, along with their data
+func readTree(sdb *cerberus.ScopedSDB) ([]string, map[string]map[string]interface{}, error) {
+	secrets := map[string]map[string]interface{}{}
+	dirs := []string{""}
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		list, err := sdb.Secret().List(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if list == nil {
+			continue
+		}
+		keys, _ := list.Data["keys"].([]interface{})
+		for _, k := range keys {
+			name, ok := k.(string)
+			if !ok {
+				continue
+			}
+			if strings.HasSuffix(name, "/") {
+				dirs = append(dirs, dir+name)
+				continue
+			}
+			secret, err := sdb.Secret().Read(dir + name)
+			if err != nil {
+				return nil, nil, err
+			}
+			if secret != nil {
+				secrets[dir+name] = secret.Data
+			}
+		}
+	}
+	paths := make([]string, 0, len(secrets))
+	for p := range secrets {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, secrets, nil
+}
+
+// writeSecret writes data to the secret at p in the SDB unless it already has the same
+// content, and records the outcome in the report
+func writeSecret(sdb *cerberus.ScopedSDB, p string, data map[string]interface{}, source string, opts Options, report *Report) error {
+	destination := sdb.Path() + p
+	existing, err := sdb.Secret().Read(p)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if sameData(existing.Data, data) {
+			report.Unchanged = append(report.Unchanged, destination)
+			return nil
+		}
+		if !opts.Overwrite {
+			report.conflict(source, destination, reasonDifferent)
+			return nil
+		}
+	}
+	if !opts.DryRun {
+		if _, err := sdb.Secret().Write(p, data); err != nil {
+			return err
+		}
+	}
+	report.Copied = append(report.Copied, destination)
+	return nil
+}
+
+// sameData returns whether two secrets have the same keys and values. They are compared
+// as JSON so numbers decoded in different ways still match
+func sameData(a, b map[string]interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// valueString returns a secret value as a string. Strings are kept as is and anything else
+// is encoded as JSON
+func valueString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/auth"
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeCerberus is a Cerberus server that keeps secrets in memory
+type fakeCerberus struct {
+	*httptest.Server
+	secrets map[string]map[string]interface{}
+	writes  int
+}
+
+func newFakeCerberus(secrets map[string]map[string]interface{}) *fakeCerberus {
+	f := &fakeCerberus{secrets: secrets}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		p := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+			p = strings.TrimSuffix(p, "/") + "/"
+			seen := map[string]bool{}
+			keys := []interface{}{}
+			for k := range f.secrets {
+				if !strings.HasPrefix(k, p) {
+					continue
+				}
+				rest := strings.TrimPrefix(k, p)
+				if i := strings.Index(rest, "/"); i >= 0 {
+					rest = rest[:i+1]
+				}
+				if !seen[rest] {
+					seen[rest] = true
+					keys = append(keys, rest)
+				}
+			}
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors": []}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+		case r.Method == http.MethodGet:
+			data, ok := f.secrets[p]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors": []}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+		case r.Method == http.MethodPut:
+			data := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&data)
+			f.secrets[p] = data
+			f.writes++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return f
+}
+
+func (f *fakeCerberus) sdb(path string) *cerberus.ScopedSDB {
+	a, _ := auth.NewTokenAuth(f.URL, "a-cool-token")
+	cl, _ := cerberus.NewClient(a, nil)
+	return cl.WithNoRetry().ForSDB(path)
+}
+
+func TestReadTree(t *testing.T) {
+	Convey("An SDB with nested secrets", t, func() {
+		f := newFakeCerberus(map[string]map[string]interface{}{
+			"app/my-sdb/config":        {"user": "admin"},
+			"app/my-sdb/nested/db":     {"password": "hunter2"},
+			"app/other-sdb/not-listed": {"foo": "bar"},
+		})
+		defer f.Close()
+		paths, secrets, err := readTree(f.sdb("app/my-sdb"))
+		So(err, ShouldBeNil)
+		So(paths, ShouldResemble, []string{"config", "nested/db"})
+		So(secrets["nested/db"]["password"], ShouldEqual, "hunter2")
+	})
+}
+
+func TestSameData(t *testing.T) {
+	Convey("Numbers decoded differently", t, func() {
+		So(sameData(map[string]interface{}{"port": json.Number("5432")}, map[string]interface{}{"port": 5432.0}), ShouldBeTrue)
+	})
+	Convey("Different values", t, func() {
+		So(sameData(map[string]interface{}{"user": "admin"}, map[string]interface{}{"user": "root"}), ShouldBeFalse)
+	})
+}
+
+func TestValueString(t *testing.T) {
+	Convey("Values as strings", t, func() {
+		So(valueString("admin"), ShouldEqual, "admin")
+		So(valueString(json.Number("5432")), ShouldEqual, "5432")
+		So(valueString(map[string]interface{}{"a": true}), ShouldEqual, `{"a":true}`)
+	})
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ParameterStoreAPI is the part of the SSM client used for migrations. It is satisfied
+// by *ssm.SSM
+type ParameterStoreAPI interface {
+	GetParameter(*ssm.GetParameterInput) (*ssm.GetParameterOutput, error)
+	PutParameter(*ssm.PutParameterInput) (*ssm.PutParameterOutput, error)
+	GetParametersByPathPages(*ssm.GetParametersByPathInput, func(*ssm.GetParametersByPathOutput, bool) bool) error
+}
+
+// ToParameterStore copies every secret in the SDB to Parameter Store as SecureString
+// parameters. The key "password" of the secret at "app/my-sdb/config" is named
+// prefix + "/config/password"
+func ToParameterStore(sdb *cerberus.ScopedSDB, store ParameterStoreAPI, prefix string, opts Options) (*Report, error) {
+	paths, secrets, err := readTree(sdb)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	for _, p := range paths {
+		keys := make([]string, 0, len(secrets[p]))
+		for k := range secrets[p] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := parameterPath(prefix) + "/" + p + "/" + k
+			if err := putParameter(store, name, sdb.Path()+p, valueString(secrets[p][k]), opts, report); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// FromParameterStore copies every parameter under prefix into the SDB. Parameters are
+// grouped by their parent path, so "prefix/config/password" becomes the key "password" of
+// the secret at "app/my-sdb/config". Parameters directly under prefix are reported as
+// conflicts as they have no secret path
+func FromParameterStore(store ParameterStoreAPI, sdb *cerberus.ScopedSDB, prefix string, opts Options) (*Report, error) {
+	root := parameterPath(prefix)
+	report := &Report{}
+	secrets := map[string]map[string]interface{}{}
+	sources := map[string]string{}
+	searchPath := root
+	if searchPath == "" {
+		searchPath = "/"
+	}
+	err := store.GetParametersByPathPages(&ssm.GetParametersByPathInput{
+		Path:           aws.String(searchPath),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}, func(page *ssm.GetParametersByPathOutput, last bool) bool {
+		for _, param := range page.Parameters {
+			name := aws.StringValue(param.Name)
+			rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+			i := strings.LastIndex(rel, "/")
+			if i <= 0 {
+				report.conflict(name, sdb.Path(), reasonNoPath)
+				continue
+			}
+			p, key := rel[:i], rel[i+1:]
+			if secrets[p] == nil {
+				secrets[p] = map[string]interface{}{}
+				sources[p] = root + "/" + p
+			}
+			secrets[p][key] = aws.StringValue(param.Value)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(secrets))
+	for p := range secrets {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := writeSecret(sdb, p, secrets[p], sources[p], opts, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// parameterPath returns prefix as an SSM path, which starts with a slash and has none at
+// the end. An empty prefix is the root, which is returned as ""
+func parameterPath(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// putParameter creates or updates the named parameter unless it already has the same value
+func putParameter(store ParameterStoreAPI, name, source, value string, opts Options, report *Report) error {
+	existing, err := store.GetParameter(&ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+	exists := true
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+		exists = false
+	} else if err != nil {
+		return err
+	}
+	if exists {
+		if aws.StringValue(existing.Parameter.Value) == value {
+			report.Unchanged = append(report.Unchanged, name)
+			return nil
+		}
+		if !opts.Overwrite {
+			report.conflict(source, name, reasonDifferent)
+			return nil
+		}
+	}
+	if !opts.DryRun {
+		input := &ssm.PutParameterInput{
+			Name:      aws.String(name),
+			Value:     aws.String(value),
+			Type:      aws.String(ssm.ParameterTypeSecureString),
+			Overwrite: aws.Bool(exists),
+		}
+		if opts.KMSKeyID != "" {
+			input.KeyId = aws.String(opts.KMSKeyID)
+		}
+		if _, err := store.PutParameter(input); err != nil {
+			return err
+		}
+	}
+	report.Copied = append(report.Copied, name)
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeParameterStore keeps parameters in memory by name
+type fakeParameterStore struct {
+	params map[string]string
+	writes int
+}
+
+func (f *fakeParameterStore) GetParameter(in *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	value, ok := f.params[*in.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil)
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssm.Parameter{Name: in.Name, Value: aws.String(value)}}, nil
+}
+
+func (f *fakeParameterStore) PutParameter(in *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	if _, ok := f.params[*in.Name]; ok && !aws.BoolValue(in.Overwrite) {
+		return nil, awserr.New(ssm.ErrCodeParameterAlreadyExists, "exists", nil)
+	}
+	f.params[*in.Name] = *in.Value
+	f.writes++
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (f *fakeParameterStore) GetParametersByPathPages(in *ssm.GetParametersByPathInput, fn func(*ssm.GetParametersByPathOutput, bool) bool) error {
+	names := []string{}
+	for name := range f.params {
+		if strings.HasPrefix(name, strings.TrimSuffix(*in.Path, "/")+"/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	// One parameter per page, to make sure every page is read
+	for i, name := range names {
+		page := &ssm.GetParametersByPathOutput{Parameters: []*ssm.Parameter{{Name: aws.String(name), Value: aws.String(f.params[name])}}}
+		if !fn(page, i == len(names)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestToParameterStore(t *testing.T) {
+	Convey("An SDB with secrets", t, func() {
+		f := newFakeCerberus(map[string]map[string]interface{}{
+			"app/my-sdb/config":    {"user": "admin", "port": 5432},
+			"app/my-sdb/nested/db": {"password": "hunter2"},
+		})
+		defer f.Close()
+		store := &fakeParameterStore{params: map[string]string{
+			"/my-app/nested/db/password": "changed",
+		}}
+		Convey("Should copy each key and report conflicts", func() {
+			report, err := ToParameterStore(f.sdb("app/my-sdb"), store, "my-app", Options{})
+			So(err, ShouldBeNil)
+			So(report.Copied, ShouldResemble, []string{"/my-app/config/port", "/my-app/config/user"})
+			So(report.Conflicts, ShouldResemble, []Conflict{
+				{Source: "app/my-sdb/nested/db", Destination: "/my-app/nested/db/password", Reason: reasonDifferent},
+			})
+			So(store.params["/my-app/config/port"], ShouldEqual, "5432")
+		})
+		Convey("Should overwrite conflicts when asked", func() {
+			_, err := ToParameterStore(f.sdb("app/my-sdb"), store, "/my-app/", Options{Overwrite: true})
+			So(err, ShouldBeNil)
+			So(store.params["/my-app/nested/db/password"], ShouldEqual, "hunter2")
+		})
+	})
+}
+
+func TestFromParameterStore(t *testing.T) {
+	Convey("Parameters under a prefix", t, func() {
+		f := newFakeCerberus(map[string]map[string]interface{}{
+			"app/my-sdb/config": {"user": "root"},
+		})
+		defer f.Close()
+		store := &fakeParameterStore{params: map[string]string{
+			"/my-app/config/user":        "admin",
+			"/my-app/config/port":        "5432",
+			"/my-app/nested/db/password": "hunter2",
+			"/my-app/loose":              "value",
+			"/other-app/config/user":     "admin",
+		}}
+		Convey("Should group them into secrets and report conflicts", func() {
+			report, err := FromParameterStore(store, f.sdb("app/my-sdb"), "my-app", Options{})
+			So(err, ShouldBeNil)
+			So(report.Copied, ShouldResemble, []string{"app/my-sdb/nested/db"})
+			So(report.Conflicts, ShouldResemble, []Conflict{
+				{Source: "/my-app/loose", Destination: "app/my-sdb/", Reason: reasonNoPath},
+				{Source: "/my-app/config", Destination: "app/my-sdb/config", Reason: reasonDifferent},
+			})
+			So(f.secrets["app/my-sdb/nested/db"]["password"], ShouldEqual, "hunter2")
+		})
+		Convey("Should replace conflicting secrets when asked", func() {
+			_, err := FromParameterStore(store, f.sdb("app/my-sdb"), "my-app", Options{Overwrite: true})
+			So(err, ShouldBeNil)
+			So(f.secrets["app/my-sdb/config"], ShouldResemble, map[string]interface{}{"user": "admin", "port": "5432"})
+		})
+	})
+}
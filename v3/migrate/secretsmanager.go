@@ -0,0 +1,155 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// SecretsManagerAPI is the part of the Secrets Manager client used for migrations. It is
+// satisfied by *secretsmanager.SecretsManager
+type SecretsManagerAPI interface {
+	GetSecretValue(*secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+	CreateSecret(*secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error)
+	PutSecretValue(*secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error)
+	ListSecretsPages(*secretsmanager.ListSecretsInput, func(*secretsmanager.ListSecretsOutput, bool) bool) error
+}
+
+// ToSecretsManager copies every secret in the SDB to Secrets Manager. A secret at
+// "app/my-sdb/config" is named prefix + "/config", or "config" if prefix is empty
+func ToSecretsManager(sdb *cerberus.ScopedSDB, sm SecretsManagerAPI, prefix string, opts Options) (*Report, error) {
+	paths, secrets, err := readTree(sdb)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	for _, p := range paths {
+		if err := putSecretValue(sm, smName(prefix, p), sdb.Path()+p, secrets[p], opts, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// FromSecretsManager copies every secret whose name starts with prefix + "/" into the SDB.
+// The rest of the name is used as the secret path, so "prefix/config" is written to
+// "app/my-sdb/config". Secrets that aren't a JSON object are reported as conflicts
+func FromSecretsManager(sm SecretsManagerAPI, sdb *cerberus.ScopedSDB, prefix string, opts Options) (*Report, error) {
+	namePrefix := smName(prefix, "")
+	input := &secretsmanager.ListSecretsInput{}
+	if namePrefix != "" {
+		input.Filters = []*secretsmanager.Filter{{
+			Key:    aws.String(secretsmanager.FilterNameStringTypeName),
+			Values: []*string{aws.String(namePrefix)},
+		}}
+	}
+	var names []string
+	err := sm.ListSecretsPages(input, func(page *secretsmanager.ListSecretsOutput, last bool) bool {
+		for _, entry := range page.SecretList {
+			name := aws.StringValue(entry.Name)
+			if strings.HasPrefix(name, namePrefix) && len(name) > len(namePrefix) {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	report := &Report{}
+	for _, name := range names {
+		value, err := sm.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+		if err != nil {
+			return report, err
+		}
+		data := map[string]interface{}{}
+		if value.SecretString == nil || json.Unmarshal([]byte(*value.SecretString), &data) != nil {
+			report.conflict(name, sdb.Path()+strings.TrimPrefix(name, namePrefix), reasonNotObject)
+			continue
+		}
+		if err := writeSecret(sdb, strings.TrimPrefix(name, namePrefix), data, name, opts, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// smName returns the Secrets Manager name for a secret path
+func smName(prefix, p string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return p
+	}
+	return prefix + "/" + p
+}
+
+// putSecretValue creates or updates the named secret unless it already has the same content
+func putSecretValue(sm SecretsManagerAPI, name, source string, data map[string]interface{}, opts Options, report *Report) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	existing, err := sm.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	exists := true
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		exists = false
+	} else if err != nil {
+		return err
+	}
+	if exists {
+		current := map[string]interface{}{}
+		if existing.SecretString != nil && json.Unmarshal([]byte(*existing.SecretString), &current) == nil && sameData(current, data) {
+			report.Unchanged = append(report.Unchanged, name)
+			return nil
+		}
+		if !opts.Overwrite {
+			report.conflict(source, name, reasonDifferent)
+			return nil
+		}
+	}
+	if !opts.DryRun {
+		if exists {
+			_, err = sm.PutSecretValue(&secretsmanager.PutSecretValueInput{
+				SecretId:     aws.String(name),
+				SecretString: aws.String(string(encoded)),
+			})
+		} else {
+			input := &secretsmanager.CreateSecretInput{
+				Name:         aws.String(name),
+				SecretString: aws.String(string(encoded)),
+			}
+			if opts.KMSKeyID != "" {
+				input.KmsKeyId = aws.String(opts.KMSKeyID)
+			}
+			_, err = sm.CreateSecret(input)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	report.Copied = append(report.Copied, name)
+	return nil
+}
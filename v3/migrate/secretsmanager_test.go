@@ -0,0 +1,130 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeSecretsManager keeps secrets in memory by name
+type fakeSecretsManager struct {
+	secrets map[string]string
+	writes  int
+}
+
+func (f *fakeSecretsManager) GetSecretValue(in *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f.secrets[*in.SecretId]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	return &secretsmanager.GetSecretValueOutput{Name: in.SecretId, SecretString: aws.String(value)}, nil
+}
+
+func (f *fakeSecretsManager) CreateSecret(in *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
+	f.secrets[*in.Name] = *in.SecretString
+	f.writes++
+	return &secretsmanager.CreateSecretOutput{Name: in.Name}, nil
+}
+
+func (f *fakeSecretsManager) PutSecretValue(in *secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error) {
+	f.secrets[*in.SecretId] = *in.SecretString
+	f.writes++
+	return &secretsmanager.PutSecretValueOutput{Name: in.SecretId}, nil
+}
+
+func (f *fakeSecretsManager) ListSecretsPages(in *secretsmanager.ListSecretsInput, fn func(*secretsmanager.ListSecretsOutput, bool) bool) error {
+	page := &secretsmanager.ListSecretsOutput{}
+	for name := range f.secrets {
+		page.SecretList = append(page.SecretList, &secretsmanager.SecretListEntry{Name: aws.String(name)})
+	}
+	fn(page, true)
+	return nil
+}
+
+func TestToSecretsManager(t *testing.T) {
+	Convey("An SDB with secrets", t, func() {
+		f := newFakeCerberus(map[string]map[string]interface{}{
+			"app/my-sdb/config":    {"user": "admin"},
+			"app/my-sdb/nested/db": {"password": "hunter2"},
+		})
+		defer f.Close()
+		sm := &fakeSecretsManager{secrets: map[string]string{
+			"my-app/nested/db": `{"password": "changed"}`,
+		}}
+
+		Convey("Should copy new secrets and report conflicts", func() {
+			report, err := ToSecretsManager(f.sdb("app/my-sdb"), sm, "my-app", Options{})
+			So(err, ShouldBeNil)
+			So(report.Copied, ShouldResemble, []string{"my-app/config"})
+			So(report.Conflicts, ShouldResemble, []Conflict{
+				{Source: "app/my-sdb/nested/db", Destination: "my-app/nested/db", Reason: reasonDifferent},
+			})
+			So(sm.secrets["my-app/config"], ShouldEqual, `{"user":"admin"}`)
+			So(sm.secrets["my-app/nested/db"], ShouldEqual, `{"password": "changed"}`)
+
+			Convey("And report them as unchanged the next time", func() {
+				report, err := ToSecretsManager(f.sdb("app/my-sdb"), sm, "my-app", Options{})
+				So(err, ShouldBeNil)
+				So(report.Copied, ShouldBeEmpty)
+				So(report.Unchanged, ShouldResemble, []string{"my-app/config"})
+			})
+		})
+		Convey("Should overwrite conflicts when asked", func() {
+			report, err := ToSecretsManager(f.sdb("app/my-sdb"), sm, "my-app", Options{Overwrite: true})
+			So(err, ShouldBeNil)
+			So(report.Copied, ShouldResemble, []string{"my-app/config", "my-app/nested/db"})
+			So(sm.secrets["my-app/nested/db"], ShouldEqual, `{"password":"hunter2"}`)
+		})
+		Convey("Should not write in a dry run", func() {
+			report, err := ToSecretsManager(f.sdb("app/my-sdb"), sm, "my-app", Options{DryRun: true, Overwrite: true})
+			So(err, ShouldBeNil)
+			So(report.Copied, ShouldHaveLength, 2)
+			So(sm.writes, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestFromSecretsManager(t *testing.T) {
+	Convey("Secrets Manager secrets under a prefix", t, func() {
+		f := newFakeCerberus(map[string]map[string]interface{}{
+			"app/my-sdb/nested/db": {"password": "hunter2"},
+		})
+		defer f.Close()
+		sm := &fakeSecretsManager{secrets: map[string]string{
+			"my-app/config":    `{"user": "admin", "port": 5432}`,
+			"my-app/nested/db": `{"password": "hunter2"}`,
+			"my-app/plain":     "not json",
+			"other-app/config": `{"user": "root"}`,
+		}}
+		Convey("Should copy them into the SDB", func() {
+			report, err := FromSecretsManager(sm, f.sdb("app/my-sdb"), "my-app/", Options{})
+			So(err, ShouldBeNil)
+			So(report.Copied, ShouldResemble, []string{"app/my-sdb/config"})
+			So(report.Unchanged, ShouldResemble, []string{"app/my-sdb/nested/db"})
+			So(report.Conflicts, ShouldResemble, []Conflict{
+				{Source: "my-app/plain", Destination: "app/my-sdb/plain", Reason: reasonNotObject},
+			})
+			So(f.secrets["app/my-sdb/config"]["user"], ShouldEqual, "admin")
+			So(f.secrets, ShouldNotContainKey, "app/my-sdb/other-app/config")
+		})
+	})
+}
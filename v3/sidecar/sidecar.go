@@ -0,0 +1,165 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sidecar shares the authentication of a Cerberus client with co-located processes,
+// like the EC2 instance metadata service does for AWS credentials. It serves the current
+// Cerberus token and secret reads over HTTP on a loopback address or a Unix socket, so
+// services written in other languages don't need their own Cerberus auth logic.
+//
+// The endpoints are:
+//
+//	GET /v1/token                 the token and its expiry, as {"token": "...", "expiry": "..."}
+//	GET /v1/secret/<secret path>  the secret, as {"data": {...}}, or a 404 if it is missing
+//
+// Every request must send the RequiredHeader with the value "true", which stops other
+// services from being tricked into reading from the sidecar on behalf of an attacker.
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	vault "github.com/hashicorp/vault/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequiredHeader must be set to "true" on every request to the sidecar
+const RequiredHeader = "X-Cerberus-Sidecar"
+
+const (
+	tokenPath  = "/v1/token"
+	secretPath = "/v1/secret/"
+)
+
+// ErrorNotLoopback is returned by Listen for TCP addresses that other hosts could reach
+var ErrorNotLoopback = fmt.Errorf("The sidecar can only listen on a loopback address or a Unix socket")
+
+// Server serves the token and secret reads of a Cerberus client. It is an http.Handler
+type Server struct {
+	client *cerberus.Client
+}
+
+// tokenResponse is the body of the token endpoint
+type tokenResponse struct {
+	Token  string     `json:"token"`
+	Expiry *time.Time `json:"expiry,omitempty"`
+}
+
+// secretResponse is the body of the secret endpoint
+type secretResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer returns a Server for the given client. The client should keep its token valid,
+// e.g. with an auth.TokenWatcher, as the sidecar serves whatever token it has
+func NewServer(client *cerberus.Client) (*Server, error) {
+	if client == nil {
+		return nil, fmt.Errorf("Client cannot be nil")
+	}
+	return &Server{client: client}, nil
+}
+
+// Listen returns a listener for the sidecar. The network is "unix" for a Unix socket, or
+// "tcp" for an address that must be on the loopback interface, such as "127.0.0.1:8999"
+func Listen(network, address string) (net.Listener, error) {
+	if network != "unix" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		ip := net.ParseIP(host)
+		if host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, ErrorNotLoopback
+		}
+	}
+	return net.Listen(network, address)
+}
+
+// ServeHTTP handles a request to the sidecar
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(RequiredHeader) != "true" {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: RequiredHeader + " header is required"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "Only GET is supported"})
+		return
+	}
+	switch {
+	case r.URL.Path == tokenPath:
+		s.token(w)
+	case strings.HasPrefix(r.URL.Path, secretPath) && len(r.URL.Path) > len(secretPath):
+		s.secret(w, strings.TrimPrefix(r.URL.Path, secretPath))
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "Not found"})
+	}
+}
+
+func (s *Server) token(w http.ResponseWriter) {
+	token, err := s.client.Authentication.GetToken(nil)
+	if err != nil {
+		log.Info(fmt.Sprintf("Sidecar unable to get a Cerberus token: %v", err))
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: "Unable to get a Cerberus token"})
+		return
+	}
+	resp := tokenResponse{Token: token}
+	if expiry, err := s.client.Authentication.GetExpiry(); err == nil {
+		resp.Expiry = &expiry
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) secret(w http.ResponseWriter, path string) {
+	secret, err := s.client.Secret().Read(path)
+	if err != nil {
+		log.Info(fmt.Sprintf("Sidecar unable to read secret %s: %v", path, err))
+		writeJSON(w, statusFor(err), errorResponse{Error: "Unable to read secret"})
+		return
+	}
+	if secret == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "Secret not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, secretResponse{Data: secret.Data})
+}
+
+// statusFor returns the status code to answer with for an error from Cerberus. Permission
+// errors are passed on so callers can tell them from outages
+func statusFor(err error) int {
+	var respErr *vault.ResponseError
+	if errors.As(err, &respErr) && (respErr.StatusCode == http.StatusForbidden || respErr.StatusCode == http.StatusUnauthorized) {
+		return respErr.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/cerberus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// staticAuth is an Auth with a fixed token
+type staticAuth struct {
+	baseURL *url.URL
+	expiry  time.Time
+}
+
+func (a *staticAuth) GetToken(*os.File) (string, error) { return "a-cool-token", nil }
+func (a *staticAuth) IsAuthenticated() bool             { return true }
+func (a *staticAuth) Refresh() error                    { return nil }
+func (a *staticAuth) Logout() error                     { return nil }
+func (a *staticAuth) GetURL() *url.URL                  { return a.baseURL }
+func (a *staticAuth) GetExpiry() (time.Time, error)     { return a.expiry, nil }
+func (a *staticAuth) GetHeaders() (http.Header, error) {
+	return http.Header{"X-Cerberus-Token": []string{"a-cool-token"}}, nil
+}
+
+func TestNewServer(t *testing.T) {
+	Convey("A nil client should error", t, func() {
+		s, err := NewServer(nil)
+		So(err, ShouldNotBeNil)
+		So(s, ShouldBeNil)
+	})
+}
+
+func TestListen(t *testing.T) {
+	Convey("Listen", t, func() {
+		Convey("Should allow loopback addresses", func() {
+			l, err := Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			l.Close()
+		})
+		Convey("Should refuse other addresses", func() {
+			_, err := Listen("tcp", "0.0.0.0:0")
+			So(err, ShouldEqual, ErrorNotLoopback)
+			_, err = Listen("tcp", ":0")
+			So(err, ShouldEqual, ErrorNotLoopback)
+		})
+	})
+}
+
+func TestServer(t *testing.T) {
+	cerberusServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/secret/app/sdb/config":
+			w.Write([]byte(`{"data": {"password": "hunter2"}}`))
+		case "/v1/secret/app/other/config":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer cerberusServer.Close()
+	u, _ := url.Parse(cerberusServer.URL)
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	client, _ := cerberus.NewClient(&staticAuth{baseURL: u, expiry: expiry}, nil)
+	server, _ := NewServer(client)
+
+	get := func(path string, header bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header {
+			req.Header.Set(RequiredHeader, "true")
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	Convey("A sidecar", t, func() {
+		Convey("Should require the sidecar header", func() {
+			So(get("/v1/token", false).Code, ShouldEqual, http.StatusForbidden)
+		})
+		Convey("Should only allow GET", func() {
+			req := httptest.NewRequest(http.MethodPost, "/v1/token", nil)
+			req.Header.Set(RequiredHeader, "true")
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			So(rec.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+		Convey("Should return the token", func() {
+			rec := get("/v1/token", true)
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(rec.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+			resp := tokenResponse{}
+			So(json.Unmarshal(rec.Body.Bytes(), &resp), ShouldBeNil)
+			So(resp.Token, ShouldEqual, "a-cool-token")
+			So(resp.Expiry.Equal(expiry), ShouldBeTrue)
+		})
+		Convey("Should return a secret", func() {
+			rec := get("/v1/secret/app/sdb/config", true)
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			resp := secretResponse{}
+			So(json.Unmarshal(rec.Body.Bytes(), &resp), ShouldBeNil)
+			So(resp.Data["password"], ShouldEqual, "hunter2")
+		})
+		Convey("Should return 404 for a missing secret", func() {
+			So(get("/v1/secret/app/sdb/missing", true).Code, ShouldEqual, http.StatusNotFound)
+		})
+		Convey("Should pass on permission errors", func() {
+			So(get("/v1/secret/app/other/config", true).Code, ShouldEqual, http.StatusForbidden)
+		})
+		Convey("Should return 404 for unknown paths", func() {
+			So(get("/v1/secret/", true).Code, ShouldEqual, http.StatusNotFound)
+			So(get("/latest/meta-data", true).Code, ShouldEqual, http.StatusNotFound)
+		})
+		Convey("Should serve requests concurrently", func() {
+			codes := make(chan int, 20)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					codes <- get("/v1/token", true).Code
+				}()
+				go func() {
+					defer wg.Done()
+					codes <- get("/v1/secret/app/sdb/config", true).Code
+				}()
+			}
+			wg.Wait()
+			close(codes)
+			for code := range codes {
+				So(code, ShouldEqual, http.StatusOK)
+			}
+		})
+	})
+}
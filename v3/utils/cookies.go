@@ -0,0 +1,157 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PersistentCookieJar is an http.CookieJar that saves its cookies to a file, so a session with
+// an authenticating proxy in front of Cerberus survives process restarts. Cookies the proxy
+// sets or renews on any response are saved as they arrive, so the session is kept up to date
+// as long as the proxy renews it
+type PersistentCookieJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	path    string
+	cookies map[string][]*http.Cookie
+}
+
+// NewPersistentCookieJar returns a PersistentCookieJar that keeps its cookies in the file at
+// path, loading any cookies saved there before. The file and its directory are created when
+// needed and are only readable by the current user
+func NewPersistentCookieJar(path string) (*PersistentCookieJar, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("Cookie jar path cannot be empty")
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	j := &PersistentCookieJar{
+		jar:     jar,
+		path:    path,
+		cookies: map[string][]*http.Cookie{},
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read cookie jar: %v", err)
+	}
+	if err := json.Unmarshal(data, &j.cookies); err != nil {
+		log.Info(fmt.Sprintf("Ignoring invalid cookie jar %s: %v", path, err))
+		j.cookies = map[string][]*http.Cookie{}
+		return j, nil
+	}
+	for key, cookies := range j.cookies {
+		if u, err := url.Parse(key); err == nil {
+			j.jar.SetCookies(u, cookies)
+		}
+	}
+	return j, nil
+}
+
+// SetCookies stores the cookies received in a response from u and saves them to the file
+func (j *PersistentCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+	if len(cookies) == 0 {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key := cookieKey(u)
+	j.cookies[key] = mergeCookies(j.cookies[key], cookies)
+	if err := j.save(); err != nil {
+		log.Info(fmt.Sprintf("Unable to save cookie jar %s: %v", j.path, err))
+	}
+}
+
+// Cookies returns the cookies to send in a request to u
+func (j *PersistentCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// save writes the cookies to the file, readable only by the current user. Must be called
+// with the lock held
+func (j *PersistentCookieJar) save() error {
+	data, err := json.Marshal(j.cookies)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(j.path, data, 0600)
+}
+
+// cookieKey returns the URL cookies from u are saved under, without a query or fragment
+func cookieKey(u *url.URL) string {
+	key := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	return key.String()
+}
+
+// mergeCookies replaces saved cookies with received ones of the same name, domain and path,
+// and drops any that have expired or been deleted
+func mergeCookies(saved, received []*http.Cookie) []*http.Cookie {
+	now := time.Now()
+	merged := []*http.Cookie{}
+	same := func(a, b *http.Cookie) bool {
+		return a.Name == b.Name && a.Domain == b.Domain && a.Path == b.Path
+	}
+	for _, s := range saved {
+		replaced := false
+		for _, r := range received {
+			if same(s, r) {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, s)
+		}
+	}
+	for _, r := range received {
+		if r.MaxAge > 0 {
+			// Save an absolute expiry, so the cookie doesn't live longer after a restart
+			c := *r
+			c.Expires = now.Add(time.Duration(r.MaxAge) * time.Second)
+			c.MaxAge = 0
+			r = &c
+		}
+		merged = append(merged, r)
+	}
+	kept := merged[:0]
+	for _, c := range merged {
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now)) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
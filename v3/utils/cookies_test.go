@@ -0,0 +1,74 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPersistentCookieJar(t *testing.T) {
+	u, _ := url.Parse("https://cerberus.example.com/v1/metadata")
+	Convey("A persistent cookie jar", t, func() {
+		path := filepath.Join(t.TempDir(), "cerberus", "cookies")
+		jar, err := NewPersistentCookieJar(path)
+		So(err, ShouldBeNil)
+		jar.SetCookies(u, []*http.Cookie{
+			{Name: "session", Value: "first", Path: "/"},
+			{Name: "short", Value: "lived", Path: "/", MaxAge: 3600},
+		})
+		Convey("Should return the cookies it was given", func() {
+			So(jar.Cookies(u), ShouldHaveLength, 2)
+		})
+		Convey("Should load them in a new process", func() {
+			next, err := NewPersistentCookieJar(path)
+			So(err, ShouldBeNil)
+			cookies := next.Cookies(u)
+			So(cookies, ShouldHaveLength, 2)
+			So(cookies[0].Value+cookies[1].Value, ShouldContainSubstring, "first")
+		})
+		Convey("Should save renewed cookies", func() {
+			jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "renewed", Path: "/"}})
+			next, _ := NewPersistentCookieJar(path)
+			for _, c := range next.Cookies(u) {
+				if c.Name == "session" {
+					So(c.Value, ShouldEqual, "renewed")
+				}
+			}
+			So(next.Cookies(u), ShouldHaveLength, 2)
+		})
+		Convey("Should forget deleted and expired cookies", func() {
+			jar.SetCookies(u, []*http.Cookie{
+				{Name: "session", Path: "/", MaxAge: -1},
+				{Name: "short", Path: "/", Expires: time.Now().Add(-time.Hour)},
+			})
+			So(jar.Cookies(u), ShouldBeEmpty)
+			next, _ := NewPersistentCookieJar(path)
+			So(next.Cookies(u), ShouldBeEmpty)
+		})
+	})
+	Convey("An empty path", t, func() {
+		jar, err := NewPersistentCookieJar("")
+		So(err, ShouldNotBeNil)
+		So(jar, ShouldBeNil)
+	})
+}
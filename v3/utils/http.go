@@ -1,26 +1,28 @@
 package utils
 
 import (
-	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Nike-Inc/cerberus-go-client/v3/api"
+	log "github.com/sirupsen/logrus"
 )
 
-var defaultHttpClient *http.Client = nil
+var defaultHttpClient = NewHttpClient(http.Header{})
 
+// NewHttpClient returns a new HTTP client that sends defaultHeaders with every request. The
+// headers are copied, so each client keeps the defaults it was created with and clients with
+// different defaults can be used side by side. http.DefaultClient is not changed
 func NewHttpClient(defaultHeaders http.Header) *http.Client {
-	newClient := http.DefaultClient
-	roundTripper := RoundTripperWithDefaultHeaders(http.DefaultTransport, defaultHeaders)
-	newClient.Transport = roundTripper
-	return newClient
+	return &http.Client{
+		Transport: RoundTripperWithDefaultHeaders(http.DefaultTransport, defaultHeaders),
+	}
 }
 
+// DefaultHttpClient returns a shared HTTP client without any default headers
 func DefaultHttpClient() *http.Client {
-	if defaultHttpClient == nil {
-		newClient := http.DefaultClient
-		roundTripper := RoundTripperWithDefaultHeaders(http.DefaultTransport, http.Header{})
-		newClient.Transport = roundTripper
-		defaultHttpClient = newClient
-	}
 	return defaultHttpClient
 }
 
@@ -29,25 +31,108 @@ type roundTripperWithDefaultHeaders struct {
 	rt http.RoundTripper
 }
 
+// RoundTripperWithDefaultHeaders returns a RoundTripper that adds defaultHeaders to every
+// request. The headers are copied, so later changes to defaultHeaders, such as a refreshed
+// token, don't race with requests in flight. A header already set on a request overrides the
+// default, and a header set with no values removes it from that request
 func RoundTripperWithDefaultHeaders(rt http.RoundTripper, defaultHeaders http.Header) roundTripperWithDefaultHeaders {
 	if rt == nil {
 		rt = http.DefaultTransport
 	}
-	return roundTripperWithDefaultHeaders{Header: defaultHeaders, rt: rt}
+	return roundTripperWithDefaultHeaders{Header: defaultHeaders.Clone(), rt: rt}
 }
 
 func (h roundTripperWithDefaultHeaders) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not change the request it is given, so the headers go on a copy
+	req = req.Clone(req.Context())
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
 	for k, v := range h.Header {
-		req.Header[k] = v
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if _, ok := req.Header["X-Cerberus-Client"]; !ok {
+		req.Header = AddClientHeader(req.Header)
+	}
+	for k, v := range req.Header {
+		if len(v) == 0 {
+			delete(req.Header, k)
+		}
 	}
-	req.Header = AddClientHeader(req.Header)
 	return h.rt.RoundTrip(req)
 }
 
+// RemoveHeader marks the header name to be removed from a request, so a default header of the
+// client isn't sent with it
+func RemoveHeader(headers http.Header, name string) {
+	headers[http.CanonicalHeaderKey(name)] = []string{}
+}
+
+// DefaultFallbackPeriod is how long a FallbackTransport sends requests straight to the fallback
+// after the primary transport fails
+const DefaultFallbackPeriod = 5 * time.Minute
+
+type fallbackTransport struct {
+	primary  http.RoundTripper
+	fallback http.RoundTripper
+	period   time.Duration
+	mu       sync.Mutex
+	until    time.Time
+}
+
+// FallbackTransport returns a RoundTripper that sends requests with primary, such as an
+// experimental HTTP/3 transport, and sends them again with fallback when primary doesn't get a
+// response. After a failure, requests go straight to fallback for period, so a network that
+// blocks the primary protocol doesn't slow down every request. If fallback is nil,
+// http.DefaultTransport is used, which negotiates HTTP/2 or HTTP/1.1
+func FallbackTransport(primary, fallback http.RoundTripper, period time.Duration) http.RoundTripper {
+	if fallback == nil {
+		fallback = http.DefaultTransport
+	}
+	if period <= 0 {
+		period = DefaultFallbackPeriod
+	}
+	return &fallbackTransport{primary: primary, fallback: fallback, period: period}
+}
+
+func (t *fallbackTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	skip := time.Now().Before(t.until)
+	t.mu.Unlock()
+	if skip {
+		return t.fallback.RoundTrip(req)
+	}
+	resp, err := t.primary.RoundTrip(req)
+	if err == nil || req.Context().Err() != nil {
+		return resp, err
+	}
+	// The body was sent to the primary transport, so the request can only be sent again if
+	// its body can be read again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, err
+	}
+	t.mu.Lock()
+	t.until = time.Now().Add(t.period)
+	t.mu.Unlock()
+	log.Debug(fmt.Sprintf("Falling back to another transport for %v: %v", t.period, err))
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return t.fallback.RoundTrip(retry)
+}
+
 // utils.AddClientHeader is a helper to create the default client headers for every request
 func AddClientHeader(headers http.Header) http.Header {
 	if headers.Get("X-Cerberus-Client") == "" {
-		headers.Set("X-Cerberus-Client", api.ClientHeader)
+		headers.Set("X-Cerberus-Client", api.ClientInfo().Header(""))
 	}
 	return headers
 }
@@ -0,0 +1,141 @@
+/*
+Copyright 2017 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewHttpClient(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer ts.Close()
+
+	Convey("Two clients with different default headers", t, func() {
+		first := http.Header{"X-Team": []string{"first"}}
+		second := http.Header{"X-Team": []string{"second"}}
+		a := NewHttpClient(first)
+		b := NewHttpClient(second)
+		first.Set("X-Team", "changed")
+
+		Convey("Should each send their own headers", func() {
+			_, err := a.Get(ts.URL)
+			So(err, ShouldBeNil)
+			So(received.Get("X-Team"), ShouldEqual, "first")
+			_, err = b.Get(ts.URL)
+			So(err, ShouldBeNil)
+			So(received.Get("X-Team"), ShouldEqual, "second")
+			So(received.Get("X-Cerberus-Client"), ShouldNotBeEmpty)
+		})
+		Convey("Should not change http.DefaultClient", func() {
+			So(a, ShouldNotEqual, http.DefaultClient)
+			So(http.DefaultClient.Transport, ShouldBeNil)
+		})
+		Convey("Should let a request override a default header", func() {
+			req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+			req.Header.Set("X-Team", "override")
+			_, err := a.Do(req)
+			So(err, ShouldBeNil)
+			So(received.Get("X-Team"), ShouldEqual, "override")
+			Convey("Without changing the request", func() {
+				So(req.Header.Get("X-Cerberus-Client"), ShouldBeEmpty)
+			})
+		})
+		Convey("Should let a request remove a default header", func() {
+			req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+			RemoveHeader(req.Header, "x-team")
+			_, err := a.Do(req)
+			So(err, ShouldBeNil)
+			_, ok := received["X-Team"]
+			So(ok, ShouldBeFalse)
+			So(received.Get("X-Cerberus-Client"), ShouldNotBeEmpty)
+		})
+	})
+}
+
+// roundTripperFunc turns a function into an http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFallbackTransport(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer ts.Close()
+
+	Convey("A fallback transport", t, func() {
+		bodies = nil
+		var primaryCalls int
+		var primaryFails bool
+		primary := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			primaryCalls++
+			if primaryFails {
+				if req.Body != nil {
+					io.ReadAll(req.Body)
+				}
+				return nil, errors.New("no QUIC for you")
+			}
+			return http.DefaultTransport.RoundTrip(req)
+		})
+		client := &http.Client{Transport: FallbackTransport(primary, nil, time.Hour)}
+
+		Convey("Should use the primary transport while it works", func() {
+			resp, err := client.Get(ts.URL)
+			So(err, ShouldBeNil)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(primaryCalls, ShouldEqual, 1)
+		})
+
+		Convey("When the primary transport fails", func() {
+			primaryFails = true
+			resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("payload"))
+			Convey("Should send the request again with the fallback", func() {
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(bodies, ShouldResemble, []string{"payload"})
+			})
+			Convey("Should skip the primary transport for a while", func() {
+				_, err := client.Get(ts.URL)
+				So(err, ShouldBeNil)
+				So(primaryCalls, ShouldEqual, 1)
+			})
+		})
+
+		Convey("Should not resend a body that can't be read again", func() {
+			primaryFails = true
+			req, _ := http.NewRequest(http.MethodPost, ts.URL, io.NopCloser(strings.NewReader("payload")))
+			_, err := client.Do(req)
+			So(err, ShouldNotBeNil)
+			So(bodies, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Nike Inc.
+
+Licensed under the Apache License, Version 2.0 (the License);
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an AS IS BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeClientCert writes a self-signed client certificate and its key to dir and returns
+// their paths and the certificate
+func writeClientCert(dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cerberus-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile, cert
+}
+
+func TestClientTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeClientCert(dir)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	ts.StartTLS()
+	defer ts.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+
+	Convey("A TLS config with a client certificate and CA bundle", t, func() {
+		config, err := ClientTLSConfig(certFile, keyFile, caFile)
+		So(err, ShouldBeNil)
+		So(config.MinVersion, ShouldEqual, tls.VersionTLS12)
+
+		Convey("Should pass mutual TLS", func() {
+			resp, err := (&http.Client{Transport: TLSTransport(config)}).Get(ts.URL)
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Should be required by the server", func() {
+			withoutCert, err := ClientTLSConfig("", "", caFile)
+			So(err, ShouldBeNil)
+			_, err = (&http.Client{Transport: TLSTransport(withoutCert)}).Get(ts.URL)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Invalid TLS files", t, func() {
+		Convey("Should return an error for a missing certificate", func() {
+			_, err := ClientTLSConfig(filepath.Join(dir, "missing.pem"), keyFile, "")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should return an error for a CA bundle without certificates", func() {
+			_, err := ClientTLSConfig("", "", keyFile)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("The TLS transport", t, func() {
+		Convey("Should not change the default transport", func() {
+			config := &tls.Config{}
+			So(TLSTransport(config).TLSClientConfig, ShouldEqual, config)
+			So(http.DefaultTransport.(*http.Transport).TLSClientConfig, ShouldNotEqual, config)
+		})
+	})
+}
@@ -18,11 +18,20 @@ limitations under the License.
 package utils
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 )
@@ -44,34 +53,181 @@ func ValidateURL(fullURL string) (*url.URL, error) {
 	return parsed, nil
 }
 
+// ServiceUnavailable returns an api.ErrorServiceUnavailable for a 503 response, using the
+// Retry-After header (either in seconds or as an HTTP date) as the retry hint. It returns
+// nil for any other response
+func ServiceUnavailable(resp *http.Response) error {
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+	return api.ErrorServiceUnavailable{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// MaxSnippetSize is the most of an unexpected response body, in bytes, kept in an
+// api.ErrorUnexpectedResponse
+const MaxSnippetSize = 512
+
+// UnexpectedResponse returns an api.ErrorUnexpectedResponse if resp has a body that isn't JSON,
+// such as an HTML error page from a load balancer or WAF. Bodies with a JSON Content-Type are
+// trusted, and other bodies are only treated as unexpected if they don't start like JSON. The
+// error includes the start of the body, and the body is replaced with one that returns the error
+// when read, so code that goes on to parse it gets the same error instead of a JSON decode error.
+// Otherwise the body is left readable from the start
+func UnexpectedResponse(resp *http.Response) error {
+	if resp == nil || resp.Body == nil || isJSON(resp.Header.Get("Content-Type")) {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, MaxSnippetSize))
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		resp.Body = &limitedBody{Reader: io.MultiReader(bytes.NewReader(data), resp.Body), Closer: resp.Body}
+		return nil
+	}
+	unexpected := api.ErrorUnexpectedResponse{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Snippet:     strings.Join(strings.Fields(strings.ToValidUTF8(string(data), "")), " "),
+	}
+	resp.Body = &limitedBody{Reader: errorReader{unexpected}, Closer: resp.Body}
+	return unexpected
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// errorReader is a reader that always returns err
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
 // CheckAndParse is a helper function to check for user auth and token refresh errors and parse a response. It will return a user friendly error
 func CheckAndParse(resp *http.Response) (*api.UserAuthResponse, error) {
+	if err := ServiceUnavailable(resp); err != nil {
+		return nil, err
+	}
+	// A 401 or 403 page from a WAF isn't a rejection from Cerberus, so report it as is
+	if err := UnexpectedResponse(resp); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
 		return nil, api.ErrorUnauthorized
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("Error while trying to authenticate. Got HTTP response code %d", resp.StatusCode)
 	}
-	decoder := json.NewDecoder(resp.Body)
+	decoder := json.NewDecoder(LimitReader(resp.Body, MaxResponseSize))
 	u := &api.UserAuthResponse{}
 	err := decoder.Decode(u)
 	if err != nil {
-		return nil, fmt.Errorf("Error while trying to parse response from Cerberus: %v", err)
+		return nil, fmt.Errorf("Error while trying to parse response from Cerberus: %w", err)
 	}
 	return u, nil
 }
 
 var ErrorBodyNotReturned = fmt.Errorf("No error body returned from server")
 
+// MaxResponseSize is the default for the largest response body, in bytes, that is read into
+// memory when parsing
+const MaxResponseSize = 10 << 20
+
+// MaxErrorSize is the largest error response body, in bytes, that is read by ParseAPIError
+const MaxErrorSize = 64 << 10
+
+// ReadLimited reads r into buf, returning an api.ErrorResponseTooLarge if there are more than
+// MaxResponseSize bytes
+func ReadLimited(buf *bytes.Buffer, r io.Reader) error {
+	if _, err := buf.ReadFrom(io.LimitReader(r, MaxResponseSize+1)); err != nil {
+		return err
+	}
+	if buf.Len() > MaxResponseSize {
+		return api.ErrorResponseTooLarge{Limit: MaxResponseSize}
+	}
+	return nil
+}
+
+// LimitReader returns a reader that reads from r until limit bytes have been read. Unlike
+// io.LimitReader, reading past the limit returns an api.ErrorResponseTooLarge instead of
+// io.EOF, so a truncated body isn't mistaken for a complete one
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	return &limitedReader{r: r, remaining: limit, limit: limit}
+}
+
+// LimitBody is LimitReader for a response body
+func LimitBody(body io.ReadCloser, limit int64) io.ReadCloser {
+	return &limitedBody{Reader: LimitReader(body, limit), Closer: body}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	limit     int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Only error if there is more to read, so a body of exactly limit bytes is fine
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, api.ErrorResponseTooLarge{Limit: l.limit}
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
 // utils.ParseAPIError is a helper for parsing an error response body from the API.
 // If the body doesn't have an error, it will return ErrorBodyNotReturned to indicate that there was no error body sent (probably means there was a server error)
 func ParseAPIError(r io.Reader) error {
 	var apiErr = api.ErrorResponse{}
-	if err := json.NewDecoder(r).Decode(&apiErr); err != nil {
+	// Error bodies are small, so the limit only guards against unexpected responses
+	if err := json.NewDecoder(LimitReader(r, MaxErrorSize)).Decode(&apiErr); err != nil {
 		// If the body is empty or a string, it will hit this error
 		if err == io.EOF {
 			return ErrorBodyNotReturned
 		}
+		var tooLarge api.ErrorResponseTooLarge
+		if errors.As(err, &tooLarge) {
+			return tooLarge
+		}
+		var unexpected api.ErrorUnexpectedResponse
+		if errors.As(err, &unexpected) {
+			return unexpected
+		}
 		return fmt.Errorf("Error while parsing API error response: %v", err)
 	}
 	// Check to see if there is an error ID set and return a different error if not
@@ -81,3 +237,29 @@ func ParseAPIError(r io.Reader) error {
 	}
 	return apiErr
 }
+
+// ConnectionError wraps err in an api.ErrorConnection for the given host if it is a DNS, dial
+// or TLS handshake failure. Any other error, including a nil one, is returned unchanged
+func ConnectionError(host string, err error) error {
+	if err == nil || !isConnectionError(err) {
+		return err
+	}
+	return api.ErrorConnection{Host: host, Err: err}
+}
+
+func isConnectionError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var headerErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &headerErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
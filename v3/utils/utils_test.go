@@ -18,9 +18,15 @@ package utils
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Nike-Inc/cerberus-go-client/v3/api"
 	. "github.com/smartystreets/goconvey/convey"
@@ -235,3 +241,209 @@ func TestHandleAPIError(t *testing.T) {
 		})
 	})
 }
+
+func TestServiceUnavailable(t *testing.T) {
+	Convey("A maintenance response with a Retry-After in seconds", t, func() {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+		resp.Header.Set("Retry-After", "120")
+		Convey("Should return a typed error with the retry hint", func() {
+			err := ServiceUnavailable(resp)
+			So(err, ShouldResemble, api.ErrorServiceUnavailable{RetryAfter: 120 * time.Second})
+		})
+	})
+
+	Convey("A maintenance response with a Retry-After date", t, func() {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+		resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		Convey("Should return a typed error with the time until that date", func() {
+			err := ServiceUnavailable(resp)
+			So(err, ShouldHaveSameTypeAs, api.ErrorServiceUnavailable{})
+			So(err.(api.ErrorServiceUnavailable).RetryAfter, ShouldBeBetween, 58*time.Minute, time.Hour)
+		})
+	})
+
+	Convey("A maintenance response with an invalid Retry-After", t, func() {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+		resp.Header.Set("Retry-After", "soon")
+		Convey("Should return a typed error without a retry hint", func() {
+			So(ServiceUnavailable(resp), ShouldResemble, api.ErrorServiceUnavailable{})
+		})
+	})
+
+	Convey("Any other response", t, func() {
+		Convey("Should not return an error", func() {
+			So(ServiceUnavailable(&http.Response{StatusCode: http.StatusInternalServerError}), ShouldBeNil)
+			So(ServiceUnavailable(nil), ShouldBeNil)
+		})
+	})
+
+	Convey("A maintenance response to CheckAndParse", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<html><body>Down for maintenance</body></html>"))
+		}))
+		defer ts.Close()
+		Convey("Should return a typed error", func() {
+			resp, err := http.Get(ts.URL)
+			So(err, ShouldBeNil)
+			authResp, err := CheckAndParse(resp)
+			So(err, ShouldResemble, api.ErrorServiceUnavailable{})
+			So(authResp, ShouldBeNil)
+		})
+	})
+}
+
+func TestUnexpectedResponse(t *testing.T) {
+	newResponse := func(status int, contentType, body string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+		if contentType != "" {
+			resp.Header.Set("Content-Type", contentType)
+		}
+		return resp
+	}
+
+	Convey("An HTML error page", t, func() {
+		resp := newResponse(http.StatusBadGateway, "text/html", "<html>\n  <body>502 Bad Gateway</body>\n</html>")
+		err := UnexpectedResponse(resp)
+		Convey("Should return a typed error with a snippet", func() {
+			So(err, ShouldResemble, api.ErrorUnexpectedResponse{
+				StatusCode:  http.StatusBadGateway,
+				ContentType: "text/html",
+				Snippet:     "<html> <body>502 Bad Gateway</body> </html>",
+			})
+		})
+		Convey("Should return the same error when the body is parsed", func() {
+			So(ParseAPIError(resp.Body), ShouldResemble, err)
+		})
+	})
+
+	Convey("A long HTML page", t, func() {
+		err := UnexpectedResponse(newResponse(http.StatusForbidden, "text/html", "<html>"+strings.Repeat("a", 2*MaxSnippetSize)))
+		Convey("Should truncate the snippet", func() {
+			So(len(err.(api.ErrorUnexpectedResponse).Snippet), ShouldEqual, MaxSnippetSize)
+		})
+	})
+
+	Convey("A JSON body", t, func() {
+		Convey("Should not be unexpected with a JSON content type", func() {
+			So(UnexpectedResponse(newResponse(http.StatusBadRequest, "application/json; charset=utf-8", "<oops")), ShouldBeNil)
+			So(UnexpectedResponse(newResponse(http.StatusBadRequest, "application/problem+json", "<oops")), ShouldBeNil)
+		})
+		Convey("Should not be unexpected with another content type and be readable", func() {
+			resp := newResponse(http.StatusBadRequest, "text/plain", `  {"error_id": "an-id"}`)
+			So(UnexpectedResponse(resp), ShouldBeNil)
+			So(ParseAPIError(resp.Body), ShouldResemble, api.ErrorResponse{ErrorID: "an-id"})
+		})
+	})
+
+	Convey("An empty body or no response", t, func() {
+		Convey("Should not be unexpected", func() {
+			So(UnexpectedResponse(newResponse(http.StatusForbidden, "text/html", "")), ShouldBeNil)
+			So(UnexpectedResponse(nil), ShouldBeNil)
+		})
+	})
+
+	Convey("A WAF page to CheckAndParse", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<html><body>Request blocked</body></html>"))
+		}))
+		defer ts.Close()
+		Convey("Should return a typed error instead of unauthorized", func() {
+			resp, err := http.Get(ts.URL)
+			So(err, ShouldBeNil)
+			authResp, err := CheckAndParse(resp)
+			So(err, ShouldHaveSameTypeAs, api.ErrorUnexpectedResponse{})
+			So(err.Error(), ShouldContainSubstring, "Request blocked")
+			So(authResp, ShouldBeNil)
+		})
+	})
+}
+
+func TestReadLimited(t *testing.T) {
+	Convey("A body within the limit", t, func() {
+		var buf bytes.Buffer
+		err := ReadLimited(&buf, bytes.NewBufferString(`{"foo": "bar"}`))
+		Convey("Should be read completely", func() {
+			So(err, ShouldBeNil)
+			So(buf.String(), ShouldEqual, `{"foo": "bar"}`)
+		})
+	})
+	Convey("A body over the limit", t, func() {
+		var buf bytes.Buffer
+		err := ReadLimited(&buf, bytes.NewReader(make([]byte, MaxResponseSize+10)))
+		Convey("Should error without reading everything", func() {
+			So(err, ShouldNotBeNil)
+			So(buf.Len(), ShouldEqual, MaxResponseSize+1)
+			So(err, ShouldResemble, api.ErrorResponseTooLarge{Limit: MaxResponseSize})
+		})
+	})
+}
+
+func TestLimitReader(t *testing.T) {
+	Convey("A body of exactly the limit", t, func() {
+		var buf bytes.Buffer
+		_, err := buf.ReadFrom(LimitReader(bytes.NewBufferString("12345"), 5))
+		Convey("Should be read completely", func() {
+			So(err, ShouldBeNil)
+			So(buf.String(), ShouldEqual, "12345")
+		})
+	})
+	Convey("A body over the limit", t, func() {
+		var buf bytes.Buffer
+		_, err := buf.ReadFrom(LimitReader(bytes.NewBufferString("123456"), 5))
+		Convey("Should return a typed error", func() {
+			var tooLarge api.ErrorResponseTooLarge
+			So(errors.As(err, &tooLarge), ShouldBeTrue)
+			So(tooLarge.Limit, ShouldEqual, 5)
+			So(buf.Len(), ShouldEqual, 5)
+		})
+	})
+	Convey("An error body over the limit", t, func() {
+		body := `{"error_id": "` + string(bytes.Repeat([]byte("a"), MaxErrorSize)) + `"}`
+		err := ParseAPIError(bytes.NewBufferString(body))
+		Convey("Should return a typed error", func() {
+			So(err, ShouldResemble, api.ErrorResponseTooLarge{Limit: MaxErrorSize})
+		})
+	})
+}
+
+func TestConnectionError(t *testing.T) {
+	Convey("A DNS error", t, func() {
+		err := ConnectionError("cerberus.example.com", &net.DNSError{Err: "no such host", Name: "cerberus.example.com"})
+		Convey("Should be a connection error for the host", func() {
+			var connErr api.ErrorConnection
+			So(errors.As(err, &connErr), ShouldBeTrue)
+			So(connErr.Host, ShouldEqual, "cerberus.example.com")
+			var dnsErr *net.DNSError
+			So(errors.As(err, &dnsErr), ShouldBeTrue)
+		})
+	})
+	Convey("A request to a closed port", t, func() {
+		_, err := http.Get("http://127.0.0.1:32876")
+		err = ConnectionError("127.0.0.1:32876", err)
+		Convey("Should be a connection error", func() {
+			So(err, ShouldHaveSameTypeAs, api.ErrorConnection{})
+			So(err.Error(), ShouldStartWith, "Unable to connect to Cerberus at 127.0.0.1:32876")
+		})
+	})
+	Convey("Other errors", t, func() {
+		err := fmt.Errorf("something else")
+		Convey("Should be returned unchanged", func() {
+			So(ConnectionError("cerberus.example.com", err), ShouldEqual, err)
+			So(ConnectionError("cerberus.example.com", nil), ShouldBeNil)
+		})
+	})
+}
+
+func BenchmarkParseAPIError(b *testing.B) {
+	body := []byte(`{"error_id": "a041aa4d-1d5a-4eed-8e8a-6dc18bdf96db", "errors": [{"code": 99208, "message": "The name may not be blank.", "metadata": {"field": "name"}}]}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, ok := ParseAPIError(bytes.NewReader(body)).(api.ErrorResponse); !ok {
+			b.Fatal("expected an api.ErrorResponse")
+		}
+	}
+}